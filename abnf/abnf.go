@@ -0,0 +1,422 @@
+/*
+Package abnf converts Augmented Backus-Naur Form (ABNF, RFC 5234 and
+RFC 7405) grammars, in which much of the syntax published in RFCs is
+written, into rat/x expressions, PEGN, or fully compiled *rat.Grammar
+instances. Like the pegn package, the ABNF parser itself is written
+entirely in rat/x.
+
+The following ABNF is supported (see rat/x for the equivalent types):
+
+	foo = rule           x.N{"foo", rule}
+	foo =/ rule          x.N{"foo", x.One{previous, rule}}
+	foo                  x.Ref{"foo"}
+	rule1 rule2          x.Seq{rule1, rule2}
+	rule1 / rule2        x.One{rule1, rule2}
+	(rule)               rule
+	[rule]               x.Mmx{0, 1, rule}
+	*rule                x.Mmx{0, -1, rule}
+	m*rule               x.Mmx{m, -1, rule}
+	*nrule               x.Mmx{0, n, rule}
+	m*nrule              x.Mmx{m, n, rule}
+	nrule                x.Mmx{n, n, rule}
+	"ab"                 x.Seq{x.One{"a", "A"}, x.One{"b", "B"}}
+	%i"ab"               x.Seq{x.One{"a", "A"}, x.One{"b", "B"}}
+	%s"ab"               x.Str{"ab"}
+	%x41 / %d65 / %b1    x.Str{"A"} / x.Str{"A"} / x.Str{"\x01"}
+	%x41.42.43           x.Str{"ABC"}
+	%x41-5A              x.Rng{'A', 'Z'}
+
+Rule names are case-insensitive (as ABNF requires) and take the spelling
+of their first definition with any hyphens replaced by underscores so
+that they are also valid PEGN (rule-name becomes rule_name). The core
+rules (ALPHA, DIGIT, CRLF, etc.) from RFC 5234 Appendix B are added as
+needed unless defined by the grammar itself. Prose values (<prose>) are
+not supported and cause an ErrParse. Both CRLF and LF line endings are
+accepted.
+*/
+package abnf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	wsp     = x.One{' ', '\t'}
+	eol     = x.One{"\r\n", '\n'}
+	comment = x.Seq{';', x.Mmx{0, -1, x.Seq{x.Not{eol}, x.Any{1}}}}
+	cnl     = x.One{comment, eol}
+	cwsp    = x.One{wsp, x.Seq{cnl, wsp}}
+	alpha   = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}
+	digit   = x.Rng{'0', '9'}
+	hexdig  = x.One{digit, x.Rng{'a', 'f'}, x.Rng{'A', 'F'}}
+	bindig  = x.One{'0', '1'}
+)
+
+// nums returns the rat/x expression for a numeric value with digits
+// matching dig (ex: %x41.42 or %x41-5A).
+func nums(dig any) x.Seq {
+	d := x.Mmx{1, -1, dig}
+	return x.Seq{d, x.Mmx{0, 1, x.One{
+		x.Mmx{1, -1, x.Seq{'.', d}},
+		x.Seq{'-', d},
+	}}}
+}
+
+// syntax is the ABNF grammar itself expressed in rat/x (see
+// notation.Parser).
+var syntax = []any{
+	x.N{`Rulelist`, x.Seq{
+		x.Mmx{0, -1, x.One{
+			x.Ref{`Rule`},
+			x.Seq{x.Mmx{0, -1, cwsp}, cnl},
+		}},
+		x.Mmx{0, -1, cwsp},
+		x.End{},
+	}},
+	x.N{`Rule`, x.Seq{
+		x.Ref{`Rulename`},
+		x.Mmx{0, -1, cwsp}, x.N{`Defined`, x.One{"=/", '='}}, x.Mmx{0, -1, cwsp},
+		x.Ref{`Alternation`}, x.Mmx{0, -1, cwsp},
+		x.One{cnl, x.End{}},
+	}},
+	x.N{`Rulename`, x.Seq{alpha, x.Mmx{0, -1, x.One{alpha, digit, '-'}}}},
+	x.N{`Alternation`, x.Seq{
+		x.Ref{`Concatenation`},
+		x.Mmx{0, -1, x.Seq{x.Mmx{0, -1, cwsp}, '/', x.Mmx{0, -1, cwsp}, x.Ref{`Concatenation`}}},
+	}},
+	x.N{`Concatenation`, x.Seq{
+		x.Ref{`Repetition`},
+		x.Mmx{0, -1, x.Seq{x.Mmx{1, -1, cwsp}, x.Ref{`Repetition`}}},
+	}},
+	x.N{`Repetition`, x.Seq{
+		x.Mmx{0, 1, x.N{`Repeat`, x.One{
+			x.Seq{x.Mmx{0, -1, digit}, '*', x.Mmx{0, -1, digit}},
+			x.Mmx{1, -1, digit},
+		}}},
+		x.One{
+			x.Ref{`Rulename`},
+			x.N{`Group`, x.Seq{'(', x.Mmx{0, -1, cwsp}, x.Ref{`Alternation`}, x.Mmx{0, -1, cwsp}, ')'}},
+			x.N{`Option`, x.Seq{'[', x.Mmx{0, -1, cwsp}, x.Ref{`Alternation`}, x.Mmx{0, -1, cwsp}, ']'}},
+			x.N{`CharVal`, x.Seq{
+				x.Mmx{0, 1, x.One{"%s", "%i", "%S", "%I"}},
+				'"', x.Mmx{0, -1, x.One{x.Rng{' ', '!'}, x.Rng{'#', '~'}}}, '"',
+			}},
+			x.N{`NumVal`, x.Seq{'%', x.One{
+				x.Seq{x.One{'x', 'X'}, nums(hexdig)},
+				x.Seq{x.One{'d', 'D'}, nums(digit)},
+				x.Seq{x.One{'b', 'B'}, nums(bindig)},
+			}}},
+			x.N{`ProseVal`, x.Seq{'<', x.Mmx{0, -1, x.One{x.Rng{' ', '='}, x.Rng{'?', '~'}}}, '>'}},
+		},
+	}},
+}
+
+// Core contains the core rules of RFC 5234 (Appendix B) which are
+// added to any converted grammar that references them without defining
+// them itself.
+var Core = map[string]any{
+	`ALPHA`:  x.One{x.Rng{'A', 'Z'}, x.Rng{'a', 'z'}},
+	`BIT`:    x.One{'0', '1'},
+	`CHAR`:   x.Rng{rune(0x01), rune(0x7F)},
+	`CR`:     "\r",
+	`CRLF`:   "\r\n",
+	`CTL`:    x.One{x.Rng{rune(0x00), rune(0x1F)}, "\x7F"},
+	`DIGIT`:  x.Rng{'0', '9'},
+	`DQUOTE`: `"`,
+	`HEXDIG`: x.One{x.Rng{'0', '9'}, x.Rng{'A', 'F'}, x.Rng{'a', 'f'}},
+	`HTAB`:   "\t",
+	`LF`:     "\n",
+	`LWSP`:   x.Mmx{0, -1, x.One{' ', '\t', x.Seq{"\r\n", x.One{' ', '\t'}}}},
+	`OCTET`:  x.Rng{rune(0x00), rune(0xFF)},
+	`SP`:     " ",
+	`VCHAR`:  x.Rng{rune(0x21), rune(0x7E)},
+	`WSP`:    x.One{' ', '\t'},
+}
+
+// ToX converts an ABNF grammar (string, []byte, []rune, or io.Reader)
+// into a named rat/x expression (x.N) for every rule (in the order
+// first defined) followed by any referenced core rules (see Core). An
+// ErrParse is returned if the ABNF is invalid or unsupported.
+func ToX(in any) ([]x.N, error) {
+	res := notation.Scan(syntax, in)
+	if res.X != nil {
+		return nil, newErrParse(res)
+	}
+
+	// rule names are case-insensitive and take first spelling
+	names := map[string]string{}
+	var order []string
+	for _, rule := range res.Children(`Rule`) {
+		name, _ := rule.Child(`Rulename`)
+		key := strings.ToLower(name.Text())
+		if _, has := names[key]; !has {
+			names[key] = strings.ReplaceAll(name.Text(), `-`, `_`)
+			order = append(order, key)
+		}
+	}
+
+	c := converter{names: names, core: map[string]bool{}}
+	alts := map[string][]any{}
+	for _, rule := range res.Children(`Rule`) {
+		name, _ := rule.Child(`Rulename`)
+		def, _ := rule.Child(`Defined`)
+		alt, _ := rule.Child(`Alternation`)
+		key := strings.ToLower(name.Text())
+		if def.Text() == `=` && len(alts[key]) > 0 {
+			return nil, newErrParse(name)
+		}
+		it, err := c.convert(alt)
+		if err != nil {
+			return nil, err
+		}
+		alts[key] = append(alts[key], it)
+	}
+
+	var defs []x.N
+	for _, key := range order {
+		it := alts[key]
+		if len(it) == 1 {
+			defs = append(defs, x.N{names[key], it[0]})
+			continue
+		}
+		var one x.One
+		for _, alt := range it {
+			if o, is := alt.(x.One); is {
+				one = append(one, o...)
+				continue
+			}
+			one = append(one, alt)
+		}
+		defs = append(defs, x.N{names[key], one})
+	}
+	for _, name := range c.corder {
+		defs = append(defs, x.N{name, Core[name]})
+	}
+	return defs, nil
+}
+
+// Parse converts an ABNF grammar (see ToX) into a new compiled
+// *rat.Grammar with the first rule as the Main rule.
+func Parse(in any) (*rat.Grammar, error) {
+	defs, err := ToX(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, ErrParse{Notation: Notation, Line: 1, Col: 1}
+	}
+	g := new(rat.Grammar).Init()
+	for _, def := range defs[1:] {
+		g.MakeRule(def)
+	}
+	return g.Pack(defs[0]), nil
+}
+
+// ToPEGN converts an ABNF grammar (see ToX) into the equivalent PEGN
+// document (see rat.Grammar.PEGN).
+func ToPEGN(in any) (string, error) {
+	g, err := Parse(in)
+	if err != nil {
+		return "", err
+	}
+	return g.PEGN(), nil
+}
+
+// converter tracks rule names and the core rules referenced while
+// converting.
+type converter struct {
+	names  map[string]string // lowercase to first spelling
+	core   map[string]bool
+	corder []string
+}
+
+// convert converts a named result from the ABNF syntax into the
+// equivalent rat/x expression.
+func (c *converter) convert(r rat.Result) (any, error) {
+
+	list := func() ([]any, error) {
+		var list []any
+		for _, it := range r.C {
+			v, err := c.convert(it)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	switch r.N {
+
+	case `Alternation`, `Concatenation`:
+		l, err := list()
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 1 {
+			return l[0], nil
+		}
+		if r.N == `Alternation` {
+			return x.One(l), nil
+		}
+		return x.Seq(l), nil
+
+	case `Group`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		return c.convert(r.C[0])
+
+	case `Option`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		it, err := c.convert(r.C[0])
+		return x.Mmx{0, 1, it}, err
+
+	case `Repetition`:
+		if len(r.C) == 1 {
+			return c.convert(r.C[0])
+		}
+		if len(r.C) != 2 {
+			return nil, newErrParse(r)
+		}
+		it, err := c.convert(r.C[1])
+		if err != nil {
+			return nil, err
+		}
+		return repeat(it, r.C[0])
+
+	case `Rulename`:
+		key := strings.ToLower(r.Text())
+		if name, has := c.names[key]; has {
+			return x.Ref{name}, nil
+		}
+		name := strings.ToUpper(r.Text())
+		if _, has := Core[name]; has {
+			if !c.core[name] {
+				c.core[name] = true
+				c.corder = append(c.corder, name)
+			}
+			return x.Ref{name}, nil
+		}
+		return x.Ref{strings.ReplaceAll(r.Text(), `-`, `_`)}, nil
+
+	case `CharVal`:
+		text := r.Text()
+		if text[0] == '%' {
+			s := text[3 : len(text)-1]
+			if text[1] == 's' || text[1] == 'S' {
+				return s, nil
+			}
+			return insensitive(s), nil
+		}
+		return insensitive(text[1 : len(text)-1]), nil
+
+	case `NumVal`:
+		return numval(r)
+
+	}
+
+	return nil, newErrParse(r)
+}
+
+// insensitive returns an expression matching the string regardless of
+// the case of any letters.
+func insensitive(s string) any {
+	if strings.ToLower(s) == strings.ToUpper(s) {
+		return s
+	}
+	var seq x.Seq
+	var lit string
+	for _, r := range s {
+		lo, up := unicode.ToLower(r), unicode.ToUpper(r)
+		if lo == up {
+			lit += string(r)
+			continue
+		}
+		if lit != "" {
+			seq = append(seq, lit)
+			lit = ""
+		}
+		seq = append(seq, x.One{string(lo), string(up)})
+	}
+	if lit != "" {
+		seq = append(seq, lit)
+	}
+	if len(seq) == 1 {
+		return seq[0]
+	}
+	return seq
+}
+
+// repeat wraps the expression in the x.Mmx matching the ABNF repeat.
+func repeat(it any, r rat.Result) (any, error) {
+	m, n, star := strings.Cut(r.Text(), `*`)
+	min, max := 0, -1
+	var err error
+	if m != "" {
+		if min, err = strconv.Atoi(m); err != nil {
+			return nil, newErrParse(r)
+		}
+	}
+	switch {
+	case !star:
+		max = min
+	case n != "":
+		if max, err = strconv.Atoi(n); err != nil {
+			return nil, newErrParse(r)
+		}
+	}
+	if max != -1 && max < min {
+		return nil, newErrParse(r)
+	}
+	return x.Mmx{min, max, it}, nil
+}
+
+// numval converts a numeric value (%x41, %x41.42, %x41-5A) into an
+// x.Str (string) or x.Rng.
+func numval(r rat.Result) (any, error) {
+	text := r.Text()
+	base := 16
+	switch text[1] {
+	case 'd', 'D':
+		base = 10
+	case 'b', 'B':
+		base = 2
+	}
+	parse := func(s string) (rune, error) {
+		n, err := strconv.ParseUint(s, base, 32)
+		if err != nil || n > unicode.MaxRune {
+			return 0, newErrParse(r)
+		}
+		return rune(n), nil
+	}
+	val := text[2:]
+	if from, to, is := strings.Cut(val, `-`); is {
+		beg, err := parse(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parse(to)
+		if err != nil || end < beg {
+			return nil, newErrParse(r)
+		}
+		return x.Rng{beg, end}, nil
+	}
+	var s []rune
+	for _, it := range strings.Split(val, `.`) {
+		n, err := parse(it)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, n)
+	}
+	return string(s), nil
+}
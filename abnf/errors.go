@@ -0,0 +1,15 @@
+package abnf
+
+import (
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+)
+
+// ErrParse is returned when ABNF text cannot be parsed or contains
+// something that cannot be converted (such as a prose value). Line and
+// Col (both starting at 1) are the location of the offending ABNF.
+type ErrParse = notation.ErrParse
+
+// newErrParse returns a new ErrParse of the ABNF Notation from the
+// position of the result (see notation.NewErrParse).
+func newErrParse(r rat.Result) ErrParse { return notation.NewErrParse(Notation, r) }
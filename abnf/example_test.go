@@ -0,0 +1,61 @@
+package abnf_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/abnf"
+)
+
+func ExampleToPEGN() {
+
+	s, err := abnf.ToPEGN("; from RFC 3986 (simplified)\r\n" +
+		"URI         = scheme \":\" hier-part\r\n" +
+		"scheme      = ALPHA *( ALPHA / DIGIT / \"+\" / \"-\" / \".\" )\r\n" +
+		"hier-part   = \"//\" 1*pchar\r\n" +
+		"            / path\r\n" +
+		"pchar       = %x61-7A / %x41-5A / \"%\" 2HEXDIG ; letters or escapes\r\n" +
+		"hier-PART   =/ %s\"FILE\"\r\n" +
+		"path        = [ \"/\" ] %x41.42\r\n")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(s)
+
+	// Output:
+	// URI       <= scheme ':' hier_part
+	// scheme    <= ALPHA (ALPHA / DIGIT / '+' / '-' / '.')*
+	// hier_part <= '//' pchar+ / path / 'FILE'
+	// pchar     <= [a-z] / [A-Z] / '%' HEXDIG{2}
+	// path      <= '/'? 'AB'
+	// ALPHA     <= [A-Z] / [a-z]
+	// DIGIT     <= [0-9]
+	// HEXDIG    <= [0-9] / [A-F] / [a-f]
+
+}
+
+func ExampleParse() {
+
+	g, err := abnf.Parse(`greeting = ("hello" / "hi") SP name
+name = 1*ALPHA
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Print()
+	g.Scan(`HeLLo Rob`).PrintText()
+	g.Scan(`hey Rob`).PrintError()
+
+	_, err = abnf.Parse(`greeting = <a friendly hello>`)
+	fmt.Println(err)
+
+	// Output:
+	// x.N{"greeting", x.Seq{x.One{x.Seq{x.One{x.Str{"h"}, x.Str{"H"}}, x.One{x.Str{"e"}, x.Str{"E"}}, x.One{x.Str{"l"}, x.Str{"L"}}, x.One{x.Str{"l"}, x.Str{"L"}}, x.One{x.Str{"o"}, x.Str{"O"}}}, x.Seq{x.One{x.Str{"h"}, x.Str{"H"}}, x.One{x.Str{"i"}, x.Str{"I"}}}}, x.Ref{"SP"}, x.Ref{"name"}}}
+	// HeLLo Rob
+	// expected: x.One{x.Seq{x.One{x.Str{"h"}, x.Str{"H"}}, x.One{x.Str{"e"}, x.Str{"E"}}, x.One{x.Str{"l"}, x.Str{"L"}}, x.One{x.Str{"l"}, x.Str{"L"}}, x.One{x.Str{"o"}, x.Str{"O"}}}, x.Seq{x.One{x.Str{"h"}, x.Str{"H"}}, x.One{x.Str{"i"}, x.Str{"I"}}}}
+	// 1 | hey Rob
+	//   | ^
+	// invalid ABNF at line 1, column 12: "<a friendly hello>"
+
+}
@@ -0,0 +1,8 @@
+package abnf
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	Notation = `ABNF` // named by every ErrParse
+)
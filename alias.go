@@ -0,0 +1,54 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// Alias keeps the old name of a rule renamed to name so that references
+// (x.Ref) to it, callers looking it up (see Lookup), and results
+// serialized before the rename (see Renamed) all continue to work. The
+// old name is made an alias (see MakeAlias) of a reference to the new
+// name, so the renamed rule need not be made yet and the old name is
+// kept when the grammar is exported (Old <- New in PEGN). Results are
+// only ever named (and hooks only keyed to) the new name.
+//
+//	g.Alias(`Greet`, `Greeting`)
+func (g *Grammar) Alias(old, name string) *Rule { return g.MakeAlias(old, x.Ref{name}) }
+
+// Renamed returns the current name of every rule keyed to the old name
+// it was renamed from (see Alias) for use with Result.Rename. Renames
+// of renamed rules are followed to the current name. Every alias of
+// a single reference counts as a rename.
+func (g *Grammar) Renamed() map[string]string {
+	names := map[string]string{}
+	for _, rule := range g.Ordered() {
+		if ref, is := rule.Expr.(x.Ref); is && rule.IsAlias() && len(ref) == 1 {
+			if name, is := ref[0].(string); is {
+				names[rule.Name] = name
+			}
+		}
+	}
+	for old, name := range names {
+		for n := 0; n < len(names); n++ {
+			next, has := names[name]
+			if !has || next == old {
+				break
+			}
+			name = next
+		}
+		names[old] = name
+	}
+	return names
+}
+
+// Rename changes the name (N) of the result and every one of its
+// descendants keyed to an old name to the new name. This is usually
+// done with the Renamed names of a grammar to bring results serialized
+// (see MarshalJSON and MarshalCBOR) before rules were renamed up to
+// date.
+func (m *Result) Rename(names map[string]string) {
+	if name, has := names[m.N]; has {
+		m.N = name
+	}
+	for n := range m.C {
+		m.C[n].Rename(names)
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Package antlr converts the combinator subset of ANTLR4 grammars (.g4)
+into rat/x expressions, PEGN, or fully compiled *rat.Grammar instances
+providing a path for the large corpus of existing ANTLR grammars. Like
+the pegn package, the ANTLR parser itself is written entirely in rat/x.
+
+The following ANTLR is supported (see rat/x for the equivalent types):
+
+	foo : rule ;         x.N{"foo", rule}
+	FOO : rule ;         x.N{"FOO", rule} (lexer rules, fragments too)
+	foo                  x.Ref{"foo"}
+	rule1 rule2          x.Seq{rule1, rule2}
+	rule1 | rule2        x.One{rule1, rule2}
+	(rule)               rule
+	rule? rule??         x.Mmx{0, 1, rule}
+	rule* rule*?         x.Mmx{0, -1, rule}
+	rule+ rule+?         x.Mmx{1, -1, rule}
+	'ab\n'               x.Str{"ab\n"}
+	'a'..'z'             x.Rng{'a', 'z'}
+	[a-zA-Z_]            x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}, x.Str{"_"}}
+	~[\r\n]              x.Seq{x.Not{x.One{x.Str{"\r"}, x.Str{"\n"}}}, x.Any{1}}
+	.                    x.Any{1}
+	EOF                  x.End{}
+
+The grammar header, options, tokens, channels, imports, modes, named
+actions (@header), rule arguments (returns, locals), actions ({...}),
+predicates ({...}?), element labels (x=rule), alternative labels
+(#Label), and lexer commands (-> skip) are all ignored. Note that
+ANTLR separates lexing from parsing while a rat grammar does not, so
+tokens skipped by the lexer (whitespace and comments) are not
+automatically skipped between the elements of parser rules and must be
+referenced explicitly. Non-greedy quantifiers are converted as greedy
+since a parsing expression grammar never backtracks. Unicode property
+escapes (\p{...}) are not supported and cause an ErrParse.
+*/
+package antlr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	ws      = x.One{' ', '\t', '\r', '\n', '\f'}
+	comment = x.One{
+		x.Seq{"//", x.Mmx{0, -1, x.Seq{x.Not{x.One{'\r', '\n'}}, x.Any{1}}}},
+		x.Seq{"/*", x.To{"*/"}, "*/"},
+	}
+	sp      = x.Mmx{0, -1, x.One{ws, comment}}
+	letter  = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}, '_'}
+	digit   = x.Rng{'0', '9'}
+	name    = x.Seq{letter, x.Mmx{0, -1, x.One{letter, digit}}}
+	literal = x.N{`Lit`, x.Seq{'\'', x.Mmx{0, -1, x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{'\''}, x.Any{1}}}}, '\''}}
+	arg     = x.Seq{'[', x.To{']'}, ']'}
+	command = x.Seq{name, x.Mmx{0, 1, x.Seq{sp, '(', x.To{')'}, ')'}}}
+	block   = x.Ref{`Block`}
+)
+
+// syntax is the ANTLR grammar itself expressed in rat/x (see
+// notation.Parser).
+var syntax = []any{
+	x.N{`Grammar`, x.Seq{
+		sp,
+		x.Mmx{0, -1, x.Seq{x.One{x.Ref{`Prequel`}, x.Ref{`Rule`}}, sp}},
+		x.End{},
+	}},
+	x.N{`Prequel`, x.One{
+		x.Seq{x.Mmx{0, 1, x.Seq{x.One{"lexer", "parser"}, sp}}, "grammar", sp, name, sp, ';'},
+		x.Seq{x.One{"options", "tokens", "channels"}, sp, block},
+		x.Seq{"import", sp, x.To{';'}, ';'},
+		x.Seq{"mode", sp, name, sp, ';'},
+		x.Seq{'@', name, x.Mmx{0, 1, x.Seq{"::", name}}, sp, block},
+	}},
+	x.N{`Block`, x.Seq{'{', x.Mmx{0, -1, x.One{block, x.Seq{x.Not{'}'}, x.Any{1}}}}, '}'}},
+	x.N{`Rule`, x.Seq{
+		x.Mmx{0, 1, x.Seq{"fragment", sp}},
+		x.N{`Name`, name}, sp,
+		x.Mmx{0, -1, x.Seq{x.One{"returns", "locals", "throws"}, sp, x.One{arg, name}, sp}},
+		x.Mmx{0, -1, x.Seq{'@', name, sp, block, sp}},
+		':', sp, x.Ref{`Alts`}, sp, ';',
+	}},
+	x.N{`Alts`, x.Seq{
+		x.Ref{`Alt`},
+		x.Mmx{0, -1, x.Seq{sp, '|', sp, x.Ref{`Alt`}}},
+	}},
+	x.N{`Alt`, x.Seq{
+		x.Mmx{0, 1, x.Seq{x.Ref{`Element`}, x.Mmx{0, -1, x.Seq{sp, x.Ref{`Element`}}}}},
+		x.Mmx{0, 1, x.Seq{sp, '#', sp, name}},
+		x.Mmx{0, 1, x.Seq{sp, "->", sp, command, x.Mmx{0, -1, x.Seq{sp, ',', sp, command}}}},
+	}},
+	x.N{`Element`, x.One{
+		x.Seq{block, x.Mmx{0, 1, '?'}},
+		x.Seq{
+			x.Mmx{0, 1, x.Seq{name, sp, x.One{"+=", '='}, sp}},
+			x.Ref{`Atom`},
+			x.Mmx{0, 1, x.N{`Quant`, x.Seq{x.One{'?', '*', '+'}, x.Mmx{0, 1, '?'}}}},
+		},
+	}},
+	x.N{`Atom`, x.One{
+		x.N{`Negated`, x.Seq{'~', sp, x.Ref{`Atom`}}},
+		x.Seq{'(', sp, x.Ref{`Alts`}, sp, ')'},
+		x.N{`Range`, x.Seq{literal, sp, "..", sp, literal}},
+		literal,
+		x.N{`Set`, x.Seq{'[', x.Mmx{0, -1, x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{']'}, x.Any{1}}}}, ']'}},
+		x.N{`Any`, '.'},
+		x.N{`Ref`, name},
+	}},
+}
+
+// ToX converts an ANTLR4 grammar (string, []byte, []rune, or
+// io.Reader) into a named rat/x expression (x.N) for every rule (in the
+// order defined). An ErrParse is returned if the grammar is invalid,
+// unsupported, or defines the same rule more than once.
+func ToX(in any) ([]x.N, error) {
+	res := notation.Scan(syntax, in)
+	if res.X != nil {
+		return nil, newErrParse(res)
+	}
+
+	var defs []x.N
+	seen := map[string]bool{}
+	for _, rule := range res.Children(`Rule`) {
+		name, _ := rule.Child(`Name`)
+		alts, _ := rule.Child(`Alts`)
+		if seen[name.Text()] {
+			return nil, newErrParse(name)
+		}
+		seen[name.Text()] = true
+		it, err := convert(alts)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, x.N{name.Text(), it})
+	}
+	return defs, nil
+}
+
+// Parse converts an ANTLR4 grammar (see ToX) into a new compiled
+// *rat.Grammar with the first rule as the Main rule.
+func Parse(in any) (*rat.Grammar, error) {
+	defs, err := ToX(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, ErrParse{Notation: Notation, Line: 1, Col: 1}
+	}
+	g := new(rat.Grammar).Init()
+	for _, def := range defs[1:] {
+		g.MakeRule(def)
+	}
+	return g.Pack(defs[0]), nil
+}
+
+// ToPEGN converts an ANTLR4 grammar (see ToX) into the equivalent PEGN
+// document (see rat.Grammar.PEGN).
+func ToPEGN(in any) (string, error) {
+	g, err := Parse(in)
+	if err != nil {
+		return "", err
+	}
+	return g.PEGN(), nil
+}
+
+// convert converts a named result from the ANTLR syntax into the
+// equivalent rat/x expression.
+func convert(r rat.Result) (any, error) {
+
+	switch r.N {
+
+	case `Alts`:
+		var one x.One
+		for _, it := range r.C {
+			v, err := convert(it)
+			if err != nil {
+				return nil, err
+			}
+			one = append(one, v)
+		}
+		if len(one) == 1 {
+			return one[0], nil
+		}
+		return one, nil
+
+	case `Alt`:
+		var seq x.Seq
+		for _, it := range r.C {
+			if len(it.C) == 0 || it.C[0].N == `Block` { // action or predicate
+				continue
+			}
+			v, err := convert(it)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		switch len(seq) {
+		case 0:
+			return "", nil
+		case 1:
+			return seq[0], nil
+		}
+		return seq, nil
+
+	case `Element`:
+		it, err := convert(r.C[0])
+		if err != nil || len(r.C) == 1 {
+			return it, err
+		}
+		switch r.C[1].Text()[0] {
+		case '?':
+			return x.Mmx{0, 1, it}, nil
+		case '*':
+			return x.Mmx{0, -1, it}, nil
+		}
+		return x.Mmx{1, -1, it}, nil
+
+	case `Atom`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		return convert(r.C[0])
+
+	case `Negated`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		it, err := convert(r.C[0])
+		if err != nil {
+			return nil, err
+		}
+		return x.Seq{x.Not{it}, x.Any{1}}, nil
+
+	case `Range`:
+		if len(r.C) != 2 {
+			return nil, newErrParse(r)
+		}
+		beg, err := unescape(r.C[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := unescape(r.C[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(beg) != 1 || len(end) != 1 || end[0] < beg[0] {
+			return nil, newErrParse(r)
+		}
+		return x.Rng{beg[0], end[0]}, nil
+
+	case `Lit`:
+		s, err := unescape(r)
+		return string(s), err
+
+	case `Set`:
+		return set(r)
+
+	case `Any`:
+		return x.Any{1}, nil
+
+	case `Ref`:
+		if r.Text() == `EOF` {
+			return x.End{}, nil
+		}
+		return x.Ref{r.Text()}, nil
+
+	}
+
+	return nil, newErrParse(r)
+}
+
+// unescape returns the runes of a quoted literal (Lit) result with any
+// escapes replaced.
+func unescape(r rat.Result) ([]rune, error) {
+	text := []rune(r.Text())
+	text = text[1 : len(text)-1]
+	var s []rune
+	for i := 0; i < len(text); {
+		c, n := char(text, i)
+		if n == 0 {
+			return nil, newErrParse(r)
+		}
+		s = append(s, c)
+		i += n
+	}
+	return s, nil
+}
+
+// char returns the (possibly escaped) rune at i within the text and the
+// number of runes consumed (0 if the escape is invalid or unsupported).
+func char(text []rune, i int) (rune, int) {
+	if text[i] != '\\' || i+1 >= len(text) {
+		return text[i], 1
+	}
+	switch text[i+1] {
+	case 'n':
+		return '\n', 2
+	case 'r':
+		return '\r', 2
+	case 't':
+		return '\t', 2
+	case 'b':
+		return '\b', 2
+	case 'f':
+		return '\f', 2
+	case 'p', 'P':
+		return 0, 0
+	case 'u':
+		hex := string(text[i+2:])
+		n := 6
+		switch {
+		case strings.HasPrefix(hex, `{`):
+			end := strings.IndexRune(hex, '}')
+			if end < 0 {
+				return 0, 0
+			}
+			n = 3 + len([]rune(hex[:end]))
+			hex = hex[1:end]
+		case len([]rune(hex)) >= 4:
+			hex = string([]rune(hex)[:4])
+		default:
+			return 0, 0
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || v > unicode.MaxRune {
+			return 0, 0
+		}
+		return rune(v), n
+	}
+	return text[i+1], 2
+}
+
+// set converts a character set result ([a-zA-Z_]) into the equivalent
+// x.One of x.Rng and single rune strings.
+func set(r rat.Result) (any, error) {
+	text := []rune(r.Text())
+	text = text[1 : len(text)-1]
+	var one x.One
+	for i := 0; i < len(text); {
+		beg, n := char(text, i)
+		if n == 0 {
+			return nil, newErrParse(r)
+		}
+		i += n
+		if i+1 < len(text) && text[i] == '-' {
+			end, m := char(text, i+1)
+			if m == 0 || end < beg {
+				return nil, newErrParse(r)
+			}
+			one = append(one, x.Rng{beg, end})
+			i += 1 + m
+			continue
+		}
+		one = append(one, string(beg))
+	}
+	switch len(one) {
+	case 0:
+		return nil, newErrParse(r)
+	case 1:
+		return one[0], nil
+	}
+	return one, nil
+}
@@ -0,0 +1,73 @@
+package antlr_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/antlr"
+)
+
+func ExampleToPEGN() {
+
+	s, err := antlr.ToPEGN(`
+// simplified from grammars-v4/json
+grammar JSON;
+
+json : value EOF ;
+
+value
+   : STRING                      # String
+   | NUMBER
+   | 'true' | 'false' | 'null'
+   | arr
+   ;
+
+arr : '[' value (',' value)* ']' | '[' ']' ;
+
+STRING : '"' (ESC | ~["\\\u0000-\u001F])* '"' ;
+fragment ESC : '\\' ["\\/bfnrt] ;
+NUMBER : '-'? INT ('.' [0-9]+)? ;
+fragment INT : '0' | [1-9] [0-9]* ;
+WS : [ \t\n\r]+ -> skip ;
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(s)
+
+	// Output:
+	// json   <= value !.
+	// value  <= STRING / NUMBER / 'true' / 'false' / 'null' / arr
+	// arr    <= '[' value (',' value)* ']' / '[]'
+	// STRING <= '"' (ESC / !('"' / '\' / [x00-x1F]) .)* '"'
+	// ESC    <= '\' ('"' / '\' / '/' / 'b' / 'f' / 'n' / 'r' / 't')
+	// NUMBER <= '-'? INT ('.' [0-9]+)?
+	// INT    <= '0' / [1-9] [0-9]*
+	// WS     <= (' ' / x09 / x0A / x0D)+
+
+}
+
+func ExampleParse() {
+
+	g, err := antlr.Parse(`
+greet : ('hello' | 'hi') ' ' name {System.out.println("hi");} ;
+name  : 'A'..'Z' ('a'..'z')+? ;
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Scan(`hi Rob`).PrintText()
+	g.Scan(`hey Rob`).PrintError()
+
+	_, err = antlr.Parse(`name : [\p{Lu}]+ ;`)
+	fmt.Println(err)
+
+	// Output:
+	// hi Rob
+	// expected: x.One{x.Str{"hello"}, x.Str{"hi"}}
+	// 1 | hey Rob
+	//   | ^
+	// invalid ANTLR grammar at line 1, column 8: "[\\p{Lu}]"
+
+}
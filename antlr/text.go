@@ -0,0 +1,8 @@
+package antlr
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	Notation = `ANTLR grammar` // named by every ErrParse
+)
@@ -0,0 +1,63 @@
+package rat
+
+// arenaBlock is the number of results in every block of an Arena.
+const arenaBlock = 4096
+
+// Arena allocates the children (C) slices of every Result produced by
+// a Grammar (see Grammar.Arena) from large blocks that are all freed at
+// once (see Free) rather than individually by the garbage collector.
+// This eliminates most garbage collection when scanning millions of
+// small documents in batches. The zero value is ready to use. An Arena
+// is not safe for concurrent use (just like a Grammar).
+type Arena struct {
+	blocks [][]Result
+	n      int // current block
+	off    int // offset within current block
+}
+
+// alloc returns an empty slice with capacity for n results.
+func (a *Arena) alloc(n int) []Result {
+	for {
+		if a.n == len(a.blocks) {
+			size := arenaBlock
+			if n > size {
+				size = n
+			}
+			a.blocks = append(a.blocks, make([]Result, size))
+		}
+		block := a.blocks[a.n]
+		if a.off+n <= len(block) {
+			c := block[a.off : a.off : a.off+n]
+			a.off += n
+			return c
+		}
+		a.n++
+		a.off = 0
+	}
+}
+
+// grow returns a copy of c with twice the capacity.
+func (a *Arena) grow(c []Result) []Result {
+	n := 2 * cap(c)
+	if n < 4 {
+		n = 4
+	}
+	return append(a.alloc(n), c...)
+}
+
+// Free clears every block (so that nothing within them keeps any input
+// from being collected) for reuse by subsequent scans. Every Result
+// (and children) produced while the Arena was in use must no longer be
+// used after.
+func (a *Arena) Free() {
+	for n := 0; n <= a.n && n < len(a.blocks); n++ {
+		block := a.blocks[n]
+		if n == a.n {
+			block = block[:a.off]
+		}
+		for i := range block {
+			block[i] = Result{}
+		}
+	}
+	a.n, a.off = 0, 0
+}
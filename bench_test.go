@@ -0,0 +1,350 @@
+package rat_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// benchmark is a single grammar (made fresh for each benchmark) and
+// input. Fail is set when the input is expected not to match (worst-case
+// backtracking, for example).
+type benchmark struct {
+	name  string
+	make  func() *rat.Grammar
+	input string
+	fail  bool
+}
+
+func runBenchmarks(b *testing.B, list []benchmark) {
+	for _, it := range list {
+		it := it
+		b.Run(it.name, func(b *testing.B) {
+			g := it.make()
+			in := []rune(it.input)
+			if res := g.Scan(in); (res.X != nil) != it.fail {
+				b.Fatalf(`unexpected result: %v`, res)
+			}
+			b.SetBytes(int64(len(it.input)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.Scan(in)
+			}
+		})
+	}
+}
+
+// number of repetitions of the input unit for small and large
+// variations (large is much smaller for quadratic backtracking)
+const (
+	benchSmall     = 16
+	benchLarge     = 16 * 1024
+	benchBacktrack = 512
+)
+
+// sized returns a benchmark for both small and large input made by
+// repeating unit (large times) with the optional suffix appended.
+func sized(name string, make func() *rat.Grammar, unit, suffix string, fail bool, large int) []benchmark {
+	return []benchmark{
+		{name + `/small`, make, strings.Repeat(unit, benchSmall) + suffix, fail},
+		{name + `/large`, make, strings.Repeat(unit, large) + suffix, fail},
+	}
+}
+
+func BenchmarkMake(b *testing.B) {
+	var list []benchmark
+
+	for _, n := range []int{benchSmall, benchLarge} {
+		str := strings.Repeat(`ab`, n)
+		list = append(list, benchmark{
+			name:  `Str/` + size(n),
+			make:  func() *rat.Grammar { return rat.Pack(str) },
+			input: str,
+		})
+	}
+
+	list = append(list, sized(`Rng`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Rng{'a', 'z'}}) },
+		`abcd`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`Is`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Is{unicode.IsLetter}}) },
+		`abcd`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`Any`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Any{4}}) },
+		`abcd`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`One/runes`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.One{'a', 'e', 'i', 'o', 'u', x.Rng{'0', '9'}}}) },
+		`aeiou0123`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`One/strings`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.One{`foo`, `bar`, `baz`, `qux`}}) },
+		`foobarbazqux`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`One/mixed`,
+		func() *rat.Grammar {
+			return rat.Pack(x.Mmx{0, -1, x.One{
+				x.Seq{`if`, x.Rng{'0', '9'}}, x.Seq{`for`, x.Rng{'0', '9'}},
+				x.Mmx{1, -1, x.Rng{'a', 'z'}}, x.Mmx{1, -1, x.Rng{'0', '9'}}, ' ',
+			}})
+		},
+		`if1 for2 foo 42 `, ``, false, benchLarge)...)
+
+	list = append(list, sized(`Seq`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Seq{'a', x.Rng{'0', '9'}, 'b'}}) },
+		`a1b`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`See`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Seq{x.See{'a'}, x.Any{1}}}) },
+		`a`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`Not`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.Seq{x.Not{'\n'}, x.Any{1}}}) },
+		`abcd`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`To`,
+		func() *rat.Grammar { return rat.Pack(x.To{'\n'}) },
+		`abcd`, "\n", false, benchLarge)...)
+
+	list = append(list, sized(`End`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, 'a'}, x.End{}) },
+		`a`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`N`,
+		func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, x.N{`Letter`, x.Rng{'a', 'z'}}}) },
+		`abcd`, ``, false, benchLarge)...)
+
+	list = append(list, sized(`Ref`,
+		func() *rat.Grammar {
+			g := new(rat.Grammar).Init()
+			g.MakeRule(x.N{`Letter`, x.Rng{'a', 'z'}})
+			return g.Pack(x.Mmx{0, -1, x.Ref{`Letter`}})
+		},
+		`abcd`, ``, false, benchLarge)...)
+
+	for _, n := range []int{benchSmall, benchLarge} {
+		list = append(list, benchmark{
+			name: `Sav/` + size(n),
+			make: func() *rat.Grammar {
+				g := new(rat.Grammar).Init()
+				g.MakeRule(x.N{`Fence`, x.Mmx{1, -1, '`'}})
+				return g.Pack(x.Sav{`Fence`}, x.To{x.Val{`Fence`}}, x.Val{`Fence`})
+			},
+			input: "```" + strings.Repeat(`abcd`, n) + "```",
+		})
+	}
+
+	runBenchmarks(b, list)
+}
+
+func BenchmarkNested(b *testing.B) {
+	var list []benchmark
+	for _, depth := range []int{10, 100, 1000} {
+		depth := depth
+		nest := func() any {
+			var it any = 'a'
+			for i := 0; i < depth; i++ {
+				if i%2 == 0 {
+					it = x.Seq{it}
+				} else {
+					it = x.One{'z', it}
+				}
+			}
+			return it
+		}
+		list = append(list, benchmark{
+			name:  `Depth` + itoa(depth),
+			make:  func() *rat.Grammar { return rat.Pack(x.Mmx{0, -1, nest()}) },
+			input: strings.Repeat(`a`, benchSmall),
+		})
+	}
+	runBenchmarks(b, list)
+}
+
+func BenchmarkBacktrack(b *testing.B) {
+	var list []benchmark
+
+	// every alternative shares the entire input as prefix and fails only
+	// at the very end
+	list = append(list, sized(`One/prefix`,
+		func() *rat.Grammar {
+			as := x.Mmx{0, -1, 'a'}
+			return rat.Pack(x.One{
+				x.Seq{as, 'b'}, x.Seq{as, 'c'}, x.Seq{as, 'd'}, x.Seq{as, 'e'},
+			})
+		},
+		`a`, `f`, true, benchBacktrack)...)
+
+	// the repetition is retried from every position of To
+	list = append(list, sized(`To/repeat`,
+		func() *rat.Grammar { return rat.Pack(x.To{x.Seq{x.Mmx{1, -1, 'a'}, 'b'}}) },
+		`a`, ``, true, benchBacktrack)...)
+
+	// nested repetitions each consuming the same input
+	list = append(list, sized(`Mmx/nested`,
+		func() *rat.Grammar {
+			return rat.Pack(x.Mmx{0, -1, x.One{x.Seq{x.Mmx{1, -1, 'a'}, 'b'}, 'a'}}, x.End{})
+		},
+		`a`, ``, false, benchBacktrack)...)
+
+	runBenchmarks(b, list)
+}
+
+// size returns the name of the variation for n repetitions.
+func size(n int) string {
+	if n == benchSmall {
+		return `small`
+	}
+	return `large`
+}
+
+func itoa(n int) string {
+	if n < 10 {
+		return string(rune('0' + n))
+	}
+	return itoa(n/10) + string(rune('0'+n%10))
+}
+
+func BenchmarkRelease(b *testing.B) {
+	in := []rune(strings.Repeat(`foo=bar,`, benchSmall))
+	make := func() *rat.Grammar {
+		return rat.Pack(x.Mmx{0, -1, x.Seq{
+			x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, '=',
+			x.N{`Val`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ',',
+		}})
+	}
+	b.Run(`without`, func(b *testing.B) {
+		g := make()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.Scan(in)
+		}
+	})
+	b.Run(`with`, func(b *testing.B) {
+		g := make()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.Release(g.Scan(in))
+		}
+	})
+	b.Run(`arena`, func(b *testing.B) {
+		g := make()
+		g.Arena = new(rat.Arena)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.Scan(in)
+			if i%100 == 99 {
+				g.Arena.Free()
+			}
+		}
+	})
+}
+
+func BenchmarkScanEach(b *testing.B) {
+	in := make([]string, 100)
+	for n := range in {
+		in[n] = `user` + itoa(n) + `@example`
+	}
+	make := func() *rat.Grammar {
+		return rat.Pack(x.Seq{
+			x.N{`User`, x.Mmx{1, -1, x.One{x.Rng{'a', 'z'}, x.Rng{'0', '9'}}}}, '@',
+			x.N{`Host`, x.Mmx{1, -1, x.Rng{'a', 'z'}}},
+		})
+	}
+	b.Run(`scan`, func(b *testing.B) {
+		g := make()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, s := range in {
+				g.Scan(s)
+			}
+		}
+	})
+	b.Run(`each`, func(b *testing.B) {
+		g := make()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.ScanEach(in, func(int, rat.Result) {})
+		}
+	})
+}
+
+// checker is the struct (method) alternative to a CheckFunc closure
+// (see BenchmarkDispatch).
+type checker interface {
+	Check(r []rune, i int) rat.Result
+}
+
+type rngChecker struct{ beg, end rune }
+
+func (c rngChecker) Check(r []rune, i int) rat.Result {
+	if i < len(r) && r[i] >= c.beg && r[i] <= c.end {
+		return rat.Result{R: r, B: i, E: i + 1}
+	}
+	return rat.Result{R: r, B: i, E: i, X: rat.ErrIsZero{}}
+}
+
+type mmxChecker struct{ it checker }
+
+func (c mmxChecker) Check(r []rune, i int) rat.Result {
+	start := i
+	for {
+		res := c.it.Check(r, i)
+		if res.X != nil {
+			break
+		}
+		i = res.E
+	}
+	return rat.Result{R: r, B: start, E: i}
+}
+
+func rngRule(beg, end rune) *rat.Rule {
+	return &rat.Rule{Check: func(r []rune, i int) rat.Result {
+		if i < len(r) && r[i] >= beg && r[i] <= end {
+			return rat.Result{R: r, B: i, E: i + 1}
+		}
+		return rat.Result{R: r, B: i, E: i, X: rat.ErrIsZero{}}
+	}}
+}
+
+func mmxRule(it *rat.Rule) *rat.Rule {
+	return &rat.Rule{Check: func(r []rune, i int) rat.Result {
+		start := i
+		for {
+			res := it.Check(r, i)
+			if res.X != nil {
+				break
+			}
+			i = res.E
+		}
+		return rat.Result{R: r, B: start, E: i}
+	}}
+}
+
+// BenchmarkDispatch compares calling checks through the CheckFunc
+// closure stored in every Rule (just as rules call each other) with
+// calling them through small structs implementing a Check method. Both
+// are dynamic calls so neither is inlined.
+func BenchmarkDispatch(b *testing.B) {
+	in := []rune(strings.Repeat(`abcd`, benchLarge))
+	b.Run(`closure`, func(b *testing.B) {
+		rule := mmxRule(rngRule('a', 'z'))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rule.Check(in, 0)
+		}
+	})
+	b.Run(`struct`, func(b *testing.B) {
+		checks := []checker{mmxChecker{rngChecker{'a', 'z'}}}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			checks[0].Check(in, 0)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package rat
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+)
+
+// binding is a named rule bound with Bind or BindFunc.
+type binding struct {
+	name string
+	set  func(text string) error
+	all  bool // every result (not just first)
+}
+
+// Bind sets the variable pointed to by ptr from the text of the first
+// result (see WithName) of the named rule after every successful Scan
+// so that simple extraction needs no walking of results at all. Any
+// type implementing encoding.TextUnmarshaler (time.Time, net.IP) is
+// decoded (see Result.Decode) and strings, bools, and numbers (of any
+// kind) are parsed (with strconv). A pointer to a slice of any of them
+// has every result appended instead. Variables of rules without
+// a result are left unchanged. When the text cannot be parsed the Scan
+// fails with an ErrBind. Use BindFunc to parse text differently (time
+// in another layout, for example). Panics with ErrArgs if ptr is not
+// a pointer to one of these types. Returns self for convenience.
+//
+//	var port int
+//	g.Bind(`Port`, &port).Scan(`localhost:8080`)
+func (g *Grammar) Bind(name string, ptr any) *Grammar {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		panic(ErrArgs{ptr})
+	}
+	v = v.Elem()
+	if set := setter(v); set != nil {
+		g.binds = append(g.binds, binding{name, set, false})
+		return g
+	}
+	if v.Kind() != reflect.Slice || setter(reflect.New(v.Type().Elem()).Elem()) == nil {
+		panic(ErrArgs{ptr})
+	}
+	set := func(text string) error {
+		it := reflect.New(v.Type().Elem()).Elem()
+		if err := setter(it)(text); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, it))
+		return nil
+	}
+	g.binds = append(g.binds, binding{name, set, true})
+	return g
+}
+
+// BindFunc is the same as Bind but calls the function with the text of
+// every result of the named rule instead (in the order found). When it
+// returns an error the Scan fails with an ErrBind for it.
+//
+//	var when time.Time
+//	g.BindFunc(`Date`, func(text string) (err error) {
+//		when, err = time.Parse(`02 Jan 2006`, text)
+//		return
+//	})
+func (g *Grammar) BindFunc(name string, set func(text string) error) *Grammar {
+	g.binds = append(g.binds, binding{name, set, true})
+	return g
+}
+
+// bind sets every variable bound from the result returning an ErrBind
+// for the first that cannot be.
+func (g *Grammar) bind(res Result) error {
+	for _, b := range g.binds {
+		found := res.WithName(b.name)
+		if !b.all && len(found) > 1 {
+			found = found[:1]
+		}
+		for _, it := range found {
+			if err := b.set(it.Text()); err != nil {
+				return ErrBind{b.name, it.Text(), err}
+			}
+		}
+	}
+	return nil
+}
+
+// setter returns a function setting the (addressable) value from text
+// or nil if its type is not supported (see Bind).
+func setter(v reflect.Value) func(text string) error {
+	if u, is := v.Addr().Interface().(encoding.TextUnmarshaler); is {
+		return func(text string) error { return u.UnmarshalText([]byte(text)) }
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return func(text string) error {
+			v.SetString(text)
+			return nil
+		}
+	case reflect.Bool:
+		return func(text string) error {
+			b, err := strconv.ParseBool(text)
+			if err == nil {
+				v.SetBool(b)
+			}
+			return err
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(text string) error {
+			i, err := strconv.ParseInt(text, 0, v.Type().Bits())
+			if err == nil {
+				v.SetInt(i)
+			}
+			return err
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(text string) error {
+			u, err := strconv.ParseUint(text, 0, v.Type().Bits())
+			if err == nil {
+				v.SetUint(u)
+			}
+			return err
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(text string) error {
+			f, err := strconv.ParseFloat(text, v.Type().Bits())
+			if err == nil {
+				v.SetFloat(f)
+			}
+			return err
+		}
+	}
+	return nil
+}
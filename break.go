@@ -0,0 +1,48 @@
+package rat
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// BreakEnv is the environment variable containing the names of rules
+// (separated by commas) added to Breakpoints when the program starts so
+// that breakpoints can be set in the field without recompiling.
+const BreakEnv = `RAT_BREAK`
+
+// Breakpoints are the names of the named (x.N) rules that break (see
+// Grammar.Break) whenever entered in any Grammar, initially those in
+// BreakEnv. Grammars have their own Breakpoints as well.
+var Breakpoints = breakpoints(os.Getenv(BreakEnv))
+
+// breakpoints returns the set of names separated by commas.
+func breakpoints(names string) map[string]bool {
+	set := map[string]bool{}
+	for _, name := range strings.Split(names, `,`) {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// breaking returns true if entering the named rule breaks.
+func (g *Grammar) breaking(name string) bool {
+	return (len(g.Breakpoints) > 0 && g.Breakpoints[name]) ||
+		(len(Breakpoints) > 0 && Breakpoints[name])
+}
+
+// brk calls Break (or logs the step if not set) for the named rule
+// entered at i. The Stack of the step contains only the rule.
+func (g *Grammar) brk(name string, r []rune, i int) {
+	s := Step{
+		TraceEvent: TraceEvent{Level: TraceCheck, T: EventEnter, Rule: name, I: i, R: r},
+		Stack:      []string{name},
+	}
+	if g.Break == nil {
+		log.Print(BreakT, s)
+		return
+	}
+	g.Break(s)
+}
@@ -0,0 +1,108 @@
+package rat
+
+// NodeBuilder implementations construct their own (usually typed) AST
+// nodes from the named (x.N) results of a scan rather than converting
+// the generic Result tree afterwards. See Grammar.ScanBuild and
+// Result.Build.
+//
+// Open is called when a named result begins and Close when it ends.
+// Text is called (between Open and Close) with the result itself only
+// when it contains no other named results (a terminal node) so that
+// the matched text can be retrieved (see Result.Text).
+type NodeBuilder interface {
+	Open(name string)
+	Text(span Result)
+	Close()
+}
+
+// buildOp is a single NodeBuilder call recorded during ScanBuild.
+type buildOp struct {
+	name string // rule opened (or with the text of a terminal)
+	b, e int    // span of a terminal (text) or -1 (open and close)
+	end  bool   // close
+}
+
+// ScanBuild is the same as Scan but drives the NodeBuilder with every
+// named result as its rule is checked instead of building children (C)
+// of any result, so the returned Result has none (only the position
+// and error). Since PEG parsing backtracks, the calls of every named
+// rule are recorded as it completes (and forgotten when it or its
+// parent fails) and only made once the entire Scan succeeds so the
+// builder never sees a node that was not part of the match. Nothing is
+// passed to the builder if the Scan fails.
+func (g *Grammar) ScanBuild(in any, b NodeBuilder) Result {
+	g.build, g.built = true, g.built[:0]
+	res := g.Scan(in)
+	g.build = false
+	if res.X != nil {
+		return res
+	}
+	for _, op := range g.built {
+		switch {
+		case op.end:
+			b.Close()
+		case op.b < 0:
+			b.Open(op.name)
+		default:
+			b.Text(Result{N: op.name, R: res.R, B: op.b, E: op.e})
+		}
+	}
+	return res
+}
+
+// opened records the opening of the named rule (see ScanBuild)
+// returning the mark to pass to closed.
+func (g *Grammar) opened(name string) int {
+	mark := len(g.built)
+	g.built = append(g.built, buildOp{name: name, b: -1, e: -1})
+	return mark
+}
+
+// closed records the end of the named rule opened at mark (see opened)
+// with the text of its result if no other named rule was recorded
+// within it or forgets everything since if it failed.
+func (g *Grammar) closed(mark int, res Result) {
+	if res.X != nil {
+		g.built = g.built[:mark]
+		return
+	}
+	if len(g.built) == mark+1 {
+		g.built = append(g.built, buildOp{name: res.N, b: res.B, e: res.E})
+	}
+	g.built = append(g.built, buildOp{b: -1, e: -1, end: true})
+}
+
+// unbuild forgets the calls recorded since mark (see ScanBuild) for
+// a result checked but not part of the match (a failed alternative or
+// repetition, or a lookahead).
+func (g *Grammar) unbuild(mark int) {
+	if g.build {
+		g.built = g.built[:mark]
+	}
+}
+
+// Build drives the NodeBuilder with every named result in the tree
+// (depth-first, preorder). Anonymous results are never passed, only
+// traversed.
+func (m Result) Build(b NodeBuilder) { m.build(b) }
+
+// build is Build returning true if any named result was passed.
+func (m Result) build(b NodeBuilder) bool {
+	if m.N == "" {
+		var named bool
+		for _, c := range m.C {
+			named = c.build(b) || named
+		}
+		return named
+	}
+	b.Open(m.N)
+	var named bool
+	for _, c := range m.C {
+		named = c.build(b) || named
+	}
+	if !named {
+		b.Text(m)
+	}
+	b.Close()
+	return true
+}
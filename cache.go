@@ -0,0 +1,88 @@
+package rat
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// CacheStats describes what a Grammar is holding onto (see
+// Grammar.CacheStats) to help diagnose memory growth, usually from
+// making rules from many different dynamically created expressions
+// (each of which is cached forever, see Grammar.Rules) or from very
+// large keys (see HashKeyLen). Results are not memoized so there are
+// no hit or miss counts.
+type CacheStats struct {
+	Keys      int // keys in Rules
+	Rules     int // distinct rules in Rules (one rule may have many keys)
+	Saved     int // literals created by Sav (see Grammar.Saved)
+	Hashed    int // keys hashed (see HashKeyLen)
+	KeyBytes  int // total length of every key
+	MaxKey    int // length of the longest key
+	TextBytes int // total length of the Text of every distinct rule
+	Free      int // released children slices kept for reuse (see Release)
+	FreeCap   int // total capacity (in results) of those slices
+	Buffer    int // capacity (in runes) of the reused input buffer
+}
+
+// String fulfills the fmt.Stringer interface with a single line of
+// every count.
+func (s CacheStats) String() string {
+	return fmt.Sprintf(CacheStatsT, s.Keys, s.Rules, s.Saved, s.Hashed,
+		s.KeyBytes, s.MaxKey, s.TextBytes, s.Free, s.FreeCap, s.Buffer)
+}
+
+// CacheStats returns the current size of the rule cache (Rules and
+// Saved) and of everything else reused between scans.
+func (g *Grammar) CacheStats() CacheStats {
+	s := CacheStats{
+		Keys:   len(g.Rules),
+		Saved:  len(g.Saved),
+		Free:   len(g.free),
+		Buffer: cap(g.runes),
+	}
+	seen := map[*Rule]bool{}
+	for key, rule := range g.Rules {
+		s.KeyBytes += len(key)
+		if len(key) > s.MaxKey {
+			s.MaxKey = len(key)
+		}
+		if isHashKey(key) {
+			s.Hashed++
+		}
+		if !seen[rule] {
+			seen[rule] = true
+			s.TextBytes += len(rule.Text)
+		}
+	}
+	s.Rules = len(seen)
+	for _, c := range g.free {
+		s.FreeCap += cap(c)
+	}
+	return s
+}
+
+// WriteCache writes every key of the rule cache (see Grammar.Rules) as
+// a table with aligned columns (key length, rule ID, text length, and
+// key) and a header, largest keys first, to find the rules responsible
+// for memory growth.
+func (g *Grammar) WriteCache(w io.Writer) error {
+	keys := make([]string, 0, len(g.Rules))
+	for key := range g.Rules {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if len(keys[a]) != len(keys[b]) {
+			return len(keys[a]) > len(keys[b])
+		}
+		return keys[a] < keys[b]
+	})
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, CacheHeaderT)
+	for _, key := range keys {
+		rule := g.Rules[key]
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", len(key), rule.ID, len(rule.Text), key)
+	}
+	return tw.Flush()
+}
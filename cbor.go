@@ -0,0 +1,282 @@
+package rat
+
+import (
+	"errors"
+	"math"
+	"unicode/utf8"
+)
+
+// MarshalCBOR returns the compact binary CBOR (RFC 8949) encoding of the
+// result tree for cross-language consumption and caching of large parse
+// trees for which the JSON form (see MarshalJSON) is too bulky. Every
+// result is a map with the same single letter text keys and omissions
+// as the JSON form: name (N), id (I), begin (B), end (E), error (X, as
+// text), truncated (T), children (C), buffer (R, only for the root), and
+// source (S, omitted from children with the same source as their
+// parent). An error is never returned.
+func (m Result) MarshalCBOR() ([]byte, error) {
+	return m.cbor(nil, m.redacted(), ``), nil
+}
+
+func (m Result) cbor(buf []byte, data []rune, src string) []byte {
+	var n uint64 = 2
+	for _, has := range []bool{m.N != "", m.I != 0, m.X != nil, m.T,
+		len(m.C) > 0, data != nil, m.S != src} {
+		if has {
+			n++
+		}
+	}
+	buf = cborHead(buf, 5, n)
+
+	if m.N != "" {
+		buf = cborText(cborText(buf, `N`), m.N)
+	}
+	if m.I != 0 {
+		buf = cborInt(cborText(buf, `I`), m.I)
+	}
+	buf = cborInt(cborText(buf, `B`), m.B)
+	buf = cborInt(cborText(buf, `E`), m.E)
+	if m.X != nil {
+		buf = cborText(cborText(buf, `X`), m.X.Error())
+	}
+	if m.T {
+		buf = append(cborText(buf, `T`), 0xf5)
+	}
+	if len(m.C) > 0 {
+		buf = cborHead(cborText(buf, `C`), 4, uint64(len(m.C)))
+		for _, c := range m.C {
+			buf = c.cbor(buf, nil, m.S)
+		}
+	}
+	if data != nil {
+		buf = cborText(cborText(buf, `R`), string(data))
+	}
+	if m.S != src {
+		buf = cborText(cborText(buf, `S`), m.S)
+	}
+	return buf
+}
+
+// cborHead appends the head of a data item of the major type with the
+// argument n using the shortest form.
+func cborHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	size := 8
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		buf, size = append(buf, major|24), 1
+	case n <= math.MaxUint16:
+		buf, size = append(buf, major|25), 2
+	case n <= math.MaxUint32:
+		buf, size = append(buf, major|26), 4
+	default:
+		buf = append(buf, major|27)
+	}
+	for shift := (size - 1) * 8; shift >= 0; shift -= 8 {
+		buf = append(buf, byte(n>>shift))
+	}
+	return buf
+}
+
+func cborInt(buf []byte, i int) []byte {
+	if i < 0 {
+		return cborHead(buf, 1, uint64(-1-i))
+	}
+	return cborHead(buf, 0, uint64(i))
+}
+
+func cborText(buf []byte, s string) []byte {
+	return append(cborHead(buf, 3, uint64(len(s))), s...)
+}
+
+// UnmarshalCBOR fulfills the cbor.Unmarshaler interface (of most CBOR
+// packages) decoding the CBOR produced by MarshalCBOR. The buffer (R)
+// is shared by every result in the tree and children without a source
+// (S) inherit that of their parent. Decoded errors (X) are plain errors
+// containing only the original error text. Unknown keys are skipped.
+// An ErrCBOR is returned if the data is not a valid encoding of
+// a result.
+func (m *Result) UnmarshalCBOR(data []byte) error {
+	d := cborDecoder{buf: data}
+	res, err := d.result(nil, ``)
+	if err != nil {
+		return err
+	}
+	if d.i != len(data) {
+		return ErrCBOR{d.i}
+	}
+	*m = res
+	return nil
+}
+
+type cborDecoder struct {
+	buf []byte
+	i   int
+}
+
+// head returns the major type and argument of the next data item.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.i >= len(d.buf) {
+		return 0, 0, ErrCBOR{d.i}
+	}
+	major, info := d.buf[d.i]>>5, d.buf[d.i]&0x1f
+	d.i++
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, ErrCBOR{d.i - 1}
+	}
+	size := 1 << (info - 24)
+	if d.i+size > len(d.buf) {
+		return 0, 0, ErrCBOR{d.i - 1}
+	}
+	var n uint64
+	for _, b := range d.buf[d.i : d.i+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.i += size
+	return major, n, nil
+}
+
+func (d *cborDecoder) int() (int, error) {
+	at := d.i
+	major, n, err := d.head()
+	switch {
+	case err != nil:
+		return 0, err
+	case n > math.MaxInt:
+		return 0, ErrCBOR{at}
+	case major == 0:
+		return int(n), nil
+	case major == 1:
+		return -1 - int(n), nil
+	}
+	return 0, ErrCBOR{at}
+}
+
+func (d *cborDecoder) text() (string, error) {
+	at := d.i
+	major, n, err := d.head()
+	if err != nil {
+		return "", err
+	}
+	if major != 3 || n > uint64(len(d.buf)-d.i) {
+		return "", ErrCBOR{at}
+	}
+	s := string(d.buf[d.i : d.i+int(n)])
+	d.i += int(n)
+	if !utf8.ValidString(s) {
+		return "", ErrCBOR{at}
+	}
+	return s, nil
+}
+
+// skip skips the next (definite length) data item.
+func (d *cborDecoder) skip() error {
+	at := d.i
+	major, n, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case 2, 3:
+		if n > uint64(len(d.buf)-d.i) {
+			return ErrCBOR{at}
+		}
+		d.i += int(n)
+	case 4, 5:
+		if major == 5 {
+			n *= 2
+		}
+		for ; n > 0; n-- {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+	case 6:
+		return d.skip()
+	case 7:
+		if d.buf[at]&0x1f == 31 {
+			return ErrCBOR{at}
+		}
+	}
+	return nil
+}
+
+func (d *cborDecoder) result(data []rune, src string) (Result, error) {
+	res := Result{R: data, S: src}
+	at := d.i
+	major, n, err := d.head()
+	if err != nil {
+		return res, err
+	}
+	if major != 5 {
+		return res, ErrCBOR{at}
+	}
+	var kids int
+	for ; n > 0; n-- {
+		key, err := d.text()
+		if err != nil {
+			return res, err
+		}
+		switch key {
+		case `N`:
+			res.N, err = d.text()
+		case `I`:
+			res.I, err = d.int()
+		case `B`:
+			res.B, err = d.int()
+		case `E`:
+			res.E, err = d.int()
+		case `X`:
+			var s string
+			s, err = d.text()
+			res.X = errors.New(s)
+		case `T`:
+			if d.i >= len(d.buf) || d.buf[d.i]&0xfe != 0xf4 {
+				return res, ErrCBOR{d.i}
+			}
+			res.T = d.buf[d.i] == 0xf5
+			d.i++
+		case `C`:
+			at := d.i
+			var major byte
+			var n uint64
+			major, n, err = d.head()
+			if err == nil && (major != 4 || n > uint64(len(d.buf)-d.i)) {
+				err = ErrCBOR{at}
+			}
+			kids, res.C = d.i, make([]Result, n)
+			for c := 0; err == nil && c < len(res.C); c++ {
+				err = d.skip()
+			}
+		case `R`:
+			var s string
+			s, err = d.text()
+			res.R = []rune(s)
+		case `S`:
+			res.S, err = d.text()
+		default:
+			err = d.skip()
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+
+	// children decoded last since buffer (R) and source (S) may follow
+	if len(res.C) > 0 {
+		end := d.i
+		d.i = kids
+		for c := range res.C {
+			if res.C[c], err = d.result(res.R, res.S); err != nil {
+				return res, err
+			}
+		}
+		d.i = end
+	}
+	return res, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func Example() {
+	code := run([]string{`testdata/greet.pegn`}, strings.NewReader(`hi Rob!`), os.Stdout, os.Stdout)
+	fmt.Println(code)
+	// Output:
+	// {"N":"Greet","B":0,"E":7,"C":[{"B":0,"E":2,"C":[{"B":0,"E":2}]},{"B":2,"E":3},{"N":"Name","B":3,"E":6,"C":[{"N":"upper","B":3,"E":4},{"B":4,"E":6,"C":[{"N":"lower","B":4,"E":5},{"N":"lower","B":5,"E":6}]}]},{"B":6,"E":7}],"R":"hi Rob!","S":"-"}
+	// 0
+}
+
+func Example_tree() {
+	run([]string{`-f`, `tree`, `-named`, `testdata/greet.pegn`}, strings.NewReader(`hello Rob!`), os.Stdout, os.Stdout)
+	// Output:
+	// Greet [0,10) "hello Rob!"
+	//   Name [6,9) "Rob"
+	//     upper [6,7) "R"
+	//     lower [7,8) "o"
+	//     lower [8,9) "b"
+}
+
+func Example_html() {
+	run([]string{`-f`, `html`, `testdata/greet.pegn`}, strings.NewReader(`hi Rob!`), os.Stdout, os.Stdout)
+	// Output:
+	// <pre class="src"><span class="Greet">hi <span class="Name"><span class="upper">R</span><span class="lower">o</span><span class="lower">b</span></span>!</span></pre>
+}
+
+func Example_rule() {
+	run([]string{`-r`, `Name`, `-f`, `text`, `testdata/greet.pegn`, `-`}, strings.NewReader(`Rob`), os.Stdout, os.Stdout)
+	// Output:
+	// Rob
+}
+
+func Example_noMatch() {
+	code := run([]string{`-all`, `testdata/greet.pegn`}, strings.NewReader("hi rob!\n"), os.Stdout, os.Stdout)
+	fmt.Println(code)
+	// Output:
+	// -:1:4: expected: x.Rng{'A', 'Z'}
+	// 1 | hi rob!
+	//   |    ^
+	// 1
+}
+
+func Example_grep() {
+	run([]string{`-g`, `testdata/email.pegn`, `testdata/contacts.txt`}, nil, os.Stdout, os.Stdout)
+	run([]string{`-g`, `-n`, `-o`, `Host`, `testdata/email.pegn`, `testdata/contacts.txt`}, nil, os.Stdout, os.Stdout)
+	code := run([]string{`-g`, `testdata/email.pegn`}, strings.NewReader(`nobody`), os.Stdout, os.Stdout)
+	fmt.Println(code)
+	// Output:
+	// rob@rwx.gg
+	// bob.smith@example.com
+	// testdata/contacts.txt:1:13:rwx.gg
+	// testdata/contacts.txt:2:11:example.com
+	// 1
+}
+
+func Example_rules() {
+	run([]string{`-rules`, `testdata/greet.pegn`}, nil, os.Stdout, os.Stdout)
+	// Output:
+	// Greet
+	//     Greet is a short greeting of someone by name.
+	// Name
+	//     Name is capitalized.
+}
+
+func Example_interactive() {
+	run([]string{`-i`, `testdata/greet.pegn`}, strings.NewReader("hi Rob!\n:quit\n"), os.Stdout, os.Stdout)
+	// Output:
+	// rat> Greet [0,7) "hi Rob!"
+	//   - [0,2) "hi"
+	//     - [0,2) "hi"
+	//   - [2,3) " "
+	//   Name [3,6) "Rob"
+	//     upper [3,4) "R"
+	//     - [4,6) "ob"
+	//       lower [4,5) "o"
+	//       lower [5,6) "b"
+	//   - [6,7) "!"
+	// rat>
+}
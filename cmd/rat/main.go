@@ -0,0 +1,300 @@
+// Command rat scans input with a grammar (PEGN, ABNF, EBNF, PEG, or
+// ANTLR) and prints the results as JSON, the matched text, or an
+// indented tree so that grammars can be developed and used in shell
+// pipelines without writing a Go program each time.
+//
+//	rat greet.pegn hello.txt
+//	echo 'hi Rob!' | rat -f tree greet.pegn
+//	rat -r Name -f text greet.pegn *.txt
+//
+// With -g the rule is instead matched anywhere in the input (like grep)
+// printing every match (or the named result within it chosen with -o)
+// one per line, optionally preceded by its position (-n).
+//
+//	rat -g -r Email -o Host contacts.pegn *.txt
+//
+// With -rules every rule of the grammar is listed instead along with its
+// documentation (the comments before its definition).
+//
+//	rat -rules greet.pegn
+//
+// With -i an interactive session is started instead (see rat/repl) with
+// the rules of the GRAMMAR (if any) for trying expressions on input.
+//
+//	rat -i greet.pegn
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/abnf"
+	"github.com/rwxrob/rat/antlr"
+	"github.com/rwxrob/rat/ebnf"
+	"github.com/rwxrob/rat/peg"
+	"github.com/rwxrob/rat/pegn"
+	"github.com/rwxrob/rat/repl"
+	"github.com/rwxrob/rat/x"
+)
+
+// Exit codes.
+const (
+	ExitMatch   = 0 // every input matched
+	ExitNoMatch = 1 // at least one input did not match
+	ExitError   = 2 // grammar or input could not be read
+)
+
+// Parsers are the functions that parse grammars keyed to the file
+// extension of the format.
+var Parsers = map[string]func(in any) (*rat.Grammar, error){
+	`.pegn`: pegn.Parse,
+	`.abnf`: abnf.Parse,
+	`.ebnf`: ebnf.Parse,
+	`.peg`:  peg.Parse,
+	`.g4`:   antlr.Parse,
+}
+
+func main() { os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)) }
+
+// run is the entire command (minus exiting) with the arguments and
+// standard input, output, and error passed in.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(`rat`, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, Usage)
+		flags.PrintDefaults()
+	}
+	format := flags.String(`f`, `json`, FlagFormat)
+	name := flags.String(`r`, ``, FlagRule)
+	named := flags.Bool(`named`, false, FlagNamed)
+	indent := flags.String(`indent`, ``, FlagIndent)
+	all := flags.Bool(`all`, false, FlagAll)
+	grep := flags.Bool(`g`, false, FlagGrep)
+	only := flags.String(`o`, ``, FlagOnly)
+	number := flags.Bool(`n`, false, FlagNumber)
+	list := flags.Bool(`rules`, false, FlagRules)
+	interactive := flags.Bool(`i`, false, FlagInteractive)
+	if err := flags.Parse(args); err != nil {
+		return ExitError
+	}
+	if *interactive {
+		return session(flags.Arg(0), stdin, stdout, stderr)
+	}
+	if flags.NArg() < 1 {
+		fmt.Fprintln(stderr, ErrNoGrammarT)
+		flags.Usage()
+		return ExitError
+	}
+
+	print, has := printers[*format]
+	if !has {
+		fmt.Fprintf(stderr, ErrOutputT+"\n", *format)
+		return ExitError
+	}
+
+	g, err := load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	if *list {
+		rules(stdout, g)
+		return ExitMatch
+	}
+	g.NamedOnly = *named
+	if *name != "" {
+		rule, has := g.Rules[*name]
+		if !has {
+			fmt.Fprintf(stderr, ErrNoRuleT+"\n", *name)
+			return ExitError
+		}
+		g.Main = rule
+	}
+
+	files := flags.Args()[1:]
+	if len(files) == 0 {
+		files = []string{`-`}
+	}
+	code := ExitMatch
+	if *grep {
+		code = ExitNoMatch
+	}
+	for _, file := range files {
+		in, err := open(file, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitError
+		}
+		if *grep {
+			if find(stdout, g.Main, file, in, *only, *number) && code == ExitNoMatch {
+				code = ExitMatch
+			}
+			continue
+		}
+		res := g.Scan(in)
+		res.SetSource(file)
+		if *all && res.X == nil && res.E < len(res.R) {
+			res.X = rat.ErrIncomplete{B: res.E, E: len(res.R)}
+		}
+		if res.X != nil {
+			code = ExitNoMatch
+			info := res.ErrorInfo()
+			fmt.Fprintf(stderr, ErrScanT+"\n", file, info.Line, info.Column, info.Message)
+			fmt.Fprintln(stderr, res.Snippet(rat.SnippetContext))
+			continue
+		}
+		print(stdout, res, *indent)
+	}
+	return code
+}
+
+// load parses the grammar file according to its extension (see
+// Parsers). PEGN files are parsed with their imports (see
+// pegn.ParseFile).
+func load(path string) (*rat.Grammar, error) {
+	ext := filepath.Ext(path)
+	if ext == `.pegn` {
+		return pegn.ParseFile(path)
+	}
+	parse, has := Parsers[ext]
+	if !has {
+		return nil, fmt.Errorf(ErrFormatT, ext)
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(buf)
+}
+
+// session runs an interactive session (see rat/repl) starting with the
+// rules of the grammar file (if any).
+func session(path string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var g *rat.Grammar
+	if path != "" {
+		var err error
+		if g, err = load(path); err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitError
+		}
+	}
+	if err := repl.New(g).Run(stdin, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	return ExitMatch
+}
+
+// rules prints (to w) the name of the Main rule followed by that of
+// every named rule and alias of the grammar (in the order added) each
+// followed by the lines of its documentation (if any) indented. The
+// built-in classes are skipped.
+func rules(w io.Writer, g *rat.Grammar) {
+	var list []*rat.Rule
+	if g.Main != nil {
+		list = append(list, g.Main)
+	}
+	for _, rule := range g.Ordered() {
+		if _, named := rule.Expr.(x.N); rule == g.Main || !named && !rule.IsAlias() {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		list = append(list, rule)
+	}
+	for _, rule := range list {
+		name := rule.Name
+		if _, named := rule.Expr.(x.N); !named && !rule.IsAlias() {
+			name = rat.DefaultMainName
+		}
+		fmt.Fprintln(w, name)
+		if rule.Doc == "" {
+			continue
+		}
+		for _, line := range strings.Split(rule.Doc, "\n") {
+			fmt.Fprintln(w, strings.TrimRight(`    `+line, ` `))
+		}
+	}
+}
+
+// open returns the contents of the file (standard input if -).
+func open(file string, stdin io.Reader) ([]byte, error) {
+	if file == `-` {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// find prints (to w) every match of the rule anywhere in the input
+// (from the file) one per line returning true if there were any.
+// Matches do not overlap and those matching nothing are skipped. If
+// only is set the text of every result with that name within each
+// match is printed instead. If number is set each is preceded by the
+// file, line, and column at which it begins.
+func find(w io.Writer, rule *rat.Rule, file string, in []byte, only string, number bool) bool {
+	runes := []rune(string(in))
+	var found bool
+	for i := 0; i < len(runes); {
+		res := rule.Check(runes, i)
+		if res.X != nil || res.E == i {
+			i++
+			continue
+		}
+		i = res.E
+		matches := []rat.Result{res}
+		if only != "" {
+			matches = res.WithName(only)
+		}
+		for _, it := range matches {
+			found = true
+			if number {
+				line, col := it.Pos()
+				fmt.Fprintf(w, `%v:%v:%v:`, file, line, col)
+			}
+			fmt.Fprintln(w, it.Text())
+		}
+	}
+	return found
+}
+
+// printers print a result to w in each output format (see -f).
+var printers = map[string]func(w io.Writer, res rat.Result, indent string){
+	`json`: func(w io.Writer, res rat.Result, indent string) {
+		res.EncodeWith(w, rat.JSONOpts{Indent: indent})
+		fmt.Fprintln(w)
+	},
+	`text`: func(w io.Writer, res rat.Result, indent string) {
+		fmt.Fprintln(w, res.Text())
+	},
+	`html`: func(w io.Writer, res rat.Result, indent string) {
+		res.Highlight(w, rat.HighlightOpts{Pre: true})
+		fmt.Fprintln(w)
+	},
+	`tree`: func(w io.Writer, res rat.Result, indent string) {
+		if indent == "" {
+			indent = `  `
+		}
+		tree(w, res, indent, 0)
+	},
+}
+
+// tree prints the result and its children (indented one more level)
+// one per line with the name (if any), span, and quoted text.
+func tree(w io.Writer, res rat.Result, indent string, depth int) {
+	name := res.N
+	if name == "" {
+		name = `-`
+	}
+	fmt.Fprintf(w, "%v%v [%v,%v) %q\n", strings.Repeat(indent, depth), name, res.B, res.E, string(res.R[res.B:res.E]))
+	for _, it := range res.C {
+		it.R = res.R
+		tree(w, it, indent, depth+1)
+	}
+}
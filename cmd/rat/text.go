@@ -0,0 +1,47 @@
+package main
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const Usage = `usage: rat [flags] GRAMMAR [FILE ...]
+       rat -i [GRAMMAR]
+
+Scans every FILE (or standard input if none or -) with the Main rule of
+the GRAMMAR (or the rule named with -r) and prints the results. The
+format of the grammar is that of its file extension: .pegn, .abnf,
+.ebnf, .peg, or .g4 (ANTLR). Exits 1 if any input does not match
+(entirely with -all) and 2 if the grammar or an input cannot be read.
+
+With -g every match of the rule anywhere in the input is printed (or
+the named result within it chosen with -o) one per line instead and
+exits 1 only if there are none.
+
+With -rules every rule of the GRAMMAR is listed with its documentation
+(the comments before its definition) and nothing is scanned.
+
+With -i an interactive session is started for trying definitions and
+expressions on input lines instead (enter :help for more).
+
+Flags:
+`
+
+const (
+	FlagFormat      = `output format: json, text, tree, or html (highlighted input)`
+	FlagRule        = `name of the rule to scan with (default Main)`
+	FlagNamed       = `keep only named results`
+	FlagIndent      = `indent JSON (or tree) with this`
+	FlagAll         = `input must match entirely`
+	FlagGrep        = `print every match of the rule anywhere in the input`
+	FlagOnly        = `print the named result within every match instead (with -g)`
+	FlagNumber      = `print the file, line, and column of every match (with -g)`
+	FlagRules       = `list every rule with its documentation (and scan nothing)`
+	FlagInteractive = `start an interactive session (with the rules of the GRAMMAR, if any)`
+)
+
+const (
+	ErrFormatT    = `unknown grammar format: %v`
+	ErrOutputT    = `unknown output format: %v`
+	ErrNoRuleT    = `no such rule: %v`
+	ErrNoGrammarT = `missing GRAMMAR`
+	ErrScanT      = `%v:%v:%v: %v`
+)
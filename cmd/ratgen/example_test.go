@@ -0,0 +1,27 @@
+package main
+
+import "os"
+
+func Example() {
+	run([]string{`testdata/greet.pegn`, `-o`, `-`, `-pkg`, `greet`}, os.Stdout, os.Stdout)
+	// Output:
+	// // Code generated by rat/pegn from greet.pegn. DO NOT EDIT.
+	//
+	// package greet
+	//
+	// import (
+	// 	"github.com/rwxrob/rat"
+	// 	"github.com/rwxrob/rat/x"
+	// )
+	//
+	// var Greet = x.N{"Greet", x.Seq{x.One{x.Str{"hello"}, x.Str{"hi"}}, x.Str{" "}, x.Ref{"Name"}, x.Str{"!"}}}
+	//
+	// var Name = x.N{"Name", x.Seq{x.Ref{"upper"}, x.Mmx{1, -1, x.Ref{"lower"}}}}
+	//
+	// // Grammar returns a new compiled Grammar with Greet as the Main rule.
+	// func Grammar() *rat.Grammar {
+	// 	g := new(rat.Grammar).Init()
+	// 	g.MakeRule(Name)
+	// 	return g.Pack(Greet)
+	// }
+}
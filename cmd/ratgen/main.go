@@ -0,0 +1,80 @@
+// Command ratgen writes the Go source constructing a PEGN grammar (see
+// pegn.Generate) and is designed to be called by go:generate so that
+// grammars are compiled into the packages using them with every build:
+//
+//	//go:generate ratgen grammar.pegn -o grammar_gen.go -pkg mypkg
+//
+// The package defaults to the one go:generate is run for ($GOPACKAGE).
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rwxrob/rat/pegn"
+)
+
+func main() { os.Exit(run(os.Args[1:], os.Stdout, os.Stderr)) }
+
+// run is the entire command (minus exiting) with the arguments and
+// standard output and error passed in. Zero is returned if the source
+// was written, 2 otherwise.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(`ratgen`, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, Usage)
+		flags.PrintDefaults()
+	}
+	out := flags.String(`o`, ``, FlagOut)
+	pkg := flags.String(`pkg`, os.Getenv(`GOPACKAGE`), FlagPkg)
+
+	// flags may follow the grammar (as usual for go:generate lines)
+	var files []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 2
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		files = append(files, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(files) != 1 {
+		fmt.Fprintln(stderr, ErrArgsT)
+		flags.Usage()
+		return 2
+	}
+	path := files[0]
+	if *out == "" {
+		*out = path + `.go`
+	}
+	if *pkg == "" {
+		*pkg = `main`
+	}
+
+	g, err := pegn.ParseFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	buf := new(bytes.Buffer)
+	if err := pegn.Generate(buf, g, *pkg, filepath.Base(path)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *out == `-` {
+		stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
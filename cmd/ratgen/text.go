@@ -0,0 +1,23 @@
+package main
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const Usage = `usage: ratgen GRAMMAR [-o FILE] [-pkg NAME]
+
+Writes the Go source constructing the PEGN GRAMMAR as a *rat.Grammar
+(see pegn.Generate) for use with go:generate:
+
+	//go:generate ratgen grammar.pegn -o grammar_gen.go -pkg mypkg
+
+Flags (which may also follow GRAMMAR):
+`
+
+const (
+	FlagOut = `file to write (default GRAMMAR.go, - for standard output)`
+	FlagPkg = `package name (default $GOPACKAGE or main)`
+)
+
+const (
+	ErrArgsT = `exactly one GRAMMAR is required`
+)
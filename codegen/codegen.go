@@ -0,0 +1,110 @@
+/*
+Package codegen generates parsers from a compiled *rat.Grammar for use
+where the rat interpreter itself cannot be. The Go backend (see Go)
+emits a complete, dependency-free Go package with one function per
+rule as gofmt formatted source or as a go/ast File (see GoAST) for
+further programmatic processing. Backends for other languages are text/template templates executed
+with a description of every rule (see Data, Describe, and Execute). The
+official templates (see Template) are embedded and include python (see
+Python), typescript (see TypeScript), and lsp (a Go language server
+scaffold, see LSP). Custom templates can be used as well.
+
+Every backend generates the same parser: the Main rule first followed
+by every named (x.N) rule and alias (see rat.Grammar.MakeAlias) in the
+order added to the grammar (see rat.Grammar.Ordered) and then any
+built-in classes (see x.Classes) referenced. Unlike the rat interpreter,
+generated parsers only retain named results as children (as if
+rat.Grammar.NamedOnly were always set). Expressions are made canonical
+(see x.Canonical) first. Rules containing Go functions (x.Is) cannot be
+generated.
+*/
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// rule is a single rule of the grammar to be generated. Named rules
+// produce named results. Doc is the documentation of the rule (see
+// rat.Rule) included in the generated code.
+type rule struct {
+	Name  string
+	Named bool
+	Doc   string
+	Expr  any
+}
+
+// rules returns the rules of the grammar to generate (with the Main
+// rule first) with canonical expressions. An ErrNoMain is returned if
+// the grammar has no Main rule made from a rat/x expression and an
+// ErrUndefined for any rule referenced but not defined.
+func rules(g *rat.Grammar) ([]rule, error) {
+	if g.Main == nil || g.Main.Expr == nil {
+		return nil, ErrNoMain{}
+	}
+
+	var list []rule
+	defined := map[string]bool{}
+	add := func(name string, named bool, doc string, it any) {
+		if !defined[name] {
+			defined[name] = true
+			list = append(list, rule{name, named, doc, x.Canonical(it)})
+		}
+	}
+
+	switch n, is := g.Main.Expr.(x.N); {
+	case is && len(n) == 2:
+		add(g.Main.Name, true, g.Main.Doc, n[1])
+	case g.Main.IsAlias():
+		add(g.Main.Name, false, g.Main.Doc, g.Main.Expr)
+	default:
+		add(rat.DefaultMainName, false, g.Main.Doc, g.Main.Expr)
+	}
+
+	for _, it := range g.Ordered() {
+		if it.IsAlias() {
+			add(it.Name, false, it.Doc, it.Expr)
+			continue
+		}
+		n, is := it.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[it.Name]; is && class.String() == it.Text {
+			continue
+		}
+		add(it.Name, true, it.Doc, n[1])
+	}
+
+	// classes are appended as referenced so must be walked as well
+	for n := 0; n < len(list); n++ {
+		var err error
+		x.Walk(list[n].Expr, func(it any) {
+			var name string
+			switch v := it.(type) {
+			case x.Ref:
+				name = fmt.Sprint(v...)
+			case x.Sav:
+				name = fmt.Sprint(v...)
+			default:
+				return
+			}
+			if defined[name] || err != nil {
+				return
+			}
+			if class, is := x.Classes[name]; is {
+				add(name, true, "", class[1])
+				return
+			}
+			err = ErrUndefined{name}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
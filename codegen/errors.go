@@ -0,0 +1,25 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// ErrUnsupported is returned when a rat/x expression (V) cannot be
+// generated (such as a Go function of x.Is).
+type ErrUnsupported struct{ V any }
+
+func (e ErrUnsupported) Error() string { return fmt.Sprintf(ErrUnsupportedT, x.String(e.V)) }
+
+// ErrUndefined is returned when a rule referenced (by x.Ref or x.Sav)
+// is neither defined by the grammar nor a built-in class.
+type ErrUndefined struct{ Name string }
+
+func (e ErrUndefined) Error() string { return fmt.Sprintf(ErrUndefinedT, e.Name) }
+
+// ErrNoMain is returned when the grammar has no Main rule made from
+// a rat/x expression.
+type ErrNoMain struct{}
+
+func (ErrNoMain) Error() string { return ErrNoMainT }
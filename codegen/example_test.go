@@ -0,0 +1,240 @@
+package codegen_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"os"
+	"strings"
+
+	"github.com/rwxrob/rat/codegen"
+	"github.com/rwxrob/rat/pegn"
+)
+
+func ExampleGo() {
+
+	g, err := pegn.Parse(`
+Greet <= ('hello' / 'hi') ' ' Name '!'?
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := codegen.Go(buf, g, `greet`); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the rule definitions (not the runtime)
+	src := buf.String()
+	beg := strings.Index(src, `func init()`)
+	end := strings.Index(src[beg:], "}\n") + beg + 2
+	fmt.Print(src[beg:end])
+
+	// Output:
+	// func init() {
+	// 	ruleGreet = named("Greet", seq(lits("'hello' / 'hi'", "hello", "hi"), str(" ", "' '"), ref(&ruleName), mmx(0, 1, "'!'?", str("!", "'!'"))))
+	// 	ruleName = named("Name", seq(ref(&ruleUpper), mmx(1, -1, "lower+", ref(&ruleLower))))
+	// 	ruleUpper = named("upper", rng('A', 'Z', "[A-Z]"))
+	// 	ruleLower = named("lower", rng('a', 'z', "[a-z]"))
+	// }
+
+}
+
+func ExampleGo_doc() {
+
+	g, err := pegn.Parse(`
+# Greet is a friendly greeting
+# (never a rude one).
+Greet <= 'hi ' Name
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := codegen.Go(buf, g, `greet`); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the documentation of the Greet parse function
+	src := buf.String()
+	beg := strings.Index(src, `// ParseGreet`)
+	end := strings.Index(src, `func ParseGreet`)
+	fmt.Print(src[beg:end])
+
+	// Output:
+	// // ParseGreet parses the input according to the Greet rule:
+	// //
+	// //	Greet <= 'hi ' Name
+	// //
+	// // Greet is a friendly greeting
+	// // (never a rude one).
+
+}
+
+func ExampleGoAST() {
+
+	g, err := pegn.Parse(`
+Greet <= ('hello' / 'hi') ' ' Name
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_, file, err := codegen.GoAST(g, `greet`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the exported parse functions
+	for _, decl := range file.Decls {
+		if fn, is := decl.(*ast.FuncDecl); is && fn.Name.IsExported() {
+			fmt.Println(fn.Name)
+		}
+	}
+
+	// Output:
+	// Parse
+	// ParseGreet
+	// ParseName
+	// ParseUpper
+	// ParseLower
+	// Text
+	// Error
+
+}
+
+func ExamplePython() {
+
+	g, err := pegn.Parse(`
+Greet <= ('hello' / 'hi') ' ' Name '!'?
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := codegen.Python(buf, g); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the rule definitions (not the runtime)
+	src := buf.String()
+	fmt.Print(src[strings.Index(src, `_rules = {`):])
+
+	// Output:
+	// _rules = {
+	//     "Greet": _named("Greet", _seq(_one("'hello' / 'hi'", _str("hello", "'hello'"), _str("hi", "'hi'")), _str(" ", "' '"), _ref("Name"), _mmx(0, 1, "'!'?", _str("!", "'!'")))),
+	//     "Name": _named("Name", _seq(_ref("upper"), _mmx(1, -1, "lower+", _ref("lower")))),
+	//     "upper": _named("upper", _rng("A", "Z", "[A-Z]")),
+	//     "lower": _named("lower", _rng("a", "z", "[a-z]")),
+	// }
+
+}
+
+func ExampleTemplate() {
+
+	g, err := pegn.Parse(`
+Greet <= ('hello' / 'hi') ' ' Name
+Name  <- [A-Z] [a-z]+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	t, err := codegen.Template(`{{range .Rules}}{{.Ident}} {{.Named}} {{.Expr.Kind}}{{range .Expr.Items}} {{.Kind}}{{end}}
+{{end}}`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := codegen.Execute(os.Stdout, t, g, ``); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// Greet true seq one str ref
+	// Name false seq rng mmx
+
+}
+
+func ExampleTypeScript() {
+
+	g, err := pegn.Parse(`
+Greet <= ('hello' / 'hi') ' ' Name '!'?
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := codegen.TypeScript(buf, g); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the rule definitions (not the runtime)
+	src := buf.String()
+	fmt.Print(src[strings.Index(src, `const rules`):])
+
+	// Output:
+	// const rules: Record<string, Check> = {
+	//   "Greet": named("Greet", seq(one("'hello' / 'hi'", str("hello", "'hello'"), str("hi", "'hi'")), str(" ", "' '"), ref("Name"), mmx(0, 1, "'!'?", str("!", "'!'")))),
+	//   "Name": named("Name", seq(ref("upper"), mmx(1, -1, "lower+", ref("lower")))),
+	//   "upper": named("upper", rng(65, 90, "[A-Z]")),
+	//   "lower": named("lower", rng(97, 122, "[a-z]")),
+	// };
+
+}
+
+func ExampleLSP() {
+
+	g, err := pegn.Parse(`
+Conf  <= (Pair / ws)*
+Pair  <= Key '=' Value x0A
+Key   <= lower+
+Value <= digit+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := codegen.LSP(buf, g, `confls`); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the embedded grammar
+	src := buf.String()
+	beg := strings.Index(src, `const grammar`)
+	end := strings.Index(src[beg:], "\n\n") + beg + 1
+	fmt.Print(src[beg:end])
+
+	// Output:
+	// const grammar = "Conf <= (Pair / ws)*\n" +
+	// 	"Pair <= Key '=' Value x0A\n" +
+	// 	"Key <= lower+\n" +
+	// 	"Value <= digit+\n" +
+	// 	"ws <= ' ' / x09 / x0D / x0A\n" +
+	// 	"lower <= [a-z]\n" +
+	// 	"digit <= [0-9]\n"
+
+}
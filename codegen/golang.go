@@ -0,0 +1,535 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// Go writes the formatted source of a complete, dependency-free Go
+// package (named pkg) implementing a parser for the grammar. The
+// package contains a Result type (mirroring rat.Result), a Parse
+// function for the Main rule, and a Parse function for every rule
+// (ParseGreet for Greet) along with the small runtime they share.
+// Consecutive literal alternatives (keywords) are matched with a trie
+// looking at each rune of input only once (in the same order). An
+// error is returned if the grammar cannot be generated (see package
+// documentation).
+func Go(w io.Writer, g *rat.Grammar, pkg string) error {
+	src, err := goSource(g, pkg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// GoAST is the same as Go but returns the generated source parsed (with
+// comments) as a go/ast File (and the FileSet with its positions,
+// recorded as file pkg.go) so that downstream tools can inspect and
+// modify the generated parser before printing it (see go/format.Node).
+func GoAST(g *rat.Grammar, pkg string) (*token.FileSet, *ast.File, error) {
+	src, err := goSource(g, pkg)
+	if err != nil {
+		return nil, nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, pkg+`.go`, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fset, file, nil
+}
+
+// goSource returns the gofmt formatted source written by Go.
+func goSource(g *rat.Grammar, pkg string) ([]byte, error) {
+	list, err := rules(g)
+	if err != nil {
+		return nil, err
+	}
+
+	idents := identify(list)
+	gen := golang{idents: idents}
+	defs := make([]string, len(list))
+	for n, r := range list {
+		s, err := gen.expr(r.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if r.Named {
+			s = fmt.Sprintf(`named(%q, %v)`, r.Name, s)
+		}
+		defs[n] = s
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("// Code generated by rat/codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %v\n\n", pkg)
+
+	main := idents[list[0].Name]
+	fmt.Fprintf(buf, "// Parse parses the input according to the Main rule (%v).\n", list[0].Name)
+	fmt.Fprintf(buf, "func Parse(in string) Result { return parse(in, rule%v) }\n\n", main)
+
+	var vars []string
+	for _, r := range list {
+		id := idents[r.Name]
+		op := `<=`
+		if !r.Named {
+			op = `<-`
+		}
+		fmt.Fprintf(buf, "// Parse%v parses the input according to the %v rule:\n//\n", id, r.Name)
+		fmt.Fprintf(buf, "//\t%v %v %v\n", r.Name, op, x.PEGNExpr(r.Expr))
+		if r.Doc != "" {
+			buf.WriteString("//\n")
+			for _, line := range lines(r.Doc) {
+				fmt.Fprintln(buf, strings.TrimRight(`// `+line, ` `))
+			}
+		}
+		fmt.Fprintf(buf, "func Parse%v(in string) Result { return parse(in, rule%[1]v) }\n\n", id)
+		vars = append(vars, `rule`+id)
+	}
+
+	fmt.Fprintf(buf, "var %v check\n\n", strings.Join(vars, `, `))
+	buf.WriteString("func init() {\n")
+	for n, def := range defs {
+		fmt.Fprintf(buf, "\t%v = %v\n", vars[n], def)
+	}
+	buf.WriteString("}\n")
+	buf.WriteString(goRuntime)
+
+	return format.Source(buf.Bytes())
+}
+
+// identify returns a unique identifier (see goIdent) for every rule
+// keyed to its name.
+func identify(list []rule) map[string]string {
+	idents := map[string]string{}
+	used := map[string]bool{}
+	for _, r := range list {
+		id := goIdent(r.Name)
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf(`%v%v`, goIdent(r.Name), n)
+		}
+		used[id] = true
+		idents[r.Name] = id
+	}
+	return idents
+}
+
+// goIdent returns the name as an exported Go identifier suffix with
+// any rune not valid in a Go identifier replaced with an underscore.
+func goIdent(name string) string {
+	var s []rune
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			r = '_'
+		}
+		s = append(s, r)
+	}
+	if len(s) == 0 {
+		return `_`
+	}
+	s[0] = unicode.ToUpper(s[0])
+	return string(s)
+}
+
+// golang generates the Go expressions for rat/x expressions using the
+// identifiers of the rules.
+type golang struct {
+	idents map[string]string
+}
+
+// lits are alternative literal strings (of a One) to be matched with
+// a trie rather than one at a time.
+type lits []any
+
+// expr returns the Go expression (of type check) for the canonical
+// rat/x expression.
+func (c golang) expr(it any) (string, error) {
+	exp := fmt.Sprintf(`%q`, x.PEGNExpr(it))
+
+	list := func(items []any) (string, error) {
+		var args []string
+		for _, it := range items {
+			s, err := c.expr(it)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, s)
+		}
+		return strings.Join(args, `, `), nil
+	}
+
+	switch v := it.(type) {
+
+	case x.N:
+		name := fmt.Sprint(v[0])
+		if id, has := c.idents[name]; has {
+			return `ref(&rule` + id + `)`, nil
+		}
+		s, err := c.expr(v[1])
+		return fmt.Sprintf(`named(%q, %v)`, name, s), err
+
+	case x.Ref:
+		return `ref(&rule` + c.idents[fmt.Sprint(v...)] + `)`, nil
+
+	case x.Sav:
+		name := fmt.Sprint(v...)
+		return fmt.Sprintf(`sav(%q, ref(&rule%v))`, name, c.idents[name]), nil
+
+	case x.Val:
+		return fmt.Sprintf(`val(%q, %v)`, fmt.Sprint(v...), exp), nil
+
+	case x.Seq:
+		args, err := list(v)
+		return `seq(` + args + `)`, err
+
+	case x.One:
+		// runs of literals are matched with a single trie
+		var items []any
+		for n := 0; n < len(v); {
+			end := n
+			for end < len(v) {
+				if _, is := v[end].(string); !is {
+					break
+				}
+				end++
+			}
+			if end-n < 2 {
+				items = append(items, v[n])
+				n++
+				continue
+			}
+			items = append(items, lits(v[n:end]))
+			n = end
+		}
+		if len(items) == 1 {
+			return c.expr(items[0])
+		}
+		args, err := list(items)
+		return `one(` + exp + `, ` + args + `)`, err
+
+	case lits:
+		args := make([]string, len(v))
+		for n, it := range v {
+			args[n] = fmt.Sprintf(`%q`, it)
+		}
+		exp = fmt.Sprintf(`%q`, x.PEGNExpr(x.One(v)))
+		return `lits(` + exp + `, ` + strings.Join(args, `, `) + `)`, nil
+
+	case x.Mmx:
+		s, err := c.expr(v[2])
+		return fmt.Sprintf(`mmx(%v, %v, %v, %v)`, v[0], v[1], exp, s), err
+
+	case x.See:
+		s, err := c.expr(v[0])
+		return `see(` + s + `)`, err
+
+	case x.Not:
+		s, err := c.expr(v[0])
+		return `not(` + exp + `, ` + s + `)`, err
+
+	case x.To:
+		s, err := c.expr(v[0])
+		return `to(` + exp + `, ` + s + `)`, err
+
+	case x.Any:
+		switch {
+		case len(v) == 1:
+			return fmt.Sprintf(`dot(%v, %[1]v, %v)`, v[0], exp), nil
+		case v[1] == 0:
+			return fmt.Sprintf(`dot(%v, -1, %v)`, v[0], exp), nil
+		}
+		return fmt.Sprintf(`dot(%v, %v, %v)`, v[0], v[1], exp), nil
+
+	case x.Rng:
+		return fmt.Sprintf(`rng(%q, %q, %v)`, v[0], v[1], exp), nil
+
+	case x.End:
+		return `end(` + exp + `)`, nil
+
+	case x.Wb:
+		return `wb(` + exp + `)`, nil
+
+	case string:
+		return fmt.Sprintf(`str(%q, %v)`, v, exp), nil
+
+	}
+
+	return "", ErrUnsupported{it}
+}
+
+// goRuntime is appended to every generated Go package.
+const goRuntime = `
+// Result is the result of parsing with any named results (only) as
+// children (C). B (inclusive) and E (exclusive) are the positions
+// within the input runes (R). X is set to an Error on failure.
+type Result struct {
+	N string   // name of the rule (empty if anonymous)
+	B int      // beginning (inclusive)
+	E int      // ending (exclusive)
+	C []Result // named children
+	R []rune   // reference to the input
+	X error    // error, if any
+}
+
+// Text returns the runes of the input from B to E as a string.
+func (r Result) Text() string { return string(r.R[r.B:r.E]) }
+
+// Error is the error of a failed Result containing the PEGN of what
+// was expected.
+type Error struct{ Expected string }
+
+func (e Error) Error() string { return "expected: " + e.Expected }
+
+type parser struct {
+	r     []rune
+	saved map[string]string
+}
+
+type check func(p *parser, i int) Result
+
+func parse(in string, c check) Result {
+	p := &parser{r: []rune(in), saved: map[string]string{}}
+	return c(p, 0)
+}
+
+// keep adds the child if named or its named children if not.
+func keep(parent *Result, kid Result) {
+	if kid.N != "" {
+		parent.C = append(parent.C, kid)
+		return
+	}
+	parent.C = append(parent.C, kid.C...)
+}
+
+func named(name string, c check) check {
+	return func(p *parser, i int) Result {
+		res := c(p, i)
+		res.N = name
+		return res
+	}
+}
+
+func ref(c *check) check {
+	return func(p *parser, i int) Result { return (*c)(p, i) }
+}
+
+func str(s, exp string) check {
+	runes := []rune(s)
+	return func(p *parser, i int) Result {
+		b := i
+		for _, r := range runes {
+			if i >= len(p.r) || p.r[i] != r {
+				return Result{R: p.r, B: b, E: i, X: Error{exp}}
+			}
+			i++
+		}
+		return Result{R: p.r, B: b, E: i}
+	}
+}
+
+func sav(name string, c check) check {
+	return func(p *parser, i int) Result {
+		res := c(p, i)
+		if res.X == nil {
+			p.saved[name] = res.Text()
+		}
+		return res
+	}
+}
+
+func val(name, exp string) check {
+	return func(p *parser, i int) Result {
+		s, has := p.saved[name]
+		if !has {
+			return Result{R: p.r, B: i, E: i, X: Error{exp}}
+		}
+		return str(s, exp)(p, i)
+	}
+}
+
+func seq(checks ...check) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i}
+		for _, c := range checks {
+			kid := c(p, res.E)
+			keep(&res, kid)
+			res.E = kid.E
+			if kid.X != nil {
+				res.X = kid.X
+				break
+			}
+		}
+		return res
+	}
+}
+
+func one(exp string, checks ...check) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i}
+		for _, c := range checks {
+			kid := c(p, i)
+			if kid.X == nil {
+				keep(&res, kid)
+				res.E = kid.E
+				return res
+			}
+		}
+		res.X = Error{exp}
+		return res
+	}
+}
+
+// trie matches the first of many alternative literals (in order) by
+// looking at every rune of the input only once. The alt is the index
+// of the first alternative ending at the node (-1 if none).
+type trie struct {
+	alt  int
+	next map[rune]*trie
+}
+
+func lits(exp string, alts ...string) check {
+	root := &trie{alt: -1}
+	for n, s := range alts {
+		node := root
+		for _, r := range s {
+			next, has := node.next[r]
+			if !has {
+				next = &trie{alt: -1}
+				if node.next == nil {
+					node.next = map[rune]*trie{}
+				}
+				node.next[r] = next
+			}
+			node = next
+		}
+		if node.alt < 0 {
+			node.alt = n
+		}
+	}
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i, X: Error{exp}}
+		alt := -1
+		for node, e := root, i; node != nil; e++ {
+			if node.alt >= 0 && (alt < 0 || node.alt < alt) {
+				alt, res.E, res.X = node.alt, e, nil
+			}
+			if e >= len(p.r) {
+				break
+			}
+			node = node.next[p.r[e]]
+		}
+		return res
+	}
+}
+
+func mmx(m, n int, exp string, c check) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i}
+		var count int
+		for n < 0 || count < n {
+			kid := c(p, res.E)
+			if kid.X != nil {
+				break
+			}
+			keep(&res, kid)
+			count++
+			if kid.E == res.E {
+				break
+			}
+			res.E = kid.E
+		}
+		if count < m {
+			res.X = Error{exp}
+		}
+		return res
+	}
+}
+
+func see(c check) check {
+	return func(p *parser, i int) Result {
+		return Result{R: p.r, B: i, E: i, X: c(p, i).X}
+	}
+}
+
+func not(exp string, c check) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i}
+		if c(p, i).X == nil {
+			res.X = Error{exp}
+		}
+		return res
+	}
+}
+
+func to(exp string, c check) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i}
+		for ; res.E < len(p.r); res.E++ {
+			if c(p, res.E).X == nil {
+				return res
+			}
+		}
+		res.X = Error{exp}
+		return res
+	}
+}
+
+func dot(m, n int, exp string) check {
+	return func(p *parser, i int) Result {
+		res := Result{R: p.r, B: i, E: i + m}
+		if res.E > len(p.r) {
+			res.E = len(p.r)
+			res.X = Error{exp}
+			return res
+		}
+		for n < 0 && res.E < len(p.r) || res.E < i+n && res.E < len(p.r) {
+			res.E++
+		}
+		return res
+	}
+}
+
+func rng(beg, end rune, exp string) check {
+	return func(p *parser, i int) Result {
+		if i < len(p.r) && beg <= p.r[i] && p.r[i] <= end {
+			return Result{R: p.r, B: i, E: i + 1}
+		}
+		return Result{R: p.r, B: i, E: i, X: Error{exp}}
+	}
+}
+
+func end(exp string) check {
+	return func(p *parser, i int) Result {
+		if i < len(p.r) {
+			return Result{R: p.r, B: i, E: i, X: Error{exp}}
+		}
+		return Result{R: p.r, B: i, E: i}
+	}
+}
+
+func isWord(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func wb(exp string) check {
+	return func(p *parser, i int) Result {
+		before := i > 0 && isWord(p.r[i-1])
+		after := i < len(p.r) && isWord(p.r[i])
+		if before != after {
+			return Result{R: p.r, B: i, E: i}
+		}
+		return Result{R: p.r, B: i, E: i, X: Error{exp}}
+	}
+}
+`
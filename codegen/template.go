@@ -0,0 +1,286 @@
+package codegen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Data is passed to every code generation template (see Execute). Main
+// is always the first of the Rules.
+type Data struct {
+	Package string // package or module name (if any)
+	Main    Rule
+	Rules   []Rule
+}
+
+// Rule describes a single rule of the grammar. Ident is the name as an
+// exported Go identifier (unique within the grammar) for backends that
+// cannot use arbitrary names. Named rules produce named results. PEGN
+// is the complete PEGN definition of the rule. Doc is its documentation
+// (see rat.Rule), if any, for the comments of the generated code.
+type Rule struct {
+	Name  string
+	Ident string
+	Named bool
+	PEGN  string
+	Doc   string
+	Expr  Node
+}
+
+// Node describes a single rat/x expression. Kind is the lowercase name
+// of the rat/x type (seq, one, mmx, see, not, to, any, rng, end, wb,
+// str, ref, sav, val, or n for an inline named expression). Name is set for
+// ref, sav, val, and n. Str is set for str. Min and Max are set for mmx
+// and any (with Max of -1 meaning unlimited). Beg and End are set for
+// rng. Items contains the nested expressions of seq and one and the
+// single nested expression of mmx, see, not, to, and n. PEGN is the
+// PEGN rendering of the expression (for error messages).
+type Node struct {
+	Kind  string
+	Name  string
+	Str   string
+	Min   int
+	Max   int
+	Beg   rune
+	End   rune
+	Items []Node
+	PEGN  string
+}
+
+// Describe returns the Data for the grammar (see package documentation
+// for which rules are included).
+func Describe(g *rat.Grammar, pkg string) (Data, error) {
+	list, err := rules(g)
+	if err != nil {
+		return Data{}, err
+	}
+	data := Data{Package: pkg}
+	idents := identify(list)
+	defined := map[string]bool{}
+	for _, r := range list {
+		defined[r.Name] = true
+	}
+	for _, r := range list {
+		op := `<=`
+		if !r.Named {
+			op = `<-`
+		}
+		node, err := describe(r.Expr, defined)
+		if err != nil {
+			return Data{}, err
+		}
+		data.Rules = append(data.Rules, Rule{
+			Name: r.Name, Ident: idents[r.Name], Named: r.Named,
+			PEGN: r.Name + ` ` + op + ` ` + x.PEGNExpr(r.Expr),
+			Doc:  r.Doc, Expr: node,
+		})
+	}
+	data.Main = data.Rules[0]
+	return data, nil
+}
+
+// describe returns the Node for the canonical rat/x expression. Inline
+// named expressions (x.N) of defined rules become references (ref).
+func describe(it any, defined map[string]bool) (Node, error) {
+	node := Node{PEGN: x.PEGNExpr(it)}
+
+	items := func(kind string, list ...any) (Node, error) {
+		node.Kind = kind
+		for _, it := range list {
+			n, err := describe(it, defined)
+			if err != nil {
+				return node, err
+			}
+			node.Items = append(node.Items, n)
+		}
+		return node, nil
+	}
+
+	switch v := it.(type) {
+	case x.N:
+		node.Name = fmt.Sprint(v[0])
+		if defined[node.Name] {
+			node.Kind = `ref`
+			return node, nil
+		}
+		return items(`n`, v[1])
+	case x.Ref:
+		node.Kind, node.Name = `ref`, fmt.Sprint(v...)
+	case x.Sav:
+		node.Kind, node.Name = `sav`, fmt.Sprint(v...)
+	case x.Val:
+		node.Kind, node.Name = `val`, fmt.Sprint(v...)
+	case x.Seq:
+		return items(`seq`, v...)
+	case x.One:
+		return items(`one`, v...)
+	case x.Mmx:
+		node.Min, node.Max = v[0].(int), v[1].(int)
+		return items(`mmx`, v[2])
+	case x.See:
+		return items(`see`, v...)
+	case x.Not:
+		return items(`not`, v...)
+	case x.To:
+		return items(`to`, v...)
+	case x.Any:
+		node.Kind = `any`
+		node.Min, node.Max = v[0].(int), v[0].(int)
+		if len(v) == 2 {
+			node.Max = v[1].(int)
+			if node.Max == 0 {
+				node.Max = -1
+			}
+		}
+	case x.Rng:
+		node.Kind, node.Beg, node.End = `rng`, v[0].(rune), v[1].(rune)
+	case x.End:
+		node.Kind = `end`
+	case x.Wb:
+		node.Kind = `wb`
+	case string:
+		node.Kind, node.Str = `str`, v
+	default:
+		return node, ErrUnsupported{it}
+	}
+	return node, nil
+}
+
+// Funcs are the functions available to every template (see Template):
+//
+//	json  - JSON encoding of any value (string literals for most languages)
+//	char  - string of a single rune (from Node.Beg or Node.End)
+//	snake - snake_case of an identifier (ParseGreet becomes parse_greet)
+//	lower - lowercase of a string
+//	lines - lines of a string (Rule.Doc) without trailing spaces
+var Funcs = template.FuncMap{
+	`json`: func(it any) (string, error) {
+		buf := new(strings.Builder)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		err := enc.Encode(it)
+		return strings.TrimSuffix(buf.String(), "\n"), err
+	},
+	`char`:  func(r rune) string { return string(r) },
+	`snake`: snake,
+	`lower`: strings.ToLower,
+	`lines`: lines,
+}
+
+// lines returns the lines of the text (none if empty) with any
+// trailing spaces removed.
+func lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	list := strings.Split(text, "\n")
+	for n, line := range list {
+		list[n] = strings.TrimRight(line, ` `)
+	}
+	return list
+}
+
+// snake returns the identifier in snake_case.
+func snake(id string) string {
+	var s []rune
+	prev := '_'
+	for _, r := range id {
+		if unicode.IsUpper(r) && prev != '_' && !unicode.IsUpper(prev) {
+			s = append(s, '_')
+		}
+		s = append(s, unicode.ToLower(r))
+		prev = r
+	}
+	return string(s)
+}
+
+// Template returns a new template (with Funcs) parsed from the
+// official template of the given name (ex: python) or from the text
+// itself if it contains a template action ({{). The template is
+// executed with Data (see Execute).
+func Template(name string) (*template.Template, error) {
+	text := name
+	if !strings.Contains(name, `{{`) {
+		buf, err := templates.ReadFile(`templates/` + name + `.tmpl`)
+		if err != nil {
+			return nil, err
+		}
+		text = string(buf)
+	}
+	return template.New(`codegen`).Funcs(Funcs).Parse(text)
+}
+
+// Execute writes the result of executing the template with the Data of
+// the grammar (see Describe) to w.
+func Execute(w io.Writer, t *template.Template, g *rat.Grammar, pkg string) error {
+	data, err := Describe(g, pkg)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// Python writes a complete, dependency-free Python 3 module implementing
+// a parser for the grammar using the official python template. The
+// module has a parse function for the Main rule and one for every rule
+// (parse_greet for Greet) returning a Result (mirroring rat.Result).
+func Python(w io.Writer, g *rat.Grammar) error {
+	t, err := Template(`python`)
+	if err != nil {
+		return err
+	}
+	return Execute(w, t, g, ``)
+}
+
+// TypeScript writes a complete, dependency-free TypeScript module
+// implementing a parser for the grammar using the official typescript
+// template so that web frontends can validate the same formats as Go
+// from a single grammar. The module exports a parse function for the
+// Main rule and one for every rule (parseGreet for Greet) returning a
+// Result (mirroring rat.Result) with positions counted in code points
+// (runes) rather than UTF-16 code units.
+func TypeScript(w io.Writer, g *rat.Grammar) error {
+	t, err := Template(`typescript`)
+	if err != nil {
+		return err
+	}
+	return Execute(w, t, g, ``)
+}
+
+// LSP writes the formatted source of a Go command (package main) named
+// cmd implementing a minimal language server for the grammar using the
+// official lsp template so that format authors get editor support
+// nearly for free. The server embeds the grammar as PEGN (see
+// rat/pegn) and publishes diagnostics from the farthest failure (see
+// rat.Grammar.Farthest) of every scan and document symbols from the
+// named results of the Main rule. Unlike the other backends, the
+// server depends on rat itself.
+func LSP(w io.Writer, g *rat.Grammar, cmd string) error {
+	t, err := Template(`lsp`)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := Execute(buf, t, g, cmd); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
@@ -0,0 +1,10 @@
+package codegen
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrUnsupportedT = `cannot generate code for: %v`
+	ErrUndefinedT   = `undefined rule: %v`
+	ErrNoMainT      = `grammar has no Main rule with a rat/x expression`
+)
@@ -0,0 +1,130 @@
+/*
+Package compare runs the same regular expressions and inputs through
+both the regexp package and rat (see rat.CompileRegexp) reporting where
+they disagree and how long each took so that the converters can be
+validated and performance claims documented with reproducible numbers.
+
+Both are anchored at the beginning of the input but not the end, so
+each match is the longest prefix accepted: the leftmost-first match of
+regexp and the result of the Main rule for rat. Since a parsing
+expression grammar never backtracks into repetitions or alternatives
+that have already matched, expressions relying on backtracking (a*a,
+for example) are expected to mismatch. Such mismatches are reported
+rather than treated as errors.
+*/
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rwxrob/rat"
+)
+
+// Case is a single regular expression (Expr) and the input to check
+// with it.
+type Case struct {
+	Expr  string
+	Input string
+}
+
+// Match is the outcome of checking a Case with one engine. End is the
+// byte offset of the end of the match within the Input (if matched).
+type Match struct {
+	Matched bool
+	End     int
+	Time    time.Duration // average of every iteration
+}
+
+// String fulfills the fmt.Stringer interface.
+func (m Match) String() string {
+	if !m.Matched {
+		return fmt.Sprintf(`no match (%v)`, m.Time)
+	}
+	return fmt.Sprintf(`match to %v (%v)`, m.End, m.Time)
+}
+
+// Report is the outcome of a Case for both regexp and rat.
+type Report struct {
+	Case
+	Regexp Match
+	Rat    Match
+}
+
+// Mismatch returns true if regexp and rat disagree about whether the
+// input matched or where the match ended.
+func (r Report) Mismatch() bool {
+	return r.Regexp.Matched != r.Rat.Matched ||
+		r.Regexp.Matched && r.Regexp.End != r.Rat.End
+}
+
+// String fulfills the fmt.Stringer interface.
+func (r Report) String() string {
+	status := `ok`
+	if r.Mismatch() {
+		status = `MISMATCH`
+	}
+	return fmt.Sprintf("%v %q %q\n  regexp: %v\n  rat:    %v",
+		status, r.Expr, r.Input, r.Regexp, r.Rat)
+}
+
+// Run checks every Case with both engines n times (at least once)
+// returning a Report for each in the same order. The expression of
+// each is compiled once by each engine before timing. An error is
+// returned if any expression cannot be compiled by either.
+func Run(cases []Case, n int) ([]Report, error) {
+	if n < 1 {
+		n = 1
+	}
+	reports := make([]Report, 0, len(cases))
+	for _, c := range cases {
+		re, err := regexp.Compile(`^(?:` + c.Expr + `)`)
+		if err != nil {
+			return nil, err
+		}
+		g, err := rat.CompileRegexp(c.Expr)
+		if err != nil {
+			return nil, err
+		}
+		r := Report{Case: c}
+
+		start := time.Now()
+		var loc []int
+		for i := 0; i < n; i++ {
+			loc = re.FindStringIndex(c.Input)
+		}
+		r.Regexp.Time = time.Since(start) / time.Duration(n)
+		if loc != nil {
+			r.Regexp.Matched, r.Regexp.End = true, loc[1]
+		}
+
+		start = time.Now()
+		var res rat.Result
+		for i := 0; i < n; i++ {
+			res = g.Scan(c.Input)
+		}
+		r.Rat.Time = time.Since(start) / time.Duration(n)
+		if res.X == nil {
+			r.Rat.Matched = true
+			for _, ru := range res.R[:res.E] {
+				r.Rat.End += utf8.RuneLen(ru)
+			}
+		}
+
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// Mismatches returns only the reports that are mismatches.
+func Mismatches(reports []Report) []Report {
+	var list []Report
+	for _, r := range reports {
+		if r.Mismatch() {
+			list = append(list, r)
+		}
+	}
+	return list
+}
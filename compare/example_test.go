@@ -0,0 +1,36 @@
+package compare_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/compare"
+)
+
+func ExampleRun() {
+
+	reports, err := compare.Run([]compare.Case{
+		{`[a-z]+@[a-z]+\.[a-z]+`, `rob@rwx.gg`},
+		{`(?i)hello|hi`, `HI there`},
+		{`\d{3}-\d{4}`, `555-12`},
+		{`a*a`, `aaa`},
+		{`ab|abc`, `abc`},
+	}, 10)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// timing varies so only the outcomes are printed
+	for _, r := range reports {
+		fmt.Println(r.Mismatch(), r.Expr, r.Regexp.Matched, r.Regexp.End, r.Rat.Matched, r.Rat.End)
+	}
+	fmt.Println(len(compare.Mismatches(reports)))
+
+	// Output:
+	// false [a-z]+@[a-z]+\.[a-z]+ true 10 true 10
+	// false (?i)hello|hi true 2 true 2
+	// false \d{3}-\d{4} false 0 false 0
+	// true a*a true 3 false 0
+	// false ab|abc true 2 true 2
+	// 1
+}
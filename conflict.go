@@ -0,0 +1,35 @@
+package rat
+
+// conflict returns an ErrConflict if a rule with a different
+// definition (Text) is already keyed to the name of the rule.
+func (g *Grammar) conflict(rule *Rule) error {
+	if rule.Name == "" {
+		return nil
+	}
+	prev, has := g.Rules[rule.Name]
+	if !has || prev == rule || prev.Text == rule.Text {
+		return nil
+	}
+	return ErrConflict{rule.Name, prev.Text, rule.Text}
+}
+
+// AddRuleE is the same as AddRule but returns an ErrConflict (leaving
+// the grammar unchanged) instead of replacing a rule keyed to the same
+// name with a different definition (even if not Strict) so that
+// collisions are not hidden when merging rules from different sources.
+func (g *Grammar) AddRuleE(rule *Rule) (*Rule, error) {
+	if err := g.conflict(rule); err != nil {
+		return nil, err
+	}
+	return g.AddRule(rule), nil
+}
+
+// Override makes the expression (see MakeRule), usually a named x.N,
+// replacing any rule keyed to the same name even if Strict so that
+// intentional replacements are explicit.
+func (g *Grammar) Override(in any) *Rule {
+	strict := g.Strict
+	g.Strict = false
+	defer func() { g.Strict = strict }()
+	return g.MakeRule(in)
+}
@@ -0,0 +1,59 @@
+package rat
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// CSVHeader is the first row written by WriteCSV and WriteTSV.
+var CSVHeader = []string{`name`, `begin`, `end`, `line`, `col`, `text`}
+
+// WriteCSV writes one comma-separated row (see encoding/csv) for every
+// named (N) result in the tree (in the depth-first order of Walk) with
+// the name, beginning (B), ending (E), line and column (see Pos), and
+// matched text following a CSVHeader row so that parsed data can be
+// imported directly into spreadsheets and databases for analysis.
+// Errors (X) are not included. Any error writing is returned.
+func (m Result) WriteCSV(w io.Writer) error { return m.writeRows(w, ',') }
+
+// WriteTSV is the same as WriteCSV but separates fields with tabs.
+func (m Result) WriteTSV(w io.Writer) error { return m.writeRows(w, '\t') }
+
+func (m Result) writeRows(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	// beginnings of every line (to avoid Pos counting from 0 each time)
+	lines := []int{0}
+	for n, r := range m.R {
+		if r == '\n' {
+			lines = append(lines, n+1)
+		}
+	}
+
+	var err error
+	Walk(m, func(r Result) {
+		if err != nil || r.N == "" {
+			return
+		}
+		line := sort.Search(len(lines), func(n int) bool { return lines[n] > r.B })
+		var text string
+		if 0 <= r.B && r.B <= r.E && r.E <= len(m.R) {
+			text = string(m.R[r.B:r.E])
+		}
+		err = cw.Write([]string{
+			r.N, strconv.Itoa(r.B), strconv.Itoa(r.E),
+			strconv.Itoa(line), strconv.Itoa(r.B - lines[line-1] + 1), text,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
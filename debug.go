@@ -0,0 +1,114 @@
+package rat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultSnippet is the number of runes on either side of the position
+// shown for every Step (see Step.String).
+var DefaultSnippet = 10
+
+// Step is a single pause of a Debugger as a named rule check is entered
+// or exited with the Stack of every named rule being checked (outermost
+// first, including the rule of the step). The Stack is reused and must
+// not be kept after the StepFunc returns.
+type Step struct {
+	TraceEvent
+	Stack []string
+}
+
+// Pos returns the current position: the beginning (I) when entered and
+// the end (E) when exited.
+func (s Step) Pos() int {
+	if s.T == EventExit {
+		return s.E
+	}
+	return s.I
+}
+
+// Snippet returns up to n runes of the input on either side of the
+// current position (see Pos) quoted (%q) with a bar (|) marking the
+// position.
+func (s Step) Snippet(n int) string {
+	pos := s.Pos()
+	if pos > len(s.R) {
+		pos = len(s.R)
+	}
+	beg, end := pos-n, pos+n
+	if beg < 0 {
+		beg = 0
+	}
+	if end > len(s.R) {
+		end = len(s.R)
+	}
+	return fmt.Sprintf(`%q`, string(s.R[beg:pos])+`|`+string(s.R[pos:end]))
+}
+
+// String fulfills the fmt.Stringer interface with two lines containing
+// the type, rule, position, stack, and snippet (see DefaultSnippet).
+// The error is included when a failed check exits.
+func (s Step) String() string {
+	what := s.T.String()
+	if s.T == EventExit && s.X != nil {
+		what = `fail`
+	}
+	return fmt.Sprintf(StepT, what, s.Rule, s.Pos(),
+		strings.Join(s.Stack, ` > `), s.Snippet(DefaultSnippet))
+}
+
+// StepFunc is called by a Debugger at every Step pausing the scan until
+// it returns.
+type StepFunc func(s Step)
+
+// Debugger is a Tracer (see Grammar.Tracer) that calls Step for every
+// named rule (x.N) check entered and exited, like stepping through
+// a regular expression debugger (see Grammar.Debug).
+type Debugger struct {
+	Step  StepFunc
+	stack []string
+}
+
+// Trace fulfills the Tracer interface.
+func (d *Debugger) Trace(e TraceEvent) {
+	if e.Level != TraceCheck {
+		return
+	}
+	if e.T == EventEnter {
+		d.stack = append(d.stack, e.Rule)
+	}
+	d.Step(Step{e, d.stack})
+	if e.T == EventExit && len(d.stack) > 0 {
+		d.stack = d.stack[:len(d.stack)-1]
+	}
+}
+
+// Debug scans the input (see Scan) calling step at every named rule
+// check entered and exited (see Debugger). The Trace and Tracer of the
+// grammar are restored after.
+func (g *Grammar) Debug(in any, step StepFunc) Result {
+	trace, tracer := g.Trace, g.Tracer
+	defer func() { g.Trace, g.Tracer = trace, tracer }()
+	g.Trace, g.Tracer = TraceCheck, &Debugger{Step: step}
+	return g.Scan(in)
+}
+
+// Prompt returns a simple interactive StepFunc (REPL) that writes every
+// Step to out and waits for a line from in before continuing. A line
+// of "c" continues without pausing again (as does the end of in).
+func Prompt(in io.Reader, out io.Writer) StepFunc {
+	lines := bufio.NewScanner(in)
+	paused := true
+	return func(s Step) {
+		fmt.Fprintln(out, s)
+		if !paused {
+			return
+		}
+		fmt.Fprint(out, StepPromptT)
+		if !lines.Scan() || strings.TrimSpace(lines.Text()) == `c` {
+			paused = false
+		}
+	}
+}
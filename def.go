@@ -0,0 +1,84 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// Def is a named (x.N) rule defined one element at a time with typed
+// methods (see Grammar.Def) as an alternative to nesting rat/x
+// composite literals by hand. Every method appends an element to the
+// sequence of the definition and returns the Def so that calls can be
+// chained. The arguments of methods taking ...any are any rat/x
+// expression (including those from other Defs, see Expr) and are
+// a sequence when more than one. Nothing is made until Rule or Pack is
+// called.
+//
+//	g.Def(`Greet`).Or(`hello`, `hi`).Lit(` `).Ref(`Name`).Opt('!').Rule()
+type Def struct {
+	g    *Grammar
+	name string
+	seq  x.Seq
+}
+
+// Def begins the definition of the named rule (see Def).
+func (g *Grammar) Def(name string) *Def { return &Def{g: g, name: name} }
+
+// seqOf returns the expressions as one (a Seq if more than one).
+func seqOf(in []any) any {
+	if len(in) == 1 {
+		return in[0]
+	}
+	return x.Seq(in)
+}
+
+// Seq appends every expression in order.
+func (d *Def) Seq(in ...any) *Def {
+	d.seq = append(d.seq, in...)
+	return d
+}
+
+// Lit appends the literal string (x.Str).
+func (d *Def) Lit(s string) *Def { return d.Seq(x.Str{s}) }
+
+// Ref appends a reference to the named rule (x.Ref).
+func (d *Def) Ref(name string) *Def { return d.Seq(x.Ref{name}) }
+
+// Rng appends the inclusive range of runes (x.Rng).
+func (d *Def) Rng(lo, hi rune) *Def { return d.Seq(x.Rng{lo, hi}) }
+
+// Or appends the first alternative that matches (x.One).
+func (d *Def) Or(alts ...any) *Def { return d.Seq(x.One(alts)) }
+
+// Opt appends the optional sequence (x.Mmx{0, 1}).
+func (d *Def) Opt(in ...any) *Def { return d.Seq(x.Mmx{0, 1, seqOf(in)}) }
+
+// Min appends the sequence repeated at least min times (x.Mmx{min, -1}).
+func (d *Def) Min(min int, in ...any) *Def { return d.Seq(x.Mmx{min, -1, seqOf(in)}) }
+
+// Mmx appends the sequence repeated at least min and at most max times
+// (x.Mmx).
+func (d *Def) Mmx(min, max int, in ...any) *Def { return d.Seq(x.Mmx{min, max, seqOf(in)}) }
+
+// Not appends the negative lookahead of the sequence (x.Not).
+func (d *Def) Not(in ...any) *Def { return d.Seq(x.Not{seqOf(in)}) }
+
+// See appends the positive lookahead of the sequence (x.See).
+func (d *Def) See(in ...any) *Def { return d.Seq(x.See{seqOf(in)}) }
+
+// To appends everything up to (but not including) the sequence (x.To).
+func (d *Def) To(in ...any) *Def { return d.Seq(x.To{seqOf(in)}) }
+
+// Any appends exactly n runes of anything (x.Any).
+func (d *Def) Any(n int) *Def { return d.Seq(x.Any{n}) }
+
+// End appends the end of the input (x.End).
+func (d *Def) End() *Def { return d.Seq(x.End{}) }
+
+// Expr returns the named expression defined so far (x.N).
+func (d *Def) Expr() x.N { return x.N{d.name, seqOf(d.seq)} }
+
+// Rule makes the rule (see Grammar.MakeRule) from the definition so far
+// panicking with an ErrUsage if it is malformed (see Grammar.Try).
+func (d *Def) Rule() *Rule { return d.g.MakeRule(d.Expr()) }
+
+// Pack makes the rule the Main rule of the grammar (see Grammar.Pack)
+// and returns the grammar.
+func (d *Def) Pack() *Grammar { return d.g.Pack(d.Expr()) }
@@ -0,0 +1,72 @@
+package rat
+
+import (
+	"errors"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// Diagnose returns an ErrSyntax for the farthest failure of the Main
+// rule checking the buffer (R) of the result (usually from Scan) or nil
+// if the result matched the entire buffer. Since the error of a failed
+// result rarely points to the actual problem (see Farthest Failure)
+// the buffer is checked again keeping the error of every rule that
+// failed at the farthest position, which the optimizations that skip
+// checking rules otherwise prevent. Diagnose should therefore only be
+// called after a Scan has failed.
+func (g *Grammar) Diagnose(res Result) error {
+	if res.X == nil && res.E == len(res.R) {
+		return nil
+	}
+	if g.Main == nil || g.Main.Check == nil {
+		return ErrNoCheckFunc{g.Main}
+	}
+
+	g.diag, g.expect = true, g.expect[:0]
+	g.Farthest = Result{}
+	g.discard(g.Main.Check(res.R, 0))
+	g.diag = false
+
+	at := res
+	if g.Farthest.X != nil && g.Farthest.E >= res.E {
+		at = g.Farthest
+	}
+	at.B = at.E
+	line, col := at.Pos()
+	err := ErrSyntax{Line: line, Col: col, I: at.E}
+
+	expect := g.expect
+	if g.Farthest.X == nil || g.Farthest.E < res.E {
+		expect = []error{res.X}
+	}
+	err.Expected = expectations(expect)
+	return err
+}
+
+// expectations returns the text of every error (the PEGN of those that
+// are ErrExpected) listing the same expectation from different rules
+// only once.
+func expectations(errs []error) []string {
+	var list []string
+	seen := map[string]bool{}
+	for _, it := range errs {
+		if it == nil {
+			continue
+		}
+		text := it.Error()
+		var e ErrExpected
+		if errors.As(it, &e) {
+			text = x.PEGNExpr(e.V)
+		}
+		if !seen[text] {
+			seen[text] = true
+			list = append(list, text)
+		}
+	}
+	return list
+}
+
+// oneOf returns an ErrOneOf at position i for the errors.
+func oneOf(i int, errs []error) error {
+	return ErrOneOf{I: i, Expected: expectations(errs)}
+}
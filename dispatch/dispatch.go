@@ -0,0 +1,121 @@
+/*
+Package dispatch detects which of several grammars (log formats, config
+files, protocol messages) an input is written in and scans it with that
+grammar for tools that must accept any of them without being told
+which.
+
+Every grammar is added to a Dispatcher by name with an optional cheap
+prefix expression (a magic number, a keyword, the first character) that
+must match the beginning of the input before the whole grammar is even
+tried. Grammars with a matching prefix are tried first, then those
+without a prefix, each in the order added. The first grammar to match
+wins.
+
+	d := new(dispatch.Dispatcher)
+	d.Add(`json`, jsonGrammar, x.One{'{', '['})
+	d.Add(`ini`, iniGrammar, '[')
+	d.Add(`env`, envGrammar)
+	name, res, err := d.Dispatch(input)
+
+Since a rat.Grammar cannot be used by concurrent scans neither can
+a Dispatcher.
+*/
+package dispatch
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rwxrob/rat"
+)
+
+// Format is a grammar of a Dispatcher.
+type Format struct {
+	Name    string       // returned by Dispatch when the grammar matches
+	Grammar *rat.Grammar // scanned with the Main rule
+	Prefix  *rat.Grammar // must match the beginning first (if not nil)
+}
+
+// Dispatcher tries the grammars of its Formats on an input (see
+// Dispatch). The zero value has none and is ready to use.
+type Dispatcher struct {
+	Formats []Format
+	All     bool // the whole input must be matched (not just a prefix)
+}
+
+// Add adds the grammar with the name and the optional rat/x expressions
+// (a sequence) that must first match the beginning of the input (see
+// Format) and returns an error if they are malformed (see
+// rat.Grammar.PackE). The prefix is scanned in the same UTF8 mode as
+// the grammar (so binary magic numbers work as expected).
+func (d *Dispatcher) Add(name string, g *rat.Grammar, prefix ...any) error {
+	f := Format{Name: name, Grammar: g}
+	if len(prefix) > 0 {
+		p, err := rat.New(rat.WithUTF8(g.UTF8)).PackE(prefix...)
+		if err != nil {
+			return err
+		}
+		f.Prefix = p
+	}
+	d.Formats = append(d.Formats, f)
+	return nil
+}
+
+// Dispatch scans the input (see rat.Grammar.Scan) with the grammar of
+// every Format whose Prefix matches (if any), then with every one
+// without a Prefix (each in order) and returns the name and result of
+// the first to match (entirely if All). An io.Reader is read only once
+// (as bytes) no matter how many grammars are tried. If none match,
+// ErrNoMatch is returned along with the result of the grammar that
+// matched the most of the input (if any were tried).
+func (d *Dispatcher) Dispatch(in any) (string, rat.Result, error) {
+	if rd, is := in.(io.Reader); is {
+		buf, err := io.ReadAll(rd)
+		if err != nil {
+			return "", rat.Result{}, err
+		}
+		in = buf
+	}
+
+	var sniffed, rest []Format
+	for _, f := range d.Formats {
+		switch {
+		case f.Prefix == nil:
+			rest = append(rest, f)
+		case f.Prefix.Scan(in).X == nil:
+			sniffed = append(sniffed, f)
+		}
+	}
+
+	var best rat.Result
+	var tried bool
+	for _, f := range append(sniffed, rest...) {
+		res := f.Grammar.Scan(in)
+		if res.X == nil && (!d.All || res.E == len(res.R)) {
+			return f.Name, res, nil
+		}
+		if !tried || farther(res, best) {
+			best, tried = res, true
+		}
+	}
+	return "", best, ErrNoMatch{len(d.Formats)}
+}
+
+// farther returns true if the result got farther into the input than
+// the other (see rat.Result.ErrorInfo).
+func farther(res, other rat.Result) bool { return reach(res) > reach(other) }
+
+// reach returns the position of the error of the result (or its end if
+// it has none).
+func reach(res rat.Result) int {
+	if info := res.ErrorInfo(); info != nil {
+		return info.Offset
+	}
+	return res.E
+}
+
+// ErrNoMatch is returned by Dispatch when none of the grammars of the
+// Formats (the number of them) match.
+type ErrNoMatch struct{ Formats int }
+
+func (e ErrNoMatch) Error() string { return fmt.Sprintf(ErrNoMatchT, e.Formats) }
@@ -0,0 +1,46 @@
+package dispatch_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/dispatch"
+	"github.com/rwxrob/rat/x"
+)
+
+func Example() {
+
+	word := x.Mmx{1, -1, x.Rng{'a', 'z'}}
+	value := x.Mmx{0, -1, x.Seq{x.Not{'\n'}, x.Any{1}}}
+	lines := func(it any) any { return x.Mmx{1, -1, x.Seq{it, x.Mmx{0, 1, '\n'}}} }
+
+	ini := rat.Pack(lines(x.One{
+		x.N{`Section`, x.Seq{'[', word, ']'}},
+		x.N{`Key`, x.Seq{word, '=', value}},
+	}))
+	env := rat.Pack(lines(x.N{`Var`, x.Seq{x.Mmx{1, -1, x.Rng{'A', 'Z'}}, '=', value}}))
+	logs := rat.Pack(lines(x.N{`Entry`, x.Seq{x.Mmx{4, 4, x.Rng{'0', '9'}}, '-', value}}))
+
+	d := dispatch.Dispatcher{All: true}
+	d.Add(`ini`, ini, '[')
+	d.Add(`log`, logs, x.Rng{'0', '9'})
+	d.Add(`env`, env)
+
+	for _, in := range []string{
+		"[main]\nname=rat\n",
+		"HOME=/root\nUSER=rob\n",
+		"2024-01-02 started\n",
+		"not any of them",
+	} {
+		name, res, err := d.Dispatch(strings.NewReader(in))
+		fmt.Printf("%q %v %v\n", name, len(res.C), err)
+	}
+
+	// Output:
+	// "ini" 2 <nil>
+	// "env" 2 <nil>
+	// "log" 1 <nil>
+	// "" 0 input matched none of 3 grammars
+
+}
@@ -0,0 +1,8 @@
+package dispatch
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrNoMatchT = `input matched none of %v grammars`
+)
@@ -0,0 +1,207 @@
+package rat
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// DOT returns a Graphviz DOT directed graph of the dependencies between
+// the rules of the grammar to help understand and refactor large
+// grammars. Every named rule and alias (including the Main rule, with
+// the same names as PEGN) is a node with an edge to every rule it
+// references (x.Ref and x.Sav) or encapsulates (nested x.N) in the
+// order first found. The Main rule is drawn bold and filled. Built-in
+// classes (see x.Classes) and undefined references are drawn dashed.
+// Edges that are part of a cycle (recursion) are colored red.
+func (g *Grammar) DOT() string {
+	var names []string
+	exps := map[string]any{}
+	add := func(name string, it any) {
+		if _, has := exps[name]; !has {
+			names = append(names, name)
+			exps[name] = it
+		}
+	}
+
+	var main string
+	if g.Main != nil && g.Main.Expr != nil {
+		switch n, is := g.Main.Expr.(x.N); {
+		case is && len(n) == 2:
+			main = g.Main.Name
+			add(main, n[1])
+		case g.Main.IsAlias():
+			main = g.Main.Name
+			add(main, g.Main.Expr)
+		default:
+			main = DefaultMainName
+			add(main, g.Main.Expr)
+		}
+	}
+
+	for _, rule := range g.Ordered() {
+		if rule == g.Main {
+			continue
+		}
+		if rule.IsAlias() {
+			add(rule.Name, rule.Expr)
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		add(rule.Name, n[1])
+	}
+
+	edges := map[string][]string{}
+	var external []string
+	for _, name := range names {
+		seen := map[string]bool{}
+		dotRefs(exps[name], func(to string) {
+			if seen[to] {
+				return
+			}
+			seen[to] = true
+			edges[name] = append(edges[name], to)
+			if _, has := exps[to]; !has && !slices.Contains(external, to) {
+				external = append(external, to)
+			}
+		})
+	}
+
+	cyclic := dotCycles(names, edges)
+
+	var b strings.Builder
+	b.WriteString("digraph Grammar {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, name := range names {
+		if name == main {
+			fmt.Fprintf(&b, "  %q [style=\"bold,filled\"];\n", name)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q;\n", name)
+	}
+	sort.Strings(external)
+	for _, name := range external {
+		fmt.Fprintf(&b, "  %q [style=dashed];\n", name)
+	}
+	for _, name := range names {
+		for _, to := range edges[name] {
+			if cyclic[name] != 0 && cyclic[name] == cyclic[to] {
+				fmt.Fprintf(&b, "  %q -> %q [color=red];\n", name, to)
+				continue
+			}
+			fmt.Fprintf(&b, "  %q -> %q;\n", name, to)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotRefs calls do with the name of every rule referenced or
+// encapsulated by the expression (without descending into the
+// encapsulated rules).
+func dotRefs(it any, do func(name string)) {
+	switch v := it.(type) {
+	case x.N:
+		do(fmt.Sprint(v[0]))
+		return
+	case x.Ref:
+		do(fmt.Sprint(v...))
+		return
+	case x.Sav:
+		do(fmt.Sprint(v[0]))
+		return
+	}
+	var list []any
+	switch v := it.(type) {
+	case x.Doc:
+		list = v[1:]
+	case x.Mmx:
+		if len(v) == 3 {
+			list = v[2:]
+		}
+	case x.See:
+		list = v
+	case x.Not:
+		list = v
+	case x.Len:
+		if len(v) >= 2 {
+			list = v[:2]
+		}
+	case x.To:
+		list = v
+	case x.Seq:
+		list = v
+	case x.One:
+		list = v
+	case []any:
+		list = v
+	}
+	for _, it := range list {
+		dotRefs(it, do)
+	}
+}
+
+// dotCycles returns the (non-zero) number of the strongly connected
+// component (see Tarjan) for every rule that is part of a cycle (even
+// with itself).
+func dotCycles(names []string, edges map[string][]string) map[string]int {
+	index := map[string]int{}
+	low := map[string]int{}
+	onstack := map[string]bool{}
+	var stack []string
+	cyclic := map[string]int{}
+	var count, comps int
+
+	var connect func(v string)
+	connect = func(v string) {
+		count++
+		index[v], low[v] = count, count
+		stack = append(stack, v)
+		onstack[v] = true
+		for _, w := range edges[v] {
+			if index[w] == 0 {
+				connect(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onstack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var comp []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onstack[w] = false
+			comp = append(comp, w)
+			if w == v {
+				break
+			}
+		}
+		if len(comp) > 1 || slices.Contains(edges[v], v) {
+			comps++
+			for _, w := range comp {
+				cyclic[w] = comps
+			}
+		}
+	}
+
+	for _, name := range names {
+		if index[name] == 0 {
+			connect(name)
+		}
+	}
+	return cyclic
+}
@@ -0,0 +1,71 @@
+package rat
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// Dump returns the entire grammar as PEGN (see PEGN) in a canonical,
+// deterministic form for golden files and version control so that any
+// change to the meaning of a grammar shows up as a readable diff and
+// nothing else does. The Main rule comes first followed by every other
+// named rule and alias sorted by name (not the order added). Every
+// expression is made canonical (see x.Canonical) and rendered on
+// a single line without padding (so renaming one rule never changes
+// another line). The Doc of each rule (if any) is included as comment
+// lines before its definition. Built-in classes (see x.Classes) and
+// rules without a rat/x expression are skipped.
+func (g *Grammar) Dump() string {
+	type def struct {
+		name, op, doc string
+		exp           any
+	}
+	var main []def
+	var defs []def
+
+	if g.Main != nil && g.Main.Expr != nil {
+		switch n, is := g.Main.Expr.(x.N); {
+		case is && len(n) == 2:
+			main = append(main, def{g.Main.Name, `<=`, g.Main.Doc, n[1]})
+		case g.Main.IsAlias():
+			main = append(main, def{g.Main.Name, `<-`, g.Main.Doc, g.Main.Expr})
+		default:
+			main = append(main, def{DefaultMainName, `<-`, g.Main.Doc, g.Main.Expr})
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range g.Ordered() {
+		if rule == g.Main || seen[rule.Name] {
+			continue
+		}
+		if rule.IsAlias() {
+			seen[rule.Name] = true
+			defs = append(defs, def{rule.Name, `<-`, rule.Doc, rule.Expr})
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		seen[rule.Name] = true
+		defs = append(defs, def{rule.Name, `<=`, rule.Doc, n[1]})
+	}
+	sort.Slice(defs, func(a, b int) bool { return defs[a].name < defs[b].name })
+
+	buf := new(strings.Builder)
+	for _, d := range append(main, defs...) {
+		if d.doc != "" {
+			for _, line := range strings.Split(d.doc, "\n") {
+				buf.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+			}
+		}
+		buf.WriteString(d.name + ` ` + d.op + ` ` + x.PEGNExpr(x.Canonical(d.exp)) + "\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,37 @@
+package rat
+
+// EachFunc is passed the index (n) of every input scanned by ScanEach
+// and its Result.
+type EachFunc func(n int, res Result)
+
+// ScanEach scans every input with the Main rule (see Scan) passing each
+// Result to do. Since a single input buffer is reused (decoding every
+// input into it) and the result tree is released (see Release) after do
+// returns (unless Emit is set), the setup costs of scanning are paid
+// once for all of the inputs rather than once for each making ScanEach
+// much faster than Scan for validating many short strings. The Result
+// (and its buffer) passed to do must not be kept after it returns (see
+// Result.Text and such to keep what is needed).
+func (g *Grammar) ScanEach(inputs []string, do EachFunc) {
+	if g.Main == nil {
+		for n := range inputs {
+			do(n, Result{X: ErrIsZero{g.Main}})
+		}
+		return
+	}
+	for n, in := range inputs {
+		g.runes = g.runes[:0]
+		for _, r := range in {
+			g.runes = append(g.runes, r)
+		}
+		g.Farthest = Result{}
+		var res Result
+		if g.Main.Check == nil {
+			res = Result{X: ErrNoCheckFunc{*g.Main}}
+		} else {
+			res = g.Main.Check(g.runes, 0)
+		}
+		do(n, res)
+		g.discard(res)
+	}
+}
@@ -0,0 +1,56 @@
+package ebnf
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// Diff is the EBNF difference (A - B) of two expressions: what A
+// matches unless B matches exactly the same span of the input. It is
+// a custom expression type (see rat.Maker) since no rat/x expression
+// can compare the spans of two others. PEGN has no difference so it is
+// rendered as !B A instead, which only differs when B matches
+// a prefix of what A matches (the ToPEGN of Name ::= NameChar+ - 'xml'
+// also rejects xmlns).
+//
+//	ebnf.Diff{rule, except}
+type Diff []any
+
+func (d Diff) String() string {
+	if len(d) != 2 {
+		return UsageDiff
+	}
+	return fmt.Sprintf(`ebnf.Diff{%v, %v}`, x.String(d[0]), x.String(d[1]))
+}
+
+func (d Diff) PEGN() string {
+	if len(d) != 2 {
+		return UsageDiff
+	}
+	return x.PEGNExpr(x.Seq{x.Not{d[1]}, d[0]})
+}
+
+// Make makes the rule checking A and then B at the same position
+// failing with rat.ErrExpected if B ends where A ended. The result of
+// A is returned otherwise.
+func (d Diff) Make(g *rat.Grammar) *rat.Rule {
+	if len(d) != 2 {
+		panic(UsageDiff)
+	}
+	rule, except := g.MakeRule(d[0]), g.MakeRule(d[1])
+	expected := error(rat.ErrExpected{V: d})
+	diff := new(rat.Rule)
+	diff.Check = func(r []rune, i int) rat.Result {
+		res := rule.Check(r, i)
+		if res.X != nil {
+			return res
+		}
+		if not := except.Check(r, i); not.X != nil || not.E != res.E {
+			return res
+		}
+		return rat.Result{O: diff, R: r, B: i, E: i, X: expected}
+	}
+	return diff
+}
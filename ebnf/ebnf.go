@@ -0,0 +1,294 @@
+/*
+Package ebnf converts grammars written in the Extended Backus-Naur Form
+notation of the W3C (used by the XML, XPath, and many other
+specifications) into rat/x expressions, PEGN, or fully compiled
+*rat.Grammar instances. Like the pegn package, the EBNF parser itself
+is written entirely in rat/x.
+
+The following EBNF is supported (see rat/x for the equivalent types):
+
+	foo ::= rule         x.N{"foo", rule}
+	[1] foo ::= rule     x.N{"foo", rule} (production number ignored)
+	foo                  x.Ref{"foo"}
+	rule1 rule2          x.Seq{rule1, rule2}
+	rule1 | rule2        x.One{rule1, rule2}
+	rule1 - rule2        ebnf.Diff{rule1, rule2}
+	(rule)               rule
+	rule?                x.Mmx{0, 1, rule}
+	rule*                x.Mmx{0, -1, rule}
+	rule+                x.Mmx{1, -1, rule}
+	"ab" / 'ab'          x.Str{"ab"}
+	#x41                 x.Str{"A"}
+	[a-zA-Z_]            x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}, x.Str{"_"}}
+	[#x41-#x5A]          x.Rng{'A', 'Z'}
+	[^<&]                x.Seq{x.Not{x.One{x.Str{"<"}, x.Str{"&"}}}, x.Any{1}}
+
+The difference (A - B) matches what A matches unless B matches exactly
+the same span (see Diff) so Name ::= NameChar+ - 'xml' rejects xml but
+not xmlns. It is approximated as !B A in PEGN. Both C-style comments
+and the well-formedness and validity constraint annotations ([ wfc: ...
+] and [ vc: ... ]) are ignored. The difference operator binds tighter
+than a sequence (as in the XML specification).
+*/
+package ebnf
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	ws      = x.One{' ', '\t', '\r', '\n'}
+	comment = x.Seq{"/*", x.To{"*/"}, "*/"}
+	note    = x.Seq{'[', x.Mmx{0, -1, ws}, x.One{"wfc", "WFC", "vc", "VC"}, x.Mmx{0, -1, ws}, ':', x.To{']'}, ']'}
+	sp      = x.Mmx{0, -1, x.One{ws, comment, note}}
+	letter  = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}, '_'}
+	digit   = x.Rng{'0', '9'}
+	hexdig  = x.One{digit, x.Rng{'a', 'f'}, x.Rng{'A', 'F'}}
+	name    = x.Seq{letter, x.Mmx{0, -1, x.One{letter, digit}}}
+	number  = x.Seq{'[', x.Mmx{1, -1, digit}, ']'}
+	start   = x.Seq{x.Mmx{0, 1, x.Seq{number, sp}}, name, sp, "::="}
+	hexchar = x.N{`Hex`, x.Seq{"#x", x.Mmx{1, -1, hexdig}}}
+)
+
+// syntax is the EBNF grammar itself expressed in rat/x (see
+// notation.Parser).
+var syntax = []any{
+	x.N{`Grammar`, x.Seq{
+		sp, x.Mmx{0, -1, x.Seq{x.Ref{`Production`}, sp}}, x.End{},
+	}},
+	x.N{`Production`, x.Seq{
+		x.Mmx{0, 1, x.Seq{number, sp}},
+		x.N{`Symbol`, name}, sp, "::=", sp,
+		x.Ref{`Choice`},
+	}},
+	x.N{`Choice`, x.Seq{
+		x.Ref{`Sequence`},
+		x.Mmx{0, -1, x.Seq{sp, '|', sp, x.Ref{`Sequence`}}},
+	}},
+	x.N{`Sequence`, x.Seq{
+		x.Ref{`Diff`},
+		x.Mmx{0, -1, x.Seq{sp, x.Not{start}, x.Ref{`Diff`}}},
+	}},
+	x.N{`Diff`, x.Seq{
+		x.Ref{`Item`},
+		x.Mmx{0, 1, x.Seq{sp, '-', sp, x.Ref{`Item`}}},
+	}},
+	x.N{`Item`, x.Seq{
+		x.One{
+			x.Seq{'(', sp, x.Ref{`Choice`}, sp, ')'},
+			x.N{`Str`, x.One{
+				x.Seq{'"', x.Mmx{0, -1, x.Seq{x.Not{'"'}, x.Any{1}}}, '"'},
+				x.Seq{'\'', x.Mmx{0, -1, x.Seq{x.Not{'\''}, x.Any{1}}}, '\''},
+			}},
+			hexchar,
+			x.N{`Class`, x.Seq{
+				'[', x.Mmx{0, 1, x.N{`Negated`, '^'}},
+				x.Mmx{1, -1, x.Ref{`Range`}},
+				']',
+			}},
+			x.N{`Ref`, name},
+		},
+		x.Mmx{0, 1, x.N{`Quant`, x.One{'?', '*', '+'}}},
+	}},
+	x.N{`Range`, x.Seq{
+		x.Ref{`Endpoint`},
+		x.Mmx{0, 1, x.Seq{'-', x.Not{']'}, x.Ref{`Endpoint`}}},
+	}},
+	x.N{`Endpoint`, x.One{hexchar, x.N{`Char`, x.Seq{x.Not{']'}, x.Any{1}}}}},
+}
+
+// ToX converts a W3C EBNF grammar (string, []byte, []rune, or
+// io.Reader) into a named rat/x expression (x.N) for every production
+// (in the order defined). An ErrParse is returned if the EBNF is
+// invalid, unsupported, or defines the same symbol more than once.
+func ToX(in any) ([]x.N, error) {
+	res := notation.Scan(syntax, in)
+	if res.X != nil {
+		return nil, newErrParse(res)
+	}
+
+	var defs []x.N
+	seen := map[string]bool{}
+	for _, prod := range res.Children(`Production`) {
+		sym, _ := prod.Child(`Symbol`)
+		choice, _ := prod.Child(`Choice`)
+		if seen[sym.Text()] {
+			return nil, newErrParse(sym)
+		}
+		seen[sym.Text()] = true
+		it, err := convert(choice)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, x.N{sym.Text(), it})
+	}
+	return defs, nil
+}
+
+// Parse converts a W3C EBNF grammar (see ToX) into a new compiled
+// *rat.Grammar with the first production as the Main rule.
+func Parse(in any) (*rat.Grammar, error) {
+	defs, err := ToX(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, ErrParse{Notation: Notation, Line: 1, Col: 1}
+	}
+	g := new(rat.Grammar).Init()
+	for _, def := range defs[1:] {
+		g.MakeRule(def)
+	}
+	return g.Pack(defs[0]), nil
+}
+
+// ToPEGN converts a W3C EBNF grammar (see ToX) into the equivalent PEGN
+// document (see rat.Grammar.PEGN).
+func ToPEGN(in any) (string, error) {
+	g, err := Parse(in)
+	if err != nil {
+		return "", err
+	}
+	return g.PEGN(), nil
+}
+
+// convert converts a named result from the EBNF syntax into the
+// equivalent rat/x expression.
+func convert(r rat.Result) (any, error) {
+
+	list := func() ([]any, error) {
+		var list []any
+		for _, it := range r.C {
+			v, err := convert(it)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	switch r.N {
+
+	case `Choice`, `Sequence`:
+		l, err := list()
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 1 {
+			return l[0], nil
+		}
+		if r.N == `Choice` {
+			return x.One(l), nil
+		}
+		return x.Seq(l), nil
+
+	case `Diff`:
+		l, err := list()
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 1 {
+			return l[0], nil
+		}
+		return Diff{l[0], l[1]}, nil
+
+	case `Item`:
+		if len(r.C) == 0 {
+			return nil, newErrParse(r)
+		}
+		it, err := convert(r.C[0])
+		if err != nil || len(r.C) == 1 {
+			return it, err
+		}
+		switch r.C[1].Text() {
+		case `?`:
+			return x.Mmx{0, 1, it}, nil
+		case `*`:
+			return x.Mmx{0, -1, it}, nil
+		}
+		return x.Mmx{1, -1, it}, nil
+
+	case `Ref`:
+		return x.Ref{r.Text()}, nil
+
+	case `Str`:
+		text := r.Text()
+		return text[1 : len(text)-1], nil
+
+	case `Hex`:
+		c, err := hex(r)
+		return string(c), err
+
+	case `Class`:
+		var one x.One
+		var negated bool
+		for _, it := range r.C {
+			if it.N == `Negated` {
+				negated = true
+				continue
+			}
+			rng, err := class(it)
+			if err != nil {
+				return nil, err
+			}
+			one = append(one, rng)
+		}
+		var it any = one
+		if len(one) == 1 {
+			it = one[0]
+		}
+		if negated {
+			return x.Seq{x.Not{it}, x.Any{1}}, nil
+		}
+		return it, nil
+
+	}
+
+	return nil, newErrParse(r)
+}
+
+// class converts a character class Range result (a, a-z, #x41-#x5A)
+// into an x.Str (string) or x.Rng.
+func class(r rat.Result) (any, error) {
+	var ends []rune
+	for _, it := range r.C {
+		if len(it.C) != 1 {
+			return nil, newErrParse(it)
+		}
+		c, err := endpoint(it.C[0])
+		if err != nil {
+			return nil, err
+		}
+		ends = append(ends, c)
+	}
+	switch {
+	case len(ends) == 1:
+		return string(ends[0]), nil
+	case len(ends) == 2 && ends[0] <= ends[1]:
+		return x.Rng{ends[0], ends[1]}, nil
+	}
+	return nil, newErrParse(r)
+}
+
+// endpoint returns the rune of a Hex or Char result.
+func endpoint(r rat.Result) (rune, error) {
+	if r.N == `Hex` {
+		return hex(r)
+	}
+	return []rune(r.Text())[0], nil
+}
+
+// hex returns the rune of a Hex result (#x41).
+func hex(r rat.Result) (rune, error) {
+	n, err := strconv.ParseUint(r.Text()[2:], 16, 32)
+	if err != nil || n > unicode.MaxRune {
+		return 0, newErrParse(r)
+	}
+	return rune(n), nil
+}
@@ -0,0 +1,16 @@
+package ebnf
+
+import (
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+)
+
+// ErrParse is returned when EBNF text cannot be parsed or contains
+// something that cannot be converted (such as an invalid character
+// range). Line and Col (both starting at 1) are the location of the
+// offending EBNF.
+type ErrParse = notation.ErrParse
+
+// newErrParse returns a new ErrParse of the EBNF Notation from the
+// position of the result (see notation.NewErrParse).
+func newErrParse(r rat.Result) ErrParse { return notation.NewErrParse(Notation, r) }
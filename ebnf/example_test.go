@@ -0,0 +1,76 @@
+package ebnf_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/ebnf"
+)
+
+func ExampleToPEGN() {
+
+	s, err := ebnf.ToPEGN(`
+/* from XML 1.0 (simplified) */
+[3]  S        ::= (#x20 | #x9 | #xD | #xA)+
+[5]  Name     ::= NameChar+ - 'xml'
+     NameChar ::= [a-zA-Z0-9_:#x2D.]
+[10] AttValue ::= '"' ([^<&"] | Ref)* '"'
+                | "'" ([^<&'] | Ref)* "'"   [ wfc: No < in Values ]
+     Ref      ::= '&' Name ';'
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(s)
+
+	// Output:
+	// S        <= (' ' / x09 / x0D / x0A)+
+	// Name     <= !'xml' NameChar+
+	// NameChar <= [a-z] / [A-Z] / [0-9] / '_' / ':' / '-' / '.'
+	// AttValue <= '"' (!('<' / '&' / '"') . / Ref)* '"' / x27 (!('<' / '&' / x27) . / Ref)* x27
+	// Ref      <= '&' Name ';'
+
+}
+
+func ExampleParse() {
+
+	g, err := ebnf.Parse(`Greeting ::= ("hello" | "hi") " " [A-Z] [a-z]*`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Scan(`hi Rob`).PrintText()
+	g.Scan(`hey Rob`).PrintError()
+
+	_, err = ebnf.Parse(`Greeting ::= "hello" | [z-a]`)
+	fmt.Println(err)
+
+	// Output:
+	// hi Rob
+	// expected: x.One{x.Str{"hello"}, x.Str{"hi"}}
+	// 1 | hey Rob
+	//   | ^
+	// invalid EBNF at line 1, column 25: "z-a"
+
+}
+
+func ExampleDiff() {
+
+	g, err := ebnf.Parse(`
+Name     ::= NameChar+ - 'xml'
+NameChar ::= [a-z]
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Scan(`xmlns`).PrintText()
+	fmt.Println(g.Scan(`xml`).X)
+	fmt.Println(g.Rules[`Name`])
+
+	// Output:
+	// xmlns
+	// expected: ebnf.Diff{x.Mmx{1, -1, x.Ref{"NameChar"}}, x.Str{"xml"}}
+	// x.N{"Name", ebnf.Diff{x.Mmx{1, -1, x.Ref{"NameChar"}}, x.Str{"xml"}}}
+
+}
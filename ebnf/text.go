@@ -0,0 +1,12 @@
+package ebnf
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	Notation = `EBNF` // named by every ErrParse
+)
+
+const (
+	UsageDiff = `%!USAGE: ebnf.Diff{rule, except}`
+)
@@ -0,0 +1,27 @@
+package rat
+
+// lf returns the runes with every CRLF and lone CR replaced by a single
+// LF (see NormalizeEOL). The runes are returned as is (never changed)
+// if there is no CR.
+func lf(runes []rune) []rune {
+	n := 0
+	for n < len(runes) && runes[n] != '\r' {
+		n++
+	}
+	if n == len(runes) {
+		return runes
+	}
+	out := make([]rune, n, len(runes))
+	copy(out, runes[:n])
+	for i := n; i < len(runes); i++ {
+		if runes[i] != '\r' {
+			out = append(out, runes[i])
+			continue
+		}
+		out = append(out, '\n')
+		if i+1 < len(runes) && runes[i+1] == '\n' {
+			i++
+		}
+	}
+	return out
+}
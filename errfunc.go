@@ -0,0 +1,43 @@
+package rat
+
+// ErrFunc returns the error that replaces the error (err) of the named
+// rule (name) that failed to match the input (r) beginning at i so
+// that applications can turn low-level expectations (see ErrExpected)
+// into diagnostics that make sense to those writing the input.
+// Returning nil keeps the original error.
+type ErrFunc func(name string, r []rune, i int, err error) error
+
+// OnError adds (or replaces if fn is nil) the ErrFunc called whenever
+// the named rule fails (see Errors). Only named (x.N) rules have hooks.
+func (g *Grammar) OnError(name string, fn ErrFunc) *Grammar {
+	if fn == nil {
+		delete(g.Errors, name)
+		return g
+	}
+	if g.Errors == nil {
+		g.Errors = map[string]ErrFunc{}
+	}
+	g.Errors[name] = fn
+	return g
+}
+
+// Message replaces the error of the named rule whenever it fails with
+// an ErrMessage containing the message (msg) which wraps the original.
+func (g *Grammar) Message(name, msg string) *Grammar {
+	return g.OnError(name, func(name string, r []rune, i int, err error) error {
+		return ErrMessage{Name: name, I: i, Msg: msg, X: err}
+	})
+}
+
+// rewrite returns the error (err) of the named rule as rewritten by its
+// ErrFunc (if any).
+func (g *Grammar) rewrite(name string, r []rune, i int, err error) error {
+	fn, has := g.Errors[name]
+	if !has {
+		return err
+	}
+	if e := fn(name, r, i, err); e != nil {
+		return e
+	}
+	return err
+}
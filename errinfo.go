@@ -0,0 +1,148 @@
+package rat
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Error codes (see ErrorInfo) are stable identifiers of the kind of
+// error for programs (unlike the message, which can be translated, see
+// SetMessages).
+const (
+	CodeExpected   = `expected`    // ErrExpected
+	CodeOneOf      = `one_of`      // ErrOneOf
+	CodeIncomplete = `incomplete`  // ErrIncomplete
+	CodeSyntax     = `syntax`      // ErrSyntax
+	CodeMessage    = `message`     // ErrMessage
+	CodeUnresolved = `unresolved`  // ErrUnresolved or ErrRefs
+	CodeTooDeep    = `too_deep`    // ErrTooDeep
+	CodeTooMany    = `too_many`    // ErrTooManySteps
+	CodeTooLarge   = `too_large`   // ErrTooLarge
+	CodeTimeout    = `timeout`     // ErrTimeout
+	CodeEncoding   = `encoding`    // ErrInvalidEncoding
+	CodeGrammar    = `bad_grammar` // any other ErrBadGrammar
+	CodeLimit      = `limit`       // any other ErrLimit
+	CodeError      = `error`       // anything else
+)
+
+// ErrorInfo is the machine-readable form of the error (X) of a result
+// (see Result.ErrorInfo) for services returning failures to clients
+// as JSON. Offset is the position (in runes) at which the input failed
+// and Line and Column (both starting at 1) are the same position. Rule
+// is the name of the innermost named rule known to have failed.
+type ErrorInfo struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Source  string `json:"source,omitempty"`
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// ErrorInfo returns the machine-readable form of the error (X) of the
+// result or nil if there is none. The position and rule are taken from
+// the error when it has them (see ErrAt, ErrIn, ErrMessage, and
+// ErrOneOf, for example) and from the result otherwise.
+func (m Result) ErrorInfo() *ErrorInfo {
+	if m.X == nil {
+		return nil
+	}
+	info := &ErrorInfo{
+		Code:    Code(m.X),
+		Message: m.X.Error(),
+		Source:  m.S,
+		Offset:  m.E,
+		Rule:    m.N,
+	}
+	if i, has := offset(m.X); has {
+		info.Offset = i
+	}
+	if rule := failed(m.X); rule != "" {
+		info.Rule = rule
+	}
+	info.Line, info.Column = Result{R: m.R, B: info.Offset}.Pos()
+	return info
+}
+
+// MarshalError returns the JSON form of the ErrorInfo of the result
+// (null if there is no error).
+func (m Result) MarshalError() ([]byte, error) { return json.Marshal(m.ErrorInfo()) }
+
+// Code returns the code (see ErrorInfo) of the first error in the
+// chain (see errors.Unwrap) that has one.
+func Code(err error) string {
+	for it := err; it != nil; it = errors.Unwrap(it) {
+		switch it.(type) {
+		case ErrExpected:
+			return CodeExpected
+		case ErrOneOf:
+			return CodeOneOf
+		case ErrIncomplete:
+			return CodeIncomplete
+		case ErrSyntax:
+			return CodeSyntax
+		case ErrMessage:
+			return CodeMessage
+		case ErrUnresolved, ErrRefs:
+			return CodeUnresolved
+		case ErrTooDeep:
+			return CodeTooDeep
+		case ErrTooManySteps:
+			return CodeTooMany
+		case ErrTooLarge:
+			return CodeTooLarge
+		case ErrTimeout:
+			return CodeTimeout
+		case ErrInvalidEncoding:
+			return CodeEncoding
+		}
+	}
+	switch {
+	case errors.Is(err, ErrBadGrammar):
+		return CodeGrammar
+	case errors.Is(err, ErrLimit):
+		return CodeLimit
+	}
+	return CodeError
+}
+
+// offset returns the position of the first error in the chain that has
+// one.
+func offset(err error) (int, bool) {
+	for it := err; it != nil; it = errors.Unwrap(it) {
+		switch e := it.(type) {
+		case ErrAt:
+			return e.I, true
+		case ErrOneOf:
+			return e.I, true
+		case ErrSyntax:
+			return e.I, true
+		case ErrTooDeep:
+			return e.I, true
+		case ErrTooManySteps:
+			return e.I, true
+		case ErrTimeout:
+			return e.I, true
+		}
+	}
+	return 0, false
+}
+
+// failed returns the name of the innermost named rule in the chain of
+// the error (see ErrIn and ErrMessage) or nothing if there is none.
+func failed(err error) string {
+	var name string
+	for it := err; it != nil; it = errors.Unwrap(it) {
+		switch e := it.(type) {
+		case ErrMessage:
+			name = e.Name
+		case ErrIn:
+			if !strings.HasPrefix(e.Rule, `x.`) {
+				name = e.Rule
+			}
+		}
+	}
+	return name
+}
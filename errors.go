@@ -1,33 +1,471 @@
 package rat
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// ---------------------------- Categories ----------------------------
+
+// Every error type of this package that is in a category matches it
+// with errors.Is (even when wrapped) so that callers can branch on the
+// kind of failure without matching error text:
+//
+//	ErrNoMatch    ErrExpected, ErrOneOf, ErrIncomplete, ErrSyntax, ErrMessage
+//	ErrLimit      ErrEmptyToken, ErrNullable, ErrGenerate, ErrTooDeep,
+//	              ErrTooManySteps, ErrTooLarge, ErrTimeout
+//	ErrBadGrammar ErrIsZero, ErrNoCheckFunc, ErrArgs, ErrUsage, ErrMake, ErrUnreachable,
+//	              ErrUnresolved, ErrBounds, ErrConflict
+var (
+	ErrNoMatch    = errors.New(ErrNoMatchT)    // input does not match
+	ErrLimit      = errors.New(ErrLimitT)      // stopped to avoid endless work
+	ErrBadGrammar = errors.New(ErrBadGrammarT) // grammar cannot be used
+)
 
 // ----------------------------- ErrIsZero ----------------------------
 
 type ErrIsZero struct{ V any }
 
-func (e ErrIsZero) Error() string { return fmt.Sprintf(ErrIsZeroT, e.V) }
+func (e ErrIsZero) Error() string { return fmt.Sprintf(msg(ErrIsZeroT), e.V) }
+
+func (e ErrIsZero) Is(target error) bool { return target == ErrBadGrammar }
 
 // ---------------------------- ErrExpected ---------------------------
 
 type ErrExpected struct{ V any }
 
-func (e ErrExpected) Error() string { return fmt.Sprintf(ErrExpectedT, e.V) }
+func (e ErrExpected) Error() string { return fmt.Sprintf(msg(ErrExpectedT), e.V) }
+
+func (e ErrExpected) Is(target error) bool { return target == ErrNoMatch }
+
+// ------------------------------- ErrAt ------------------------------
+
+// ErrAt is an error (X, usually ErrExpected) at position I (in runes)
+// on Line at column Col (both starting at 1). Grammar.Scan wraps the
+// ErrExpected of a failed result with it (see Grammar.Locate) so that
+// the error alone says where the input failed. Use errors.As to get
+// the ErrExpected.
+type ErrAt struct {
+	I    int
+	Line int
+	Col  int
+	X    error
+}
+
+func (e ErrAt) Error() string { return fmt.Sprintf(msg(ErrAtT), e.X, e.Line, e.Col) }
+
+func (e ErrAt) Unwrap() error { return e.X }
 
 // ------------------------------ ErrArgs -----------------------------
 
 type ErrArgs struct{ any }
 
 func (e ErrArgs) Error() string {
-	return fmt.Sprintf(ErrArgsT, e.any)
+	return fmt.Sprintf(msg(ErrArgsT), e.any)
 }
 
+func (e ErrArgs) Is(target error) bool { return target == ErrBadGrammar }
+
 // -------------------------- ErrNoCheckFunc --------------------------
 
 type ErrNoCheckFunc struct{ V any }
 
-func (e ErrNoCheckFunc) Error() string { return fmt.Sprintf(ErrNoCheckFuncT, e.V) }
+func (e ErrNoCheckFunc) Error() string { return fmt.Sprintf(msg(ErrNoCheckFuncT), e.V) }
+
+func (e ErrNoCheckFunc) Is(target error) bool { return target == ErrBadGrammar }
+
+// --------------------------- ErrEmptyToken --------------------------
+
+type ErrEmptyToken struct{ V any }
+
+func (e ErrEmptyToken) Error() string { return fmt.Sprintf(msg(ErrEmptyTokenT), e.V) }
+
+func (e ErrEmptyToken) Is(target error) bool { return target == ErrLimit }
+
+// ------------------------- ErrInvalidEncoding -----------------------
+
+// ErrInvalidEncoding is input that is not valid UTF-8 beginning at
+// Offset (in bytes, see UTF8Strict).
+type ErrInvalidEncoding struct{ Offset int }
+
+func (e ErrInvalidEncoding) Error() string {
+	return fmt.Sprintf(msg(ErrInvalidEncodingT), e.Offset)
+}
+
+// ------------------------------ ErrCBOR -----------------------------
+
+type ErrCBOR struct{ I int }
+
+func (e ErrCBOR) Error() string { return fmt.Sprintf(msg(ErrCBORT), e.I) }
 
 // ---------------------------- ErrNotFound ---------------------------
 
 type ErrNotFound struct{ any }
+
+func (e ErrNotFound) Error() string { return fmt.Sprintf(msg(ErrNotExistT), e.any) }
+
+// --------------------------- ErrIncomplete --------------------------
+
+// ErrIncomplete is set when a match ends (B) before the end (E) of the
+// input that must be matched entirely (see ScanAll and ScanWith).
+type ErrIncomplete struct{ B, E int }
+
+func (e ErrIncomplete) Error() string { return fmt.Sprintf(msg(ErrIncompleteT), e.B, e.E) }
+
+func (e ErrIncomplete) Is(target error) bool { return target == ErrNoMatch }
+
+// ------------------------------- ErrIn ------------------------------
+
+// ErrIn is the error (X) of a rule within the rule (Rule, the name of
+// a named rule, x.Seq, x.One, or the x.Val) that failed at position I (in runes)
+// because of it (see Grammar.Chain). The message is that of X.
+type ErrIn struct {
+	Rule string
+	I    int
+	X    error
+}
+
+func (e ErrIn) Error() string { return e.X.Error() }
+
+func (e ErrIn) Unwrap() error { return e.X }
+
+// Path returns the Rule of every ErrIn in the chain (outermost first)
+// ending with the expression (Go form, see x.String) that was expected
+// by the innermost rule (if an ErrExpected), for example:
+//
+//	[Fenced x.Seq x.Val{"Post"} x.Str{"`"}]
+func (e ErrIn) Path() []string {
+	var path []string
+	var err error = e
+	for {
+		in, is := err.(ErrIn)
+		if !is {
+			break
+		}
+		path = append(path, in.Rule)
+		err = in.X
+	}
+	if exp, is := err.(ErrExpected); is {
+		path = append(path, x.String(exp.V))
+	}
+	return path
+}
+
+// unchained returns the innermost error of the ErrIn chain (see
+// Grammar.Chain) or the error itself if not an ErrIn.
+func unchained(err error) error {
+	for {
+		in, is := err.(ErrIn)
+		if !is {
+			return err
+		}
+		err = in.X
+	}
+}
+
+// --------------------------- ErrUnresolved --------------------------
+
+// ErrUnresolved is a rule (V, an x.Ref or x.Val) referring to a name
+// that no rule of the grammar has (or saves) with the closest names
+// that do (Suggest, closest first) in case of a typo.
+type ErrUnresolved struct {
+	V       any
+	Suggest []string
+}
+
+func (e ErrUnresolved) Error() string {
+	if len(e.Suggest) == 0 {
+		return fmt.Sprintf(msg(ErrUnresolvedT), e.V)
+	}
+	return fmt.Sprintf(msg(ErrSuggestT), e.V, strings.Join(e.Suggest, msg(ErrSuggestSep)))
+}
+
+func (e ErrUnresolved) Is(target error) bool { return target == ErrBadGrammar }
+
+// ------------------------------ ErrRefs -----------------------------
+
+// ErrRefs is every ErrUnresolved of a grammar (see Unresolved).
+type ErrRefs []error
+
+func (e ErrRefs) Error() string {
+	list := make([]string, len(e))
+	for n, err := range e {
+		list[n] = err.Error()
+	}
+	return strings.Join(list, msg(ErrRefsSep))
+}
+
+func (e ErrRefs) Unwrap() []error { return e }
+
+// ----------------------------- ErrStream ----------------------------
+
+// ErrStream is the error (X) of a record that failed at a position (I,
+// in runes) within a stream (see ScanStream).
+type ErrStream struct {
+	I int
+	X error
+}
+
+func (e ErrStream) Error() string { return fmt.Sprintf(msg(ErrStreamT), e.I, e.X) }
+
+func (e ErrStream) Unwrap() error { return e.X }
+
+// ----------------------------- ErrOneOf -----------------------------
+
+// ErrOneOf is every expectation (PEGN for those that are ErrExpected)
+// that failed at the farthest position (I, in runes) of a failed Scan
+// (see Grammar.Expect).
+type ErrOneOf struct {
+	I        int
+	Expected []string
+}
+
+func (e ErrOneOf) Error() string {
+	if len(e.Expected) == 1 {
+		return fmt.Sprintf(msg(ErrExpectedT), e.Expected[0])
+	}
+	return fmt.Sprintf(msg(ErrOneOfT), strings.Join(e.Expected, msg(ErrOneOfSep)))
+}
+
+func (e ErrOneOf) Is(target error) bool { return target == ErrNoMatch }
+
+// ---------------------------- ErrMessage ----------------------------
+
+// ErrMessage is the message (Msg) replacing the error (X) of the named
+// rule (Name) that failed to match beginning at position I (see
+// Grammar.Message). Use errors.As to get the original error.
+type ErrMessage struct {
+	Name string
+	I    int
+	Msg  string
+	X    error
+}
+
+func (e ErrMessage) Error() string { return e.Msg }
+
+func (e ErrMessage) Is(target error) bool { return target == ErrNoMatch }
+
+func (e ErrMessage) Unwrap() error { return e.X }
+
+// ----------------------------- ErrSyntax ----------------------------
+
+// ErrSyntax is the farthest failure (see Diagnose) at line and column
+// (Line and Col starting at 1) and position (I, in runes) with every
+// expectation that failed there (PEGN for those that are ErrExpected).
+type ErrSyntax struct {
+	Line     int
+	Col      int
+	I        int
+	Expected []string
+}
+
+func (e ErrSyntax) Error() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf(msg(ErrUnexpectedT), e.Line, e.Col)
+	}
+	return fmt.Sprintf(msg(ErrSyntaxT), e.Line, e.Col, strings.Join(e.Expected, msg(ErrSyntaxOr)))
+}
+
+func (e ErrSyntax) Is(target error) bool { return target == ErrNoMatch }
+
+// -------------------------- ErrUnreachable --------------------------
+
+// ErrUnreachable is an alternative (Alt) of a One (Text) that is never
+// checked because an earlier alternative (By) always matches first
+// (see Grammar.Validate).
+type ErrUnreachable struct {
+	One string
+	Alt any
+	By  any
+}
+
+func (e ErrUnreachable) Error() string {
+	return fmt.Sprintf(msg(ErrUnreachableT), x.String(e.Alt), x.String(e.By), e.One)
+}
+
+func (e ErrUnreachable) Is(target error) bool { return target == ErrBadGrammar }
+
+// ----------------------------- ErrBounds ----------------------------
+
+// ErrBounds is a repetition (V, an x.Any or x.Mmx) with bounds that can
+// only match nothing, which is almost certainly a mistake (see
+// Grammar.Warnings).
+type ErrBounds struct{ V any }
+
+func (e ErrBounds) Error() string { return fmt.Sprintf(msg(ErrBoundsT), e.V) }
+
+func (e ErrBounds) Is(target error) bool { return target == ErrBadGrammar }
+
+// --------------------------- ErrLongLiteral -------------------------
+
+// ErrLongLiteral is a literal string (of Len bytes) so long that its
+// rule is keyed by hash (see HashKeyLen and Grammar.Warnings), usually
+// text that should be matched by a rule rather than spelled out.
+type ErrLongLiteral struct{ Len int }
+
+func (e ErrLongLiteral) Error() string { return fmt.Sprintf(msg(ErrLongLiteralT), e.Len) }
+
+// ---------------------------- ErrNullable ---------------------------
+
+// ErrNullable is an unlimited repetition (x.Mmx) of a rule that can
+// match without advancing which (since it would otherwise repeat
+// forever) stops at the first such match (see Grammar.Validate).
+type ErrNullable struct{ V any }
+
+func (e ErrNullable) Error() string { return fmt.Sprintf(msg(ErrNullableT), e.V) }
+
+func (e ErrNullable) Is(target error) bool { return target == ErrLimit }
+
+// ---------------------------- ErrTooDeep ----------------------------
+
+// ErrTooDeep is returned when the nesting of rule checks exceeds the
+// Limit (see Grammar.MaxNesting) at position I (in runes).
+type ErrTooDeep struct{ Limit, I int }
+
+func (e ErrTooDeep) Error() string { return fmt.Sprintf(msg(ErrTooDeepT), e.Limit, e.I) }
+
+func (e ErrTooDeep) Is(target error) bool { return target == ErrLimit }
+
+// -------------------------- ErrTooManySteps -------------------------
+
+// ErrTooManySteps is returned when the number of rules checked exceeds
+// the Limit (see Grammar.MaxSteps) at position I (in runes).
+type ErrTooManySteps struct{ Limit, I int }
+
+func (e ErrTooManySteps) Error() string {
+	return fmt.Sprintf(msg(ErrTooManyStepsT), e.Limit, e.I)
+}
+
+func (e ErrTooManySteps) Is(target error) bool { return target == ErrLimit }
+
+// ---------------------------- ErrTooLarge ---------------------------
+
+// ErrTooLarge is returned when the input (of at least Size runes)
+// exceeds the Limit (see Grammar.MaxInput) before it is scanned.
+type ErrTooLarge struct{ Limit, Size int }
+
+func (e ErrTooLarge) Error() string { return fmt.Sprintf(msg(ErrTooLargeT), e.Limit) }
+
+func (e ErrTooLarge) Is(target error) bool { return target == ErrLimit }
+
+// ---------------------------- ErrTimeout ----------------------------
+
+// ErrTimeout is returned when a scan takes longer than the Limit (see
+// Grammar.Timeout) stopping at position I (in runes).
+type ErrTimeout struct {
+	Limit time.Duration
+	I     int
+}
+
+func (e ErrTimeout) Error() string { return fmt.Sprintf(msg(ErrTimeoutT), e.Limit, e.I) }
+
+func (e ErrTimeout) Is(target error) bool { return target == ErrLimit }
+
+// ---------------------------- ErrGenerate ---------------------------
+
+// ErrGenerate is returned when none of the inputs generated (Tries)
+// were matched entirely by the grammar (see Generator.Next).
+type ErrGenerate struct{ Tries int }
+
+func (e ErrGenerate) Error() string { return fmt.Sprintf(msg(ErrGenerateT), e.Tries) }
+
+func (e ErrGenerate) Is(target error) bool { return target == ErrLimit }
+
+// ----------------------------- ErrReplay ----------------------------
+
+// ErrReplay is the first part (What) of a replayed Recording that
+// differs from what was recorded (see Grammar.Replay).
+type ErrReplay struct {
+	What string
+	Want string
+	Got  string
+}
+
+func (e ErrReplay) Error() string { return fmt.Sprintf(msg(ErrReplayT), e.What, e.Got, e.Want) }
+
+// ----------------------------- ErrUsage -----------------------------
+
+// ErrUsage is panicked when making a rule from a malformed expression
+// with the Usage of its rat/x type (usually) and the Path of every
+// expression enclosing it (outermost first, ending with its type) in
+// the expression made, such as x.N{"Fenced"}, x.Seq[2] (third item),
+// and x.Mmx.
+type ErrUsage struct {
+	Usage any
+	Path  []string
+	at    string // String form of the expression at the front of Path
+}
+
+func (e ErrUsage) Error() string {
+	if len(e.Path) < 2 {
+		return fmt.Sprint(e.Usage)
+	}
+	return fmt.Sprintf(msg(ErrUsageT), e.Usage, strings.Join(e.Path, msg(ErrUsageSep)))
+}
+
+func (e ErrUsage) Is(target error) bool { return target == ErrBadGrammar }
+
+// ------------------------------ ErrMake -----------------------------
+
+// ErrMake is a malformed expression that could not be made into a rule
+// (see Grammar.Try) with the value (V) that would have been panicked
+// (usually the usage of the rat/x type).
+type ErrMake struct{ V any }
+
+func (e ErrMake) Error() string { return fmt.Sprintf(msg(ErrMakeT), e.V) }
+
+func (e ErrMake) Is(target error) bool { return target == ErrBadGrammar }
+
+func (e ErrMake) Unwrap() error {
+	err, _ := e.V.(error)
+	return err
+}
+
+// ---------------------------- ErrConflict ---------------------------
+
+// ErrConflict is a rule (Text) added with the Name of another rule with
+// a different definition (Prev) to a Strict grammar (see AddRuleE).
+type ErrConflict struct {
+	Name string
+	Prev string
+	Text string
+}
+
+func (e ErrConflict) Error() string { return fmt.Sprintf(msg(ErrConflictT), e.Name, e.Prev, e.Text) }
+
+func (e ErrConflict) Is(target error) bool { return target == ErrBadGrammar }
+
+// ------------------------------ ErrBind -----------------------------
+
+// ErrBind is the error (X) of converting the Text of a result of the
+// named rule (Name) to the type of the variable bound to it (see
+// Grammar.Bind).
+type ErrBind struct {
+	Name string
+	Text string
+	X    error
+}
+
+func (e ErrBind) Error() string { return fmt.Sprintf(msg(ErrBindT), e.Name, e.Text, e.X) }
+
+func (e ErrBind) Unwrap() error { return e.X }
+
+// --------------------------- ErrNoDecoder ---------------------------
+
+// ErrNoDecoder is returned when unmarshaling a grammar without
+// a DecodePEGN function (see Grammar.UnmarshalText).
+type ErrNoDecoder struct{}
+
+func (ErrNoDecoder) Error() string { return msg(ErrNoDecoderT) }
+
+// ----------------------------- ErrLength ----------------------------
+
+// ErrLength is the Text of the count of a length-prefixed field (see
+// x.Len) that is not a valid length.
+type ErrLength struct{ Text string }
+
+func (e ErrLength) Error() string { return fmt.Sprintf(msg(ErrLengthT), e.Text) }
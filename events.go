@@ -0,0 +1,45 @@
+package rat
+
+// EventType identifies the kind of Event emitted during a scan.
+type EventType int
+
+const (
+	EventEnter EventType = iota + 1 // named rule about to be checked
+	EventExit                       // named rule check complete
+)
+
+// String fulfills the fmt.Stringer interface.
+func (t EventType) String() string {
+	switch t {
+	case EventEnter:
+		return `enter`
+	case EventExit:
+		return `exit`
+	default:
+		return `unknown`
+	}
+}
+
+// Event is emitted (see Grammar.Emit) when a named rule (x.N) is entered
+// and exited during a Check. I is the current position when entered
+// and the ending position (E) when exited. R is only set for
+// EventExit and contains the Result of the named rule (which may have
+// an error). Since PEG parsing backtracks, consumers must check R.X on
+// exit to know if the enclosed events actually contributed to the
+// match.
+type Event struct {
+	T EventType // enter or exit
+	N string    // name of the rule
+	I int       // position within the []rune buffer
+	R Result    // result (EventExit only)
+}
+
+// EventFunc is passed every Event as it happens. See Grammar.Emit.
+type EventFunc func(e Event)
+
+// ChanEvents returns an EventFunc that sends every Event to the
+// channel passed. Note that the channel must be drained concurrently
+// (or be buffered sufficiently) to avoid blocking the scan.
+func ChanEvents(ch chan<- Event) EventFunc {
+	return func(e Event) { ch <- e }
+}
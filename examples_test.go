@@ -1,10 +1,25 @@
 package rat_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 	"unicode"
 
 	"github.com/rwxrob/rat"
+	_ "github.com/rwxrob/rat/pegn" // see Grammar.UnmarshalText
 	"github.com/rwxrob/rat/x"
 )
 
@@ -34,6 +49,33 @@ func ExampleFlatFunc_ByDepth() {
 
 }
 
+func ExampleWalkPath() {
+
+	key := rat.Result{N: `Key`, B: 2, E: 3}
+	sec := rat.Result{N: `Section`, B: 1, E: 3, C: []rat.Result{key}}
+	other := rat.Result{N: `Other`, B: 3, E: 4, C: []rat.Result{key}}
+
+	root := rat.Result{
+		N: `Root`, B: 1, E: 4, C: []rat.Result{sec, other},
+	}
+
+	rat.WalkPath(root, func(r rat.Result, path []rat.Result) {
+		if r.N != `Key` {
+			return
+		}
+		names := []string{}
+		for _, a := range path {
+			names = append(names, a.N)
+		}
+		fmt.Println(names)
+	})
+
+	// Output:
+	// [Root Section]
+	// [Root Other]
+
+}
+
 func ExampleResult_WithName() {
 
 	foo := rat.Result{N: `foo`, I: 1, B: 2, E: 3}
@@ -62,6 +104,607 @@ func ExampleResult_WithName() {
 
 }
 
+func ExampleResult_WithNameMatch() {
+
+	h1 := rat.Result{N: `Heading1`, B: 0, E: 1}
+	h2 := rat.Result{N: `Heading2`, B: 1, E: 2}
+	p := rat.Result{N: `Para`, B: 2, E: 3}
+	h6 := rat.Result{N: `Heading6`, B: 3, E: 4}
+	root := rat.Result{N: `Doc`, B: 0, E: 4, C: []rat.Result{h1, h2, p, h6}}
+
+	for _, it := range root.WithNameMatch(`Heading?`) {
+		fmt.Println(it.N)
+	}
+
+	for _, it := range root.WithNameRegexp(regexp.MustCompile(`^(Para|Doc)$`)) {
+		fmt.Println(it.N)
+	}
+
+	// Output:
+	// Heading1
+	// Heading2
+	// Heading6
+	// Doc
+	// Para
+
+}
+
+func ExampleResult_Child() {
+
+	opt := rat.Result{N: `Opt`, B: 0, E: 1}
+	key := rat.Result{N: `Key`, B: 1, E: 2}
+	val := rat.Result{N: `Val`, B: 2, E: 3}
+	pair := rat.Result{N: `Pair`, B: 0, E: 3, C: []rat.Result{opt, key, val}}
+
+	if it, has := pair.Child(`Key`); has {
+		it.Print()
+	}
+	_, has := pair.Child(`Missing`)
+	fmt.Println(has)
+
+	// Output:
+	// {"N":"Key","B":1,"E":2}
+	// false
+
+}
+
+func ExampleResult_Children() {
+
+	item := rat.Result{N: `Item`, B: 0, E: 1}
+	sep := rat.Result{B: 1, E: 2}
+	list := rat.Result{N: `List`, B: 0, E: 3, C: []rat.Result{item, sep, item}}
+
+	for _, it := range list.Children(`Item`) {
+		it.Print()
+	}
+	fmt.Println(len(list.Children(`Missing`)))
+
+	// Output:
+	// {"N":"Item","B":0,"E":1}
+	// {"N":"Item","B":0,"E":1}
+	// 0
+
+}
+
+func ExampleResult_Contains() {
+
+	r := []rune(`some thing`)
+	some := rat.Result{B: 0, E: 4, R: r}
+	thing := rat.Result{B: 5, E: 10, R: r}
+	sel := rat.Result{B: 2, E: 7} // editor selection
+
+	fmt.Println(some.Contains(rat.Result{B: 1, E: 3}))
+	fmt.Println(some.Contains(sel))
+	fmt.Println(some.Overlaps(sel), thing.Overlaps(sel))
+	fmt.Println(some.Overlaps(rat.Result{B: 4, E: 5}))
+	some.Union(thing).PrintText()
+
+	// Output:
+	// true
+	// false
+	// true true
+	// false
+	// some thing
+
+}
+
+func ExampleResult_Leaves() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, 3, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, `foo`})
+
+	for _, leaf := range g.Scan(`ab=foo`).Leaves() {
+		leaf.PrintText()
+	}
+
+	// Output:
+	// a
+	// b
+	// =
+	// foo
+
+}
+
+func ExampleGrammar_Emit() {
+
+	g := rat.Pack(x.N{`Pair`, x.Seq{x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}}})
+
+	g.Emit = func(e rat.Event) {
+		switch e.T {
+		case rat.EventEnter:
+			fmt.Println(e.T, e.N, e.I)
+		case rat.EventExit:
+			fmt.Println(e.T, e.N, e.I, e.R.X == nil)
+		}
+	}
+
+	g.Scan(`foo=bar`)
+
+	// Output:
+	// enter Pair 0
+	// enter Key 0
+	// exit Key 3 true
+	// enter Val 4
+	// exit Val 7 true
+	// exit Pair 7 true
+
+}
+
+func ExampleGrammar_NamedOnly() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, 3, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, `foo`})
+	g.Scan(`ab=foo`).Print()
+
+	g.NamedOnly = true
+	g.Scan(`ab=foo`).Print()
+
+	// Output:
+	// {"B":0,"E":6,"C":[{"N":"Key","B":0,"E":2,"C":[{"B":0,"E":1},{"B":1,"E":2}]},{"B":2,"E":3},{"N":"Val","B":3,"E":6}],"R":"ab=foo"}
+	// {"B":0,"E":6,"C":[{"N":"Key","B":0,"E":2},{"N":"Val","B":3,"E":6}],"R":"ab=foo"}
+
+}
+
+func ExampleResult_Bytes() {
+
+	res := rat.Pack(`foo`, x.N{`Smile`, '😀'}).Scan(`foo😀`)
+	smile, _ := res.Child(`Smile`)
+
+	fmt.Println(string(smile.Runes()))
+	fmt.Println(smile.Bytes())
+	buf := make([]byte, 0, 8)
+	buf = smile.AppendBytes(buf)
+	buf = res.AppendBytes(buf)
+	fmt.Println(string(buf))
+
+	// Output:
+	// 😀
+	// [240 159 152 128]
+	// 😀foo😀
+
+}
+
+func ExampleGrammar_MaxChildren() {
+
+	g := rat.Pack(x.Mmx{0, 10, x.N{`Item`, x.Rng{'a', 'z'}}})
+	g.MaxChildren = 2
+	g.Scan(`abcd!`).Print()
+
+	g.MaxChildren = 0
+	g.MaxDepth = 1
+	g.Scan(`abcd!`).Print()
+
+	// Output:
+	// {"B":0,"E":4,"T":true,"C":[{"N":"Item","B":0,"E":1},{"N":"Item","B":1,"E":2}],"R":"abcd!"}
+	// {"B":0,"E":4,"T":true,"R":"abcd!"}
+
+}
+
+func ExampleResult_O() {
+
+	g := rat.Pack(x.N{`Fenced`, x.Seq{"```", x.Mmx{1, 3, '.'}, "```"}})
+	res := g.Scan("```????```")
+
+	fmt.Println(res.O.Name)
+	for _, err := range res.C {
+		if err.X != nil {
+			fmt.Println(err.O)
+		}
+	}
+
+	// Output:
+	// Fenced
+	// x.Mmx{1, 3, x.Str{"."}}
+
+}
+
+func ExampleResult_MarshalWith() {
+
+	res := rat.Pack(x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}).Scan(`foo=bar`)
+
+	buf, _ := res.MarshalWith(rat.JSONOpts{Long: true, Text: true})
+	fmt.Println(string(buf))
+
+	buf, _ = res.C[0].MarshalWith(rat.JSONOpts{Indent: "  "})
+	fmt.Println(string(buf))
+
+	// Output:
+	// {"Begin":0,"End":7,"Text":"foo=bar","Children":[{"Name":"Key","Begin":0,"End":3,"Text":"foo"},{"Begin":3,"End":4,"Text":"="},{"Name":"Val","Begin":4,"End":7,"Text":"bar"}],"Buffer":"foo=bar"}
+	// {
+	//   "N": "Key",
+	//   "B": 0,
+	//   "E": 3,
+	//   "R": "foo=bar"
+	// }
+
+}
+
+func ExampleResult_Encode() {
+
+	res := rat.Pack(x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}).Scan(`foo=bar`)
+	res.Encode(os.Stdout)
+	fmt.Println()
+
+	// Output:
+	// {"B":0,"E":7,"C":[{"N":"Key","B":0,"E":3},{"B":3,"E":4},{"N":"Val","B":4,"E":7}],"R":"foo=bar"}
+
+}
+
+func ExampleResult_Tree() {
+
+	res := rat.Pack(x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}).ScanSource(`in`, `foo=bar`)
+	tree := res.Tree()
+
+	for _, node := range tree.Root.C {
+		fmt.Printf("%q %q\n", node.N, tree.Text(node))
+	}
+	fmt.Println(tree)
+	fmt.Println(tree.Result().C[2].Location())
+
+	// Output:
+	// "Key" "foo"
+	// "" "="
+	// "Val" "bar"
+	// {"B":0,"E":7,"C":[{"N":"Key","B":0,"E":3},{"B":3,"E":4},{"N":"Val","B":4,"E":7}],"R":"foo=bar","S":"in"}
+	// in:1:5
+}
+
+func ExampleResult_HTML() {
+
+	res := rat.Pack(x.N{`Key`, `foo`}, `=`, x.N{`Val`, `b<r`}).Scan(`foo=b<r`)
+
+	buf := new(strings.Builder)
+	if err := res.HTML(buf, `Pairs`); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the input and the nodes of the tree
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, `<pre`) || strings.HasPrefix(line, `<li><span`) {
+			fmt.Println(line)
+		}
+	}
+
+	// Output:
+	// <pre id="input"><span id="s0" class="n">foo</span>=<span id="s1" class="n">b&lt;r</span></pre>
+	// <li><span class="r" data-s="s0"><b>Key</b> [0,3) <code>&#34;foo&#34;</code></span></li>
+	// <li><span class="r">[3,4) <code>&#34;=&#34;</code></span></li>
+	// <li><span class="r" data-s="s1"><b>Val</b> [4,7) <code>&#34;b&lt;r&#34;</code></span></li>
+}
+
+func ExampleResult_Highlight() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{
+		x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`,
+		x.N{`Val`, x.One{
+			x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}},
+			x.N{`Str`, x.Seq{'"', x.To{'"'}, '"'}},
+		}},
+		x.Mmx{0, 1, "\n"},
+	}})
+	res := g.Scan("name=\"<rob>\"\nage=42\n")
+
+	res.Highlight(os.Stdout, rat.HighlightOpts{Prefix: `tok-`, Pre: true})
+	fmt.Println()
+
+	// only keys and strings
+	classes := map[string]string{`Key`: `k`, `Str`: `s`}
+	res.Highlight(os.Stdout, rat.HighlightOpts{Classes: classes})
+	fmt.Println()
+
+	// Output:
+	// <pre class="tok-src"><span class="tok-Key">name</span>=<span class="tok-Val"><span class="tok-Str">&#34;&lt;rob&gt;&#34;</span></span>
+	// <span class="tok-Key">age</span>=<span class="tok-Val"><span class="tok-Num">42</span></span>
+	// </pre>
+	// <span class="k">name</span>=<span class="s">&#34;&lt;rob&gt;&#34;</span>
+	// <span class="k">age</span>=42
+}
+
+func ExampleResult_Errors() {
+
+	bad1 := rat.Result{B: 4, E: 5, X: rat.ErrExpected{`b`}}
+	bad2 := rat.Result{B: 1, E: 2, X: rat.ErrExpected{`a`}}
+	good := rat.Result{B: 2, E: 4}
+	root := rat.Result{B: 0, E: 5, C: []rat.Result{good, bad1, bad2}}
+
+	for _, err := range root.Errors() {
+		fmt.Println(err.B, err.X)
+	}
+
+	// Output:
+	// 1 expected: a
+	// 4 expected: b
+
+}
+
+func ExampleGrammar_ScanSource() {
+
+	g := rat.Pack(x.N{`Line`, x.Any{4}}, x.N{`Bad`, `foo`})
+
+	res := g.ScanSource(`some.txt`, "abc\nbar")
+	fmt.Println(res.S)
+	for _, err := range res.Errors() {
+		fmt.Println(err.Location(), err.X)
+	}
+	res.Print()
+
+	// Output:
+	// some.txt
+	// some.txt:1:1 expected: f
+	// some.txt:2:1 expected: f
+	// {"B":0,"E":4,"X":"expected: f","C":[{"N":"Line","B":0,"E":4},{"N":"Bad","B":4,"E":4,"X":"expected: f"}],"R":"abc\nbar","S":"some.txt"}
+
+}
+
+type printBuilder struct{ depth int }
+
+func (p *printBuilder) Open(name string) {
+	fmt.Printf("%v<%v>\n", strings.Repeat(`  `, p.depth), name)
+	p.depth++
+}
+
+func (p *printBuilder) Text(span rat.Result) {
+	fmt.Printf("%v%q\n", strings.Repeat(`  `, p.depth), span.Text())
+}
+
+func (p *printBuilder) Close() { p.depth-- }
+
+func ExampleGrammar_ScanBuild() {
+
+	g := rat.Pack(x.N{`Pair`, x.Seq{x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}}})
+	g.ScanBuild(`foo=bar`, new(printBuilder))
+
+	// nodes of alternatives that failed are never built (nor children)
+	g = rat.Pack(x.One{x.Seq{x.N{`Word`, `foo`}, `!`}, x.N{`Sentence`, `foo.`}})
+	res := g.ScanBuild(`foo.`, new(printBuilder))
+	fmt.Println(len(res.C), res.X)
+
+	// Output:
+	// <Pair>
+	//   <Key>
+	//     "foo"
+	//   <Val>
+	//     "bar"
+	// <Sentence>
+	//   "foo."
+	// 0 <nil>
+
+}
+
+func ExampleGrammar_PEGN() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, x.To{'\n'}})
+	fmt.Print(g.PEGN())
+
+	// Output:
+	// Main <- Key '=' Val
+	// Key  <= [a-z]+
+	// Val  <= .. x0A
+
+}
+
+func ExampleGrammar_Generator() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`List`, x.Seq{'[', x.Mmx{0, 1, x.Seq{x.Ref{`Item`}, x.Mmx{0, -1, x.Seq{',', x.Ref{`Item`}}}}}, ']'}})
+	g.MakeRule(x.N{`Item`, x.One{x.Mmx{1, 3, x.Rng{'0', '9'}}, x.Ref{`List`}}})
+	g.Pack(x.Ref{`List`})
+
+	// same seed, same inputs
+	gen := g.Generator(rat.GenOpts{Seed: 1, Depth: 3, Repeat: 2})
+	for n := 0; n < 4; n++ {
+		in, err := gen.Next()
+		fmt.Println(in, err)
+	}
+
+	// never matches
+	_, err := rat.Pack(x.Not{'a'}, 'a').Generator(rat.GenOpts{}).Next()
+	fmt.Println(err)
+
+	// Output:
+	// [[],[],[]] <nil>
+	// [] <nil>
+	// [94,538] <nil>
+	// [[],[],789] <nil>
+	// no input generated was matched in 100 tries
+}
+
+func ExampleGrammar_Try() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	before := len(g.Rules)
+
+	// from a user-provided definition, for example
+	_, err := g.TryPack(x.Ref{`Key`}, '=', x.N{`Val`, x.Mmx{1, x.Rng{'0', '9'}}})
+	fmt.Println(err)
+	fmt.Println(len(g.Rules) == before, g.Main)
+
+	err = g.Try(func() { g.MakeAlias(`Eq`, x.Rng{'='}) })
+	fmt.Println(err)
+
+	// rules replaced are restored as well
+	err = g.Try(func() {
+		g.Override(x.N{`Key`, x.Mmx{1, -1, x.Rng{'A', 'Z'}}})
+		g.MakeRule(x.Rng{'='})
+	})
+	fmt.Println(err != nil, g.Rules[`Key`])
+
+	g, err = rat.TryPack(x.Ref{`Key`}, '=')
+	fmt.Println(err, g.Main)
+
+	// Output:
+	// malformed expression: "%!USAGE: x.Mmx{m, n, rule}" in x.Seq[2] → x.N{"Val"} → x.Mmx
+	// true <nil>
+	// malformed expression: "%!USAGE: x.Rng{beg, end}"
+	// true x.N{"Key", x.Mmx{1, -1, x.Rng{'a', 'z'}}}
+	// <nil> x.Seq{x.Ref{"Key"}, x.Str{"="}}
+}
+
+func ExampleErrUsage() {
+
+	defer func() { fmt.Println(recover()) }()
+	rat.Pack(x.N{`Fenced`, x.Seq{x.Sav{`Fence`}, x.To{x.Val{`Fence`}}, x.Mmx{1, x.Val{`Fence`}}}})
+
+	// Output:
+	// "%!USAGE: x.Mmx{m, n, rule}" in x.N{"Fenced"} → x.Seq[2] → x.Mmx
+}
+
+func ExampleGrammar_Dump() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeAlias(`WS`, x.Mmx{1, -1, x.One{' ', '\t'}})
+	g.MakeRule(x.N{`Val`, x.Seq{x.Seq{'"'}, x.To{'"'}, '"'}})
+	g.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, x.Ref{`WS`}, x.Ref{`Val`})
+	fmt.Print(g.Dump())
+
+	// Output:
+	// Main <- Key WS Val
+	// Key <= [a-z]+
+	// Val <= '"' .. '"' '"'
+	// WS <- (' ' / x09)+
+}
+
+func ExampleGrammar_MakeRef_classes() {
+
+	g := rat.Pack(x.N{`Id`, x.Seq{x.Ref{`alpha`}, x.Mmx{0, -1, x.Ref{`alphanum`}}}}, x.Ref{`ws`})
+	g.Scan("a1b2 ").Print()
+	g.Scan("1ab ").PrintError()
+	fmt.Print(g.PEGN())
+
+	// Output:
+	// {"B":0,"E":5,"C":[{"N":"Id","B":0,"E":4,"C":[{"N":"alpha","B":0,"E":1,"C":[{"B":0,"E":1}]},{"B":1,"E":4,"C":[{"N":"alphanum","B":1,"E":2,"C":[{"B":1,"E":2}]},{"N":"alphanum","B":2,"E":3,"C":[{"B":2,"E":3}]},{"N":"alphanum","B":3,"E":4,"C":[{"B":3,"E":4}]}]}]},{"N":"ws","B":4,"E":5,"C":[{"B":4,"E":5}]}],"R":"a1b2 "}
+	// expected: x.One{x.Rng{'A', 'Z'}, x.Rng{'a', 'z'}}
+	// 1 | 1ab
+	//   | ^
+	// Main <- Id ws
+	// Id   <= alpha alphanum*
+
+}
+
+func ExampleGrammar_MakeAlias() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeAlias(`WS`, x.Mmx{1, -1, x.One{' ', '\t'}})
+	g.Pack(x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, x.Ref{`WS`}, x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	g.Scan("hi \tthere").Print()
+	fmt.Println(g.Rules[`WS`].IsAlias())
+	fmt.Print(g.PEGN())
+
+	// Output:
+	// {"B":0,"E":9,"C":[{"N":"Word","B":0,"E":2,"C":[{"B":0,"E":1},{"B":1,"E":2}]},{"B":2,"E":4,"C":[{"B":2,"E":3,"C":[{"B":2,"E":3}]},{"B":3,"E":4,"C":[{"B":3,"E":4}]}]},{"N":"Word","B":4,"E":9,"C":[{"B":4,"E":5},{"B":5,"E":6},{"B":6,"E":7},{"B":7,"E":8},{"B":8,"E":9}]}],"R":"hi \tthere"}
+	// true
+	// Main <- Word WS Word
+	// WS   <- (' ' / x09)+
+	// Word <= [a-z]+
+
+}
+
+func ExampleGrammar_Alias() {
+
+	// Greet has been renamed Greeting
+	g := new(rat.Grammar).Init()
+	g.Alias(`Greet`, `Greeting`)
+	g.Pack(x.N{`Greeting`, x.One{`hello`, `hi`}})
+	fmt.Print(g.PEGN())
+
+	// existing references and callers still work
+	g.Rules[`Greet`].Scan(`hi`).Print()
+	fmt.Println(g.Renamed())
+
+	// and so do results from before the rename
+	old := rat.Result{N: `Greet`, R: []rune(`hello`), E: 5}
+	old.Rename(g.Renamed())
+	fmt.Println(len(old.WithName(`Greeting`)))
+
+	// Output:
+	// Greeting <= 'hello' / 'hi'
+	// Greet    <- Greeting
+	// {"N":"Greeting","B":0,"E":2,"C":[{"B":0,"E":2}],"R":"hi"}
+	// map[Greet:Greeting]
+	// 1
+
+}
+
+func ExampleGrammar_PEGNWith() {
+
+	g := rat.Pack(x.N{`Keyword`, x.One{"break", "case", "chan", "const", "continue", "default", "defer"}})
+	fmt.Print(g.PEGNWith(rat.PEGNOpts{Width: 30}))
+
+	// Output:
+	// Keyword <= 'break'
+	//          / 'case'
+	//          / 'chan'
+	//          / 'const'
+	//          / 'continue'
+	//          / 'default'
+	//          / 'defer'
+
+}
+
+func ExampleGrammar_PEGNWith_minify() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Digit`, x.One{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9'}})
+	g.Main = g.MakeRule(x.N{`Num`, x.Seq{x.Mmx{0, 1, x.One{'+', '-'}}, x.Mmx{1, -1, x.Ref{`Digit`}}}})
+	fmt.Print(g.PEGNWith(rat.PEGNOpts{Minify: true}))
+
+	// Output:
+	// Num<=('+'/'-')? Digit+
+	// Digit<=[0-9]
+
+}
+
+func ExampleGrammar_Regexp() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Word`, x.Mmx{1, -1, x.Ref{`lower`}}})
+	g.Main = g.MakeRule(x.N{`Words`, x.Seq{x.Ref{`Word`}, x.Mmx{0, -1, x.Seq{' ', x.Ref{`Word`}}}}})
+
+	re, err := g.Regexp()
+	fmt.Println(re, err)
+	fmt.Println(regexp.MustCompile(`^` + re + `$`).MatchString(`just some words`))
+
+	// Output:
+	// [a-z]+(?: [a-z]+)* <nil>
+	// true
+
+}
+
+func ExampleGrammar_Farthest() {
+
+	g := rat.Pack(x.Mmx{0, -1, x.Seq{x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ';'}}, x.End{})
+	res := g.Scan(`one;two;thr3e;`)
+	res.PrintError()
+	fmt.Println(g.Farthest.E, g.Farthest.X)
+
+	// Output:
+	// expected: x.End{}
+	// 1 | one;two;thr3e;
+	//   |         ^
+	// 11 expected: x.Rng{'a', 'z'}
+
+}
+
+func ExampleGrammarEqual() {
+
+	a := rat.Pack(x.N{`Greet`, x.Seq{x.One{`hi`, `hello`}, ' ', x.Ref{`Name`}}})
+	a.MakeRule(x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+
+	b := new(rat.Grammar).Init()
+	b.MakeRule(x.N{`Name`, x.Mmx{1, -1, x.Seq{x.Rng{'a', 'z'}}}})
+	b.Main = b.MakeRule(x.N{`Greet`, x.Seq{x.One{x.One{`hi`}, `hello`}, x.Seq{" ", x.Ref{`Name`}}}})
+
+	fmt.Println(rat.GrammarEqual(a, b))
+
+	b.MakeRule(x.N{`Name`, x.Mmx{1, -1, x.Rng{'A', 'Z'}}})
+	fmt.Println(rat.GrammarEqual(a, b))
+
+	// Output:
+	// true
+	// false
+
+}
+
 func ExamplePack_one() {
 
 	g := rat.Pack(x.One{`foo`, `bar`})
@@ -257,6 +900,8 @@ func ExamplePack_seq() {
 	// foobarfalsebaz
 	// {"B":0,"E":3,"X":"expected: b","C":[{"B":0,"E":3,"X":"expected: b"}],"R":"foo"}
 	// expected: x.One{x.Str{"true"}, x.Str{"false"}}
+	// 1 | foobarbaz
+	//   |       ^
 
 }
 
@@ -288,15 +933,30 @@ func ExamplePack_mmx() {
 	// foofoofoo
 	// {"B":0,"E":9,"C":[{"B":0,"E":3},{"B":3,"E":6},{"B":6,"E":9}],"R":"foofoofoo"}
 	// foofoofoo
-	// {"B":0,"E":9,"C":[{"B":0,"E":3},{"B":3,"E":6},{"B":6,"E":9},{"B":9,"E":12}],"R":"foofoofoofoo"}
+	// {"B":0,"E":9,"C":[{"B":0,"E":3},{"B":3,"E":6},{"B":6,"E":9}],"R":"foofoofoofoo"}
 	// {"B":0,"E":0,"X":"expected: x.Mmx{1, 3, x.Str{\"foo\"}}","R":"barfoofoo"}
 
 }
 
-func ExamplePack_see() {
+func ExamplePack_mmx_empty() {
 
-	g := rat.Pack(x.See{`foo`})
-	g.Print()
+	// a minimum of a rule matching nothing is met by matching nothing
+	g := rat.Pack(x.Mmx{2, 2, x.Mmx{0, 1, "a"}}, x.End{})
+	fmt.Println(g.Scan(``).X)
+	fmt.Println(g.Scan(`a`).X)
+	fmt.Println(g.Scan(`aaa`).X)
+
+	// Output:
+	// <nil>
+	// <nil>
+	// expected: x.End{}
+
+}
+
+func ExamplePack_see() {
+
+	g := rat.Pack(x.See{`foo`})
+	g.Print()
 
 	g.Scan(`fooooo`).PrintText()
 	g.Scan(`fooooo`).Print()
@@ -354,6 +1014,26 @@ func ExamplePack_end() {
 
 }
 
+func ExamplePack_wb() {
+
+	keyword := x.Seq{x.One{`if`, `for`}, x.Wb{}}
+	g := rat.Pack(keyword)
+	g.Print()
+
+	g.Scan(`if x`).PrintText()
+	g.Scan(`for`).PrintText()
+	g.Scan(`ifdef`).PrintError()
+
+	// Output:
+	// x.Seq{x.One{x.Str{"if"}, x.Str{"for"}}, x.Wb{}}
+	// if
+	// for
+	// expected: x.Wb{}
+	// 1 | ifdef
+	//   |   ^
+
+}
+
 func ExamplePack_rng() {
 
 	g := rat.Pack(x.Rng{'😀', '🙏'})
@@ -452,3 +1132,1568 @@ func ExampleMakeStr() {
 	// key: "x.Str{\"oo\"}" name: "x.Str{\"oo\"}" text: "x.Str{\"oo\"}"
 
 }
+
+func ExampleCompileRegexp() {
+
+	g, err := rat.CompileRegexp(`(?P<User>[a-z]+)@(?P<Host>[a-z]+(?:\.[a-z]+)+)`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Scan(`rob@rwx.gg`).PrintText()
+	for _, it := range g.Scan(`rob@rwx.gg`).C {
+		if it.N != "" {
+			fmt.Println(it.N, it.Text())
+		}
+	}
+	g.Scan(`rob@localhost`).PrintError()
+
+	// Output:
+	// rob@rwx.gg
+	// User rob
+	// Host rwx.gg
+	// expected: x.Mmx{1, -1, x.Seq{x.Str{"."}, x.Mmx{1, -1, x.Rng{'a', 'z'}}}}
+	// 1 | rob@localhost
+	//   |              ^
+
+}
+
+func ExampleCompileRegexp_withName() {
+
+	expr := `(?:(?P<Key>[a-z]+)=(?P<Val>[0-9]+);?)+`
+	in := `a=1;bb=22;c=3`
+
+	// regexp only captures the last repetition
+	re := regexp.MustCompile(expr)
+	m := re.FindStringSubmatch(in)
+	fmt.Println(m[re.SubexpIndex(`Key`)], m[re.SubexpIndex(`Val`)])
+
+	// but every repetition is a named result
+	g, _ := rat.CompileRegexp(expr)
+	res := g.Scan(in)
+	for _, it := range res.WithName(`Key`, `Val`) {
+		fmt.Print(it.N, `:`, it.Text(), ` `)
+	}
+	fmt.Println()
+
+	// Output:
+	// c 3
+	// Key:a Val:1 Key:bb Val:22 Key:c Val:3
+}
+
+func ExamplePack_class() {
+
+	greek, _ := x.Class(`\p{Greek}`)
+	g := rat.Pack(x.Mmx{1, -1, greek}, x.End{})
+	g.Scan(`αβγδ`).PrintText()
+	g.Scan(`αβcδ`).PrintError()
+
+	// searched directly but limited as any other rule (see Limits)
+	abc := rat.Pack(x.Mmx{1, -1, x.One{'a', 'b', 'c'}})
+	abc.MaxSteps = 6
+	fmt.Println(abc.Scan(`abcabcabc`).X)
+
+	// Output:
+	// αβγδ
+	// expected: x.End{}
+	// 1 | αβcδ
+	//   |   ^
+	// more than 6 rules checked at 5
+
+}
+
+func ExamplePack_keywords() {
+
+	// first alternative matching wins (not the longest)
+	g := rat.Pack(x.One{`for`, `foreach`, `func`, `fun`, `if`})
+	g.Scan(`foreach`).PrintText()
+	g.Scan(`funky`).PrintText()
+	g.Scan(`fog`).PrintError()
+	g.Farthest.Print()
+
+	// searched with a trie but limited as any other rule (see Limits)
+	kw := rat.Pack(x.Mmx{1, -1, x.One{`ab`, `ac`, `b`}})
+	kw.MaxSteps = 6
+	fmt.Println(kw.Scan(`abacbabacb`).X)
+
+	// Output:
+	// for
+	// fun
+	// expected: x.One{x.Str{"for"}, x.Str{"foreach"}, x.Str{"func"}, x.Str{"fun"}, x.Str{"if"}}
+	// 1 | fog
+	//   | ^
+	// {"B":0,"E":2,"X":"expected: r","R":"fog"}
+	// more than 6 rules checked at 9
+
+}
+
+func ExampleGrammar_SplitFunc() {
+
+	// log records begin with a date and may continue on lines that
+	// begin with whitespace
+	line := x.Seq{x.Mmx{0, -1, x.Seq{x.Not{"\n"}, x.Any{1}}}, "\n"}
+	record := x.Seq{
+		x.Mmx{4, 4, x.Rng{'0', '9'}}, line,
+		x.Mmx{0, -1, x.Seq{x.One{" ", "\t"}, line}},
+	}
+	g := rat.Pack(record)
+
+	in := "2023 starting\n2024 failed\n  with trace\n  and more\n2025 done\n"
+	s := bufio.NewScanner(strings.NewReader(in))
+	s.Buffer(make([]byte, 8), 64)
+	s.Split(g.SplitFunc())
+	for s.Scan() {
+		fmt.Printf("%q\n", s.Text())
+	}
+	fmt.Println(s.Err())
+
+	// Output:
+	// "2023 starting\n"
+	// "2024 failed\n  with trace\n  and more\n"
+	// "2025 done\n"
+	// <nil>
+
+}
+
+func ExampleScanFS() {
+
+	fsys := fstest.MapFS{
+		`a.txt`:        {Data: []byte(`id: 42`)},
+		`docs/b.txt`:   {Data: []byte(`id: 7`)},
+		`docs/c.txt`:   {Data: []byte(`id: x`)},
+		`docs/d.md`:    {Data: []byte(`id: 1`)},
+		`more/e/f.txt`: {Data: []byte(`id: 1000`)},
+	}
+
+	grammar := func() *rat.Grammar {
+		return rat.Pack(`id: `, x.N{`ID`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	}
+
+	found := map[string]string{}
+	err := rat.ScanFS(fsys, `*.txt`, grammar, 2, func(path string, res rat.Result) {
+		if res.X != nil {
+			found[path] = res.X.Error()
+			return
+		}
+		found[path] = res.C[1].Text()
+	})
+	fmt.Println(err)
+
+	paths := []string{}
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Println(path, found[path])
+	}
+
+	// Output:
+	// <nil>
+	// a.txt 42
+	// docs/b.txt 7
+	// docs/c.txt expected: x.Mmx{1, -1, x.Rng{'0', '9'}}
+	// more/e/f.txt 1000
+
+}
+
+func ExampleResult_MarshalCBOR() {
+
+	g := rat.Pack(x.N{`Pair`, x.Seq{
+		x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`,
+		x.N{`Val`, x.Mmx{1, -1, x.Rng{'0', '9'}}},
+	}})
+	g.NamedOnly = true
+	res := g.ScanSource(`in.txt`, `answer=42`)
+
+	buf, _ := res.MarshalCBOR()
+	json, _ := res.MarshalJSON()
+	fmt.Println(len(buf), len(json))
+
+	var back rat.Result
+	fmt.Println(back.UnmarshalCBOR(buf))
+	back.Print()
+	fmt.Println(back.C[1].Text(), back.C[1].Location())
+
+	fmt.Println(back.UnmarshalCBOR(buf[:20]))
+
+	// Output:
+	// 64 107
+	// <nil>
+	// {"N":"Pair","B":0,"E":9,"C":[{"N":"Key","B":0,"E":6},{"N":"Val","B":7,"E":9}],"R":"answer=42","S":"in.txt"}
+	// 42 in.txt:1:8
+	// invalid CBOR result at byte 20
+
+}
+
+func ExampleResult_WriteCSV() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.N{`Pair`, x.Seq{
+		x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`,
+		x.N{`Val`, x.Mmx{0, -1, x.Seq{x.Not{"\n"}, x.Any{1}}}}, "\n",
+	}}})
+	g.NamedOnly = true
+
+	res := g.Scan("name=Rob, Jr.\nage=42\n")
+	res.WriteCSV(os.Stdout)
+	res.C[1].WriteTSV(os.Stdout)
+
+	// Output:
+	// name,begin,end,line,col,text
+	// Pair,0,14,1,1,"name=Rob, Jr.
+	// "
+	// Key,0,4,1,1,name
+	// Val,5,13,1,6,"Rob, Jr."
+	// Pair,14,21,2,1,"age=42
+	// "
+	// Key,14,17,2,1,age
+	// Val,18,20,2,5,42
+	// name	begin	end	line	col	text
+	// Pair	14	21	2	1	"age=42
+	// "
+	// Key	14	17	2	1	age
+	// Val	18	20	2	5	42
+
+}
+
+func ExampleGrammar_DOT() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Value`, x.One{x.Ref{`List`}, x.Ref{`Word`}}})
+	g.MakeRule(x.N{`List`, x.Seq{`(`, x.Mmx{0, -1, x.Ref{`Value`}}, `)`}})
+	g.MakeRule(x.N{`Word`, x.Mmx{1, -1, x.Ref{`alpha`}}})
+	g.Main = g.Rules[`Value`]
+	fmt.Print(g.DOT())
+
+	// Output:
+	// digraph Grammar {
+	//   node [shape=box];
+	//   "Value" [style="bold,filled"];
+	//   "List";
+	//   "Word";
+	//   "alpha" [style=dashed];
+	//   "Value" -> "List" [color=red];
+	//   "Value" -> "Word";
+	//   "List" -> "Value" [color=red];
+	//   "Word" -> "alpha";
+	// }
+}
+
+func ExampleResult_DecodeName() {
+
+	g := rat.Pack(x.N{`Host`, x.Mmx{1, -1, x.One{x.Rng{'0', '9'}, '.'}}}, ` `,
+		x.N{`When`, x.Mmx{1, -1, x.One{x.Rng{'0', '9'}, '-', ':', 'T', 'Z'}}})
+	res := g.Scan(`10.0.0.1 2023-01-31T12:00:00Z`)
+
+	var ip net.IP
+	fmt.Println(res.DecodeName(`Host`, &ip), ip.IsPrivate())
+
+	var when time.Time
+	fmt.Println(res.DecodeName(`When`, &when), when.Month())
+
+	fmt.Println(res.DecodeName(`Port`, &ip))
+
+	// Output:
+	// <nil> true
+	// <nil> January
+	// does not exist: Port
+}
+
+func ExampleGrammar_Release() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ' '}})
+
+	for _, in := range []string{`one two `, `three four five `} {
+		res := g.Scan(in)
+		for _, word := range res.WithName(`Word`) {
+			fmt.Print(word.Text(), ` `)
+		}
+		fmt.Println(len(res.C))
+		g.Release(res) // res can no longer be used
+	}
+
+	// Output:
+	// one two 2
+	// three four five 3
+}
+
+func ExampleGrammar_ScanEach() {
+
+	g := rat.Pack(x.Seq{x.N{`User`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, '@', x.N{`Host`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}})
+
+	in := []string{`rob@rwx`, `doug@rwx`, `BAD`, `x@y`}
+	g.ScanEach(in, func(n int, res rat.Result) {
+		if res.X != nil {
+			fmt.Println(n, res.X)
+			return
+		}
+		fmt.Println(n, res.WithName(`User`)[0].Text(), res.WithName(`Host`)[0].Text())
+	})
+
+	// Output:
+	// 0 rob rwx
+	// 1 doug rwx
+	// 2 expected: x.Mmx{1, -1, x.Rng{'a', 'z'}}
+	// 3 x y
+}
+
+func ExampleGrammar_Fold() {
+
+	pack := func(seq ...any) *rat.Grammar {
+		g := new(rat.Grammar).Init()
+		g.Fold = true
+		return g.Pack(seq...)
+	}
+
+	g1 := pack(x.Seq{"f", x.Seq{'o', "o"}}, x.One{x.One{"bar"}, "baz"})
+	g2 := pack("foo", x.One{"bar", x.Seq{"baz"}})
+	fmt.Println(g1.Main)
+	fmt.Println(g2.Main)
+	fmt.Println(len(g1.Rules) == len(g2.Rules))
+	fmt.Println(len(g1.Scan(`foobaz`).C))
+
+	// Output:
+	// x.Seq{x.Str{"foo"}, x.One{x.Str{"bar"}, x.Str{"baz"}}}
+	// x.Seq{x.Str{"foo"}, x.One{x.Str{"bar"}, x.Str{"baz"}}}
+	// true
+	// 2
+}
+
+func ExampleGrammar_Tracer() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, `bar`})
+	g.Trace = rat.TraceCheck
+	g.Tracer = rat.TracerFunc(func(e rat.TraceEvent) {
+		if e.T == rat.EventExit {
+			fmt.Println(e.Rule, e.I, e.E, e.X)
+		}
+	})
+	g.Scan(`foo=baz`)
+
+	// Output:
+	// Key 0 3 <nil>
+	// Val 4 6 expected: r
+}
+
+func ExampleJSONTracer() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, `bar`})
+	g.Trace = rat.TraceCheck
+	g.Tracer = rat.TracerFunc(func(e rat.TraceEvent) {
+		e.Elapsed = 0 // varies
+		rat.JSONTracer{W: os.Stdout}.Trace(e)
+	})
+	g.Scan(`foo=baz`)
+
+	// Output:
+	// {"t":"enter","rule":"Key","pos":0}
+	// {"t":"exit","rule":"Key","pos":0,"len":3}
+	// {"t":"enter","rule":"Val","pos":4}
+	// {"t":"exit","rule":"Val","pos":4,"len":2,"error":"expected: r"}
+}
+
+func ExampleDefaultTracer() {
+
+	// every grammar without a Tracer logs structured debug events
+	defer func(t rat.Tracer) { rat.DefaultTracer = t }(rat.DefaultTracer)
+	h := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == `elapsed` { // varies
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	rat.DefaultTracer = rat.SlogTracer{L: slog.New(h)}
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`)
+	g.Trace = rat.TraceCheck
+	g.Scan(`foo=`)
+
+	// silenced
+	rat.DefaultTracer = rat.NopTracer
+	g.Scan(`foo=`)
+
+	// Output:
+	// level=DEBUG msg="enter rule" rule=Key i=0
+	// level=DEBUG msg="check rule" rule=Key i=0 e=3
+}
+
+func ExampleGrammar_Debug() {
+
+	g := rat.Pack(x.N{`Pair`, x.Seq{x.N{`Key`, `foo`}, `=`, x.N{`Val`, `bar`}}})
+
+	// steps until c is entered (normally from os.Stdin)
+	step := rat.Prompt(strings.NewReader("\n\nc\n"), os.Stdout)
+	g.Debug(`foo=baz`, step)
+
+	// Output:
+	// enter Pair at 0 (Pair)
+	//   "|foo=baz"
+	// [enter] next, c continue: enter Key at 0 (Pair > Key)
+	//   "|foo=baz"
+	// [enter] next, c continue: exit Key at 3 (Pair > Key)
+	//   "foo|=baz"
+	// [enter] next, c continue: enter Val at 4 (Pair > Val)
+	//   "foo=|baz"
+	// fail Val at 6 (Pair > Val)
+	//   "foo=ba|z"
+	// fail Pair at 6 (Pair)
+	//   "foo=ba|z"
+}
+
+func ExampleGrammar_Record() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, `bar`})
+	res, rec := g.Record(`foo=baz`, true)
+	res.PrintError()
+
+	// usually saved to a file and attached to a bug report
+	buf := new(strings.Builder)
+	rec.Encode(buf)
+	rec, _ = rat.DecodeRecording(strings.NewReader(buf.String()))
+	fmt.Print(rec.Grammar)
+	for _, e := range rec.Events {
+		fmt.Println(string(e))
+	}
+
+	// same grammar, same behavior
+	_, err := g.Replay(rec, nil)
+	fmt.Println(err)
+
+	// fixed grammar
+	fixed := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `=`, x.N{`Val`, x.One{`bar`, `baz`}})
+	_, err = fixed.Replay(rec, nil)
+	fmt.Println(err.(rat.ErrReplay).What)
+
+	// not the same input
+	_, err = g.Replay(rec, `foo=bar`)
+	fmt.Println(err.(rat.ErrReplay).What)
+
+	// Output:
+	// expected: r
+	// 1 | foo=baz
+	//   |       ^
+	// Main <- Key '=' Val
+	// Key <= [a-z]+
+	// Val <= 'bar'
+	// {"t":"enter","rule":"Key","pos":0}
+	// {"t":"exit","rule":"Key","pos":0,"len":3}
+	// {"t":"enter","rule":"Val","pos":4}
+	// {"t":"exit","rule":"Val","pos":4,"len":2,"error":"expected: r"}
+	// <nil>
+	// grammar
+	// hash
+}
+
+func ExampleGrammar_Break() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, `;`}})
+
+	// usually set with RAT_BREAK=Key when starting the program instead
+	g.Breakpoints = map[string]bool{`Key`: true}
+	g.Break = func(s rat.Step) { fmt.Println(s.Rule, s.Pos(), s.Snippet(3)) }
+	g.Scan(`ab;cd;`)
+
+	// Output:
+	// Key 0 "|ab;"
+	// Key 3 "ab;|cd;"
+	// Key 6 "cd;|"
+}
+
+func ExampleGrammar_Shrink() {
+
+	num := x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}}
+	g := rat.Pack(num, x.Mmx{0, -1, x.Seq{',', num}}, x.End{})
+
+	c, failed := g.Shrink(nil, `12,345,67,8x9,10`)
+	fmt.Println(failed)
+	fmt.Printf("%q\n", c.Prefix)
+	fmt.Println(c)
+
+	_, failed = g.Shrink(nil, `1,2`)
+	fmt.Println(failed)
+
+	// Output:
+	// true
+	// "12,345,67,8x"
+	// "x" fails at 0 in x.Rng{'0', '9'}: expected: x.Rng{'0', '9'}
+	// false
+}
+
+func ExampleErrAt() {
+
+	g := rat.Pack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, "=\n", x.N{`Val`, `bar`})
+	g.Locate = true
+	res := g.Scan("foo=\nbaz")
+	fmt.Println(res.X)
+
+	var at rat.ErrAt
+	if errors.As(res.X, &at) {
+		fmt.Println(at.I, at.Line, at.Col)
+	}
+	var e rat.ErrExpected
+	fmt.Println(errors.As(res.X, &e), e)
+
+	// Output:
+	// expected: r at line 2, col 3
+	// 7 2 3
+	// true expected: r
+}
+
+func ExampleGrammar_Expect() {
+
+	g := rat.Pack(`let `, x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, x.Mmx{0, 1, ' '}, x.One{`=`, `:=`}, x.Not{'>'})
+
+	fmt.Println(g.Scan(`let x !`).X)
+
+	g.Expect = true
+	fmt.Println(g.Scan(`let x !`).X)
+	fmt.Println(g.Scan(`let x =>`).X)
+
+	g.Locate = true
+	fmt.Println(g.Scan(`let x !`).X)
+
+	// Output:
+	// expected: x.One{x.Str{"="}, x.Str{":="}}
+	// expected one of: '=', ':'
+	// expected: x.Not{x.Str{">"}}
+	// expected one of: '=', ':' at line 1, col 7
+}
+
+func ExampleSetMessages() {
+
+	g := rat.Pack(`let `, x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, x.One{`=`, `:=`})
+	g.Expect, g.Locate = true, true
+
+	rat.SetMessages(rat.MessageMap{
+		rat.ErrOneOfT:   `erwartet wird eines von: %v`,
+		rat.ErrOneOfSep: ` oder `,
+		rat.ErrAtT:      `%v (Zeile %v, Spalte %v)`,
+	})
+	fmt.Println(g.Scan(`let x!`).X)
+
+	rat.SetMessages(nil)
+	fmt.Println(g.Scan(`let x!`).X)
+
+	// Output:
+	// erwartet wird eines von: [a-z] oder '=' oder ':' (Zeile 1, Spalte 6)
+	// expected one of: [a-z], '=', ':' at line 1, col 6
+}
+
+func ExampleErrNoMatch() {
+
+	g := rat.Pack(x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	g.Locate = true
+
+	for _, err := range []error{
+		g.Scan(`x`).X,
+		new(rat.Grammar).Scan(`1`).X,
+		rat.ErrStream{I: 3, X: rat.ErrGenerate{Tries: 10}},
+	} {
+		switch {
+		case errors.Is(err, rat.ErrNoMatch):
+			fmt.Println(`no match:`, err)
+		case errors.Is(err, rat.ErrBadGrammar):
+			fmt.Println(`bad grammar:`, err)
+		case errors.Is(err, rat.ErrLimit):
+			fmt.Println(`limit:`, err)
+		}
+	}
+
+	_, err := new(rat.Grammar).Init().TryPack(x.Mmx{1})
+	fmt.Println(errors.Is(err, rat.ErrBadGrammar))
+
+	// Output:
+	// no match: expected: x.Mmx{1, -1, x.Rng{'0', '9'}} at line 1, col 1
+	// bad grammar: zero value: *rat.Rule
+	// limit: stream position 3: no input generated was matched in 10 tries
+	// true
+}
+
+func ExampleErrUnresolved() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Post`, x.Mmx{3, 8, '`'}})
+	g.MakeRule(x.N{`Digit`, x.Rng{'0', '9'}})
+
+	g.Pack(x.Ref{`Digti`})
+	err := g.Scan("1").X
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, rat.ErrBadGrammar))
+
+	g.Pack(x.Sav{`Post`}, x.Val{`Pots`})
+	fmt.Println(g.Scan("``````").X)
+
+	g.Pack(x.Ref{`nope`})
+	fmt.Println(g.Scan("1").X)
+
+	// Output:
+	// unresolved: x.Ref{"Digti"} (did you mean Digit?)
+	// true
+	// unresolved: x.Val{"Pots"} (did you mean Post?)
+	// unresolved: x.Ref{"nope"}
+}
+
+func ExampleGrammar_Unresolved() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Post`, x.Mmx{3, 8, '`'}})
+	g.MakeRule(x.N{`Digit`, x.Rng{'0', '9'}})
+	g.Resolve = true
+
+	_, err := g.TryPack(x.Ref{`Digti`}, x.Sav{`Post`}, x.Val{`Pots`}, x.Ref{`alpha`}, x.Ref{`nope`})
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, rat.ErrBadGrammar), g.Main == nil)
+
+	var refs rat.ErrRefs
+	if errors.As(err, &refs) {
+		for _, it := range refs {
+			fmt.Println(it)
+		}
+	}
+
+	// Output:
+	// unresolved: x.Ref{"Digti"} (did you mean Digit?); unresolved: x.Val{"Pots"} (did you mean Post?); unresolved: x.Ref{"nope"}
+	// true true
+	// unresolved: x.Ref{"Digti"} (did you mean Digit?)
+	// unresolved: x.Val{"Pots"} (did you mean Post?)
+	// unresolved: x.Ref{"nope"}
+}
+
+func ExampleGrammar_MaxNesting() {
+
+	g := new(rat.Grammar).Init()
+	g.Pack(x.N{`Paren`, x.Seq{'(', x.Mmx{0, 1, x.Ref{`Paren`}}, ')'}})
+
+	g.MaxNesting = 10
+	fmt.Println(g.Scan(`((()))`).X)
+	err := g.Scan(strings.Repeat(`(`, 100)).X
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, rat.ErrLimit), errors.Is(err, rat.ErrNoMatch))
+
+	g.MaxNesting, g.MaxSteps = 0, 20
+	fmt.Println(g.Scan(strings.Repeat(`(`, 100)).X)
+
+	g.MaxSteps, g.MaxInput = 0, 50
+	fmt.Println(g.Scan(strings.Repeat(`(`, 100)).X)
+
+	// Output:
+	// <nil>
+	// rules nested deeper than 10 at 5
+	// true false
+	// more than 20 rules checked at 7
+	// input larger than 50 runes
+}
+
+func ExampleResult_Snippet() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.Mmx{0, 1, '\t'}, `let `, x.Mmx{1, -1, x.Rng{'a', 'z'}}, ` = `, x.Mmx{1, -1, x.Rng{'0', '9'}}, '\n'}}, x.End{})
+
+	g.Expect = true
+
+	res := g.Scan("let x = 1\nlet y = 2\n\tlet z = three\n")
+	fmt.Println(res.X)
+	fmt.Println(res.Snippet(0))
+	fmt.Println(res.Snippet(1))
+	fmt.Println(res.Snippet(5))
+
+	// Output:
+	// expected: [0-9]
+	// 3 | 	let z = three
+	//   | 	        ^
+	// 2 | let y = 2
+	// 3 | 	let z = three
+	//   | 	        ^
+	// 1 | let x = 1
+	// 2 | let y = 2
+	// 3 | 	let z = three
+	//   | 	        ^
+}
+
+func ExampleResult_ErrorInfo() {
+
+	g := rat.Pack(`let `, x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ` = `, x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	g.Message(`Num`, `numbers only`)
+	g.Chain, g.Locate = true, true
+
+	res := g.Scan("let x = one")
+	res.S = `vars.txt`
+	buf, _ := res.MarshalError()
+	fmt.Println(string(buf))
+
+	buf, _ = g.Scan("let X").MarshalError()
+	fmt.Println(string(buf))
+
+	g.MaxInput = 2
+	fmt.Println(g.Scan("let").ErrorInfo().Code)
+
+	// Output:
+	// {"code":"message","message":"numbers only","source":"vars.txt","offset":8,"line":1,"column":9,"rule":"Num"}
+	// {"code":"expected","message":"expected: x.Mmx{1, -1, x.Rng{'a', 'z'}} at line 1, col 5","offset":4,"line":1,"column":5,"rule":"Name"}
+	// too_large
+}
+
+func ExampleGrammar_Chain() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Post`, x.Mmx{3, 8, '`'}})
+	g.Pack(x.N{`Fenced`, x.Seq{x.Sav{`Post`}, x.Mmx{0, -1, '.'}, x.Val{`Post`}}})
+	g.Chain = true
+
+	err := g.Scan("```...``").X
+	fmt.Println(err)
+
+	var in rat.ErrIn
+	if errors.As(err, &in) {
+		fmt.Println(in.Path())
+	}
+
+	var exp rat.ErrExpected
+	fmt.Println(errors.As(err, &exp), exp.V)
+
+	// Output:
+	// expected: `
+	// [Fenced x.Seq x.Val{"Post"} x.Str{"`"}]
+	// true `
+}
+
+func ExampleGrammar_OnError() {
+
+	g := rat.Pack(`let `, x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ` = `, x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+
+	g.Message(`Name`, `names must be lowercase letters`)
+	g.OnError(`Num`, func(name string, r []rune, i int, err error) error {
+		return fmt.Errorf(`%q is not a number at %v`, string(r[i:]), i)
+	})
+
+	fmt.Println(g.Scan(`let X = 1`).X)
+	fmt.Println(g.Scan(`let x = one`).X)
+
+	var e rat.ErrMessage
+	fmt.Println(errors.As(g.Scan(`let 1 = 1`).X, &e), e.Name, e.I, e.X)
+
+	// Output:
+	// names must be lowercase letters
+	// "one" is not a number at 8
+	// true Name 4 expected: x.Mmx{1, -1, x.Rng{'a', 'z'}}
+}
+
+func ExampleGrammar_Diagnose() {
+
+	num := x.Mmx{1, -1, x.Rng{'0', '9'}}
+	ws := x.Mmx{0, -1, x.One{' ', '\n'}}
+	g := rat.Pack('[', ws, num, x.Mmx{0, -1, x.Seq{ws, ',', ws, num}}, ws, ']')
+
+	res := g.Scan("[1, 2,\n 3 4]")
+	fmt.Println(res.X)
+	fmt.Println(g.Diagnose(res))
+
+	// Output:
+	// expected: ]
+	// line 2, col 4: expected ' ' or x0A or ',' or ']'
+}
+
+func ExampleUTF8Mode() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.Not{'x'}, x.Any{1}}})
+	in := []byte("caf\xc3\xa9 \xff\xfe!")
+
+	fmt.Println(len(g.Scan(in).Runes()))
+
+	g.UTF8 = rat.UTF8Strict
+	fmt.Println(g.Scan(in).X)
+	fmt.Println(g.Scan(bytes.NewReader(in)).X)
+
+	g.UTF8 = rat.UTF8Replace
+	fmt.Printf("%q\n", g.Scan(string(in)).Text())
+
+	// Output:
+	// 8
+	// invalid UTF-8 at byte 6
+	// invalid UTF-8 at byte 6
+	// "café �!"
+}
+
+func ExampleGrammar_Def() {
+
+	g := new(rat.Grammar).Init()
+	name := g.Def(`Name`).Rng('A', 'Z').Min(1, x.Rng{'a', 'z'})
+	name.Rule()
+
+	greet := g.Def(`Greet`).Or(`hello`, `hi`).Lit(` `).Ref(`Name`).Opt('!')
+	fmt.Println(greet.Expr())
+
+	greet.Pack()
+	g.Scan(`hi Rob!`).Print()
+	fmt.Println(g.PEGN())
+
+	// Output:
+	// x.N{"Greet", x.Seq{x.One{x.Str{"hello"}, x.Str{"hi"}}, x.Str{" "}, x.Ref{"Name"}, x.Mmx{0, 1, x.Str{"!"}}}}
+	// {"N":"Greet","B":0,"E":7,"C":[{"B":0,"E":2,"C":[{"B":0,"E":2}]},{"B":2,"E":3},{"N":"Name","B":3,"E":6,"C":[{"B":3,"E":4},{"B":4,"E":6,"C":[{"B":4,"E":5},{"B":5,"E":6}]}]},{"B":6,"E":7,"C":[{"B":6,"E":7}]}],"R":"hi Rob!"}
+	// Greet <= ('hello' / 'hi') ' ' Name '!'?
+	// Name  <= [A-Z] [a-z]+
+}
+
+func ExampleGrammar_Warnings() {
+
+	g := new(rat.Grammar).Init()
+	g.Pack(x.One{`for`, `foreach`}, x.Any{0}, x.Mmx{0, 0, 'x'}, strings.Repeat(`lorem ipsum `, 30))
+	for _, it := range g.Warnings {
+		fmt.Println(it)
+	}
+
+	// Output:
+	// unreachable alternative x.Str{"foreach"} (shadowed by x.Str{"for"}) in x.One{x.Str{"for"}, x.Str{"foreach"}}
+	// repetition can only match nothing: x.Any{0}
+	// repetition can only match nothing: x.Mmx{0, 0, x.Str{"x"}}
+	// literal of 360 bytes keyed by hash
+}
+
+func ExampleGrammar_Validate() {
+
+	g := rat.Pack(x.One{`in`, `int`, x.Rng{'a', 'z'}, `x`, `if`, x.Mmx{0, 1, '_'}, `9`})
+
+	for _, err := range g.Validate() {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// unreachable alternative x.Str{"int"} (shadowed by x.Str{"in"}) in x.One{x.Str{"in"}, x.Str{"int"}, x.Rng{'a', 'z'}, x.Str{"x"}, x.Str{"if"}, x.Mmx{0, 1, x.Str{"_"}}, x.Str{"9"}}
+	// unreachable alternative x.Str{"x"} (shadowed by x.Rng{'a', 'z'}) in x.One{x.Str{"in"}, x.Str{"int"}, x.Rng{'a', 'z'}, x.Str{"x"}, x.Str{"if"}, x.Mmx{0, 1, x.Str{"_"}}, x.Str{"9"}}
+	// unreachable alternative x.Str{"if"} (shadowed by x.Rng{'a', 'z'}) in x.One{x.Str{"in"}, x.Str{"int"}, x.Rng{'a', 'z'}, x.Str{"x"}, x.Str{"if"}, x.Mmx{0, 1, x.Str{"_"}}, x.Str{"9"}}
+	// unreachable alternative x.Str{"9"} (shadowed by x.Mmx{0, 1, x.Str{"_"}}) in x.One{x.Str{"in"}, x.Str{"int"}, x.Rng{'a', 'z'}, x.Str{"x"}, x.Str{"if"}, x.Mmx{0, 1, x.Str{"_"}}, x.Str{"9"}}
+}
+
+func ExamplePack_nullable() {
+
+	// the inner rule always matches (even empty) so would repeat forever
+	g := rat.Pack(x.Mmx{0, -1, x.Mmx{0, 1, 'a'}})
+	fmt.Println(g.Validate())
+
+	g.Scan(`aa`).Print()
+	g.Scan(`b`).Print()
+
+	// Output:
+	// [unlimited repetition of rule matching empty: x.Mmx{0, -1, x.Mmx{0, 1, x.Str{"a"}}}]
+	// {"B":0,"E":2,"C":[{"B":0,"E":1,"C":[{"B":0,"E":1}]},{"B":1,"E":2,"C":[{"B":1,"E":2}]},{"B":2,"E":2}],"R":"aa"}
+	// {"B":0,"E":0,"C":[{"B":0,"E":0}],"R":"b"}
+}
+
+func ExampleGrammar_RuleByID() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Letter`, x.Rng{'a', 'z'}})
+	g.Pack(x.Mmx{1, -1, x.Ref{`Letter`}})
+
+	for _, rule := range g.Ordered() {
+		fmt.Println(rule.ID, rule.Name, g.RuleByID(rule.ID) == rule)
+	}
+	fmt.Println(g.RuleByID(42))
+
+	// Output:
+	// 1 x.Rng{'a', 'z'} true
+	// 2 Letter true
+	// 3 x.Mmx{1, -1, x.Ref{"Letter"}} true
+	// 4 x.Ref{"Letter"} true
+	// <nil>
+}
+
+func ExampleGrammar_Lookup() {
+
+	defer func(n int) { rat.HashKeyLen = n }(rat.HashKeyLen)
+	rat.HashKeyLen = 20
+
+	g := rat.Pack(x.Seq{`foo`, x.Rng{'0', '9'}, `bar`})
+	fmt.Println(g.Main.Name)
+	fmt.Println(g.Main.Text)
+
+	rule, has := g.Lookup(g.Main.Text)
+	fmt.Println(has, rule == g.Main, rule.IsAlias())
+
+	rule, has = g.Lookup(`x.Rng{'0', '9'}`)
+	fmt.Println(has, rule.Name)
+
+	// Output:
+	// #7a17b9e7e1124f71
+	// x.Seq{x.Str{"foo"}, x.Rng{'0', '9'}, x.Str{"bar"}}
+	// true true false
+	// true x.Rng{'0', '9'}
+}
+
+func ExampleScanAll() {
+
+	grammar := func() *rat.Grammar {
+		word := x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}
+		return rat.Pack(x.Mmx{0, -1, x.Seq{word, "\n"}})
+	}
+
+	in := strings.Repeat("foo\nbar\nbaz\n", 100)
+	res := rat.ScanAll(in, "\n", grammar, 2)
+	fmt.Println(res.X, res.E, len(res.C) > 1, len(res.WithName(`Word`)))
+	fmt.Println(res.WithName(`Word`)[298].Text(), res.WithName(`Word`)[298].B)
+
+	res = rat.ScanAll(in+"BAD\nfoo\n", "\n", grammar, 2)
+	fmt.Println(res.X)
+
+	// each chunk is scanned as by Scan (input pipeline, Locate, etc.)
+	located := func() *rat.Grammar {
+		g := new(rat.Grammar).Init()
+		g.NormalizeEOL, g.Locate = true, true
+		word := x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}
+		return g.Pack(x.Seq{x.Mmx{0, -1, x.Seq{word, "\n"}}, x.End{}})
+	}
+	in = strings.Repeat("foo\r\nbar\r\n", 100)
+	res = rat.ScanAll(in, "\n", located, 2)
+	fmt.Println(res.X, res.E)
+	res = rat.ScanAll(in+"BAD\r\n", "\n", located, 2)
+	fmt.Println(res.X)
+
+	// Output:
+	// <nil> 1200 true 300
+	// bar 1192
+	// unmatched input from 1200 to 1208
+	// <nil> 800
+	// expected: x.End{} at line 201, col 1
+}
+
+func ExampleGrammar_ScanStream() {
+
+	g := rat.Pack(x.Seq{x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'ö'}}}, "\n"})
+
+	// read one byte at a time (splitting multi-byte runes)
+	in := iotest.OneByteReader(strings.NewReader("foo\nbär\nbaz\nBAD\n"))
+	err := g.ScanStream(in, func(off int, res rat.Result) {
+		fmt.Println(off, res.WithName(`Word`)[0].Text())
+	})
+	fmt.Println(err)
+
+	// Output:
+	// 0 foo
+	// 4 bär
+	// 8 baz
+	// stream position 12: expected: x.Mmx{1, -1, x.Rng{'a', 'ö'}}
+}
+
+func ExampleGrammar_Profile() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ' '}})
+
+	// samples taken during scan are labeled (see pprof.StartCPUProfile)
+	g.Profile = true
+	res := g.Scan(`labeled by rule `)
+	fmt.Println(len(res.WithName(`Word`)), res.E)
+
+	// Output:
+	// 3 16
+}
+
+func ExampleGrammar_CollectStats() {
+
+	g := rat.Pack(x.Mmx{0, -1, x.One{`if`, x.Rng{'0', '9'}, ' '}})
+	g.CollectStats()
+	g.Scan(`if 1 if 23 `)
+
+	// alternatives that cannot match the next rune are not called
+
+	// sorted by most time first so sort by ID for consistent output
+	stats := g.Stats()
+	sort.Slice(stats, func(a, b int) bool { return stats[a].Rule.ID < stats[b].Rule.ID })
+	for _, s := range stats {
+		fmt.Println(s.Calls, s.Matches, s.Rule.Text)
+	}
+
+	// Output:
+	// 1 1 x.Mmx{0, -1, x.One{x.Str{"if"}, x.Rng{'0', '9'}, x.Str{" "}}}
+	// 10 9 x.One{x.Str{"if"}, x.Rng{'0', '9'}, x.Str{" "}}
+	// 10 2 x.Str{"if"}
+	// 3 3 x.Rng{'0', '9'}
+	// 4 4 x.Str{" "}
+}
+
+func ExampleGrammar_Folded() {
+
+	g := rat.Pack(x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	g.CollectStats()
+	g.Scan(`go`)
+
+	// times vary (see WriteFolded and WriteStats)
+	var stacks []string
+	for stack := range g.Folded() {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		fmt.Println(stack)
+	}
+
+	// Output:
+	// Word
+	// Word;x.Mmx{1, -1, x.Rng{'a', 'z'}}
+	// Word;x.Mmx{1, -1, x.Rng{'a', 'z'}};x.Rng{'a', 'z'}
+}
+
+func ExampleGrammar_CacheStats() {
+
+	g := rat.Pack(x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	s := g.CacheStats()
+	fmt.Println(s.Keys, s.Rules, s.Hashed)
+
+	g.WriteCache(os.Stdout)
+
+	// Output:
+	// 3 3 0
+	// size  id  text  key
+	// 29    1   29    x.Mmx{1, -1, x.Rng{'a', 'z'}}
+	// 15    2   15    x.Rng{'a', 'z'}
+	// 4     3   42    Word
+}
+
+func ExampleGrammar_FailFast() {
+
+	value := x.N{`Value`, x.Seq{x.Rng{'0', '9'}, x.Mmx{0, -1, x.Rng{'0', '9'}}}}
+	g := rat.Pack(x.Mmx{0, -1, x.Rng{'a', 'z'}}, value)
+
+	g.Scan(`ab!`).Print()
+	g.Farthest.Print()
+
+	// Value is not checked since ! cannot begin it
+	g.FailFast = true
+	g.Scan(`ab!`).Print()
+	g.Farthest.Print()
+
+	// Output:
+	// {"B":0,"E":2,"X":"expected: x.Rng{'0', '9'}","C":[{"B":0,"E":2,"C":[{"B":0,"E":1},{"B":1,"E":2}]},{"N":"Value","B":2,"E":2,"X":"expected: x.Rng{'0', '9'}","C":[{"B":2,"E":2,"X":"expected: x.Rng{'0', '9'}"}]}],"R":"ab!"}
+	// {"B":2,"E":2,"X":"expected: x.Rng{'a', 'z'}","R":"ab!"}
+	// {"B":0,"E":2,"X":"expected: x.N{\"Value\", x.Seq{x.Rng{'0', '9'}, x.Mmx{0, -1, x.Rng{'0', '9'}}}}","C":[{"B":0,"E":2,"C":[{"B":0,"E":1},{"B":1,"E":2}]},{"N":"Value","B":2,"E":2,"X":"expected: x.N{\"Value\", x.Seq{x.Rng{'0', '9'}, x.Mmx{0, -1, x.Rng{'0', '9'}}}}"}],"R":"ab!"}
+	// {"B":2,"E":2,"X":"expected: x.Rng{'a', 'z'}","R":"ab!"}
+}
+
+func ExampleArena() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ' '}})
+	g.Arena = new(rat.Arena)
+
+	batch := []string{`one two `, `three `, `four five six `}
+	for _, in := range batch {
+		res := g.Scan(in)
+		fmt.Println(len(res.WithName(`Word`)))
+	}
+
+	// every result of the batch is freed at once
+	g.Arena.Free()
+
+	// Output:
+	// 2
+	// 1
+	// 3
+}
+
+func ExampleNew() {
+
+	name := x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}
+	g, err := rat.New(
+		rat.WithLimits(rat.Limits{MaxInput: 8}),
+		rat.WithNamedOnly(),
+		rat.WithTracer(rat.TraceCheck, rat.TracerFunc(func(e rat.TraceEvent) {
+			if e.Level == rat.TraceCheck && e.T == rat.EventExit {
+				fmt.Println(e.Rule, e.I, e.E)
+			}
+		})),
+	).PackE(x.N{`Greet`, x.Seq{"hi ", name}})
+	fmt.Println(err)
+	g.Scan(`hi bob`).Print()
+	fmt.Println(g.Scan(`hi everyone`).X)
+
+	_, err = rat.PackE(x.Seq{"hi", x.Mmx{1}})
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+	// Name 3 6
+	// Greet 0 6
+	// {"N":"Greet","B":0,"E":6,"C":[{"N":"Name","B":3,"E":6}],"R":"hi bob"}
+	// input larger than 8 runes
+	// malformed expression: "%!USAGE: x.Mmx{m, n, rule}" in x.Seq[1] → x.Mmx
+}
+
+func ExampleRule_Scan_runeReader() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.N{`Word`, x.Seq{x.Mmx{1, -1, x.Rng{'a', 'z'}}, x.Mmx{0, 1, ' '}}}})
+	rd := bufio.NewReader(strings.NewReader(`rune by rune`))
+	g.Main.Scan(rd).PrintText()
+
+	g.MaxInput = 5
+	fmt.Println(g.Scan(bufio.NewReader(strings.NewReader(`too many runes`))).X)
+
+	g.MaxInput, g.UTF8 = 0, rat.UTF8Replace
+	fmt.Printf("%+q\n", string(g.Scan(bufio.NewReader(strings.NewReader("caf\xff\xfe"))).R))
+
+	// Output:
+	// rune by rune
+	// input larger than 5 runes
+	// "caf\ufffd"
+}
+
+func ExampleGrammar_ScanWith() {
+
+	g := rat.Pack(x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	in := `id=12345;`
+
+	g.ScanWith(in, rat.ScanOptions{Start: 3}).Print()
+	g.ScanWith(in, rat.ScanOptions{Start: 3, Max: 2}).Print()
+	fmt.Println(g.ScanWith(in, rat.ScanOptions{Start: 3, Full: true}).X)
+	fmt.Println(g.ScanWith(in, rat.ScanOptions{Start: 3, Max: 5, Full: true}).X)
+
+	// Output:
+	// {"N":"Num","B":3,"E":8,"C":[{"B":3,"E":4},{"B":4,"E":5},{"B":5,"E":6},{"B":6,"E":7},{"B":7,"E":8}],"R":"id=12345;"}
+	// {"N":"Num","B":3,"E":5,"C":[{"B":3,"E":4},{"B":4,"E":5}],"R":"id=12"}
+	// unmatched input from 8 to 9
+	// <nil>
+}
+
+func ExampleGrammar_AddRuleE() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+
+	_, err := g.AddRuleE(&rat.Rule{Name: `Key`, Text: `x.Str{"id"}`})
+	fmt.Println(err)
+
+	g.Strict = true
+	_, err = g.TryPack(x.N{`Key`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	fmt.Println(err, errors.Is(err, rat.ErrBadGrammar))
+	fmt.Println(g.Rules[`Key`].Text)
+
+	g.Override(x.N{`Key`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	fmt.Println(g.Rules[`Key`].Text)
+
+	// Output:
+	// rule "Key" already defined as x.N{"Key", x.Mmx{1, -1, x.Rng{'a', 'z'}}} (not x.Str{"id"})
+	// rule "Key" already defined as x.N{"Key", x.Mmx{1, -1, x.Rng{'a', 'z'}}} (not x.N{"Key", x.Mmx{1, -1, x.Rng{'0', '9'}}}) true
+	// x.N{"Key", x.Mmx{1, -1, x.Rng{'a', 'z'}}}
+	// x.N{"Key", x.Mmx{1, -1, x.Rng{'0', '9'}}}
+}
+
+func ExampleGrammar_Bind() {
+
+	var host string
+	var port uint16
+	var when time.Time
+	var tags []string
+
+	g := rat.Pack(x.Seq{
+		x.N{`Host`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, ':',
+		x.N{`Port`, x.Mmx{1, -1, x.Rng{'0', '9'}}}, ' ',
+		x.N{`When`, x.To{' '}},
+		x.Mmx{0, -1, x.Seq{' ', '#', x.N{`Tag`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}}},
+	})
+	g.Bind(`Host`, &host).Bind(`Port`, &port).Bind(`When`, &when).Bind(`Tag`, &tags)
+
+	fmt.Println(g.Scan(`localhost:8080 2024-02-29T12:00:00Z #web #dev`).X)
+	fmt.Println(host, port, when.Weekday(), tags)
+
+	err := g.Scan(`localhost:80800 2024-02-29T12:00:00Z #web`).X
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, strconv.ErrRange), port)
+
+	// Output:
+	// <nil>
+	// localhost 8080 Thursday [web dev]
+	// cannot bind Port "80800": strconv.ParseUint: parsing "80800": value out of range
+	// true 8080
+}
+
+func ExampleGrammar_MarshalText() {
+
+	g := new(rat.Grammar).Init()
+	g.MakeAlias(`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}})
+	g.Pack(x.N{`Pair`, x.Seq{x.Ref{`Key`}, '=', x.N{`Val`, x.Mmx{1, -1, x.Rng{'0', '9'}}}}})
+
+	config := struct{ Grammar *rat.Grammar }{g}
+	buf, _ := json.Marshal(config)
+	fmt.Println(string(buf))
+
+	config.Grammar = new(rat.Grammar)
+	fmt.Println(json.Unmarshal(buf, &config))
+	config.Grammar.Scan(`port=8080`).Print()
+
+	// Output:
+	// {"Grammar":"Pair \u003c= Key '=' Val\nKey \u003c- [a-z]+\nVal \u003c= [0-9]+\n"}
+	// <nil>
+	// {"N":"Pair","B":0,"E":9,"C":[{"B":0,"E":4,"C":[{"B":0,"E":1},{"B":1,"E":2},{"B":2,"E":3},{"B":3,"E":4}]},{"B":4,"E":5},{"N":"Val","B":5,"E":9,"C":[{"B":5,"E":6},{"B":6,"E":7},{"B":7,"E":8},{"B":8,"E":9}]}],"R":"port=8080"}
+}
+
+func ExampleGrammar_MakeDoc() {
+
+	g := rat.Pack(x.Doc{`Greet is said first.`, x.N{`Greet`, x.Seq{`hi `, x.Doc{
+		"Name is a first name.\nNo titles.", x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}},
+	}}}})
+	fmt.Print(g.PEGN())
+	g.Scan(`hi rob`).Print()
+
+	// Output:
+	// # Greet is said first.
+	// Greet <= 'hi ' Name
+	// # Name is a first name.
+	// # No titles.
+	// Name  <= [a-z]+
+	// {"N":"Greet","B":0,"E":6,"C":[{"B":0,"E":3},{"N":"Name","B":3,"E":6,"C":[{"B":3,"E":4},{"B":4,"E":5},{"B":5,"E":6}]}],"R":"hi rob"}
+
+}
+
+func ExampleGrammar_NormalizeEOL() {
+
+	line := x.N{`Line`, x.Mmx{0, -1, x.Seq{x.Not{x.One{'\r', '\n'}}, x.Any{1}}}}
+
+	// EndOfLine matches LF, CRLF, and CR
+	g := rat.Pack(x.Mmx{1, -1, x.Seq{line, x.Ref{`EndOfLine`}}}, x.End{})
+	for _, it := range g.Scan("one\r\ntwo\rthree\n").WithName(`Line`) {
+		fmt.Println(it.Text())
+	}
+
+	// or normalize input to LF first
+	g = rat.New(rat.WithNormalizeEOL()).Pack(x.Mmx{1, -1, x.Seq{line, '\n'}}, x.End{})
+	fmt.Printf("%q\n", g.Scan("one\r\ntwo\rthree\n").Text())
+
+	// Output:
+	// one
+	// two
+	// three
+	// "one\ntwo\nthree\n"
+
+}
+
+func ExampleGrammar_ScanUser() {
+
+	type symbols map[string]bool
+
+	g := new(rat.Grammar).Init()
+	name := g.MakeRule(x.N{`Name`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+
+	// declarations add names that uses must have
+	g.AddRule(&rat.Rule{Name: `Declared`, Check: func(r []rune, i int) rat.Result {
+		res := name.Check(r, i)
+		if res.X == nil {
+			g.User.(symbols)[res.Text()] = true
+		}
+		return res
+	}})
+	g.AddRule(&rat.Rule{Name: `Used`, Check: func(r []rune, i int) rat.Result {
+		res := name.Check(r, i)
+		if res.X == nil && !g.User.(symbols)[res.Text()] {
+			res.X = fmt.Errorf(`undeclared: %v`, res.Text())
+		}
+		return res
+	}})
+	g.Pack(`let `, x.Ref{`Declared`}, `; use `, x.Ref{`Used`}, x.End{})
+
+	fmt.Println(g.ScanUser(`let a; use a`, symbols{}).X)
+	fmt.Println(g.ScanUser(`let a; use b`, symbols{}).X)
+
+	// Output:
+	// <nil>
+	// undeclared: b
+
+}
+
+// Keyword is a word never matched as the beginning of a longer word
+// (see ExampleMaker).
+type Keyword string
+
+func (k Keyword) String() string { return fmt.Sprintf(`Keyword(%q)`, string(k)) }
+
+func (k Keyword) PEGN() string { return fmt.Sprintf(`'%v' !alphanum`, string(k)) }
+
+func (k Keyword) Make(g *rat.Grammar) *rat.Rule {
+	inner := g.MakeRule(x.Seq{string(k), x.Not{x.Ref{`alphanum`}}})
+	return &rat.Rule{Check: inner.Check}
+}
+
+func ExampleMaker() {
+
+	g := rat.Pack(x.N{`Select`, x.Seq{Keyword(`select`), ' ', x.Mmx{1, -1, x.Rng{'a', 'z'}}}})
+	fmt.Println(g.Scan(`select name`).X)
+	fmt.Println(g.Scan(`selection`).X)
+	fmt.Print(g.PEGN())
+	fmt.Println(g.Rules[`Select`])
+
+	// Output:
+	// <nil>
+	// expected: x.Not{x.Ref{"alphanum"}}
+	// Select <= ('select' !alphanum) ' ' [a-z]+
+	// x.N{"Select", x.Seq{Keyword("select"), x.Str{" "}, x.Mmx{1, -1, x.Rng{'a', 'z'}}}}
+
+}
+
+func ExampleGrammar_MakeByte() {
+
+	// PNG signature followed by the length and type of the first chunk
+	g := rat.New(rat.WithUTF8(rat.UTF8Raw)).Pack(
+		x.N{`Magic`, x.Byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}},
+		x.N{`Length`, x.Mmx{4, 4, x.BRng{0x00, 0xFF}}},
+		x.N{`Type`, x.Mmx{4, 4, x.One{x.BRng{'A', 'Z'}, x.BRng{'a', 'z'}}}},
+	)
+
+	in := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	res := g.Scan(in)
+	fmt.Println(res.X)
+	for _, it := range res.C {
+		fmt.Printf("%v %v\n", it.N, it.Runes())
+	}
+
+	// UTF-8 input of the same bytes does not match
+	g.UTF8 = rat.UTF8AsIs
+	fmt.Println(g.Scan(in).X)
+
+	// Output:
+	// <nil>
+	// Magic [137 80 78 71 13 10 26 10]
+	// Length [0 0 0 13]
+	// Type [73 72 68 82]
+	// expected: x.Byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
+}
+
+func ExampleGrammar_MakeLen() {
+
+	// netstrings: 5:hello,
+	digits := x.Mmx{1, 9, x.Rng{'0', '9'}}
+	netstring := x.Seq{x.Len{x.Seq{x.N{`Size`, digits}, ':'}, x.N{`Data`, x.Mmx{0, -1, x.Any{1}}}}, ','}
+	g := rat.Pack(x.Mmx{1, -1, netstring}, x.End{})
+	for _, it := range g.Scan(`5:hello,0:,3:a,b,`).WithName(`Data`) {
+		fmt.Printf("%q\n", it.Text())
+	}
+	fmt.Println(g.Scan(`5:hell,`).X)
+
+	// binary type-length-value records with a two byte length
+	record := x.Seq{
+		x.N{`Type`, x.BRng{0x00, 0xFF}},
+		x.Len{x.Mmx{2, 2, x.BRng{0x00, 0xFF}}, x.N{`Value`, x.Mmx{0, -1, x.Any{1}}}, x.BigEndian},
+	}
+	g = rat.New(rat.WithUTF8(rat.UTF8Raw)).Pack(x.Mmx{1, -1, record}, x.End{})
+	res := g.Scan([]byte("\x01\x00\x02hi\x02\x00\x00\x03\x00\x03abc"))
+	fmt.Println(res.X)
+	for _, it := range res.WithName(`Value`) {
+		fmt.Printf("%q\n", it.Text())
+	}
+
+	fmt.Println(x.PEGN(x.N{`Netstring`, netstring}))
+
+	// Output:
+	// "hello"
+	// ""
+	// "a,b"
+	// expected: x.Mmx{1, -1, x.Seq{x.Len{x.Seq{x.N{"Size", x.Mmx{1, 9, x.Rng{'0', '9'}}}, x.Str{":"}}, x.N{"Data", x.Mmx{0, -1, x.Any{1}}}}, x.Str{","}}}
+	// <nil>
+	// "hi"
+	// ""
+	// "abc"
+	// Netstring <= Data{Size ':'} ','
+	// Size <= [0-9]{1,9}
+	// Data <= .*
+
+}
+
+func ExampleGrammar_MakeBits() {
+
+	// DNS message header flags (after the ID)
+	g := rat.New(rat.WithUTF8(rat.UTF8Bits)).Pack(
+		x.N{`ID`, x.Bits{16}},
+		x.N{`QR`, x.Flag(true)},
+		x.N{`Opcode`, x.Bits{4, 0, 2}},
+		x.N{`AA`, x.Field(1)},
+		x.N{`TC`, x.Field(1)},
+		x.N{`RD`, x.Field(1)},
+		x.N{`RA`, x.Field(1)},
+		x.N{`Z`, x.Bits{3, 0}},
+		x.N{`RCODE`, x.Bits{4}},
+	)
+
+	res := g.Scan([]byte{0xAB, 0xCD, 0x81, 0x80})
+	fmt.Println(res.X)
+	for _, it := range res.C {
+		v, _ := it.Bits()
+		fmt.Printf("%v [%v,%v) %v\n", it.N, it.B, it.E, v)
+	}
+
+	// a query (QR not set) does not match
+	fmt.Println(g.Scan([]byte{0xAB, 0xCD, 0x01, 0x00}).X)
+
+	fmt.Println(x.PEGN(x.N{`Flags`, x.Seq{x.Flag(true), x.Bits{4, 0, 2}, x.Bits{3, 0}}}))
+
+	// Output:
+	// <nil>
+	// ID [0,16) 43981
+	// QR [16,17) 1
+	// Opcode [17,21) 0
+	// AA [21,22) 0
+	// TC [22,23) 0
+	// RD [23,24) 1
+	// RA [24,25) 1
+	// Z [25,28) 0
+	// RCODE [28,32) 0
+	// expected: x.Bits{1, 1}
+	// Flags <= '1' [01]{4} '000'
+
+}
+
+func ExampleGrammar_Extend() {
+
+	csv := new(rat.Grammar).Init()
+	csv.MakeRule(x.N{`Field`, x.Mmx{0, -1, x.Seq{x.Not{x.One{x.Ref{`Sep`}, '\n'}}, x.Any{1}}}})
+	csv.MakeRule(x.N{`Sep`, ','})
+	csv.Pack(x.N{`Record`, x.Seq{x.Ref{`Field`}, x.Mmx{0, -1, x.Seq{x.Ref{`Sep`}, x.Ref{`Field`}}}}})
+
+	// same grammar but with tabs separating fields
+	tsv := new(rat.Grammar).Extend(csv, x.N{`Sep`, '\t'})
+
+	for _, g := range []*rat.Grammar{csv, tsv} {
+		fields := []string{}
+		for _, it := range g.Scan("a,b\tc").WithName(`Field`) {
+			fields = append(fields, it.Text())
+		}
+		fmt.Printf("%q\n", fields)
+	}
+
+	fmt.Print(tsv.PEGN())
+
+	// Output:
+	// ["a" "b\tc"]
+	// ["a,b" "c"]
+	// Record <= Field (Sep Field)*
+	// Field  <= (!(Sep / x0A) .)*
+	// Sep    <= x09
+
+}
+
+func ExampleGrammar_Import() {
+
+	// both define a String rule
+	json := new(rat.Grammar).Init()
+	json.MakeRule(x.N{`String`, x.Seq{'"', x.To{'"'}, '"'}})
+	ini := new(rat.Grammar).Init()
+	ini.MakeRule(x.N{`String`, x.Mmx{1, -1, x.Ref{`alpha`}}})
+	ini.MakeRule(x.N{`Pair`, x.Seq{x.Ref{`String`}, '=', x.Ref{`String`}}})
+
+	g := new(rat.Grammar).Init()
+	g.Import(json, `Json`)
+	names := g.Import(ini, `Ini`)
+	fmt.Println(names)
+
+	g.Pack(x.Ref{`IniPair`}, ' ', x.Ref{`JsonString`})
+	res := g.Scan(`key=value "text"`)
+	fmt.Println(res.X)
+	for _, it := range res.WithName(`IniString`, `JsonString`) {
+		fmt.Println(it.N, it.Text())
+	}
+
+	fmt.Print(g.PEGN())
+
+	// Output:
+	// map[Pair:IniPair String:IniString]
+	// <nil>
+	// IniString key
+	// IniString value
+	// JsonString "text"
+	// Main       <- IniPair ' ' JsonString
+	// JsonString <= '"' .. '"' '"'
+	// IniString  <= alpha+
+	// IniPair    <= IniString '=' IniString
+
+}
+
+func ExampleGrammar_Redact() {
+
+	g := rat.New(rat.WithRedact(`Password`), rat.WithNamedOnly()).Pack(
+		"user=", x.N{`User`, x.Mmx{1, -1, x.Rng{'a', 'z'}}},
+		" password=", x.N{`Password`, x.Mmx{1, -1, x.One{x.Rng{'a', 'z'}, x.Rng{'0', '9'}}}},
+		x.End{},
+	)
+
+	res := g.Scan(`user=rob password=s3cret`)
+	fmt.Println(res)
+	fmt.Println(res.WithName(`Password`)[0].Text())
+
+	// failed in the middle of the secret
+	res = g.Scan(`user=rob password=s3cr!t`)
+	fmt.Println(res.Snippet(0))
+
+	// Output:
+	// {"B":0,"E":24,"C":[{"N":"User","B":5,"E":8},{"N":"Password","B":18,"E":24}],"R":"user=rob password=******"}
+	// s3cret
+	// 1 | user=rob password=****!t
+	//   |                       ^
+
+}
+
+// closing returns the closing bracket of the opening one (text).
+func closing(text string) any {
+	return map[string]string{`(`: `)`, `[`: `]`, `{`: `}`}[text]
+}
+
+func ExampleGrammar_MakeSave_transform() {
+
+	// HTML tag names match regardless of case
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Tag`, x.Mmx{1, -1, x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}}})
+	g.Pack(x.N{`Element`, x.Seq{
+		'<', x.Sav{`Tag`, x.Folded}, '>',
+		x.N{`Body`, x.To{"</"}},
+		"</", x.Val{`Tag`}, '>',
+	}})
+
+	fmt.Println(g.Scan(`<div>hi</DIV>`).X)
+	fmt.Println(g.Scan(`<div>hi</span>`).X)
+
+	// or any named function of the text returning an expression
+	g.MakeRule(x.N{`Open`, x.One{'(', '[', '{'}})
+	g.Pack(x.Sav{`Open`, closing}, x.Mmx{0, -1, x.Rng{'a', 'z'}}, x.Val{`Open`})
+	fmt.Println(g.Main)
+	fmt.Println(g.Scan(`[abc]`).X, g.Scan(`{abc)`).X)
+
+	// or any anonymous one (values saved are never added to Rules)
+	repeat := func(n int) func(string) any {
+		return func(text string) any { return strings.Repeat(text, n) }
+	}
+	g.MakeRule(x.N{`Twice`, x.Seq{x.Sav{`Open`, repeat(2)}, x.Val{`Open`}, x.End{}}})
+	g.MakeRule(x.N{`Thrice`, x.Seq{x.Sav{`Open`, repeat(3)}, x.Val{`Open`}, x.End{}}})
+	g.Pack(x.One{x.Ref{`Twice`}, x.Ref{`Thrice`}})
+	rules := len(g.Rules)
+	fmt.Println(g.Scan(`(((`).X, g.Scan(`{{{{`).X, g.Scan(`[[`).X)
+	fmt.Println(len(g.Rules) - rules)
+
+	// and failures to match the value are recorded as any other
+	g.Pack(x.Ref{`Twice`})
+	fmt.Println(g.Scan(`[[x`).X, g.Farthest.E, g.Farthest.X)
+
+	// Output:
+	// <nil>
+	// expected: x.One{x.Str{"d"}, x.Str{"D"}}
+	// x.Seq{x.Sav{"Open", closing}, x.Mmx{0, -1, x.Rng{'a', 'z'}}, x.Val{"Open"}}
+	// <nil> expected: }
+	// <nil> <nil> expected: x.One{x.Ref{"Twice"}, x.Ref{"Thrice"}}
+	// 0
+	// expected: [ 2 expected: [
+
+}
+
+func ExampleGrammar_FindLast() {
+
+	g := rat.Pack(x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}})
+	res := g.FindLast(`v1.20.3-rc`)
+	fmt.Println(res.Text(), res.B, res.E)
+
+	ext := rat.Pack(x.N{`Ext`, x.One{`.tar.gz`, `.gz`, `.zip`}})
+	fmt.Println(ext.FindSuffix(`backup.tar.gz`).Text())
+	fmt.Println(ext.FindSuffix(`notes.zip`).Text())
+	fmt.Println(ext.FindSuffix(`notes.zip.txt`).X)
+	fmt.Println(ext.FindLast(`notes.zip.txt`).Text())
+
+	// Output:
+	// 3 6 7
+	// .gz
+	// .zip
+	// expected: x.N{"Ext", x.One{x.Str{".tar.gz"}, x.Str{".gz"}, x.Str{".zip"}}}
+	// .zip
+}
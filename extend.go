@@ -0,0 +1,56 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// Extend makes every named rule and alias of the base grammar in this
+// one (which keeps its own options) followed by every override (see
+// Override), usually x.N expressions named after rules of the base, so
+// that references (x.Ref) to those names, even from within the rules
+// inherited, resolve to the overrides instead. This way families of
+// related formats (dialects) can share one core grammar with each
+// deriving its own from it. The base is never changed. The Main rule
+// is that of the base (or its override). Named expressions nested
+// directly within other rules (rather than referenced) are not
+// overridden and rules of the base made without an Expr (see AddRule)
+// are inherited as is. Like MakeRule, Extend panics if any expression
+// is malformed (see Try).
+//
+//	tsv := rat.New().Extend(csv, x.N{`Sep`, '\t'})
+func (g *Grammar) Extend(base *Grammar, overrides ...any) *Grammar {
+	if g.Rules == nil {
+		g.Init()
+	}
+	for _, rule := range base.Ordered() {
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		switch {
+		case rule.Expr == nil:
+			inherited := *rule
+			inherited.ID = 0
+			g.AddRule(&inherited)
+		case rule.IsAlias():
+			g.MakeAlias(rule.Name, rule.Expr).Doc = rule.Doc
+		case isNamed(rule):
+			g.MakeRule(rule.Expr).Doc = rule.Doc
+		}
+	}
+	for _, it := range overrides {
+		g.Override(it)
+	}
+	if base.Main != nil {
+		if main, has := g.Rules[base.Main.Name]; has {
+			g.Main = main
+		} else if base.Main.Expr != nil {
+			g.Main = g.MakeRule(base.Main.Expr)
+		}
+	}
+	return g
+}
+
+// isNamed returns true if the rule was made from a named (x.N)
+// expression.
+func isNamed(rule *Rule) bool {
+	_, named := rule.Expr.(x.N)
+	return named
+}
@@ -0,0 +1,42 @@
+package rat
+
+// FindLast searches the input backwards from its end for the last
+// position at which the Main rule matches (anything, empty matches are
+// skipped) and returns that match or a result failing with ErrExpected
+// (at the end of the input) if there is none. Since the search begins
+// at the end, only the end of the input is scanned when what is sought
+// is there (the last timestamp of a log, the extension of a file
+// name).
+func (g *Grammar) FindLast(in any) Result { return g.findLast(in, false) }
+
+// FindSuffix is the same as FindLast but the match must also end at the
+// very end of the input (anchored) as if the Main rule ended with an
+// x.End. The shortest such suffix (that beginning last) is returned so
+// that x.One{".gz", ".tar.gz"} and x.One{".tar.gz", ".gz"} both find
+// .gz in "a.tar.gz".
+func (g *Grammar) FindSuffix(in any) Result { return g.findLast(in, true) }
+
+func (g *Grammar) findLast(in any, anchored bool) Result {
+	if g.Main == nil {
+		return Result{X: ErrIsZero{g.Main}}
+	}
+	var runes []rune
+	var err error
+	if g.MaxInput > 0 || g.UTF8 != UTF8AsIs || g.NormalizeEOL {
+		runes, err = g.input(in)
+	} else {
+		runes, err = toRunes(in)
+	}
+	if err != nil {
+		return Result{R: runes, X: err}
+	}
+	for i := len(runes) - 1; i >= 0; i-- {
+		res := g.scan(runes, i)
+		if res.X == nil && res.E > i && (!anchored || res.E == len(runes)) {
+			return res
+		}
+		g.discard(res)
+	}
+	end := len(runes)
+	return Result{R: runes, B: end, E: end, X: ErrExpected{g.Main.Expr}}
+}
@@ -0,0 +1,81 @@
+package formats_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/formats"
+	"github.com/rwxrob/rat/pegn"
+	"github.com/rwxrob/rat/x"
+)
+
+func Example() {
+
+	g := rat.Pack(x.Seq{x.One{
+		formats.UUID, formats.IPv6, formats.IPv4, formats.SemVer,
+		formats.URL, formats.Email,
+	}, x.End{}})
+
+	for _, it := range []string{
+		`f47ac10b-58cc-4372-a567-0e02b2c3d479`,
+		`2001:db8::ff00:42:8329`,
+		`::ffff:192.0.2.128`,
+		`192.168.0.1`,
+		`1.0.0-rc.1+build.5`,
+		`https://example.com/docs`,
+		`jane.doe+news@example.com`,
+		`256.0.0.1`,
+		`1.0.0-01`,
+	} {
+		res := g.Scan(it)
+		if res.X != nil {
+			fmt.Println(`invalid`, it)
+			continue
+		}
+		fmt.Println(res.C[0].C[0].N, it)
+	}
+
+	// Output:
+	// UUID f47ac10b-58cc-4372-a567-0e02b2c3d479
+	// IPv6 2001:db8::ff00:42:8329
+	// IPv6 ::ffff:192.0.2.128
+	// IPv4 192.168.0.1
+	// SemVer 1.0.0-rc.1+build.5
+	// URL https://example.com/docs
+	// Email jane.doe+news@example.com
+	// invalid 256.0.0.1
+	// invalid 1.0.0-01
+}
+
+func ExampleAdd() {
+
+	g := formats.Add(new(rat.Grammar).Init())
+	g.NamedOnly = true
+	g.Pack(x.N{`Link`, x.Seq{'<', x.Ref{`URL`}, '>'}})
+	res := g.Scan(`<https://jo@example.com:8443/a/b?q=1#top>`)
+	for _, it := range res.WithName(`Scheme`, `Host`, `Port`, `Path`, `Query`, `Fragment`) {
+		fmt.Println(it.N, it.Text())
+	}
+
+	// Output:
+	// Scheme https
+	// Host example.com
+	// Port 8443
+	// Path /a/b
+	// Query q=1
+	// Fragment top
+}
+
+func ExamplePEGN() {
+
+	g, err := pegn.Parse(formats.PEGN + "Hosts <= (IPv4 / Hostname) (',' (IPv4 / Hostname))*\n")
+	fmt.Println(err)
+	hosts := g.Rules[`Hosts`]
+	hosts.Scan(`10.0.0.1,example.com`).PrintText()
+	fmt.Println(g.Rules[`SemVer`].Scan(`01.2.3`).X != nil)
+
+	// Output:
+	// <nil>
+	// 10.0.0.1,example.com
+	// true
+}
@@ -0,0 +1,172 @@
+/*
+Package formats contains ready-made named (x.N) rat/x rules for the
+well-known values that every grammar author eventually tries to write
+and usually gets subtly wrong: IPv4 and IPv6 addresses (RFC 3986),
+hostnames (RFC 1123), email addresses (the dot-atom form of RFC 5322),
+URLs with an authority (RFC 3986), UUIDs (RFC 4122), and semantic
+versions (semver.org). Like those of the std package they can be used
+directly within any rat/x expression or added to a Grammar (see Add) so
+that they can be referenced (x.Ref) by name. The same rules are also
+available as a PEGN document (see PEGN) for grammars written in PEGN.
+
+Every rule matches as much as it can as an ordered choice (PEG) and
+does not require the end of the data, so add an x.End{} (or check the
+end of the result) when the entire input must be a single value.
+*/
+package formats
+
+import (
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	digit    = x.Rng{'0', '9'}
+	alpha    = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}
+	alnum    = x.One{alpha, digit}
+	hexdig   = x.One{digit, x.Rng{'a', 'f'}, x.Rng{'A', 'F'}}
+	pct      = x.Seq{'%', hexdig, hexdig}
+	subdelim = x.One{'!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '='}
+	unreserv = x.One{alnum, '-', '.', '_', '~'}
+	pchar    = x.One{unreserv, pct, subdelim, ':', '@'}
+	h16      = x.Mmx{1, 4, hexdig}
+	octet    = x.One{
+		x.Seq{"25", x.Rng{'0', '5'}},
+		x.Seq{'2', x.Rng{'0', '4'}, digit},
+		x.Seq{'1', digit, digit},
+		x.Seq{x.Rng{'1', '9'}, digit},
+		digit,
+	}
+	label = x.Seq{alnum, x.Mmx{0, 62, x.One{alnum, x.Seq{'-', x.See{x.One{alnum, '-'}}}}}}
+	atext = x.One{alnum, '!', '#', '$', '%', '&', '\'', '*', '+', '-', '/', '=', '?', '^', '_', '`', '{', '|', '}', '~'}
+	ident = x.One{alnum, '-'}
+	num   = x.One{'0', x.Seq{x.Rng{'1', '9'}, x.Mmx{0, -1, digit}}}
+)
+
+// IPv4 is a dotted decimal IPv4 address (192.168.0.1) with no leading
+// zeros and every octet from 0 to 255.
+var IPv4 = x.N{`IPv4`, x.Seq{
+	octet, '.', octet, '.', octet, '.', octet, x.Not{digit},
+}}
+
+// IPv6 is an IPv6 address (2001:db8::1, ::ffff:192.0.2.128) in any of
+// the forms of RFC 3986 (including those ending with an IPv4 address)
+// without a zone. The alternatives of the RFC are reordered so that
+// the longest always matches first.
+var IPv6 = x.N{`IPv6`, ipv6()}
+
+// ipv6 returns the alternatives of IPv6 with the optional prefix of
+// 0 to n+1 groups before the :: of each written so that it never
+// consumes the :: itself.
+func ipv6() x.One {
+	ls32 := x.One{IPv4, x.Seq{h16, ':', h16}}
+	groups := func(n int) x.Mmx { return x.Mmx{n, n, x.Seq{h16, ':'}} }
+	prefix := func(n int) x.Mmx {
+		return x.Mmx{0, 1, x.Seq{h16, x.Mmx{0, n, x.Seq{':', h16}}}}
+	}
+	return x.One{
+		x.Seq{groups(6), ls32},
+		x.Seq{"::", groups(5), ls32},
+		x.Seq{x.Mmx{0, 1, h16}, "::", groups(4), ls32},
+		x.Seq{prefix(1), "::", groups(3), ls32},
+		x.Seq{prefix(2), "::", groups(2), ls32},
+		x.Seq{prefix(3), "::", h16, ':', ls32},
+		x.Seq{prefix(4), "::", ls32},
+		x.Seq{prefix(5), "::", h16},
+		x.Seq{prefix(6), "::"},
+	}
+}
+
+// Hostname is a domain name (example.com, localhost) of one or more
+// labels separated by dots. Labels are letters, digits, and hyphens of
+// no more than 63 runes that neither begin nor end with a hyphen.
+var Hostname = x.N{`Hostname`, x.Seq{label, x.Mmx{0, -1, x.Seq{'.', label}}}}
+
+// Email is an email address (jane.doe+news@example.com) with
+// a dot-atom local part and a Hostname domain. Quoted local parts,
+// comments, and address literals are not matched since they are almost
+// never intended.
+var Email = x.N{`Email`, x.Seq{
+	x.Mmx{1, -1, atext}, x.Mmx{0, -1, x.Seq{'.', x.Mmx{1, -1, atext}}},
+	'@', Hostname,
+}}
+
+// URL is an absolute URL with an authority (https://user@example.com:8080/a/b?q=1#top)
+// containing named Scheme, Host, Port, Path, Query, and Fragment
+// results (if present). The Host is an IPv6 address in brackets or any
+// registered name (including an IPv4 address). Percent-encoded runes
+// are matched but not decoded.
+var URL = x.N{`URL`, x.Seq{
+	x.N{`Scheme`, x.Seq{alpha, x.Mmx{0, -1, x.One{alnum, '+', '-', '.'}}}},
+	"://",
+	x.Mmx{0, 1, x.Seq{x.Mmx{0, -1, x.One{unreserv, pct, subdelim, ':'}}, '@'}},
+	x.N{`Host`, x.One{
+		x.Seq{'[', IPv6, ']'},
+		x.Mmx{1, -1, x.One{unreserv, pct, subdelim}},
+	}},
+	x.Mmx{0, 1, x.Seq{':', x.N{`Port`, x.Mmx{1, 5, digit}}}},
+	x.Mmx{0, 1, x.N{`Path`, x.Mmx{1, -1, x.Seq{'/', x.Mmx{0, -1, pchar}}}}},
+	x.Mmx{0, 1, x.Seq{'?', x.N{`Query`, x.Mmx{0, -1, x.One{pchar, '/', '?'}}}}},
+	x.Mmx{0, 1, x.Seq{'#', x.N{`Fragment`, x.Mmx{0, -1, x.One{pchar, '/', '?'}}}}},
+}}
+
+// UUID is a UUID in its canonical textual form of 32 hexadecimal digits
+// in groups of 8-4-4-4-12 (f47ac10b-58cc-4372-a567-0e02b2c3d479) of any
+// version or case.
+var UUID = x.N{`UUID`, x.Seq{
+	x.Mmx{8, 8, hexdig}, '-', x.Mmx{4, 4, hexdig}, '-', x.Mmx{4, 4, hexdig}, '-',
+	x.Mmx{4, 4, hexdig}, '-', x.Mmx{12, 12, hexdig},
+}}
+
+// SemVer is a semantic version (1.0.0-rc.1+build.5) exactly as defined
+// by semver.org: numbers without leading zeros, pre-release identifiers
+// without leading zeros if numeric, and any build metadata. There is
+// no leading v.
+var SemVer = x.N{`SemVer`, x.Seq{
+	num, '.', num, '.', num,
+	x.Mmx{0, 1, x.Seq{'-', prerelease, x.Mmx{0, -1, x.Seq{'.', prerelease}}}},
+	x.Mmx{0, 1, x.Seq{'+', x.Mmx{1, -1, ident}, x.Mmx{0, -1, x.Seq{'.', x.Mmx{1, -1, ident}}}}},
+	x.Not{ident},
+}}
+
+// prerelease is an identifier that is either alphanumeric (containing
+// at least one letter or hyphen) or a number without leading zeros.
+var prerelease = x.One{
+	x.Seq{x.Mmx{0, -1, digit}, x.One{alpha, '-'}, x.Mmx{0, -1, ident}},
+	x.Seq{num, x.Not{ident}},
+}
+
+// Rules contains every rule of the package in the order documented.
+var Rules = []x.N{IPv4, IPv6, Hostname, Email, URL, UUID, SemVer}
+
+// PEGN contains the definitions of every one of the Rules (and the
+// named rules within them) as a PEGN document (see x.PEGN) that can be
+// imported or copied into a PEGN grammar.
+var PEGN = document(Rules)
+
+// document returns the PEGN definitions of every rule in order without
+// repeating any (nested) definition.
+func document(rules []x.N) string {
+	var defs []string
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		for _, def := range strings.Split(x.PEGN(rule), "\n") {
+			if !seen[def] {
+				seen[def] = true
+				defs = append(defs, def)
+			}
+		}
+	}
+	return strings.Join(defs, "\n") + "\n"
+}
+
+// Add makes every one of the Rules in the Grammar so that they can be
+// referenced (x.Ref) by name. Returns the Grammar for convenience.
+func Add(g *rat.Grammar) *rat.Grammar {
+	for _, rule := range Rules {
+		g.MakeRule(rule)
+	}
+	return g
+}
@@ -0,0 +1,85 @@
+package rat
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// FSFunc is passed the path and Result of every file scanned by ScanFS.
+type FSFunc func(path string, res Result)
+
+// ScanFS walks the file system (fsys) scanning (see Grammar.ScanSource)
+// every regular file matching the glob pattern (see path.Match) with
+// a Grammar and passing the path and Result of each to do so that
+// corpus-wide extraction need not reinvent the walk and parse loop.
+// Patterns without a slash (*.log) are matched against the base name
+// of every file at any depth and those with one (logs/*.log) against
+// the full path. Files are scanned concurrently by the number of
+// workers (runtime.NumCPU if less than 1, never more than
+// MaxGoroutines if set) and since a Grammar cannot be shared between
+// concurrent scans (see Farthest) each worker calls grammar to create
+// its own. Calls to do are never concurrent but are
+// in the order the scans complete (not the order walked). Files that
+// cannot be read are passed with the error as the Result error (X). An
+// error is only returned for a bad pattern or if the walk itself fails.
+func ScanFS(fsys fs.FS, pattern string, grammar func() *Grammar, workers int, do FSFunc) error {
+
+	if _, err := path.Match(pattern, ``); err != nil {
+		return err
+	}
+	base := !strings.Contains(pattern, `/`)
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if MaxGoroutines > 0 && workers > MaxGoroutines {
+		workers = MaxGoroutines
+	}
+
+	paths := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := grammar()
+			for p := range paths {
+				var res Result
+				buf, err := fs.ReadFile(fsys, p)
+				if err != nil {
+					res = Result{X: err, S: p}
+				} else {
+					res = g.ScanSource(p, buf)
+				}
+				mu.Lock()
+				do(p, res)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := fs.WalkDir(fsys, `.`, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		name := p
+		if base {
+			name = path.Base(p)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			paths <- p
+		}
+		return nil
+	})
+
+	close(paths)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,257 @@
+package rat
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// DefaultGenDepth is the number of nested references (see x.Ref)
+// followed when generating (see Generator) before always choosing the
+// simplest alternatives and fewest repetitions so that recursive rules
+// end.
+var DefaultGenDepth = 8
+
+// DefaultGenRepeat is the most repetitions beyond the minimum generated
+// for any repetition (x.Mmx and x.Any with a range).
+var DefaultGenRepeat = 4
+
+// DefaultGenTries is the number of inputs generated (see
+// Generator.Next) and checked before giving up.
+var DefaultGenTries = 100
+
+// GenOpts are the options for generating input (see Grammar.Generator).
+// Zero values are replaced with the defaults.
+type GenOpts struct {
+	Seed   int64 // same seed produces the same inputs
+	Depth  int   // nested references (default DefaultGenDepth)
+	Repeat int   // repetitions beyond minimum (default DefaultGenRepeat)
+	Tries  int   // inputs checked before giving up (default DefaultGenTries)
+}
+
+// Generator produces random input accepted by the Main rule of
+// a grammar (the reverse of Scan) for fuzzing those that consume the
+// same format and for testing the grammar itself (see
+// Grammar.Generator). Lookahead (x.See and x.Not), word boundaries
+// (x.Wb), and the end of data (x.End) produce nothing and x.To
+// produces a few lowercase letters, so every input generated is checked
+// with Scan and only returned if matched entirely. A Generator is not
+// safe for concurrent use.
+type Generator struct {
+	g     *Grammar
+	opts  GenOpts
+	rand  *rand.Rand
+	saved map[string]string
+	depth int
+	buf   strings.Builder
+}
+
+// Generator returns a new Generator of input for the grammar with the
+// options.
+func (g *Grammar) Generator(opts GenOpts) *Generator {
+	if opts.Depth <= 0 {
+		opts.Depth = DefaultGenDepth
+	}
+	if opts.Repeat <= 0 {
+		opts.Repeat = DefaultGenRepeat
+	}
+	if opts.Tries <= 0 {
+		opts.Tries = DefaultGenTries
+	}
+	return &Generator{g: g, opts: opts, rand: rand.New(rand.NewSource(opts.Seed))}
+}
+
+// Next returns the next random input matched entirely by the Main rule
+// of the grammar. If none of the inputs generated (see GenOpts.Tries)
+// match an ErrGenerate is returned instead.
+func (gen *Generator) Next() (string, error) {
+	if gen.g.Main == nil || gen.g.Main.Expr == nil {
+		return "", ErrIsZero{gen.g.Main}
+	}
+	for n := 0; n < gen.opts.Tries; n++ {
+		gen.buf.Reset()
+		gen.saved = map[string]string{}
+		gen.depth = 0
+		gen.gen(x.Canonical(gen.g.Main.Expr))
+		in := gen.buf.String()
+		res := gen.g.Scan(in)
+		if res.X == nil && res.E == len(res.R) {
+			return in, nil
+		}
+	}
+	return "", ErrGenerate{gen.opts.Tries}
+}
+
+// deep returns true if references are nested beyond the Depth.
+func (gen *Generator) deep() bool { return gen.depth >= gen.opts.Depth }
+
+// count returns a random number from min to max (-1 for unlimited) but
+// never more than Repeat beyond min (and only min when deep).
+func (gen *Generator) count(min, max int) int {
+	if gen.deep() {
+		return min
+	}
+	if max < 0 || max-min > gen.opts.Repeat {
+		max = min + gen.opts.Repeat
+	}
+	return min + gen.rand.Intn(max-min+1)
+}
+
+// gen writes random input for the canonical expression.
+func (gen *Generator) gen(it any) {
+	switch v := it.(type) {
+
+	case string:
+		gen.buf.WriteString(v)
+
+	case x.N:
+		if len(v) == 2 {
+			gen.gen(v[1])
+		}
+
+	case x.Seq:
+		for _, i := range v {
+			gen.gen(i)
+		}
+
+	case x.One:
+		if gen.deep() {
+			gen.gen(simplest(v))
+			return
+		}
+		gen.gen(v[gen.rand.Intn(len(v))])
+
+	case x.Mmx:
+		min, _ := v[0].(int)
+		max, _ := v[1].(int)
+		for n := gen.count(min, max); n > 0; n-- {
+			gen.gen(v[2])
+		}
+
+	case x.Any:
+		n, _ := v[0].(int)
+		if len(v) == 2 {
+			max, _ := v[1].(int)
+			n = gen.count(n, max)
+		}
+		for ; n > 0; n-- {
+			gen.buf.WriteRune(rune('a' + gen.rand.Intn(26)))
+		}
+
+	case x.Rng:
+		beg, _ := v[0].(rune)
+		end, _ := v[1].(rune)
+		gen.buf.WriteRune(beg + rune(gen.rand.Int63n(int64(end-beg)+1)))
+
+	case x.Is:
+		gen.is(v)
+
+	case x.To:
+		for n := gen.count(0, -1); n > 0; n-- {
+			gen.buf.WriteRune(rune('a' + gen.rand.Intn(26)))
+		}
+
+	case x.Ref:
+		name, _ := v[0].(string)
+		gen.ref(name)
+
+	case x.Sav:
+		name, _ := v[0].(string)
+		beg := gen.buf.Len()
+		gen.ref(name)
+		gen.saved[name] = gen.buf.String()[beg:]
+
+	case x.Val:
+		name, _ := v[0].(string)
+		gen.buf.WriteString(gen.saved[name])
+
+	}
+}
+
+// ref writes random input for the named rule (or built-in class).
+func (gen *Generator) ref(name string) {
+	var exp any
+	if rule, has := gen.g.Rules[name]; has && rule.Expr != nil {
+		exp = rule.Expr
+	} else if class, has := x.Classes[name]; has {
+		exp = class
+	} else {
+		return
+	}
+	gen.depth++
+	gen.gen(x.Canonical(exp))
+	gen.depth--
+}
+
+// is writes a random rune for which the function of the x.Is returns
+// true trying printable ASCII first (or nothing if none is found).
+func (gen *Generator) is(v x.Is) {
+	var f func(r rune) bool
+	switch fn := v[0].(type) {
+	case func(r rune) bool:
+		f = fn
+	case x.IsFunc:
+		f = fn
+	default:
+		return
+	}
+	for n := 0; n < 1000; n++ {
+		r := rune(' ' + gen.rand.Intn(95))
+		if n >= 200 {
+			r = rune(gen.rand.Intn(0x3000))
+		}
+		if f(r) {
+			gen.buf.WriteRune(r)
+			return
+		}
+	}
+}
+
+// simplest returns the alternative with the fewest references (which
+// might recurse) favoring the first.
+func simplest(one x.One) any {
+	best, fewest := one[0], refs(one[0])
+	for _, alt := range one[1:] {
+		if n := refs(alt); n < fewest {
+			best, fewest = alt, n
+		}
+	}
+	return best
+}
+
+// refs returns the number of references (x.Ref and x.Sav) within the
+// expression (not following them).
+func refs(it any) int {
+	switch v := it.(type) {
+	case x.Ref, x.Sav:
+		return 1
+	case x.N:
+		if len(v) == 2 {
+			return refs(v[1])
+		}
+	case x.Seq:
+		var n int
+		for _, i := range v {
+			n += refs(i)
+		}
+		return n
+	case x.One:
+		var n int
+		for _, i := range v {
+			n += refs(i)
+		}
+		return n
+	case x.Mmx:
+		if min, _ := v[0].(int); min > 0 {
+			return refs(v[2])
+		}
+	case x.See:
+		return refs(v[0])
+	case x.Not:
+		return refs(v[0])
+	case x.To:
+		return refs(v[0])
+	}
+	return 0
+}
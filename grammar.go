@@ -1,15 +1,21 @@
 package rat
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"math"
+	"runtime/pprof"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rwxrob/rat/x"
 )
 
-// Trace enables tracing parsing and checks while they happen. Output is
-// with the log package.
+// Trace enables tracing (see TraceMake and TraceCheck) for every
+// Grammar. Events are passed to the DefaultTracer unless the Grammar
+// has a Tracer.
 var Trace int
 
 // DefaultRuleName is used by NewRule and AddRule as the prefix for new,
@@ -25,6 +31,113 @@ var DefaultRuleName = `Rule`
 // equivalent constructor. Trace may be incremented during debugging to
 // gain performant visibility into grammar construction and scanning.
 //
+// Events
+//
+// Setting Emit causes an Event to be passed to the EventFunc as each
+// named rule (x.N) is entered and exited allowing consumers to process
+// huge documents in a stream (SAX-style) as matching proceeds.
+//
+// Named Only
+//
+// Setting NamedOnly prevents anonymous intermediate results from being
+// retained as children during Check. Only named (x.N) results remain
+// (hoisted up to the nearest retained ancestor) dramatically shrinking
+// the size of result trees when parsing large files.
+//
+// Limits
+//
+// Setting MaxChildren and/or MaxDepth caps the number of children
+// retained for any result and the depth of nested (Seq, One, Mmx)
+// results retained. When a limit is reached the remaining children are
+// discarded and the parent result is marked as truncated (T) so that
+// adversarial or unexpectedly huge input cannot exhaust memory through
+// result accumulation. Scanning itself is not affected.
+//
+// Setting MaxNesting, MaxSteps, MaxInput, and/or Timeout, however,
+// stops a Scan as soon as the nesting of rule checks (Seq, One, Mmx),
+// the number of rules checked, the runes of input, or the time spent
+// exceeds it with ErrTooDeep, ErrTooManySteps, ErrTooLarge, or
+// ErrTimeout (all ErrLimit) as the error of the result so that callers
+// can tell input that is too much from input that is invalid.
+//
+// Farthest Failure
+//
+// Failures of terminal rules (strings, ranges, classes, and such) are
+// tracked during Scan and the one that reached farthest into the input
+// is kept in Farthest. Since PEG failures are usually caught by
+// alternatives and optional repetition the error of the top Result
+// rarely points to the actual problem but Farthest usually does making
+// it ideal for reporting errors in the input being parsed.
+//
+// Fail Fast
+//
+// The first set (every rune that can begin a match) of most
+// expressions is known when the grammar is made. Alternatives of a One
+// that cannot begin with the next rune are always skipped. Setting
+// FailFast also allows a Seq to fail immediately when the next rune
+// cannot begin its next element. The failed child result (C) kept is
+// then the simple expectation of the entire element (ErrExpected)
+// rather than the more detailed failure from within it. Farthest is
+// never affected.
+//
+// Folding
+//
+// Setting Fold before calling Pack makes the canonical form of the
+// expression (see x.Canonical) instead so that nested Seq and One are
+// flattened, adjacent literals (even across nested Seqs) are combined,
+// and single item wrappers are removed before any rule is made.
+// Equivalent expressions therefore produce identical, minimal rules
+// (and results with fewer children).
+//
+// Profiling
+//
+// Setting Profile labels every CPU profile sample taken while checking
+// a named (x.N) rule with the name of the innermost such rule (see
+// runtime/pprof.Do) so that profiles show which rules of the grammar
+// dominate the time spent scanning. Labeling slows scanning
+// considerably and should only be enabled while profiling.
+//
+// Tracing
+//
+// Setting Trace (or the package Trace) to TraceMake passes an event for
+// every rule made to the Tracer (or DefaultTracer if not set).
+// TraceCheck adds an event (with position, outcome, and elapsed time)
+// for every named (x.N) rule checked. WriterTracer sends the events to
+// any io.Writer (files, tests) and SlogTracer to a log/slog Logger for
+// structured production logging. NopTracer discards them.
+//
+// Origin
+//
+// Every Result produced by the Check function of a rule created by
+// a Grammar has its origin (O) set to that rule, even when the rule is
+// not named (x.N). Named rules replace the origin of the result of the
+// rule they encapsulate with themselves. Refs, Savs, and Vals are
+// transparent and leave the origin as the rule they delegate to.
+//
+// Release
+//
+// Calling Release with the root Result of a Scan when done with it
+// allows the children slices of every result to be reused by the next
+// Scan instead of allocated again.
+//
+// Concurrency
+//
+// A Grammar keeps the state of the Scan in progress (Farthest, the
+// limits and nesting counted, the expectations of Expect, and the
+// children released for reuse) and is therefore not safe for
+// concurrent use. Give every goroutine scanning concurrently its own
+// Grammar, made the same way or with the rules of one already made
+// (see Extend).
+//
+// Warnings
+//
+// Mistakes that do not prevent a rule from being made are added to
+// Warnings as it is made rather than ignored: alternatives of a One
+// shadowed by an earlier one (ErrUnreachable), repetition bounds that
+// can only match nothing (ErrBounds), and literals so long that they
+// are keyed by hash (ErrLongLiteral). Init empties them. Validate finds
+// more after the entire grammar is made.
+//
 // Memoization
 //
 // All Make* methods check the Rules map/cache for a match for the
@@ -34,26 +147,163 @@ var DefaultRuleName = `Rule`
 // for the specified name.
 //
 type Grammar struct {
-	Trace int              // activate logs for debug visibility
+	Trace int              // trace level (see Tracing) for debug visibility
 	Rules map[string]*Rule // keyed to Rule.Name (not Text)
 	Saved map[string]*Rule // dynamically created literals from Sav
 	Main  *Rule            // entry point for Check or Scan
-
-	ruleid int // auto-incrementing for ever unnamed rule added.
+	Emit  EventFunc        // called for named rule events during scan
+
+	// Tracer receives every trace event (see Tracing) instead of the
+	// DefaultTracer.
+	Tracer Tracer
+
+	// User is any value of the caller (a symbol table or include stack,
+	// for example) for the CheckFunc of any rule added (see AddRule) to
+	// consult and update during a Scan (see ScanUser) so that
+	// context-sensitive grammars need no package-level variables.
+	User any
+
+	// Breakpoints are the names of named (x.N) rules that call Break
+	// whenever entered (in addition to the package Breakpoints).
+	Breakpoints map[string]bool
+
+	// Break is called when a breakpoint is hit (see Breakpoints) and
+	// pauses the scan until it returns. Steps are logged if not set.
+	Break StepFunc
+
+	// Errors rewrite the error of every failed named (x.N) rule keyed to
+	// its name (see OnError and Message).
+	Errors map[string]ErrFunc
+
+	// NamedOnly keeps only named (x.N) results as children (C) replacing
+	// any anonymous child with its own (already filtered) children.
+	NamedOnly bool
+
+	// Profile labels CPU profile samples (see runtime/pprof) taken while
+	// checking every named (x.N) rule with the rule name (rat.rule).
+	Profile bool
+
+	// FailFast allows a Seq to fail as soon as the next rune cannot begin
+	// (is not in the first set of) its next element without checking it
+	// (see Fail Fast).
+	FailFast bool
+
+	// Fold makes the canonical form (see x.Canonical) of expressions
+	// passed to Pack (see Folding).
+	Fold bool
+
+	// Locate wraps the ErrExpected of a failed Scan with the position
+	// (rune index, line, and column) at which it failed (see ErrAt).
+	Locate bool
+
+	// Expect replaces the error of a failed Scan with every expectation
+	// at the farthest failure (see ErrOneOf) instead of only the last
+	// one checked. Scanning is slower since the optimizations that skip
+	// checking rules are disabled (see Diagnose).
+	Expect bool
+
+	// Chain wraps the error of every failed Seq, One, Val, and named
+	// (x.N) rule with ErrIn so that the path to the rule that actually failed
+	// can be retrieved (see ErrIn.Path). The message is that of the
+	// innermost error.
+	Chain bool
+
+	// Resolve makes Pack panic with an ErrRefs (or TryPack return it)
+	// if any x.Ref or x.Val of the grammar is unresolved (see
+	// Unresolved) so every rule referred to must be made before.
+	Resolve bool
+
+	// Strict makes AddRule (and so every Make method and Pack) panic
+	// with an ErrConflict (or Try return it) instead of replacing a rule
+	// keyed to the same name with a different definition (see AddRuleE
+	// and Override).
+	Strict bool
+
+	// UTF8 is how invalid UTF-8 encoding of input (string, []byte, or
+	// io.Reader) is handled by Scan (see UTF8Mode).
+	UTF8 UTF8Mode
+
+	// NormalizeEOL replaces every CRLF and lone CR line ending of the
+	// input with LF before it is scanned so that grammars written for LF
+	// also work with input from other platforms (or use the EndOfLine
+	// class to match any). Positions of results are then within the
+	// normalized input (R) rather than the original.
+	NormalizeEOL bool
+
+	// Arena (if set) allocates the children of every result (see Arena).
+	Arena *Arena
+
+	MaxChildren int // maximum children retained per result (0 is unlimited)
+	MaxDepth    int // maximum nesting of retained results (0 is unlimited)
+
+	// Limits stop a Scan with an error (see ErrLimit) as soon as one is
+	// exceeded (0 is unlimited) to protect services from hostile input
+	// (see Limits).
+	MaxNesting int           // maximum nesting of rule checks (ErrTooDeep)
+	MaxSteps   int           // maximum rule checks (ErrTooManySteps)
+	MaxInput   int           // maximum runes of input (ErrTooLarge)
+	Timeout    time.Duration // maximum time to scan (ErrTimeout)
+
+	Farthest Result // failed terminal reaching farthest during last Scan
+
+	// Warnings are the mistakes found while making rules that do not
+	// prevent them from being made (see Warnings).
+	Warnings []error
+
+	ruleid int                  // auto-incrementing for ever unnamed rule added.
+	names  []string             // rule names in the order first added
+	depth  int                  // current nesting of Seq, One, and Mmx checks
+	labels context.Context      // profile labels of current rule (see Profile)
+	stats  map[*Rule]*RuleStats // collected when enabled (see CollectStats)
+	free   [][]Result           // released children slices for reuse (see Release)
+	byid   []*Rule              // every rule added indexed by ID-1 (see RuleByID)
+	rebind int                  // incremented when a name is keyed to another rule
+	runes  []rune               // input buffer reused for every input (see ScanEach)
+	diag   bool                 // every failure at Farthest is kept (see Diagnose)
+	expect []error              // errors of every failure at Farthest (see Diagnose)
+	calls  *callNode            // rule currently checked (see Folded)
+	inner  time.Duration        // time spent in rules within current (see Stats)
+	limits bool                 // limits are checked (see over)
+	steps  int                  // rule checks during current Scan (see MaxSteps)
+	until  time.Time            // deadline of current Scan (see Timeout)
+	stop   error                // limit exceeded during current Scan
+	binds  []binding            // variables set after every Scan (see Bind)
+	redact map[string]bool      // names of rules to mark redacted (see Redact)
+	trying int                  // nesting of Try calls in progress
+	build  bool                 // NodeBuilder calls are recorded (see ScanBuild)
+	built  []buildOp            // NodeBuilder calls recorded during ScanBuild
+	kids   int                  // children of results are kept (see keep)
+	undo   []*Rule              // rules replaced during Try (restored if it fails)
 }
 
 // Init initializes the Grammar emptying the Rules if any or creating
 // a new Rules map and setting internal rule ID to 0 and disabling
-// Trace, and emptying Main.
+// Trace, and emptying Main and every variable bound (see Bind).
 func (g *Grammar) Init() *Grammar {
 	g.Trace = 0
 	g.Rules = map[string]*Rule{}
 	g.Saved = map[string]*Rule{}
 	g.Main = nil
 	g.ruleid = 0
+	g.names = nil
+	g.byid = nil
+	g.Warnings = nil
+	g.binds = nil
+	g.rebind++
 	return g
 }
 
+// empty removes every rule of g (as does Init) but keeps the memory
+// already allocated for them to be reused.
+func (g *Grammar) empty() {
+	for _, name := range g.names {
+		delete(g.Rules, name)
+	}
+	g.names, g.byid, g.ruleid, g.Main = g.names[:0], g.byid[:0], 0, nil
+	g.Warnings = g.Warnings[:0]
+	g.rebind++
+}
+
 // String fulfills the fmt.Stringer interface by producing compilable Go
 // code containing the Main rule (usually a rat/x.Seq). In this way,
 // code generators for specific, dynamically created grammars can easily
@@ -68,53 +318,275 @@ func (g Grammar) String() string {
 
 func (g Grammar) Print() { fmt.Println(g) }
 
+// DefaultMainName is used by PEGN for the Main rule when it is not
+// named (x.N).
+var DefaultMainName = `Main`
+
+// PEGN returns the entire grammar as a PEGN document with one
+// definition per line for the Main rule followed by every named (x.N)
+// rule in the order added (see Ordered). Aliases (see MakeAlias) are
+// rendered as insignificant (<-) definitions. If the Main rule is
+// neither named nor an alias it is given the DefaultMainName. Rules
+// without an Expr and the built-in classes (see x.Classes) are
+// skipped. The Doc of each rule (if any) is rendered as comment lines
+// before its definition.
+func (g *Grammar) PEGN() string { return g.PEGNWith(PEGNOpts{}) }
+
+// PEGNOpts are the options for rendering a Grammar as PEGN (see
+// PEGNWith).
+type PEGNOpts struct {
+	Width  int  // wrap expressions longer than this (0 to never wrap)
+	Minify bool // shortest equivalent rendering (see x.PEGNMin)
+}
+
+// PEGNWith is the same as PEGN but renders according to the options.
+// When Width is set, any definition that would be longer is wrapped at
+// its alternatives (one per line) and then between the items of each
+// sequence with continuation lines indented to align with the first
+// (see x.PEGNWrap). When Minify is set, each definition is rendered as
+// briefly as possible (see x.PEGNMin) without documentation, padding,
+// or wrapping.
+func (g *Grammar) PEGNWith(opts PEGNOpts) string {
+	var names, ops, docs []string
+	var exps []any
+
+	if g.Main != nil && g.Main.Expr != nil {
+		if n, is := g.Main.Expr.(x.N); is {
+			names = append(names, g.Main.Name)
+			ops = append(ops, `<=`)
+			exps = append(exps, n[1])
+		} else if g.Main.IsAlias() {
+			names = append(names, g.Main.Name)
+			ops = append(ops, `<-`)
+			exps = append(exps, g.Main.Expr)
+		} else {
+			names = append(names, DefaultMainName)
+			ops = append(ops, `<-`)
+			exps = append(exps, g.Main.Expr)
+		}
+		docs = append(docs, g.Main.Doc)
+	}
+
+	for _, rule := range g.Ordered() {
+		if rule == g.Main {
+			continue
+		}
+		if rule.IsAlias() {
+			names = append(names, rule.Name)
+			ops = append(ops, `<-`)
+			exps = append(exps, rule.Expr)
+			docs = append(docs, rule.Doc)
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		names = append(names, rule.Name)
+		ops = append(ops, `<=`)
+		exps = append(exps, n[1])
+		docs = append(docs, rule.Doc)
+	}
+
+	var width int
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	var str string
+	for i, name := range names {
+		if opts.Minify {
+			str += name + ops[i] + x.PEGNMin(exps[i]) + "\n"
+			continue
+		}
+		if docs[i] != "" {
+			for _, line := range strings.Split(docs[i], "\n") {
+				str += strings.TrimRight("# "+line, " ") + "\n"
+			}
+		}
+		exp := x.PEGNWrap(exps[i], opts.Width, width+4)
+		str += fmt.Sprintf("%-*v %v %v\n", width, name, ops[i], exp)
+	}
+	return str
+}
+
+// Regexp returns a best-effort Go regular expression equivalent to the
+// Main rule with every reference resolved from the named rules and
+// aliases of the grammar (see x.Regexp). An x.ErrRegexp is returned if
+// the grammar is not regular or the Main rule has no rat/x expression.
+func (g *Grammar) Regexp() (string, error) {
+	if g.Main == nil || g.Main.Expr == nil {
+		return "", x.ErrRegexp{V: nil, Why: x.RegexpInvalid}
+	}
+	var defs []x.N
+	for _, rule := range g.Ordered() {
+		if rule.IsAlias() {
+			defs = append(defs, x.N{rule.Name, rule.Expr})
+			continue
+		}
+		if n, is := rule.Expr.(x.N); is {
+			defs = append(defs, n)
+		}
+	}
+	return x.Regexp(g.Main.Expr, defs...)
+}
+
 // Check delegates to g.Main.Check.
 func (g *Grammar) Check(r []rune, i int) Result { return g.Main.Check(r, i) }
 
 // Scan checks the input against the current g.Main rule. It is
 // functionally identical to Check but accepts []rune, string, []byte,
-// and io.Reader as input. The error (X) on Result is set if there is
-// a problem.
-func (g *Grammar) Scan(in any) Result {
+// io.RuneReader, and io.Reader as input. The error (X) on Result is set
+// if there is a problem. If Expect is set the error is every
+// expectation at the farthest failure instead (see ErrOneOf) unless
+// rewritten by a named rule (see Errors). If Locate is set the error
+// is wrapped with the position at which the result failed (see ErrAt).
+// See ScanWith to begin elsewhere, limit, or anchor the scan.
+func (g *Grammar) Scan(in any) Result { return g.scan(in, 0) }
+
+// scan is Scan beginning at position i (in runes) of the input.
+func (g *Grammar) scan(in any, i int) Result {
 	if g.Main == nil {
 		return Result{X: ErrIsZero{g.Main}}
 	}
-	return g.Main.Scan(in)
+	g.Farthest = Result{}
+	if g.MaxInput > 0 || g.UTF8 != UTF8AsIs || g.NormalizeEOL {
+		runes, err := g.input(in)
+		if err != nil {
+			return Result{R: runes, X: err}
+		}
+		in = runes
+	}
+	return g.check(in, i)
+}
+
+// check scans input that has already been through the input pipeline
+// (see scan) with the Main rule adding expectations, limits, location,
+// and binds.
+func (g *Grammar) check(in any, i int) Result {
+	g.Farthest = Result{}
+	if g.Expect {
+		g.diag, g.expect = true, g.expect[:0]
+	}
+	g.start()
+	res := g.Main.scan(in, i)
+	if g.finish() != nil {
+		res.X = g.stop
+	}
+	at := res.E
+	if g.Expect {
+		g.diag = false
+		_, expected := unchained(res.X).(ErrExpected)
+		if expected && g.Farthest.X != nil && g.Farthest.E >= res.E {
+			at = g.Farthest.E
+			res.X = oneOf(at, g.expect)
+		}
+	}
+	switch unchained(res.X).(type) {
+	case ErrExpected, ErrOneOf:
+		if g.Locate {
+			line, col := Result{R: res.R, B: at}.Pos()
+			res.X = ErrAt{I: at, Line: line, Col: col, X: res.X}
+		}
+	}
+	if len(g.binds) > 0 && res.X == nil {
+		res.X = g.bind(res)
+	}
+	return res
+}
+
+// fail records the failed result as Farthest if it reached farther into
+// the input than any other failure so far and returns it.
+func (g *Grammar) fail(res Result) Result {
+	if g.Farthest.X == nil || res.E > g.Farthest.E {
+		g.Farthest = res
+		if g.diag {
+			g.expect = g.expect[:0]
+		}
+	}
+	if g.diag && res.X != nil && res.E == g.Farthest.E {
+		g.expect = append(g.expect, res.X)
+	}
+	return res
+}
+
+// ScanSource calls Scan and sets the source (S) for every Result in the
+// tree to src (usually a file name) so that aggregated parses of many
+// files can report locations (see Result.Location).
+func (g *Grammar) ScanSource(src string, in any) Result {
+	res := g.Scan(in)
+	res.SetSource(src)
+	return res
 }
 
 // Pack allows multiple rules to be passed (unlike MakeRule). If one
 // argument, returns MakeRule for it. If more than one argument,
 // delegates to MakeSeq. Pack is called from the package function of the
 // same name, which describes the valid argument types. As a convenience,
-// a self-reference is returned.
+// a self-reference is returned. The expression is folded first if Fold
+// is set (see Folding) and every reference must resolve after if
+// Resolve is set (see Unresolved).
 func (g *Grammar) Pack(in ...any) *Grammar {
+	g.pack(in)
+	if g.Resolve {
+		if err := g.Unresolved(); err != nil {
+			panic(err)
+		}
+	}
+	return g
+}
+
+// pack makes the Main rule from the arguments of Pack.
+func (g *Grammar) pack(in []any) {
+	defer func() {
+		if r := recover(); r != nil {
+			if len(in) == 1 {
+				panic(usage(r, in[0]))
+			}
+			panic(usage(r, x.Seq(in)))
+		}
+	}()
 	var rule *Rule
-	switch len(in) {
-	case 0:
+	switch {
+	case len(in) == 0:
 		panic(ErrArgs{in})
-	case 1:
+	case g.Fold:
+		rule = g.MakeRule(x.Canonical(x.Seq(in)))
+	case len(in) == 1:
 		rule = g.MakeRule(in[0])
 	default:
 		rule = g.MakeSeq(x.Seq(in))
 	}
 	g.Main = rule
 	g.AddRule(rule)
-	return g
 }
 
 // MakeRule fulfills the MakeRule interface. The input argument is
-// usually a rat/x ("ratex") expression type including x.IsFunc functions.
+// usually a rat/x ("ratex") expression type including x.IsFunc functions
+// or an expression type of another package implementing Maker.
 // Anything else is interpreted as a literal string by using its String
 // method or converting it into a string using the %v (string, []rune, []
 // byte, rune) or %q representation. Note that MakeRule itself does not
 // check the Rules cache for existing Rules not does it add the rule to
 // that cache. This work is left to the Make* methods themselves or to
 // the AddRule method. The result, however, is the same since MakeRule
-// delegates to those Make* methods.
+// delegates to those Make* methods. Malformed expressions (anywhere
+// within the input) panic with an ErrUsage (see Try).
 func (g *Grammar) MakeRule(in any) *Rule {
 
-	if g.Trace > 0 || Trace > 0 {
-		log.Printf("MakeRule(%v)", x.String(in))
+	defer func() {
+		if r := recover(); r != nil {
+			panic(usage(r, in))
+		}
+	}()
+
+	if g.tracing(TraceMake) {
+		g.trace(TraceEvent{Level: TraceMake, Rule: x.String(in)})
 	}
 
 	switch v := in.(type) {
@@ -126,6 +598,8 @@ func (g *Grammar) MakeRule(in any) *Rule {
 	// rat/x ("ratex") types as expressions
 	case x.N:
 		return g.MakeNamed(v)
+	case x.Doc:
+		return g.MakeDoc(v)
 	case x.Sav:
 		return g.MakeSave(v)
 	case x.Val:
@@ -156,6 +630,22 @@ func (g *Grammar) MakeRule(in any) *Rule {
 		return g.MakeRng(v)
 	case x.End:
 		return g.MakeEnd(v)
+	case x.Wb:
+		return g.MakeWb(v)
+	case x.Byte:
+		return g.MakeByte(v)
+	case x.BSet:
+		return g.MakeBSet(v)
+	case x.BRng:
+		return g.MakeBRng(v)
+	case x.Len:
+		return g.MakeLen(v)
+	case x.Bits:
+		return g.MakeBits(v)
+
+	// expression types of other packages
+	case Maker:
+		return g.MakeCustom(v)
 
 	case fmt.Stringer:
 		return g.MakeStr(v.String())
@@ -180,22 +670,216 @@ func (g *Grammar) NewRule() *Rule {
 }
 
 // AddRule adds a new rule to the grammar cache keyed to the rule.Name.
-// If a rule was already keyed to that name it is overwritten.
+// If a rule was already keyed to that name it is overwritten (unless
+// Strict, see ErrConflict).
 // If rule.Name is empty a new incremental name is created with the
 // DefaultRuleName prefix.  Avoid changing the rule.Name values after added
 // since the key in the grammar cache is hard-coded to the rule.Name
 // when called. If the rule.Name is not important consider
 // NewRule instead (which uses these defaults and requires no argument).
-// Returns self for convenience.
+// A rule added for the first time is also assigned the next integer ID
+// (see RuleByID). Always use AddRule rather than changing the Rules map
+// directly since references (x.Ref) resolve names only when a name
+// has been keyed to a different rule. Returns self for convenience.
 func (g *Grammar) AddRule(rule *Rule) *Rule {
+	if g.Strict {
+		if err := g.conflict(rule); err != nil {
+			panic(err)
+		}
+	}
 	if rule.Name == "" {
 		g.ruleid++
 		rule.Name = DefaultRuleName + strconv.Itoa(g.ruleid)
 	}
+	if g.redact[rule.Name] || rule.Redact {
+		redactRule(rule)
+	}
+	if g.RuleByID(rule.ID) != rule {
+		g.byid = append(g.byid, rule)
+		rule.ID = len(g.byid)
+	}
+	prev, has := g.Rules[rule.Name]
+	if !has {
+		g.names = append(g.names, rule.Name)
+	}
+	if has && prev != rule {
+		g.rebind++
+		if g.trying > 0 {
+			g.undo = append(g.undo, prev)
+		}
+	}
 	g.Rules[rule.Name] = rule
 	return rule
 }
 
+// RuleByID returns the rule with the integer ID assigned by AddRule or
+// nil if there is no such rule. Rule IDs are compact and faster than
+// the (sometimes enormous) String keys of the Rules map for tools that
+// must keep track of rules.
+func (g *Grammar) RuleByID(id int) *Rule {
+	if id < 1 || id > len(g.byid) {
+		return nil
+	}
+	return g.byid[id-1]
+}
+
+// resolve returns the rule keyed to the name in the Rules map looking
+// it up only when the previously resolved rule ID (id) is unset or
+// names have since been keyed to other rules (see AddRule).
+func (g *Grammar) resolve(name string, id, rebind *int) (*Rule, bool) {
+	if *id != 0 && *rebind == g.rebind {
+		return g.byid[*id-1], true
+	}
+	rule, has := g.Rules[name]
+	if has && g.RuleByID(rule.ID) == rule {
+		*id, *rebind = rule.ID, g.rebind
+	}
+	return rule, has
+}
+
+// Ordered returns all the rules in the Rules cache in the order in
+// which their names were first added (see AddRule).
+func (g *Grammar) Ordered() []*Rule {
+	rules := make([]*Rule, 0, len(g.names))
+	for _, name := range g.names {
+		if rule, has := g.Rules[name]; has {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// GrammarEqual returns true if both grammars have the same Main rule
+// and the same named rules and aliases (see Grammar.MakeAlias) with
+// equivalent rat/x expressions (see x.Equal) regardless of the order
+// in which they were made or how the expressions were nested. Built-in
+// classes (see x.Classes), rules without a rat/x expression, and
+// documentation are ignored. This is useful to verify that refactors,
+// imports, and generated code preserve the meaning of a grammar.
+func GrammarEqual(a, b *Grammar) bool {
+	ma, mb := a.canonical(), b.canonical()
+	if len(ma) != len(mb) {
+		return false
+	}
+	for k, v := range ma {
+		if mb[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// canonical returns a map of the canonical form (see x.Canonical) of
+// every rule in the grammar keyed to its name (or "" for Main) for
+// comparison.
+func (g *Grammar) canonical() map[string]string {
+	m := map[string]string{}
+	if g.Main != nil && g.Main.Expr != nil {
+		m[""] = x.String(x.Canonical(g.Main.Expr))
+	}
+	for _, rule := range g.Ordered() {
+		if rule.IsAlias() {
+			m[rule.Name] = `alias ` + x.String(x.Canonical(rule.Expr))
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		m[rule.Name] = x.String(x.Canonical(n))
+	}
+	return m
+}
+
+// nests returns true if the nesting of Seq, One, Mmx, and Len checks
+// (depth) must be counted for MaxDepth or MaxNesting. It is not
+// otherwise counted at all.
+func (g *Grammar) nests() bool { return g.MaxDepth > 0 || g.MaxNesting > 0 }
+
+// keep appends the child result to the children (C) of the parent
+// unless NamedOnly is set and the child is anonymous in which case only
+// its children are appended. Children beyond the MaxChildren and
+// MaxDepth limits are discarded and the parent is marked as truncated.
+func (g *Grammar) keep(parent *Result, res Result) {
+	if g.build && g.kids == 0 && len(g.binds) == 0 {
+		g.discard(res)
+		return
+	}
+	if g.MaxDepth > 0 && g.depth >= g.MaxDepth {
+		parent.T = true
+		g.discard(res)
+		return
+	}
+	kids := []Result{res}
+	hoist := g.NamedOnly && res.N == ""
+	if hoist {
+		kids = res.C
+		parent.T = parent.T || res.T
+	}
+	for n, kid := range kids {
+		if g.MaxChildren > 0 && len(parent.C) >= g.MaxChildren {
+			parent.T = true
+			for _, kid := range kids[n:] {
+				g.discard(kid)
+			}
+			break
+		}
+		switch {
+		case parent.C == nil:
+			parent.C = g.results(0)
+		case g.Arena != nil && len(parent.C) == cap(parent.C):
+			parent.C = g.Arena.grow(parent.C)
+		}
+		parent.C = append(parent.C, kid)
+	}
+	if hoist && g.Emit == nil {
+		g.recycle(res.C)
+	}
+}
+
+// MakeDoc makes the rule documented (second argument) and sets its Doc
+// to the text (first argument) replacing any it had. Since the rule is
+// made as usual (a named rule is cached by its name) the documented rule
+// matches exactly as it would otherwise.
+func (g *Grammar) MakeDoc(in x.Doc) *Rule {
+
+	if len(in) != 2 {
+		panic(x.UsageDoc)
+	}
+
+	doc, is := in[0].(string)
+	if !is {
+		panic(x.UsageDoc)
+	}
+
+	rule := g.MakeRule(in[1])
+	rule.Doc = doc
+	return rule
+}
+
+// MakeCustom makes the rule of an expression type of another package
+// (see Maker) with its Make method keying it to the String form with
+// the expression as its Expr. The rule is only made once.
+func (g *Grammar) MakeCustom(in Maker) *Rule {
+
+	text := in.String()
+
+	rule, has := g.Lookup(text)
+	if has {
+		return rule
+	}
+
+	rule = in.Make(g)
+	rule.Name = g.key(text)
+	rule.Text = text
+	rule.Expr = in
+
+	return g.AddRule(rule)
+}
+
 // MakeNamed makes two rules pointing to the same CheckFunc, one unnamed
 // and other named (first argument). Both produce results that have the
 // Name field set.
@@ -203,7 +887,7 @@ func (g *Grammar) MakeNamed(in x.N) *Rule {
 
 	text := in.String()
 
-	rule, has := g.Rules[text]
+	rule, has := g.Lookup(text)
 	if has {
 		return rule
 	}
@@ -219,28 +903,106 @@ func (g *Grammar) MakeNamed(in x.N) *Rule {
 
 	// check the cache for the encapsulated rule, else make one
 	iname := x.String(in[1])
-	irule, has := g.Rules[iname]
+	irule, has := g.Lookup(iname)
 	if !has {
 		irule = g.MakeRule(in[1])
 	}
 
-	rule = &Rule{Name: name, Text: in.String()}
+	rule = &Rule{Name: name, Text: in.String(), Expr: in}
 	g.AddRule(rule)
 
 	rule.Check = func(r []rune, i int) Result {
-		unnamed := irule.Check(r, i)
+		if g.limits && g.over(i) {
+			return Result{O: rule, R: r, B: i, E: i, N: name, X: g.stop}
+		}
+		if g.Emit != nil {
+			g.Emit(Event{T: EventEnter, N: name, I: i})
+		}
+		if g.breaking(name) {
+			g.brk(name, r, i)
+		}
+		var start time.Time
+		tracing := g.tracing(TraceCheck)
+		if tracing {
+			g.trace(TraceEvent{Level: TraceCheck, T: EventEnter, Rule: name, I: i, R: buffer(rule, r)})
+			start = time.Now()
+		}
+		var mark int
+		if g.build {
+			mark = g.opened(name)
+		}
+		var unnamed Result
+		if g.Profile {
+			unnamed = g.profile(name, func() Result { return irule.Check(r, i) })
+		} else {
+			unnamed = irule.Check(r, i)
+		}
+		if unnamed.X != nil && len(g.Errors) > 0 {
+			unnamed.X = g.rewrite(name, r, i, unnamed.X)
+		}
+		if unnamed.X != nil && g.Chain {
+			unnamed.X = ErrIn{Rule: name, I: i, X: unnamed.X}
+		}
+		if tracing {
+			g.trace(TraceEvent{
+				Level: TraceCheck, T: EventExit, Rule: name, I: i, E: unnamed.E,
+				X: unnamed.X, Elapsed: time.Since(start), R: buffer(rule, r),
+			})
+		}
 		unnamed.N = name
+		unnamed.O = rule
+		if g.build {
+			g.closed(mark, unnamed)
+		}
+		if g.Emit != nil {
+			g.Emit(Event{T: EventExit, N: name, I: unnamed.E, R: unnamed})
+		}
 		return unnamed
 	}
 
 	return rule
 }
 
+// profile calls check with the goroutine labeled with the rule name
+// (see Profile) restoring the labels of the enclosing rule after.
+func (g *Grammar) profile(name string, check func() Result) Result {
+	if g.labels == nil {
+		g.labels = context.Background()
+	}
+	var res Result
+	pprof.Do(g.labels, pprof.Labels(`rat.rule`, name), func(ctx context.Context) {
+		prev := g.labels
+		g.labels = ctx
+		res = check()
+		g.labels = prev
+	})
+	return res
+}
+
+// MakeAlias makes (or reuses) the rule for the expression and adds an
+// additional rule sharing the same CheckFunc to the Rules cache under
+// the name. Unlike MakeNamed, the name is not assigned to the results
+// (which remain anonymous) but the rule can still be referenced (x.Ref)
+// by name. This matches the PEGN insignificant (<-) definition.
+func (g *Grammar) MakeAlias(name string, in any) *Rule {
+	irule := g.MakeRule(in)
+	if rule, has := g.Rules[name]; has && rule.IsAlias() && rule.Text == irule.Text {
+		return rule
+	}
+	rule := &Rule{Name: name, Text: irule.Text, Check: irule.Check, Expr: in}
+	g.AddRule(rule)
+	return rule
+}
+
+// MakeRef makes a rule that delegates to the rule in the Rules cache
+// with the referenced name when checked so that rules may be referenced
+// before they are made. If no such rule exists but the name is one of
+// the built-in PEGN classes (see x.Classes) the class is made and used.
 func (g *Grammar) MakeRef(in x.Ref) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
@@ -254,15 +1016,24 @@ func (g *Grammar) MakeRef(in x.Ref) *Rule {
 		panic(x.UsageRef)
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
+	var id, rebind int
+	var missing error
+	var stamp [2]int
 	rule.Check = func(r []rune, i int) Result {
-		rule, has := g.Rules[key]
+		ref, has := g.resolve(key, &id, &rebind)
+		if !has {
+			if class, is := x.Classes[key]; is {
+				ref, has = g.MakeNamed(class), true
+			}
+		}
 		if has {
-			return rule.Check(r, i)
+			return ref.Check(r, i)
 		}
-		return Result{R: r, B: i, E: i, X: ErrExpected{in}}
+		err := g.unresolved(in, key, g.refNames, &missing, &stamp)
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: err})
 	}
 
 	return rule
@@ -272,15 +1043,15 @@ func (g *Grammar) MakeSave(in x.Sav) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
-	if len(in) != 1 {
+	if len(in) != 1 && len(in) != 2 {
 		panic(x.UsageSav)
 	}
 
@@ -289,16 +1060,53 @@ func (g *Grammar) MakeSave(in x.Sav) *Rule {
 		panic(x.UsageSav)
 	}
 
+	// transformed text is made by a grammar of its own (emptied before
+	// every save) so that Rules do not grow with every value saved
+	var transform x.SavFunc
+	var apart *Grammar
+	if len(in) == 2 {
+		transform, is = x.SavFuncOf(in[1])
+		if !is {
+			panic(x.UsageSav)
+		}
+		apart = new(Grammar).Init()
+	}
+
+	var id, rebind int
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		rule, has := g.Rules[key]
+		saved, has := g.resolve(key, &id, &rebind)
 		if has {
-			res := rule.Check(r, i)
-			if res.X == nil {
+			res := saved.Check(r, i)
+			switch {
+			case res.X != nil:
+			case transform == nil:
 				g.Saved[key] = g.MakeStr(res.Text())
+			default:
+				var val *Rule
+				apart.empty()
+				apart.NamedOnly = g.NamedOnly
+				err := apart.Try(func() { val = apart.MakeRule(transform(res.Text())) })
+				if err != nil {
+					res.X = err
+					return g.fail(res)
+				}
+				// failures of the value are recorded by apart and must be
+				// copied into g (see Farthest)
+				check, valued := val.Check, *val
+				valued.Check = func(r []rune, i int) Result {
+					apart.Farthest = Result{}
+					res := check(r, i)
+					if apart.Farthest.X != nil {
+						g.fail(apart.Farthest)
+					}
+					return res
+				}
+				g.Saved[key] = &valued
 			}
 			return res
 		}
-		return Result{R: r, B: i, E: i, X: ErrExpected{in}}
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: expected})
 	}
 
 	return rule
@@ -308,12 +1116,12 @@ func (g *Grammar) MakeVal(in x.Val) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 1 {
@@ -325,12 +1133,23 @@ func (g *Grammar) MakeVal(in x.Val) *Rule {
 		panic(x.UsageVal)
 	}
 
+	expected := error(ErrExpected{in})
+	var missing error
+	var stamp [2]int
 	rule.Check = func(r []rune, i int) Result {
-		rule, has := g.Saved[key]
+		saved, has := g.Saved[key]
 		if has {
-			return rule.Check(r, i)
+			res := saved.Check(r, i)
+			if res.X != nil && g.Chain {
+				res.X = ErrIn{Rule: name, I: i, X: res.X}
+			}
+			return res
 		}
-		return Result{R: r, B: i, E: i, X: ErrExpected{in}}
+		err := g.unresolved(in, key, g.savNames, &missing, &stamp)
+		if err == nil {
+			err = expected
+		}
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: err})
 	}
 
 	return rule
@@ -351,18 +1170,19 @@ func (g *Grammar) MakeIs(in x.Is) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
 		if i < len(r) && isfunc(r[i]) {
-			return Result{R: r, B: i, E: i + 1}
+			return Result{O: rule, R: r, B: i, E: i + 1}
 		}
-		return Result{R: r, B: i, E: i, X: ErrExpected{in}}
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: expected})
 	}
 
 	return g.AddRule(rule)
@@ -385,12 +1205,12 @@ func (g *Grammar) MakeSeq(seq x.Seq) *Rule {
 
 	name := seq.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: seq}
 	g.AddRule(rule)
 
 	rules := []*Rule{}
@@ -398,7 +1218,7 @@ func (g *Grammar) MakeSeq(seq x.Seq) *Rule {
 	for _, it := range seq {
 
 		iname := x.String(it)
-		irule, has := g.Rules[iname]
+		irule, has := g.Lookup(iname)
 		if !has {
 			irule = g.MakeRule(it)
 		}
@@ -406,20 +1226,55 @@ func (g *Grammar) MakeSeq(seq x.Seq) *Rule {
 		rules = append(rules, irule)
 	}
 
+	// single rune rules are checked directly (see inline) and others
+	// can fail fast when the next rune is not in their first set
+	trivials := make([]*trivial, len(rules))
+	firsts := make([]runeSpans, len(rules))
+	for n, it := range rules {
+		trivials[n] = g.inline(it)
+		if rngs, ok := firstRunes(it.Expr); ok {
+			firsts[n] = makeSpans(rngs)
+		}
+	}
+
 	rule.Check = func(r []rune, i int) Result {
-		start := i
-		results := []Result{}
+		if g.limits && g.over(i) {
+			return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+		}
+		result := Result{O: rule, R: r, B: i, E: i, C: g.results(0)}
+		if g.nests() {
+			g.depth++
+			defer func() { g.depth-- }()
+		}
 
-		for _, rule := range rules {
-			res := rule.Check(r, i)
+		for n, rule := range rules {
+			var res Result
+			t := trivials[n]
+			switch {
+			case t != nil && g.stats == nil:
+				res = t.check(g, r, i)
+			case g.FailFast && t == nil && firsts[n] != nil && firsts[n].find(r, i) < 0 &&
+				g.Emit == nil && !g.diag && g.Farthest.X != nil && i <= g.Farthest.E:
+				res = Result{O: rule, R: r, B: i, E: i, X: ErrExpected{rule.Expr}}
+				if _, named := rule.Expr.(x.N); named {
+					res.N = rule.Name
+				}
+			default:
+				res = rule.Check(r, i)
+			}
 			i = res.E
-			results = append(results, res)
+			g.keep(&result, res)
 			if res.X != nil {
-				return Result{R: r, B: start, E: i, C: results, X: res.X}
+				result.X = res.X
+				if g.Chain {
+					result.X = ErrIn{Rule: `x.Seq`, I: result.B, X: res.X}
+				}
+				break
 			}
 		}
 
-		return Result{R: r, B: start, E: i, C: results}
+		result.E = i
+		return result
 	}
 
 	return rule
@@ -429,12 +1284,12 @@ func (g *Grammar) MakeOne(one x.One) *Rule {
 
 	name := one.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: one}
 	g.AddRule(rule)
 
 	ln := len(one)
@@ -451,32 +1306,364 @@ func (g *Grammar) MakeOne(one x.One) *Rule {
 	rules := make([]*Rule, ln)
 	for n, exp := range one {
 		name := x.String(exp)
-		irule, has := g.Rules[name]
+		irule, has := g.Lookup(name)
 		if !has {
 			irule = g.MakeRule(exp)
 			g.AddRule(irule)
 		}
 		rules[n] = irule
 	}
+	g.Warnings = append(g.Warnings, unreachable(name, one)...)
 
-	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i}
-		for _, it := range rules {
+	// alternatives that cannot begin with the next rune are skipped
+	// unless checking them would set Farthest or emit events
+	firsts := make([]runeSpans, ln)
+	for n, it := range rules {
+		if rngs, ok := firstRunes(it.Expr); ok {
+			firsts[n] = makeSpans(rngs)
+		}
+	}
+
+	expected := error(ErrExpected{one})
+	check := func(r []rune, i int) Result {
+		if g.limits && g.over(i) {
+			return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+		}
+		result := Result{O: rule, R: r, B: i, E: i}
+		if g.nests() {
+			g.depth++
+			defer func() { g.depth-- }()
+		}
+		var deepest Result
+		for n, it := range rules {
+			if firsts[n] != nil && firsts[n].find(r, i) < 0 &&
+				g.Emit == nil && !g.diag && g.Farthest.X != nil && i <= g.Farthest.E {
+				continue
+			}
+			mark := len(g.built)
 			res := it.Check(r, i)
 			if res.X == nil {
 				result.E = res.E
-				result.C = []Result{res}
+				g.keep(&result, res)
 				return result
 			}
+			if g.Chain && (deepest.X == nil || res.E > deepest.E) {
+				deepest = res
+			}
+			g.discard(res)
+			g.unbuild(mark)
+		}
+		result.X = expected
+		if deepest.X != nil {
+			result.X = ErrIn{Rule: `x.One`, I: i, X: deepest.X}
 		}
-		result.X = ErrExpected{one}
 		return result
 	}
+	rule.Check = check
+
+	// alternatives of only single runes (classes) are searched directly
+	// (every alternative is checked when diagnosing or collecting stats,
+	// see Diagnose and CollectStats)
+	if spans := makeRuneSpans(one); spans != nil {
+		rule.Check = func(r []rune, i int) Result {
+			if g.diag || g.stats != nil {
+				return check(r, i)
+			}
+			if g.limits && g.over(i) {
+				return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+			}
+			result := Result{O: rule, R: r, B: i, E: i}
+			n := spans.find(r, i)
+			if n != 0 {
+				rules[0].Check(r, i) // record as Farthest
+			}
+			if n >= 0 {
+				if g.nests() {
+					g.depth++
+					defer func() { g.depth-- }()
+				}
+				res := rules[n].Check(r, i)
+				result.E = res.E
+				g.keep(&result, res)
+				return result
+			}
+			result.X = expected
+			return result
+		}
+		return rule
+	}
+
+	// alternatives of only literal strings (keywords) share a trie
+	// (unless diagnosing or collecting stats as above)
+	if trie := makeLitTrie(rules); trie != nil {
+		rule.Check = func(r []rune, i int) Result {
+			if g.diag || g.stats != nil {
+				return check(r, i)
+			}
+			if g.limits && g.over(i) {
+				return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+			}
+			result := Result{O: rule, R: r, B: i, E: i}
+			n, miss := trie.find(r, i)
+			if miss >= 0 {
+				rules[miss].Check(r, i) // record as Farthest
+			}
+			if n >= 0 {
+				if g.nests() {
+					g.depth++
+					defer func() { g.depth-- }()
+				}
+				res := rules[n].Check(r, i)
+				result.E = res.E
+				g.keep(&result, res)
+				return result
+			}
+			result.X = expected
+			return result
+		}
+	}
 
 	return rule
 
 }
 
+// runeSpan is a range of runes (Beg to End inclusive) first matched by
+// the alternative at index N.
+type runeSpan struct {
+	Beg, End rune
+	N        int
+}
+
+type runeSpans []runeSpan
+
+// makeRuneSpans returns the sorted, non-overlapping spans of the runes
+// matched by every alternative of the One (keeping the first
+// alternative matching each rune) or nil unless every alternative is
+// a single rune (rune or string) or a range (x.Rng).
+func makeRuneSpans(one x.One) runeSpans {
+	var rngs [][2]rune
+	for _, it := range one {
+		switch v := it.(type) {
+		case rune:
+			rngs = append(rngs, [2]rune{v, v})
+		case string:
+			r := []rune(v)
+			if len(r) != 1 {
+				return nil
+			}
+			rngs = append(rngs, [2]rune{r[0], r[0]})
+		case x.Rng:
+			if len(v) != 2 {
+				return nil
+			}
+			beg, is1 := v[0].(rune)
+			end, is2 := v[1].(rune)
+			if !is1 || !is2 || beg > end {
+				return nil
+			}
+			rngs = append(rngs, [2]rune{beg, end})
+		default:
+			return nil
+		}
+	}
+	return makeSpans(rngs)
+}
+
+// makeSpans returns the sorted, non-overlapping spans of the ranges
+// (keeping the index of the first range containing each rune as N).
+func makeSpans(rngs [][2]rune) runeSpans {
+
+	// every span begins at one of these points
+	var points []rune
+	for _, it := range rngs {
+		points = append(points, it[0], it[1]+1)
+	}
+	sort.Slice(points, func(a, b int) bool { return points[a] < points[b] })
+
+	var spans runeSpans
+	for n, p := range points {
+		if n+1 == len(points) || points[n+1] == p {
+			continue
+		}
+		for alt, it := range rngs {
+			if it[0] > p || p > it[1] {
+				continue
+			}
+			last := len(spans) - 1
+			if last >= 0 && spans[last].N == alt && spans[last].End+1 == p {
+				spans[last].End = points[n+1] - 1
+				break
+			}
+			spans = append(spans, runeSpan{p, points[n+1] - 1, alt})
+			break
+		}
+	}
+	return spans
+}
+
+// firstRunes returns the ranges of every rune that can possibly begin
+// a match of the expression or false if unknown or if the expression
+// can match without consuming a rune (see first).
+func firstRunes(it any) ([][2]rune, bool) {
+	rngs, nullable, ok := first(it)
+	return rngs, ok && !nullable && len(rngs) > 0
+}
+
+// first returns the first set (the ranges of every rune that can
+// possibly begin a match) of the expression and whether it is nullable
+// (can match without consuming a rune) or false if unknown, which
+// includes lookahead (x.Not, x.See, x.End) and any expression that
+// depends on other rules (x.Ref, x.Sav, x.Val) or functions (x.Is).
+func first(it any) (rngs [][2]rune, nullable, ok bool) {
+	switch v := it.(type) {
+	case rune:
+		return [][2]rune{{v, v}}, false, true
+	case string:
+		for _, r := range v {
+			return [][2]rune{{r, r}}, false, true
+		}
+		return nil, true, true
+	case x.Str:
+		if len(v) == 1 {
+			if s, is := v[0].(string); is {
+				return first(s)
+			}
+		}
+	case x.Rng:
+		if len(v) == 2 {
+			beg, is1 := v[0].(rune)
+			end, is2 := v[1].(rune)
+			if is1 && is2 && beg <= end {
+				return [][2]rune{{beg, end}}, false, true
+			}
+		}
+	case x.N:
+		if len(v) == 2 {
+			return first(v[1])
+		}
+	case x.Doc:
+		if len(v) == 2 {
+			return first(v[1])
+		}
+	case []any:
+		return first(x.Seq(v))
+	case x.Seq:
+		for _, it := range v {
+			r, null, known := first(it)
+			if !known {
+				return nil, false, false
+			}
+			rngs = append(rngs, r...)
+			if !null {
+				return rngs, false, true
+			}
+		}
+		return rngs, true, len(v) > 0
+	case x.Mmx:
+		if len(v) == 3 {
+			min, is := v[0].(int)
+			r, null, known := first(v[2])
+			if is && known {
+				return r, null || min == 0, true
+			}
+		}
+	case x.One:
+		for _, it := range v {
+			r, null, known := first(it)
+			if !known {
+				return nil, false, false
+			}
+			rngs = append(rngs, r...)
+			nullable = nullable || null
+		}
+		return rngs, nullable, len(v) > 0
+	}
+	return nil, false, false
+}
+
+// find returns the index of the alternative matching the rune at i or
+// -1 if none.
+func (s runeSpans) find(r []rune, i int) int {
+	if i >= len(r) {
+		return -1
+	}
+	c := r[i]
+	n := sort.Search(len(s), func(n int) bool { return s[n].End >= c })
+	if n < len(s) && s[n].Beg <= c {
+		return s[n].N
+	}
+	return -1
+}
+
+// litTrie is a prefix tree of the literal strings of every alternative
+// of a One. End is the index of the first alternative ending at the
+// node (or -1) and Min is the lowest index of any alternative passing
+// through it.
+type litTrie struct {
+	Kids map[rune]*litTrie
+	End  int
+	Min  int
+}
+
+// makeLitTrie returns the trie of the literals (x.Str) of every rule
+// (alternative) or nil if any is not a literal.
+func makeLitTrie(rules []*Rule) *litTrie {
+	root := &litTrie{End: -1}
+	for n, rule := range rules {
+		str, is := rule.Expr.(x.Str)
+		if !is || len(str) != 1 {
+			return nil
+		}
+		lit, is := str[0].(string)
+		if !is {
+			return nil
+		}
+		node := root
+		for _, c := range lit {
+			kid, has := node.Kids[c]
+			if !has {
+				if node.Kids == nil {
+					node.Kids = map[rune]*litTrie{}
+				}
+				kid = &litTrie{End: -1, Min: n}
+				node.Kids[c] = kid
+			}
+			node = kid
+		}
+		if node.End < 0 {
+			node.End = n
+		}
+	}
+	return root
+}
+
+// find returns the index of the first alternative matching at i (or -1)
+// and the index of the alternative before it that fails farthest into
+// the input (or -1) which is the one that would have set Farthest had
+// every alternative been checked in order.
+func (t *litTrie) find(r []rune, i int) (match, miss int) {
+	match, miss = -1, -1
+	for node, n := t, i; node != nil; n++ {
+		if node.End >= 0 && (match < 0 || node.End < match) {
+			match = node.End
+		}
+		if n >= len(r) {
+			break
+		}
+		node = node.Kids[r[n]]
+	}
+	for node, n := t, i; node != nil; n++ {
+		if node.Min < match || match < 0 {
+			miss = node.Min
+		}
+		if n >= len(r) {
+			break
+		}
+		node = node.Kids[r[n]]
+	}
+	return
+}
+
 func (g *Grammar) MakeStr(in any) *Rule {
 
 	var val string
@@ -491,38 +1678,49 @@ func (g *Grammar) MakeStr(in any) *Rule {
 	case rune:
 		val = string(v)
 	case x.Str:
-		return g.MakeStr(x.JoinStr(v...))
+		joined := x.JoinStr(v...)
+		unquoted, err := strconv.Unquote(`"` + joined + `"`)
+		if err != nil {
+			return g.MakeStr(joined)
+		}
+		return g.MakeStr(unquoted)
 
 	}
 
-	name := `x.Str{"` + val + `"}`
+	name := fmt.Sprintf(`x.Str{%q}`, val)
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	if len(name) > HashKeyLen {
+		g.warn(ErrLongLiteral{len(val)})
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: x.Str{val}}
 	g.AddRule(rule)
 
+	// constant for every check
+	runes := []rune(val)
+	runeslen := len(runes)
+	expected := make([]error, runeslen)
+	for n, r := range runes {
+		expected[n] = ErrExpected{string(r)}
+	}
+
 	rule.Check = func(r []rune, i int) Result {
 		var err error
 		start := i
-		runes := []rune(val)
 		var n int
-		runeslen := len(runes)
-		for i < len(r) && n < runeslen {
-			if r[i] != runes[n] {
-				err = ErrExpected{r[n]}
-				break
-			}
+		for i < len(r) && n < runeslen && r[i] == runes[n] {
 			i++
 			n++
 		}
 		if n < runeslen {
-			err = ErrExpected{string(runes[n])}
+			err = expected[n]
 		}
-		return Result{R: r, B: start, E: i, X: err}
+		return g.fail(Result{O: rule, R: r, B: start, E: i, X: err})
 	}
 
 	return rule
@@ -532,12 +1730,12 @@ func (g *Grammar) MakeMmx(in x.Mmx) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 3 {
@@ -553,35 +1751,64 @@ func (g *Grammar) MakeMmx(in x.Mmx) *Rule {
 	if !is || (max < min && max != -1) {
 		panic(x.UsageMmx)
 	}
+	if max == 0 {
+		g.warn(ErrBounds{in})
+	}
 
 	iname := x.String(in[2])
-	irule, has := g.Rules[iname]
+	irule, has := g.Lookup(iname)
 	if !has {
 		irule = g.MakeRule(in[2])
 	}
 
+	// single rune rules are checked directly (see inline)
+	t := g.inline(irule)
+
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i, C: []Result{}}
+		if g.limits && g.over(i) {
+			return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+		}
+		// no more than the runes left can be matched (see results)
+		prealloc := min
+		if left := len(r) - i; prealloc > left {
+			prealloc = left
+		}
+		result := Result{O: rule, R: r, B: i, E: i, C: g.results(prealloc)}
+		if g.nests() {
+			g.depth++
+			defer func() { g.depth-- }()
+		}
 		var count int
-		var res Result
-		for {
-			res = irule.Check(r, i)
-			if res.X != nil || count == max {
+		for ; count != max; count++ {
+			var res Result
+			mark := len(g.built)
+			if t != nil && g.stats == nil {
+				res = t.check(g, r, i)
+			} else {
+				res = irule.Check(r, i)
+			}
+			if res.X != nil {
+				g.discard(res)
+				g.unbuild(mark)
+				break
+			}
+			g.keep(&result, res)
+			if res.E == i {
+				// matched without advancing so would repeat forever
+				// (and every remaining minimum match would be the same)
+				count++
+				if count < min {
+					count = min
+				}
 				break
 			}
-			result.C = append(result.C, res)
 			i = res.E
 			result.E = i
-			count++
 		}
-
-		if min <= count && count <= max {
-			if res.X == nil {
-				result.C = append(result.C, res)
-			}
-			return result
+		if count < min {
+			result.X = expected
 		}
-		result.X = ErrExpected{in}
 		return result
 	}
 
@@ -592,12 +1819,12 @@ func (g *Grammar) MakeSee(in x.See) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 1 {
@@ -605,18 +1832,22 @@ func (g *Grammar) MakeSee(in x.See) *Rule {
 	}
 
 	iname := x.String(in[0])
-	irule, has := g.Rules[iname]
+	irule, has := g.Lookup(iname)
 	if !has {
 		irule = g.MakeRule(in[0])
 	}
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i}
+		result := Result{O: rule, R: r, B: i, E: i}
+		mark := len(g.built)
 		res := irule.Check(r, i)
+		g.discard(res)
+		g.unbuild(mark)
 		if res.X == nil {
 			return result
 		}
-		result.X = ErrExpected{in}
+		result.X = expected
 		return result
 	}
 
@@ -628,12 +1859,12 @@ func (g *Grammar) MakeNot(in x.Not) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 1 {
@@ -641,18 +1872,22 @@ func (g *Grammar) MakeNot(in x.Not) *Rule {
 	}
 
 	iname := x.String(in[0])
-	irule, has := g.Rules[iname]
+	irule, has := g.Lookup(iname)
 	if !has {
 		irule = g.MakeRule(in[0])
 	}
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i}
+		result := Result{O: rule, R: r, B: i, E: i}
+		mark := len(g.built)
 		res := irule.Check(r, i)
+		g.discard(res)
+		g.unbuild(mark)
 		if res.X != nil {
 			return result
 		}
-		result.X = ErrExpected{in}
+		result.X = expected
 		return result
 	}
 
@@ -664,12 +1899,12 @@ func (g *Grammar) MakeTo(in x.To) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 1 {
@@ -677,23 +1912,47 @@ func (g *Grammar) MakeTo(in x.To) *Rule {
 	}
 
 	iname := x.String(in[0])
-	irule, has := g.Rules[iname]
+	irule, has := g.Lookup(iname)
 	if !has {
 		irule = g.MakeRule(in[0])
 	}
 
+	// plain literals are searched for directly (see toLit)
+	var lit []rune
+	if str, is := irule.Expr.(x.Str); is && len(str) == 1 && g.made(irule) {
+		s, _ := str[0].(string)
+		lit = []rune(s)
+	}
+
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i}
+		result := Result{O: rule, R: r, B: i, E: i}
+
+		if len(lit) > 0 && g.stats == nil && !g.diag {
+			end := g.toLit(irule, lit, r, i)
+			result.E = end
+			if end < len(r) {
+				mark := len(g.built)
+				irule.Check(r, end)
+				g.unbuild(mark)
+				return result
+			}
+			result.X = expected
+			return result
+		}
 
 		for ; i < len(r); i++ {
+			mark := len(g.built)
 			res := irule.Check(r, i)
+			g.discard(res)
+			g.unbuild(mark)
 			if res.X == nil {
 				return result
 			}
 			result.E++
 		}
 
-		result.X = ErrExpected{in}
+		result.X = expected
 		return result
 	}
 
@@ -701,10 +1960,60 @@ func (g *Grammar) MakeTo(in x.To) *Rule {
 
 }
 
+// toLit returns the position of the first match of the literal (lit)
+// at or after i (or the length of r if none) just as if the literal
+// rule (which must be the MakeStr rule of lit) had been checked at
+// every position until matched but without doing so. Farthest is set
+// to the failed check that would have been recorded last.
+func (g *Grammar) toLit(rule *Rule, lit []rune, r []rune, i int) int {
+	cur, far := g.Farthest.E, g.Farthest.X != nil
+	farB, farN := -1, 0
+	record := func(p, n int) {
+		if !far || p+n > cur {
+			cur, far, farB, farN = p+n, true, p, n
+		}
+	}
+
+	p := i
+	for p < len(r) {
+
+		// every position before the next that begins with the first rune
+		// fails without matching any rune (the last of which is recorded)
+		next := p
+		for next < len(r) && r[next] != lit[0] {
+			next++
+		}
+		if next > p {
+			record(next-1, 0)
+		}
+		if next == len(r) {
+			p = next
+			break
+		}
+
+		n := 1
+		for next+n < len(r) && n < len(lit) && r[next+n] == lit[n] {
+			n++
+		}
+		if n == len(lit) {
+			p = next
+			break
+		}
+		record(next, n)
+		p = next + 1
+	}
+
+	if farB >= 0 {
+		g.Farthest = Result{O: rule, R: r, B: farB, E: farB + farN,
+			X: ErrExpected{string(lit[farN])}}
+	}
+	return p
+}
+
 func (g *Grammar) MakeAny(in x.Any) *Rule {
 
 	name := in.String()
-	if r, have := g.Rules[name]; have {
+	if r, have := g.Lookup(name); have {
 		return r
 	}
 
@@ -725,17 +2034,21 @@ func (g *Grammar) makeAnyN(in x.Any) *Rule {
 	if !is {
 		panic(x.UsageAny)
 	}
+	if n <= 0 {
+		g.warn(ErrBounds{in})
+	}
 
 	name := in.String()
-	rule := &Rule{Name: name, Text: name}
+	rule := &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
 		start := i
 		if i+n > len(r) {
-			return Result{R: r, B: start, E: len(r) - 1, X: ErrExpected{in}}
+			return g.fail(Result{O: rule, R: r, B: start, E: len(r) - 1, X: expected})
 		}
-		return Result{R: r, B: start, E: i + n}
+		return Result{O: rule, R: r, B: start, E: i + n}
 	}
 
 	return rule
@@ -759,24 +2072,25 @@ func (g *Grammar) makeAnyMmx(in x.Any) *Rule {
 	}
 
 	name := in.String()
-	rule := &Rule{Name: name, Text: name}
+	rule := &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
 		start := i
 
 		// minimum is more than we have
 		if i+m > len(r) {
-			return Result{R: r, B: start, E: len(r) - 1, X: ErrExpected{in}}
+			return g.fail(Result{O: rule, R: r, B: start, E: len(r) - 1, X: expected})
 		}
 
 		// we have enough for max
 		if i+n < len(r) {
-			return Result{R: r, B: start, E: i + n}
+			return Result{O: rule, R: r, B: start, E: i + n}
 		}
 
 		// we have less than max, but more than min
-		return Result{R: r, B: start, E: len(r)}
+		return Result{O: rule, R: r, B: start, E: len(r)}
 	}
 
 	return rule
@@ -787,12 +2101,12 @@ func (g *Grammar) MakeRng(in x.Rng) *Rule {
 
 	name := in.String()
 
-	rule, has := g.Rules[name]
+	rule, has := g.Lookup(name)
 	if has {
 		return rule
 	}
 
-	rule = &Rule{Name: name, Text: name}
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
 	g.AddRule(rule)
 
 	if len(in) != 2 {
@@ -809,15 +2123,15 @@ func (g *Grammar) MakeRng(in x.Rng) *Rule {
 		panic(x.UsageRng)
 	}
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
-		result := Result{R: r, B: i, E: i}
-		cur := r[i]
-		if beg <= cur && cur <= end {
+		result := Result{O: rule, R: r, B: i, E: i}
+		if i < len(r) && beg <= r[i] && r[i] <= end {
 			result.E++
 			return result
 		}
-		result.X = ErrExpected{in}
-		return result
+		result.X = expected
+		return g.fail(result)
 	}
 
 	return rule
@@ -834,13 +2148,301 @@ func (g *Grammar) MakeEnd(in x.End) *Rule {
 	rule := new(Rule)
 	rule.Name = name
 	rule.Text = name
+	rule.Expr = in
 
+	expected := error(ErrExpected{in})
 	rule.Check = func(r []rune, i int) Result {
 		if i == len(r) {
-			return Result{R: r, B: i, E: i}
+			return Result{O: rule, R: r, B: i, E: i}
+		}
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: expected})
+	}
+
+	return g.AddRule(rule)
+}
+
+func (g *Grammar) MakeWb(in x.Wb) *Rule {
+
+	if len(in) != 0 {
+		panic(x.UsageWb)
+	}
+
+	name := in.String()
+	rule := new(Rule)
+	rule.Name = name
+	rule.Text = name
+	rule.Expr = in
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		before := i > 0 && i <= len(r) && x.IsWord(r[i-1])
+		after := i < len(r) && x.IsWord(r[i])
+		if before != after {
+			return Result{O: rule, R: r, B: i, E: i}
 		}
-		return Result{R: r, B: i, E: i, X: ErrExpected{in}}
+		return g.fail(Result{O: rule, R: r, B: i, E: i, X: expected})
 	}
 
 	return g.AddRule(rule)
 }
+
+func (g *Grammar) MakeByte(in x.Byte) *Rule {
+
+	bytes, ok := x.Bytes(in)
+	if !ok || len(bytes) == 0 {
+		panic(x.UsageByte)
+	}
+
+	name := in.String()
+
+	rule, has := g.Lookup(name)
+	if has {
+		return rule
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
+	g.AddRule(rule)
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		result := Result{O: rule, R: r, B: i, E: i}
+		for _, b := range bytes {
+			if result.E >= len(r) || r[result.E] != rune(b) {
+				result.X = expected
+				return g.fail(result)
+			}
+			result.E++
+		}
+		return result
+	}
+
+	return rule
+
+}
+
+func (g *Grammar) MakeBSet(in x.BSet) *Rule {
+
+	bytes, ok := x.Bytes(in)
+	if !ok || len(bytes) == 0 {
+		panic(x.UsageBSet)
+	}
+
+	name := in.String()
+
+	rule, has := g.Lookup(name)
+	if has {
+		return rule
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
+	g.AddRule(rule)
+
+	var set [256]bool
+	for _, b := range bytes {
+		set[b] = true
+	}
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		result := Result{O: rule, R: r, B: i, E: i}
+		if i < len(r) && 0 <= r[i] && r[i] <= 0xFF && set[r[i]] {
+			result.E++
+			return result
+		}
+		result.X = expected
+		return g.fail(result)
+	}
+
+	return rule
+
+}
+
+func (g *Grammar) MakeBRng(in x.BRng) *Rule {
+
+	name := in.String()
+	if name == x.UsageBRng {
+		panic(x.UsageBRng)
+	}
+
+	rule, has := g.Lookup(name)
+	if has {
+		return rule
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
+	g.AddRule(rule)
+
+	beg, _ := x.ByteOf(in[0])
+	end, _ := x.ByteOf(in[1])
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		result := Result{O: rule, R: r, B: i, E: i}
+		if i < len(r) && rune(beg) <= r[i] && r[i] <= rune(end) {
+			result.E++
+			return result
+		}
+		result.X = expected
+		return g.fail(result)
+	}
+
+	return rule
+
+}
+
+func (g *Grammar) MakeLen(in x.Len) *Rule {
+
+	name := in.String()
+	if name == x.UsageLen {
+		panic(x.UsageLen)
+	}
+
+	rule, has := g.Lookup(name)
+	if has {
+		return rule
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
+	g.AddRule(rule)
+
+	base, _ := x.LenBase(in)
+
+	rules := make([]*Rule, 2)
+	for n, it := range in[:2] {
+		irule, has := g.Lookup(x.String(it))
+		if !has {
+			irule = g.MakeRule(it)
+		}
+		rules[n] = irule
+	}
+	count, field := rules[0], rules[1]
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		if g.limits && g.over(i) {
+			return Result{O: rule, R: r, B: i, E: i, X: g.stop}
+		}
+		result := Result{O: rule, R: r, B: i, E: i, C: g.results(2)}
+		if g.nests() {
+			g.depth++
+			defer func() { g.depth-- }()
+		}
+
+		// children of the count are kept (see ScanBuild) to find it
+		g.kids++
+		res := count.Check(r, i)
+		g.kids--
+		num := counted(res)
+		g.keep(&result, res)
+		result.E = res.E
+		if res.X != nil {
+			result.X = res.X
+			return result
+		}
+
+		n, ok := length(r[num.B:num.E], base)
+		if !ok {
+			result.X = ErrLength{string(r[num.B:num.E])}
+			return g.fail(result)
+		}
+		if n > len(r)-res.E {
+			result.X = expected
+			return g.fail(result)
+		}
+
+		// the field only sees (and must match) the n runes counted
+		end := res.E + n
+		res = field.Check(r[:end], res.E)
+		res.R = r
+		g.keep(&result, res)
+		result.E = res.E
+		switch {
+		case res.X != nil:
+			result.X = res.X
+		case res.E != end:
+			result.X = expected
+			return g.fail(result)
+		}
+		return result
+	}
+
+	return rule
+
+}
+
+// counted returns the part of the result of the count of a Len that is
+// the length: the first named result within it (if any) or itself.
+func counted(res Result) Result {
+	if res.N != "" {
+		return res
+	}
+	for _, c := range res.C {
+		if num := counted(c); num.N != "" {
+			return num
+		}
+	}
+	return res
+}
+
+// length returns the length of a length-prefixed field (see x.Len) from
+// the runes of its count read as an integer of the base (or bytes if
+// x.BigEndian or x.LittleEndian) or false if not a valid length.
+func length(count []rune, base int) (int, bool) {
+	if base != x.BigEndian && base != x.LittleEndian {
+		n, err := strconv.ParseUint(string(count), base, 31)
+		return int(n), err == nil
+	}
+	if len(count) == 0 || len(count) > 4 {
+		return 0, false
+	}
+	var n uint32
+	for i := range count {
+		b := count[i]
+		if base == x.LittleEndian {
+			b = count[len(count)-1-i]
+		}
+		if b < 0 || b > 0xFF {
+			return 0, false
+		}
+		n = n<<8 | uint32(b)
+	}
+	return int(n), n <= math.MaxInt32
+}
+
+func (g *Grammar) MakeBits(in x.Bits) *Rule {
+
+	n, lo, hi, ok := x.BitsOf(in)
+	if !ok {
+		panic(x.UsageBits)
+	}
+
+	name := in.String()
+
+	rule, has := g.Lookup(name)
+	if has {
+		return rule
+	}
+
+	rule = &Rule{Name: g.key(name), Text: name, Expr: in}
+	g.AddRule(rule)
+
+	expected := error(ErrExpected{in})
+	rule.Check = func(r []rune, i int) Result {
+		result := Result{O: rule, R: r, B: i, E: i + n}
+		if result.E > len(r) {
+			result.E = i
+			result.X = expected
+			return g.fail(result)
+		}
+		v, is := result.Bits()
+		if !is || v < lo || v > hi {
+			result.E = i
+			result.X = expected
+			return g.fail(result)
+		}
+		return result
+	}
+
+	return rule
+
+}
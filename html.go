@@ -0,0 +1,204 @@
+package rat
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLSnippet is the maximum number of runes of the text of a result
+// shown in the parse tree of HTML before being cut off with an
+// ellipsis.
+const HTMLSnippet = 40
+
+// HTML writes a complete, standalone HTML page with the title, the
+// input (R) and the result tree as a collapsible list (using details
+// elements) to explore while developing a grammar. Every named result
+// (N) marks its span of the input and hovering over it in the tree
+// highlights that span (and the reverse). Results with an error (X) are
+// drawn in red. The page requires no external files. Any error writing
+// is returned.
+func (m Result) HTML(w io.Writer, title string) error {
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, htmlHead, html.EscapeString(title))
+	if m.S != "" {
+		fmt.Fprintf(buf, "<p class=\"src\">%v</p>\n", html.EscapeString(m.S))
+	}
+
+	id := 0
+	buf.WriteString(`<pre id="input">`)
+	end := m.htmlInput(buf, 0, &id)
+	buf.WriteString(html.EscapeString(string(m.R[end:])))
+	buf.WriteString("</pre>\n<ul id=\"tree\">\n")
+	id = 0
+	m.htmlNode(buf, &id)
+	buf.WriteString("</ul>\n")
+	buf.WriteString(htmlFoot)
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// htmlInput writes the input from pos through the end of the result
+// wrapping the span of every named result in a span element with the
+// same id (numbered in tree order) as in the tree. Children that
+// overlap earlier ones (lookahead, for example) are not marked. The
+// new position is returned.
+func (m Result) htmlInput(buf *strings.Builder, pos int, id *int) int {
+	named := m.N != "" && m.B >= pos && m.B <= m.E && m.E <= len(m.R)
+	if named {
+		buf.WriteString(html.EscapeString(string(m.R[pos:m.B])))
+		fmt.Fprintf(buf, `<span id="s%v" class="n">`, *id)
+		pos = m.B
+	}
+	if m.N != "" {
+		*id++
+	}
+	for _, c := range m.C {
+		pos = c.htmlInput(buf, pos, id)
+	}
+	if named {
+		buf.WriteString(html.EscapeString(string(m.R[pos:m.E])))
+		buf.WriteString(`</span>`)
+		pos = m.E
+	}
+	return pos
+}
+
+// HighlightOpts are the options of Highlight. The zero value marks
+// every named result with its name as class.
+type HighlightOpts struct {
+	Classes map[string]string // class for the results of each name (only these if set)
+	Prefix  string            // prepended to every class (such as "tok-")
+	Pre     bool              // enclose in a pre element (with class Prefix+"src")
+}
+
+// Highlight writes the input (R) as an HTML fragment with the span of
+// every named result (N) wrapped in a span element with a class derived
+// from its name (see HighlightOpts) so that, just like syntax
+// highlighting, the look of each can be set with CSS when including
+// the input in documentation or review pages. Spans of nested results
+// are nested and children that overlap earlier ones (lookahead, for
+// example) are not marked. Text of redacted rules is masked (see
+// Grammar.Redact). Any error writing is returned.
+//
+//	<span class="tok-Key">foo</span>=<span class="tok-Val">bar</span>
+func (m Result) Highlight(w io.Writer, opts HighlightOpts) error {
+	m.R = m.redacted()
+	buf := new(strings.Builder)
+	if opts.Pre {
+		fmt.Fprintf(buf, `<pre class="%v">`, html.EscapeString(opts.Prefix+`src`))
+	}
+	end := m.highlight(buf, 0, opts)
+	buf.WriteString(html.EscapeString(string(m.R[end:])))
+	if opts.Pre {
+		buf.WriteString(`</pre>`)
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// highlight writes the input from pos through the end of the result
+// marking the span of every named result with its class (see
+// Highlight) and returns the new position.
+func (m Result) highlight(buf *strings.Builder, pos int, opts HighlightOpts) int {
+	class := m.N
+	if opts.Classes != nil {
+		class = opts.Classes[m.N]
+	}
+	marked := m.N != "" && class != "" && m.B >= pos && m.B <= m.E && m.E <= len(m.R)
+	if marked {
+		buf.WriteString(html.EscapeString(string(m.R[pos:m.B])))
+		fmt.Fprintf(buf, `<span class="%v">`, html.EscapeString(opts.Prefix+class))
+		pos = m.B
+	}
+	for _, c := range m.C {
+		pos = c.highlight(buf, pos, opts)
+	}
+	if marked {
+		buf.WriteString(html.EscapeString(string(m.R[pos:m.E])))
+		buf.WriteString(`</span>`)
+		pos = m.E
+	}
+	return pos
+}
+
+// htmlNode writes the result as a list item of the tree numbering named
+// results the same as htmlInput.
+func (m Result) htmlNode(buf *strings.Builder, id *int) {
+	class := "r"
+	if m.X != nil {
+		class += " err"
+	}
+	label := fmt.Sprintf(`<span class="%v"`, class)
+	if m.N != "" {
+		label += fmt.Sprintf(` data-s="s%v"`, *id)
+		*id++
+	}
+	label += `>`
+	if m.N != "" {
+		label += `<b>` + html.EscapeString(m.N) + `</b> `
+	}
+	label += fmt.Sprintf(`[%v,%v) `, m.B, m.E)
+	if m.B >= 0 && m.B <= m.E && m.E <= len(m.R) {
+		text := m.R[m.B:m.E]
+		if len(text) > HTMLSnippet {
+			text = append(text[:HTMLSnippet:HTMLSnippet], '…')
+		}
+		label += `<code>` + html.EscapeString(fmt.Sprintf(`%q`, string(text))) + `</code>`
+	}
+	if m.X != nil {
+		label += ` <i>` + html.EscapeString(m.X.Error()) + `</i>`
+	}
+	label += `</span>`
+
+	if len(m.C) == 0 {
+		fmt.Fprintf(buf, "<li>%v</li>\n", label)
+		return
+	}
+	fmt.Fprintf(buf, "<li><details open><summary>%v</summary>\n<ul>\n", label)
+	for _, c := range m.C {
+		c.htmlNode(buf, id)
+	}
+	buf.WriteString("</ul>\n</details></li>\n")
+}
+
+const htmlHead = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%v</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+#input { background: #f8f8f8; border: 1px solid #ddd; padding: 1em; white-space: pre-wrap; }
+#input span.n { border-bottom: 1px dotted #99c; }
+#tree, #tree ul { list-style: none; padding-left: 1.5em; }
+#tree summary { cursor: pointer; }
+#tree span.r code { color: #555; }
+#tree span[data-s] { cursor: default; }
+#tree span.err { color: #c00; }
+.hl { background: #ffea80; }
+</style>
+</head>
+<body>
+`
+
+const htmlFoot = `<script>
+function mark(id, on) {
+  document.querySelectorAll('#' + id + ', [data-s="' + id + '"]').forEach(function (e) {
+    e.classList.toggle('hl', on);
+  });
+}
+document.querySelectorAll('#tree [data-s]').forEach(function (e) {
+  e.addEventListener('mouseover', function (ev) { ev.stopPropagation(); mark(e.dataset.s, true); });
+  e.addEventListener('mouseout', function () { mark(e.dataset.s, false); });
+});
+document.querySelectorAll('#input span.n').forEach(function (e) {
+  e.addEventListener('mouseover', function (ev) { ev.stopPropagation(); mark(e.id, true); });
+  e.addEventListener('mouseout', function () { mark(e.id, false); });
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,41 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// Import makes every named rule and alias of the other grammar in this
+// one with its name prefixed (a namespace) along with every reference
+// (x.Ref, x.Sav, x.Val) to them (see x.Rename) so that grammars that
+// define rules with the same names (String, Number) can be combined
+// without one silently replacing the other. The names of the built-in
+// classes (see x.Classes) are never prefixed and rules of the other
+// grammar made without an Expr (see AddRule) are not imported. The
+// Main rule is not changed. The new name of every rule imported is
+// returned keyed to its old name (see Result.Rename). Like MakeRule,
+// Import panics if any expression is malformed (see Try).
+//
+//	g.Import(json, `Json`) // JsonString, JsonNumber, ...
+func (g *Grammar) Import(other *Grammar, prefix string) map[string]string {
+	if g.Rules == nil {
+		g.Init()
+	}
+	var rules []*Rule
+	names := map[string]string{}
+	for _, rule := range other.Ordered() {
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		if rule.Expr != nil && (rule.IsAlias() || isNamed(rule)) {
+			rules = append(rules, rule)
+			names[rule.Name] = prefix + rule.Name
+		}
+	}
+	for _, rule := range rules {
+		expr := x.Rename(rule.Expr, names)
+		if rule.IsAlias() {
+			g.MakeAlias(names[rule.Name], expr).Doc = rule.Doc
+			continue
+		}
+		g.MakeRule(expr).Doc = rule.Doc
+	}
+	return names
+}
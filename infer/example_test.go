@@ -0,0 +1,29 @@
+package infer_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/infer"
+	"github.com/rwxrob/rat/x"
+)
+
+func ExampleDraft() {
+
+	draft, err := infer.Draft(`Version`, `v1.2.3`, `v10.0.1-rc1`, `v2.11.0`, `v0.9.12-beta2`)
+	fmt.Println(err)
+	fmt.Println(x.PEGN(draft))
+
+	draft, err = infer.Draft(`Call`, `(555) 123-4567`, `555-123-4567`, `(212) 555-0100`)
+	fmt.Println(err)
+	fmt.Println(x.PEGN(draft))
+
+	_, err = infer.Draft(`Nothing`)
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+	// Version <= 'v' [0-9]+ '.' [0-9]+ '.' [0-9]+ ('-' [a-z]{2,} [0-9]{1})?
+	// <nil>
+	// Call <= '('? [0-9]{3} ') '? '-'? [0-9]{3} '-' [0-9]{4}
+	// no examples
+}
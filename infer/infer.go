@@ -0,0 +1,297 @@
+/*
+Package infer (experimental) proposes a draft rat/x rule from example
+strings that the rule must match as a starting point for authors to
+refine rather than having to write a first grammar from nothing.
+
+Every example is split into runs of digits, letters, and spaces and
+single runes of anything else. The runs of all examples are then
+aligned (longest common subsequence) so that runs found in every
+example at the same place become required and the rest optional
+segments. Runs that are identical in every example become literals
+and the others a repetition of their class (digits, lowercase or
+uppercase letters, and so on) exactly as long as every example if all
+are the same length.
+
+The draft is only a guess: it knows nothing about the meaning of the
+examples, is never more general than the examples require, and will
+often need alternatives (x.One) and references added by hand. Render
+it as PEGN (see x.PEGN) to edit it as a PEGN grammar.
+*/
+package infer
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// Draft returns a draft rule named name matching every one of the
+// examples (see package documentation) along with an ErrUnmatched for
+// the first example the draft does not match entirely (which should
+// not happen but is checked since the draft is only a guess).
+func Draft(name string, examples ...string) (x.N, error) {
+	if len(examples) == 0 {
+		return nil, ErrNoExamples{}
+	}
+	var slots []*slot
+	for n, ex := range examples {
+		slots = merge(slots, tokens(ex), n)
+	}
+	draft := x.N{name, render(slots, len(examples))}
+	g := rat.Pack(x.Seq{draft, x.End{}})
+	for _, ex := range examples {
+		if res := g.Scan(ex); res.X != nil {
+			return draft, ErrUnmatched{ex}
+		}
+	}
+	return draft, nil
+}
+
+// kind is the kind of a token.
+type kind int
+
+const (
+	other kind = iota
+	digits
+	letters
+	spaces
+)
+
+// token is a run of digits, letters, or spaces or a single other rune.
+type token struct {
+	k kind
+	s string
+}
+
+// kindOf returns the kind of the rune.
+func kindOf(r rune) kind {
+	switch {
+	case unicode.IsDigit(r):
+		return digits
+	case unicode.IsLetter(r):
+		return letters
+	case unicode.IsSpace(r):
+		return spaces
+	}
+	return other
+}
+
+// tokens splits the example into tokens.
+func tokens(ex string) []token {
+	var toks []token
+	for _, r := range ex {
+		k := kindOf(r)
+		if n := len(toks) - 1; n >= 0 && k != other && toks[n].k == k {
+			toks[n].s += string(r)
+			continue
+		}
+		toks = append(toks, token{k, string(r)})
+	}
+	return toks
+}
+
+// key returns what must be the same for tokens to be aligned: the kind
+// of runs and the rune of others.
+func (t token) key() string {
+	if t.k == other {
+		return t.s
+	}
+	return fmt.Sprint(t.k)
+}
+
+// slot is a place within the draft and the text of every token aligned
+// with it along with the examples (by number) in which it was found.
+type slot struct {
+	token
+	texts []string
+	seen  map[int]bool
+}
+
+func (s *slot) add(t token, ex int) {
+	s.texts = append(s.texts, t.s)
+	s.seen[ex] = true
+}
+
+// merge aligns the tokens of example number ex with the slots (see
+// align) returning the slots with every token added to its aligned
+// slot or a new slot (after any unaligned slots before it) if none.
+func merge(slots []*slot, toks []token, ex int) []*slot {
+	var out []*slot
+	fresh := func(t token) *slot {
+		s := &slot{token: t, seen: map[int]bool{}}
+		s.add(t, ex)
+		return s
+	}
+	i, j := 0, 0
+	for _, m := range align(slots, toks) {
+		out = append(out, slots[i:m[0]]...)
+		for ; j < m[1]; j++ {
+			out = append(out, fresh(toks[j]))
+		}
+		slots[m[0]].add(toks[m[1]], ex)
+		out = append(out, slots[m[0]])
+		i, j = m[0]+1, m[1]+1
+	}
+	out = append(out, slots[i:]...)
+	for ; j < len(toks); j++ {
+		out = append(out, fresh(toks[j]))
+	}
+	return out
+}
+
+// align returns the index pairs (slot, token) of the longest common
+// subsequence of keys (see token.key) of the slots and tokens in order.
+func align(slots []*slot, toks []token) [][2]int {
+	n, m := len(slots), len(toks)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case slots[i].key() == toks[j].key():
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case slots[i].key() == toks[j].key():
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// render returns the slots as a Seq with every run of slots not seen
+// in all (total) examples but in exactly the same ones made optional
+// together.
+func render(slots []*slot, total int) x.Seq {
+	seq := x.Seq{}
+	for i := 0; i < len(slots); {
+		s := slots[i]
+		if len(s.seen) == total {
+			seq = append(seq, s.expr())
+			i++
+			continue
+		}
+		group := x.Seq{}
+		for ; i < len(slots) && same(slots[i].seen, s.seen); i++ {
+			group = append(group, slots[i].expr())
+		}
+		if len(group) == 1 {
+			seq = append(seq, x.Mmx{0, 1, group[0]})
+			continue
+		}
+		seq = append(seq, x.Mmx{0, 1, group})
+	}
+	return seq
+}
+
+// same returns true if both contain exactly the same examples.
+func same(a, b map[int]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if !b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// expr returns the literal of the slot if every text is the same or
+// a repetition of the class of every rune of its texts.
+func (s *slot) expr() any {
+	lit, short, long := true, -1, 0
+	for _, t := range s.texts {
+		lit = lit && t == s.texts[0]
+		n := utf8.RuneCountInString(t)
+		if short < 0 || n < short {
+			short = n
+		}
+		if n > long {
+			long = n
+		}
+	}
+	if lit {
+		if r, n := utf8.DecodeRuneInString(s.texts[0]); n == len(s.texts[0]) {
+			return r
+		}
+		return s.texts[0]
+	}
+	max := -1
+	if short == long {
+		max = long
+	}
+	return x.Mmx{short, max, s.class()}
+}
+
+// class returns the narrowest class of every rune of the texts of the
+// slot.
+func (s *slot) class() any {
+	switch s.k {
+	case digits:
+		return x.Rng{'0', '9'}
+	case spaces:
+		var runes x.One
+		found := map[rune]bool{}
+		for _, t := range s.texts {
+			for _, r := range t {
+				if !found[r] {
+					found[r] = true
+					runes = append(runes, r)
+				}
+			}
+		}
+		if len(runes) == 1 {
+			return runes[0]
+		}
+		return runes
+	}
+	var lower, upper, ascii = true, true, true
+	for _, t := range s.texts {
+		for _, r := range t {
+			lower = lower && 'a' <= r && r <= 'z'
+			upper = upper && 'A' <= r && r <= 'Z'
+			ascii = ascii && r < utf8.RuneSelf
+		}
+	}
+	switch {
+	case lower:
+		return x.Rng{'a', 'z'}
+	case upper:
+		return x.Rng{'A', 'Z'}
+	case ascii:
+		return x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}
+	}
+	return x.Is{unicode.IsLetter}
+}
+
+// ErrNoExamples is returned by Draft when passed no examples.
+type ErrNoExamples struct{}
+
+func (ErrNoExamples) Error() string { return ErrNoExamplesT }
+
+// ErrUnmatched is an example that a draft does not match entirely.
+type ErrUnmatched struct {
+	Example string
+}
+
+func (e ErrUnmatched) Error() string { return fmt.Sprintf(ErrUnmatchedT, e.Example) }
@@ -0,0 +1,9 @@
+package infer
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrNoExamplesT = `no examples`
+	ErrUnmatchedT  = `draft does not match example: %q`
+)
@@ -0,0 +1,73 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// trivial is a rule simple enough (a single rune range, class function,
+// or literal) to be checked directly by the Seq or Mmx containing it
+// (see inline) rather than through a call to its CheckFunc. The results
+// (and failures recorded as Farthest) are identical.
+type trivial struct {
+	rule     *Rule
+	beg, end rune              // range (or single rune literal)
+	is       func(r rune) bool // class function (instead of range)
+	lit      bool              // literal (recorded even if matched)
+	err      error
+}
+
+// inline returns the trivial form of the rule made by this grammar or
+// nil if it is not trivial.
+func (g *Grammar) inline(rule *Rule) *trivial {
+	if !g.made(rule) {
+		return nil
+	}
+	switch v := rule.Expr.(type) {
+	case x.Rng:
+		if len(v) != 2 {
+			return nil
+		}
+		beg, is1 := v[0].(rune)
+		end, is2 := v[1].(rune)
+		if !is1 || !is2 {
+			return nil
+		}
+		return &trivial{rule: rule, beg: beg, end: end, err: ErrExpected{v}}
+	case x.Is:
+		if len(v) != 1 {
+			return nil
+		}
+		is, ok := v[0].(func(r rune) bool)
+		if !ok {
+			return nil
+		}
+		return &trivial{rule: rule, is: is, err: ErrExpected{v}}
+	case x.Str:
+		if len(v) != 1 {
+			return nil
+		}
+		s, ok := v[0].(string)
+		if r := []rune(s); ok && len(r) == 1 {
+			return &trivial{rule: rule, beg: r[0], end: r[0], lit: true, err: ErrExpected{s}}
+		}
+	}
+	return nil
+}
+
+// made returns true if the rule was made by this grammar from its
+// expression (not an alias or a rule added with a different name).
+func (g *Grammar) made(rule *Rule) bool {
+	return rule != nil && g.RuleByID(rule.ID) == rule && rule.Name == g.key(rule.Text)
+}
+
+// check is the same as the CheckFunc of the rule.
+func (t *trivial) check(g *Grammar, r []rune, i int) Result {
+	if i < len(r) {
+		c := r[i]
+		if t.is == nil && t.beg <= c && c <= t.end || t.is != nil && t.is(c) {
+			if t.lit {
+				return g.fail(Result{O: t.rule, R: r, B: i, E: i + 1})
+			}
+			return Result{O: t.rule, R: r, B: i, E: i + 1}
+		}
+	}
+	return g.fail(Result{O: t.rule, R: r, B: i, E: i, X: t.err})
+}
@@ -0,0 +1,72 @@
+/*
+Package notation contains what the packages converting grammars written
+in other notations (abnf, antlr, ebnf, peg, and pegn) have in common:
+parsing with the syntax of the notation expressed in rat/x and locating
+the offending text of an error (see ErrParse). Each only supplies its
+syntax and name.
+*/
+package notation
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+)
+
+// Parser returns a new Grammar for parsing a notation with its syntax
+// expressed in rat/x. The first rule is the main rule. Only named (x.N)
+// results are retained when parsing. A new one is needed for every
+// conversion since Grammars are not safe for concurrent use.
+func Parser(syntax []any) *rat.Grammar {
+	g := new(rat.Grammar).Init()
+	g.NamedOnly = true
+	for _, it := range syntax[1:] {
+		g.MakeRule(it)
+	}
+	return g.Pack(syntax[0])
+}
+
+// Scan parses the input with a new Parser of the syntax. If the input
+// is invalid the farthest failure (see rat.Grammar.Farthest) is
+// returned instead since that is almost always where the actual
+// problem is.
+func Scan(syntax []any, in any) rat.Result {
+	g := Parser(syntax)
+	res := g.Scan(in)
+	if res.X != nil && g.Farthest.X != nil {
+		return g.Farthest
+	}
+	return res
+}
+
+// ErrParse is returned when text of a Notation cannot be parsed or
+// contains something that cannot be converted. Line and Col (both
+// starting at 1) are the location of the offending text.
+type ErrParse struct {
+	Notation string // such as ABNF or EBNF
+	Line     int
+	Col      int
+	Text     string // offending text (truncated to first line)
+}
+
+func (e ErrParse) Error() string {
+	return fmt.Sprintf(ErrParseT, e.Notation, e.Line, e.Col, e.Text)
+}
+
+// NewErrParse returns a new ErrParse of the notation from the position
+// of the result. If the result failed, the ending position (E) is used
+// since that is the farthest position reached.
+func NewErrParse(notation string, r rat.Result) ErrParse {
+	if r.X != nil {
+		r.B = r.E
+	}
+	line, col := r.Pos()
+	end := r.B
+	for end < len(r.R) && r.R[end] != '\n' && r.R[end] != '\r' {
+		end++
+	}
+	if r.X == nil && r.E < end {
+		end = r.E
+	}
+	return ErrParse{Notation: notation, Line: line, Col: col, Text: string(r.R[r.B:end])}
+}
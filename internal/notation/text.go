@@ -0,0 +1,8 @@
+package notation
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrParseT = `invalid %v at line %v, column %v: %q`
+)
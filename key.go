@@ -0,0 +1,54 @@
+package rat
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// HashKeyLen is the maximum length of the String form of an expression
+// used directly as the key (and Name) of its rule in the Grammar.Rules
+// cache. Longer forms (from deeply nested expressions) are keyed by
+// a hash of the form instead (see Grammar.Lookup) so that huge keys do
+// not slow down every map operation. The readable String form is
+// always kept as the Text of the rule. Change only before making any
+// rules.
+var HashKeyLen = 256
+
+// HashKeyPrefix begins every hashed key and cannot begin a PEGN name.
+const HashKeyPrefix = `#`
+
+// Lookup returns the rule from the Rules cache keyed to the name or the
+// String form of the expression passed (even if hashed, see
+// HashKeyLen).
+func (g *Grammar) Lookup(key string) (*Rule, bool) {
+	if len(key) > HashKeyLen {
+		key = g.key(key)
+	}
+	rule, has := g.Rules[key]
+	return rule, has
+}
+
+// key returns the key for the rule with the String form (text). Long
+// text is hashed and a numeric suffix added in the unlikely case that
+// a different rule is already keyed to the hash. The key returned is
+// either that of the rule with the same text or not in use.
+func (g *Grammar) key(text string) string {
+	if len(text) <= HashKeyLen {
+		return text
+	}
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	hash := fmt.Sprintf(`%v%016x`, HashKeyPrefix, h.Sum64())
+	key := hash
+	for n := 1; ; n++ {
+		rule, has := g.Rules[key]
+		if !has || rule.Text == text {
+			return key
+		}
+		key = fmt.Sprintf(`%v.%v`, hash, n)
+	}
+}
+
+// isHashKey returns true if the name is a hashed key (see HashKeyLen).
+func isHashKey(name string) bool { return strings.HasPrefix(name, HashKeyPrefix) }
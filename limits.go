@@ -0,0 +1,87 @@
+package rat
+
+import (
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// checkEvery is the number of rule checks between checks of the time
+// (see Timeout) which is (relatively) slow to get.
+const checkEvery = 256
+
+// start begins checking the limits (if any) for a Scan.
+func (g *Grammar) start() {
+	g.limits = g.MaxNesting > 0 || g.MaxSteps > 0 || g.Timeout > 0
+	g.steps, g.stop = 0, nil
+	if g.Timeout > 0 {
+		g.until = time.Now().Add(g.Timeout)
+	}
+}
+
+// finish stops checking the limits returning the error of the limit
+// exceeded during the Scan (if any).
+func (g *Grammar) finish() error {
+	g.limits = false
+	return g.stop
+}
+
+// over returns true if a limit has been exceeded checking a rule at i
+// (and every check after it) setting the error (stop) of the Scan.
+func (g *Grammar) over(i int) bool {
+	if g.stop != nil {
+		return true
+	}
+	g.steps++
+	switch {
+	case g.MaxSteps > 0 && g.steps > g.MaxSteps:
+		g.stop = ErrTooManySteps{g.MaxSteps, i}
+	case g.MaxNesting > 0 && g.depth >= g.MaxNesting:
+		g.stop = ErrTooDeep{g.MaxNesting, i}
+	case g.Timeout > 0 && g.steps%checkEvery == 0 && time.Now().After(g.until):
+		g.stop = ErrTimeout{g.Timeout, i}
+	}
+	return g.stop != nil
+}
+
+// input returns the input as runes (see toRunes) decoded according to
+// the UTF8 mode (with line endings normalized if NormalizeEOL) or
+// ErrTooLarge if there are more than MaxInput (if set).
+// No more than needed is read from an io.RuneReader or io.Reader
+// (always read as bytes if UTF8Raw or UTF8Bits).
+func (g *Grammar) input(in any) ([]rune, error) {
+	_, bin := in.(io.Reader)
+	bin = bin && (g.UTF8 == UTF8Raw || g.UTF8 == UTF8Bits)
+	if rd, is := in.(io.RuneReader); is && !bin {
+		runes, err := readRunes(rd, g.MaxInput, g.UTF8)
+		if err != nil {
+			return nil, err
+		}
+		in = runes
+	}
+	if rd, is := in.(io.Reader); is {
+		if g.MaxInput > 0 {
+			rd = io.LimitReader(rd, int64(g.MaxInput*utf8.UTFMax+1))
+		}
+		buf, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+		in = buf
+	}
+	in, err := g.decode(in)
+	if err != nil {
+		return nil, err
+	}
+	runes, err := toRunes(in)
+	if err != nil {
+		return nil, err
+	}
+	if g.NormalizeEOL {
+		runes = lf(runes)
+	}
+	if g.MaxInput > 0 && len(runes) > g.MaxInput {
+		return nil, ErrTooLarge{g.MaxInput, len(runes)}
+	}
+	return runes, nil
+}
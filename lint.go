@@ -0,0 +1,119 @@
+package rat
+
+import (
+	"strings"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// Validate analyzes every rule of the grammar for mistakes that do not
+// prevent it from being made but that silently cause it to match
+// something other than intended and returns an error for each found:
+//
+//	ErrUnreachable - One alternative shadowed by an earlier one
+//	ErrNullable    - unlimited repetition (Mmx) of rule matching empty
+//
+// An empty (nil) slice is returned if none are found.
+func (g *Grammar) Validate() []error {
+	var errs []error
+	for _, rule := range g.Ordered() {
+		if mmx, is := rule.Expr.(x.Mmx); is && len(mmx) == 3 && mmx[1] == -1 {
+			if _, nullable, ok := first(mmx[2]); ok && nullable {
+				errs = append(errs, ErrNullable{rule.Text})
+			}
+		}
+		if one, is := rule.Expr.(x.One); is {
+			errs = append(errs, unreachable(rule.Text, one)...)
+		}
+	}
+	return errs
+}
+
+// unreachable returns an ErrUnreachable for every alternative of the
+// One (with the String form text) shadowed by an earlier one.
+func unreachable(text string, one x.One) []error {
+	var errs []error
+	alts, is := x.Canonical(one).(x.One)
+	if !is {
+		return nil
+	}
+	for n, it := range alts {
+		for _, by := range alts[:n] {
+			if shadows(by, it) {
+				errs = append(errs, ErrUnreachable{text, it, by})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// shadows returns true if the (canonical) alternative a of a One
+// always matches when the later alternative b could have so that b is
+// never reached (since PEG choices are ordered): a matches the empty
+// string (and therefore always matches), a is a literal prefix of the
+// literal b ("in" before "int"), or a matches exactly one rune of a set
+// containing every rune that can begin b.
+func shadows(a, b any) bool {
+	arngs, anull, aok := first(a)
+	if aok && anull {
+		return true
+	}
+	if as, is := a.(string); is {
+		bs, is := b.(string)
+		return is && strings.HasPrefix(bs, as)
+	}
+	if !single(a) || !aok {
+		return false
+	}
+	brngs, bnull, bok := first(b)
+	if !bok || bnull || len(brngs) == 0 {
+		return false
+	}
+	for _, br := range brngs {
+		if !covered(br, arngs) {
+			return false
+		}
+	}
+	return true
+}
+
+// single returns true if the (canonical) expression always matches
+// exactly one rune when it matches (rune, single rune string, Rng, or
+// One of only those).
+func single(it any) bool {
+	switch v := it.(type) {
+	case rune, x.Rng:
+		return true
+	case string:
+		return len([]rune(v)) == 1
+	case x.One:
+		for _, alt := range v {
+			if !single(alt) {
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+// covered returns true if every rune of the range is within the ranges.
+func covered(r [2]rune, rngs [][2]rune) bool {
+	for beg := r[0]; beg <= r[1]; {
+		next := beg
+		for _, it := range rngs {
+			if it[0] <= beg && beg <= it[1] && it[1] >= next {
+				next = it[1] + 1
+			}
+		}
+		if next == beg {
+			return false
+		}
+		beg = next
+	}
+	return true
+}
+
+// warn adds the mistake found while making a rule to Warnings.
+func (g *Grammar) warn(err error) { g.Warnings = append(g.Warnings, err) }
@@ -0,0 +1,36 @@
+package rat
+
+// DecodePEGN makes every definition of the PEGN document (see Dump) into
+// rules of the grammar setting its Main rule (see UnmarshalText). Since
+// parsing PEGN requires the rat/pegn package (which imports this one)
+// it is nil until that package is imported, if only for this:
+//
+//	import _ "github.com/rwxrob/rat/pegn"
+var DecodePEGN func(g *Grammar, doc []byte) error
+
+// MarshalText fulfills the encoding.TextMarshaler interface with the
+// declarative form of the grammar (see Dump) so that grammars can be
+// stored in caches and configuration like any other value and made
+// again with UnmarshalText. Only the rules are included, not the
+// settings (such as limits) of the grammar.
+func (g *Grammar) MarshalText() ([]byte, error) { return []byte(g.Dump()), nil }
+
+// UnmarshalText fulfills the encoding.TextUnmarshaler interface by
+// initializing the grammar (see Init) and making the rules of the text
+// (see MarshalText) with DecodePEGN returning its error or
+// ErrNoDecoder if it is not set.
+func (g *Grammar) UnmarshalText(text []byte) error {
+	if DecodePEGN == nil {
+		return ErrNoDecoder{}
+	}
+	g.Init()
+	return DecodePEGN(g, text)
+}
+
+// MarshalBinary fulfills the encoding.BinaryMarshaler interface and is
+// the same as MarshalText.
+func (g *Grammar) MarshalBinary() ([]byte, error) { return g.MarshalText() }
+
+// UnmarshalBinary fulfills the encoding.BinaryUnmarshaler interface and
+// is the same as UnmarshalText.
+func (g *Grammar) UnmarshalBinary(data []byte) error { return g.UnmarshalText(data) }
@@ -0,0 +1,46 @@
+package rat
+
+import "sync/atomic"
+
+// Messages translates the default text (templates and separators) of
+// every error message (the constants in text.go such as ErrExpectedT)
+// so that applications can localize or re-brand them (see SetMessages).
+// The text must be returned as is if it has no translation. Templates
+// must keep the same verbs in the same order.
+type Messages interface {
+	Message(text string) string
+}
+
+// MessageMap is a Messages keyed to the default text.
+//
+//	rat.SetMessages(rat.MessageMap{rat.ErrExpectedT: `erwartet: %v`})
+type MessageMap map[string]string
+
+// Message fulfills the Messages interface.
+func (m MessageMap) Message(text string) string {
+	if t, has := m[text]; has {
+		return t
+	}
+	return text
+}
+
+type catalog struct{ Messages }
+
+var messages atomic.Value // catalog
+
+// SetMessages sets the Messages used by every error of the package from
+// now on (nil restores the defaults) returning the previous one (nil if
+// none). It is safe to call from multiple goroutines.
+func SetMessages(m Messages) Messages {
+	prev, _ := messages.Swap(catalog{m}).(catalog)
+	return prev.Messages
+}
+
+// msg returns the default text translated by the current Messages (if
+// any).
+func msg(text string) string {
+	if c, _ := messages.Load().(catalog); c.Messages != nil {
+		return c.Message(text)
+	}
+	return text
+}
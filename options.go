@@ -0,0 +1,88 @@
+package rat
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a Grammar created with New.
+type Option func(g *Grammar)
+
+// New returns a new initialized Grammar (see Init) configured with the
+// options in order. Since options only set fields it never panics.
+// Combine with PackE (rather than Pack) for a grammar that can be set
+// up in libraries and servers without risking a panic.
+//
+//	g, err := rat.New(rat.WithLimits(rat.Limits{MaxSteps: 1e6})).PackE(rules...)
+func New(opts ...Option) *Grammar {
+	g := new(Grammar).Init()
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// PackE is the same as Pack but returns an error instead of panicking:
+// an ErrMake when any expression is malformed or the ErrRefs of
+// a grammar that must resolve every reference (see Resolve). The
+// grammar is left as it was before when it fails (see Try).
+func (g *Grammar) PackE(seq ...any) (*Grammar, error) { return g.TryPack(seq...) }
+
+// PackE is the same as Pack but returns an error instead of panicking
+// (see Grammar.PackE).
+func PackE(seq ...any) (*Grammar, error) { return New().PackE(seq...) }
+
+// Limits are the limits of every Scan of a Grammar (see WithLimits and
+// the Limits section of Grammar). Zero values are unlimited.
+type Limits struct {
+	MaxNesting int           // maximum nesting of rule checks (ErrTooDeep)
+	MaxSteps   int           // maximum rule checks (ErrTooManySteps)
+	MaxInput   int           // maximum runes of input (ErrTooLarge)
+	Timeout    time.Duration // maximum time of a Scan (ErrTimeout)
+}
+
+// WithLimits sets the limits of every Scan.
+func WithLimits(l Limits) Option {
+	return func(g *Grammar) {
+		g.MaxNesting, g.MaxSteps, g.MaxInput, g.Timeout = l.MaxNesting, l.MaxSteps, l.MaxInput, l.Timeout
+	}
+}
+
+// WithTrace traces every rule made and named rule checked (TraceCheck)
+// writing each event as a line to w (see WriterTracer).
+func WithTrace(w io.Writer) Option {
+	return func(g *Grammar) { g.Trace, g.Tracer = TraceCheck, WriterTracer{W: w} }
+}
+
+// WithTracer passes every event at the trace level (TraceMake or
+// TraceCheck) to the tracer.
+func WithTracer(level int, t Tracer) Option {
+	return func(g *Grammar) { g.Trace, g.Tracer = level, t }
+}
+
+// WithNamedOnly keeps only named results (see NamedOnly).
+func WithNamedOnly() Option { return func(g *Grammar) { g.NamedOnly = true } }
+
+// WithFailFast enables failing fast (see FailFast).
+func WithFailFast() Option { return func(g *Grammar) { g.FailFast = true } }
+
+// WithFold folds expressions into their canonical form (see Fold).
+func WithFold() Option { return func(g *Grammar) { g.Fold = true } }
+
+// WithResolve requires every reference to resolve when packed (see
+// Resolve).
+func WithResolve() Option { return func(g *Grammar) { g.Resolve = true } }
+
+// WithStrict refuses to replace rules with different definitions of
+// the same name (see Strict).
+func WithStrict() Option { return func(g *Grammar) { g.Strict = true } }
+
+// WithNormalizeEOL normalizes the line endings of input to LF (see
+// NormalizeEOL).
+func WithNormalizeEOL() Option { return func(g *Grammar) { g.NormalizeEOL = true } }
+
+// WithUTF8 sets how invalid UTF-8 input is handled (see UTF8Mode).
+func WithUTF8(mode UTF8Mode) Option { return func(g *Grammar) { g.UTF8 = mode } }
+
+// WithRedact marks the rules with the names as redacted (see Redact).
+func WithRedact(names ...string) Option { return func(g *Grammar) { g.Redact(names...) } }
@@ -0,0 +1,16 @@
+package peg
+
+import (
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+)
+
+// ErrParse is returned when peg grammar text cannot be parsed or
+// contains something that cannot be converted (such as an invalid
+// escape). Line and Col (both starting at 1) are the location of the
+// offending text.
+type ErrParse = notation.ErrParse
+
+// newErrParse returns a new ErrParse of the peg Notation from the
+// position of the result (see notation.NewErrParse).
+func newErrParse(r rat.Result) ErrParse { return notation.NewErrParse(Notation, r) }
@@ -0,0 +1,71 @@
+package peg_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat/peg"
+)
+
+func ExampleToPEGN() {
+
+	s, err := peg.ToPEGN(`
+package main
+
+type Calculator Peg {
+	Expression
+}
+
+e      <- sp e1 !.
+e1     <- e2 ( add e2 { p.AddOperator(TypeAdd) }
+                / minus e2 { p.AddOperator(TypeSubtract) }
+                )*
+e2     <- value
+value  <- < [0-9]+ > sp { p.AddValue(buffer[begin:end]) }
+        / "nan" sp
+add    <- '+' sp
+minus  <- '-' sp
+sp     <- ( ' ' / '\t' )*    # spacing
+hex    <- [[a-f0-9]]+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(s)
+
+	// Output:
+	// e     <= sp e1 !.
+	// e1    <= e2 (add e2 / minus e2)*
+	// e2    <= value
+	// value <= [0-9]+ sp / (('n' / 'N') ('a' / 'A') ('n' / 'N')) sp
+	// add   <= '+' sp
+	// minus <= '-' sp
+	// sp    <= (' ' / x09)*
+	// hex   <= ([a-f] / [A-F] / [0-9])+
+
+}
+
+func ExampleParse() {
+
+	g, err := peg.Parse(`
+greet <- ('hello' / 'hi') ' ' name
+name  <- [A-Z] [^ \n]+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Scan(`hi Rob`).PrintText()
+	g.Scan(`hey Rob`).PrintError()
+
+	_, err = peg.Parse(`greet <- 'hello' / [z-a]`)
+	fmt.Println(err)
+
+	// Output:
+	// hi Rob
+	// expected: x.One{x.Str{"hello"}, x.Str{"hi"}}
+	// 1 | hey Rob
+	//   | ^
+	// invalid peg grammar at line 1, column 21: "z-a"
+
+}
@@ -0,0 +1,416 @@
+/*
+Package peg converts grammars written for the popular Go peg tool
+(github.com/pointlander/peg) into rat/x expressions, PEGN, or fully
+compiled *rat.Grammar instances so that its users can move to rat
+without transcribing grammars by hand. Like the pegn package, the peg
+parser itself is written entirely in rat/x.
+
+The following peg is supported (see rat/x for the equivalent types):
+
+	foo <- rule          x.N{"foo", rule}
+	foo                  x.Ref{"foo"}
+	rule1 rule2          x.Seq{rule1, rule2}
+	rule1 / rule2        x.One{rule1, rule2}
+	(rule) / < rule >    rule
+	rule?                x.Mmx{0, 1, rule}
+	rule*                x.Mmx{0, -1, rule}
+	rule+                x.Mmx{1, -1, rule}
+	&rule                x.See{rule}
+	!rule                x.Not{rule}
+	'ab\n'               x.Str{"ab\n"}
+	"ab"                 x.Seq{x.One{"a", "A"}, x.One{"b", "B"}}
+	[a-z_]               x.One{x.Rng{'a', 'z'}, x.Str{"_"}}
+	[^a-z]               x.Seq{x.Not{x.Rng{'a', 'z'}}, x.Any{1}}
+	[[a-z]]              x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}
+	.                    x.Any{1}
+
+As with the peg tool itself, double-quoted strings and double-bracketed
+classes are case-insensitive. The package, import, and type
+declarations, the capture markers (< and >), actions ({...}), and
+comments (#) are all ignored.
+*/
+package peg
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	eol     = x.One{"\r\n", '\n', '\r'}
+	comment = x.Seq{'#', x.Mmx{0, -1, x.Seq{x.Not{eol}, x.Any{1}}}}
+	sp      = x.Mmx{0, -1, x.One{' ', '\t', eol, comment}}
+	letter  = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}, '_'}
+	digit   = x.Rng{'0', '9'}
+	ident   = x.Seq{letter, x.Mmx{0, -1, x.One{letter, digit}}}
+	line    = x.Mmx{0, -1, x.Seq{x.Not{eol}, x.Any{1}}}
+	block   = x.Ref{`Block`}
+	escaped = x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{']'}, x.Any{1}}}
+)
+
+// syntax is the peg grammar itself expressed in rat/x (see
+// notation.Parser).
+var syntax = []any{
+	x.N{`Grammar`, x.Seq{
+		sp,
+		x.Mmx{0, -1, x.Seq{x.One{x.Ref{`Declaration`}, x.Ref{`Definition`}}, sp}},
+		x.End{},
+	}},
+	x.N{`Declaration`, x.One{
+		x.Seq{"package", sp, ident},
+		x.Seq{"import", sp, x.One{x.Seq{'(', x.To{')'}, ')'}, line}},
+		x.Seq{"type", sp, ident, sp, "Peg", sp, block},
+	}},
+	x.N{`Block`, x.Seq{'{', x.Mmx{0, -1, x.One{block, x.Seq{x.Not{'}'}, x.Any{1}}}}, '}'}},
+	x.N{`Definition`, x.Seq{
+		x.N{`Name`, ident}, sp, "<-", sp, x.Ref{`Expression`},
+	}},
+	x.N{`Expression`, x.Seq{
+		x.Ref{`Sequence`},
+		x.Mmx{0, -1, x.Seq{sp, '/', sp, x.Ref{`Sequence`}}},
+	}},
+	x.N{`Sequence`, x.Mmx{0, -1, x.Seq{x.Ref{`Prefix`}, sp}}},
+	x.N{`Prefix`, x.One{
+		x.Seq{x.N{`Op`, x.One{'&', '!'}}, sp, x.Ref{`Suffix`}},
+		x.Ref{`Suffix`},
+		block,
+	}},
+	x.N{`Suffix`, x.Seq{
+		x.Ref{`Primary`},
+		x.Mmx{0, 1, x.N{`Quant`, x.One{'?', '*', '+'}}},
+	}},
+	x.N{`Primary`, x.One{
+		x.Seq{x.One{'(', '<'}, sp, x.Ref{`Expression`}, sp, x.One{')', '>'}},
+		x.N{`Lit`, x.Seq{'\'', x.Mmx{0, -1, x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{'\''}, x.Any{1}}}}, '\''}},
+		x.N{`Fold`, x.Seq{'"', x.Mmx{0, -1, x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{'"'}, x.Any{1}}}}, '"'}},
+		x.N{`FoldClass`, x.Seq{"[[", x.Mmx{0, 1, x.N{`Negated`, '^'}}, x.N{`Ranges`, x.Mmx{0, -1, x.Seq{x.Not{"]]"}, escaped}}}, "]]"}},
+		x.N{`Class`, x.Seq{'[', x.Mmx{0, 1, x.N{`Negated`, '^'}}, x.N{`Ranges`, x.Mmx{0, -1, escaped}}, ']'}},
+		x.N{`Any`, '.'},
+		x.N{`Ref`, x.Seq{ident, x.Not{x.Seq{sp, "<-"}}}},
+	}},
+}
+
+// ToX converts a peg grammar (string, []byte, []rune, or io.Reader)
+// into a named rat/x expression (x.N) for every rule (in the order
+// defined). An ErrParse is returned if the grammar is invalid,
+// unsupported, or defines the same rule more than once.
+func ToX(in any) ([]x.N, error) {
+	res := notation.Scan(syntax, in)
+	if res.X != nil {
+		return nil, newErrParse(res)
+	}
+
+	var defs []x.N
+	seen := map[string]bool{}
+	for _, def := range res.Children(`Definition`) {
+		name, _ := def.Child(`Name`)
+		exp, _ := def.Child(`Expression`)
+		if seen[name.Text()] {
+			return nil, newErrParse(name)
+		}
+		seen[name.Text()] = true
+		it, err := convert(exp)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, x.N{name.Text(), it})
+	}
+	return defs, nil
+}
+
+// Parse converts a peg grammar (see ToX) into a new compiled
+// *rat.Grammar with the first rule as the Main rule.
+func Parse(in any) (*rat.Grammar, error) {
+	defs, err := ToX(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, ErrParse{Notation: Notation, Line: 1, Col: 1}
+	}
+	g := new(rat.Grammar).Init()
+	for _, def := range defs[1:] {
+		g.MakeRule(def)
+	}
+	return g.Pack(defs[0]), nil
+}
+
+// ToPEGN converts a peg grammar (see ToX) into the equivalent PEGN
+// document (see rat.Grammar.PEGN).
+func ToPEGN(in any) (string, error) {
+	g, err := Parse(in)
+	if err != nil {
+		return "", err
+	}
+	return g.PEGN(), nil
+}
+
+// convert converts a named result from the peg syntax into the
+// equivalent rat/x expression.
+func convert(r rat.Result) (any, error) {
+
+	switch r.N {
+
+	case `Expression`:
+		var one x.One
+		for _, it := range r.C {
+			v, err := convert(it)
+			if err != nil {
+				return nil, err
+			}
+			one = append(one, v)
+		}
+		if len(one) == 1 {
+			return one[0], nil
+		}
+		return one, nil
+
+	case `Sequence`:
+		var seq x.Seq
+		for _, it := range r.C {
+			if len(it.C) == 0 || it.C[0].N == `Block` { // action
+				continue
+			}
+			v, err := convert(it)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		switch len(seq) {
+		case 0:
+			return "", nil
+		case 1:
+			return seq[0], nil
+		}
+		return seq, nil
+
+	case `Prefix`:
+		if len(r.C) == 1 {
+			return convert(r.C[0])
+		}
+		it, err := convert(r.C[1])
+		if err != nil {
+			return nil, err
+		}
+		if r.C[0].Text() == `&` {
+			return x.See{it}, nil
+		}
+		return x.Not{it}, nil
+
+	case `Suffix`:
+		it, err := convert(r.C[0])
+		if err != nil || len(r.C) == 1 {
+			return it, err
+		}
+		switch r.C[1].Text() {
+		case `?`:
+			return x.Mmx{0, 1, it}, nil
+		case `*`:
+			return x.Mmx{0, -1, it}, nil
+		}
+		return x.Mmx{1, -1, it}, nil
+
+	case `Primary`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		return convert(r.C[0])
+
+	case `Lit`, `Fold`:
+		s, err := unescape(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		if r.N == `Fold` {
+			return fold(string(s)), nil
+		}
+		return string(s), nil
+
+	case `Class`, `FoldClass`:
+		var negated bool
+		var it any
+		for _, c := range r.C {
+			if c.N == `Negated` {
+				negated = true
+				continue
+			}
+			var err error
+			if it, err = class(c, r.N == `FoldClass`); err != nil {
+				return nil, err
+			}
+		}
+		if it == nil {
+			return nil, newErrParse(r)
+		}
+		if negated {
+			return x.Seq{x.Not{it}, x.Any{1}}, nil
+		}
+		return it, nil
+
+	case `Any`:
+		return x.Any{1}, nil
+
+	case `Ref`:
+		return x.Ref{r.Text()}, nil
+
+	}
+
+	return nil, newErrParse(r)
+}
+
+// fold returns an expression matching the string regardless of the
+// case of any letters.
+func fold(s string) any {
+	var seq x.Seq
+	var lit string
+	for _, r := range s {
+		lo, up := unicode.ToLower(r), unicode.ToUpper(r)
+		if lo == up {
+			lit += string(r)
+			continue
+		}
+		if lit != "" {
+			seq = append(seq, lit)
+			lit = ""
+		}
+		seq = append(seq, x.One{string(lo), string(up)})
+	}
+	if lit != "" {
+		seq = append(seq, lit)
+	}
+	switch len(seq) {
+	case 0:
+		return ""
+	case 1:
+		return seq[0]
+	}
+	return seq
+}
+
+// class converts the Ranges of a class into the equivalent x.One of
+// x.Rng and single rune strings adding the other case of every letter
+// when fold is set.
+func class(r rat.Result, fold bool) (any, error) {
+	text := []rune(r.Text())
+	var one x.One
+	add := func(it any) {
+		for _, o := range one {
+			if x.String(o) == x.String(it) {
+				return
+			}
+		}
+		one = append(one, it)
+	}
+	for i := 0; i < len(text); {
+		beg, n := char(text, i)
+		if n == 0 {
+			return nil, newErrParse(r)
+		}
+		i += n
+		end := beg
+		if i+1 < len(text) && text[i] == '-' {
+			var m int
+			if end, m = char(text, i+1); m == 0 || end < beg {
+				return nil, newErrParse(r)
+			}
+			i += 1 + m
+		}
+		if beg == end {
+			add(string(beg))
+		} else {
+			add(x.Rng{beg, end})
+		}
+		if !fold {
+			continue
+		}
+		lo, up := unicode.ToLower(beg), unicode.ToUpper(beg)
+		elo, eup := unicode.ToLower(end), unicode.ToUpper(end)
+		switch {
+		case beg == end && lo != up && beg == lo:
+			add(string(up))
+		case beg == end && lo != up:
+			add(string(lo))
+		case beg != end && lo != up && elo != eup && beg == lo && end == elo:
+			add(x.Rng{up, eup})
+		case beg != end && lo != up && elo != eup:
+			add(x.Rng{lo, elo})
+		}
+	}
+	switch len(one) {
+	case 0:
+		return nil, nil
+	case 1:
+		return one[0], nil
+	}
+	return one, nil
+}
+
+// unescape returns the runes of the result text (without the first and
+// last trim runes) with any escapes replaced.
+func unescape(r rat.Result, trim int) ([]rune, error) {
+	text := []rune(r.Text())
+	text = text[trim : len(text)-trim]
+	var s []rune
+	for i := 0; i < len(text); {
+		c, n := char(text, i)
+		if n == 0 {
+			return nil, newErrParse(r)
+		}
+		s = append(s, c)
+		i += n
+	}
+	return s, nil
+}
+
+// char returns the (possibly escaped) rune at i within the text and the
+// number of runes consumed (0 if the escape is invalid).
+func char(text []rune, i int) (rune, int) {
+	if text[i] != '\\' || i+1 >= len(text) {
+		return text[i], 1
+	}
+	switch text[i+1] {
+	case 'a':
+		return '\a', 2
+	case 'b':
+		return '\b', 2
+	case 'e':
+		return 0x1B, 2
+	case 'f':
+		return '\f', 2
+	case 'n':
+		return '\n', 2
+	case 'r':
+		return '\r', 2
+	case 't':
+		return '\t', 2
+	case 'v':
+		return '\v', 2
+	}
+	rest := string(text[i+1:])
+	if strings.HasPrefix(rest, `0x`) {
+		n := 0
+		for n < len(rest)-2 && strings.ContainsRune(`0123456789abcdefABCDEF`, rune(rest[2+n])) {
+			n++
+		}
+		v, err := strconv.ParseUint(rest[2:2+n], 16, 32)
+		if err != nil || v > unicode.MaxRune {
+			return 0, 0
+		}
+		return rune(v), 3 + n
+	}
+	if text[i+1] >= '0' && text[i+1] <= '7' {
+		n := 0
+		for n < 3 && n < len(rest) && rest[n] >= '0' && rest[n] <= '7' {
+			n++
+		}
+		v, _ := strconv.ParseUint(rest[:n], 8, 32)
+		return rune(v), 1 + n
+	}
+	return text[i+1], 2
+}
@@ -0,0 +1,8 @@
+package peg
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	Notation = `peg grammar` // named by every ErrParse
+)
@@ -0,0 +1,174 @@
+package pegn
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+)
+
+// ErrParse is returned when PEGN text cannot be parsed. Line and Col
+// (both starting at 1) are the location of the offending PEGN within
+// the File (if any). For invalid PEGN this is the farthest position
+// reached by the PEGN parser (see rat.Grammar.Farthest) which is almost
+// always where the actual problem is (or the last token before it if
+// nothing but space remains of the line there). Token is the offending
+// token and Rule the name of the definition in which it occurs (if
+// any).
+type ErrParse struct {
+	Line  int
+	Col   int
+	Text  string // offending text (truncated to first line)
+	File  string
+	Token string
+	Rule  string
+}
+
+func (e ErrParse) Error() string {
+	var file, rule string
+	if e.File != "" {
+		file = fmt.Sprintf(ErrParseInT, e.File)
+	}
+	if e.Rule != "" {
+		rule = fmt.Sprintf(ErrParseDefT, e.Rule)
+	}
+	return fmt.Sprintf(ErrParseT, file, e.Line, e.Col, rule, e.Text)
+}
+
+// newErrParse returns a new ErrParse from the position of the result.
+// If the result failed, the ending position (E) is used since that is
+// the farthest position reached unless nothing but space remains of
+// the line there (such as after an unterminated quote) in which case
+// the last token before it is used instead.
+func newErrParse(r rat.Result) ErrParse {
+	if r.X != nil {
+		r.B = r.E
+		if blank(r.R, r.B) {
+			for r.B > 0 && unicode.IsSpace(r.R[r.B-1]) {
+				r.B--
+			}
+			for r.B > 0 && !unicode.IsSpace(r.R[r.B-1]) {
+				r.B--
+			}
+			r.E = r.B
+		}
+	}
+	e := notation.NewErrParse(`PEGN`, r)
+	tok := r.B
+	for tok < len(r.R) && !unicode.IsSpace(r.R[tok]) {
+		tok++
+	}
+	return ErrParse{
+		Line: e.Line, Col: e.Col, Text: e.Text,
+		Token: string(r.R[r.B:tok]), Rule: defining(r.R, r.B),
+	}
+}
+
+// blank returns true if nothing but space remains of the line at i.
+func blank(r []rune, i int) bool {
+	for ; i < len(r) && r[i] != '\n'; i++ {
+		if !unicode.IsSpace(r[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// newErrParseFile is the same as newErrParse but sets the File.
+func newErrParseFile(r rat.Result, file string) ErrParse {
+	e := newErrParse(r)
+	e.File = file
+	return e
+}
+
+// defining returns the name of the definition containing the position
+// by looking back through any indented continuation lines for the line
+// beginning the definition. Returns an empty string if the position is
+// not within a definition.
+func defining(r []rune, i int) string {
+	if i > len(r) {
+		i = len(r)
+	}
+	for {
+		for i > 0 && r[i-1] != '\n' {
+			i--
+		}
+		if i == len(r) || !unicode.IsSpace(r[i]) || i == 0 {
+			break
+		}
+		i--
+	}
+	end := i
+	for end < len(r) && (unicode.IsLetter(r[end]) || unicode.IsDigit(r[end]) || r[end] == '_') {
+		end++
+	}
+	next := end
+	for next < len(r) && (r[next] == ' ' || r[next] == '\t') {
+		next++
+	}
+	rest := string(r[next:])
+	if end == i || !strings.HasPrefix(rest, `<-`) && !strings.HasPrefix(rest, `<=`) {
+		return ""
+	}
+	return string(r[i:end])
+}
+
+// ErrImportCycle is returned when a PEGN file imports itself either
+// directly or through other imported files. Files contains the
+// absolute paths of the cycle beginning and ending with the same file.
+type ErrImportCycle struct {
+	Files []string
+}
+
+func (e ErrImportCycle) Error() string {
+	return fmt.Sprintf(ErrImportCycleT, strings.Join(e.Files, ` -> `))
+}
+
+// ErrNoMain is returned when a Grammar has no Main rule (or one not
+// made from a rat/x expression) and therefore cannot be rendered.
+type ErrNoMain struct{}
+
+func (e ErrNoMain) Error() string { return ErrNoMainT }
+
+// ErrStruct is returned when a grammar cannot be derived from a struct
+// type (see StructPEGN) or a value is not a pointer to a struct (see
+// Unmarshal) for the given reason (Why). Field is the offending field
+// (if any).
+type ErrStruct struct {
+	Type  string
+	Field string
+	Why   string
+}
+
+func (e ErrStruct) Error() string {
+	name := e.Type
+	if e.Field != "" {
+		name += `.` + e.Field
+	}
+	return fmt.Sprintf(ErrStructT, name, e.Why)
+}
+
+// ErrMatch is returned by Unmarshal when the input does not match the
+// grammar derived from the struct. Line and Col (both starting at 1)
+// are the location of the farthest failure (see rat.Grammar.Farthest).
+type ErrMatch struct {
+	Line int
+	Col  int
+	Text string // offending text (truncated to first line)
+}
+
+func (e ErrMatch) Error() string { return fmt.Sprintf(ErrMatchT, e.Line, e.Col, e.Text) }
+
+// ErrBind is returned by Unmarshal when the matched text of the named
+// result cannot be converted to the type of its field.
+type ErrBind struct {
+	Name string
+	Text string
+	Err  error
+}
+
+func (e ErrBind) Error() string { return fmt.Sprintf(ErrBindT, e.Name, e.Text, e.Err) }
+
+func (e ErrBind) Unwrap() error { return e.Err }
@@ -0,0 +1,422 @@
+package pegn_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode"
+
+	"github.com/rwxrob/rat/pegn"
+	"github.com/rwxrob/rat/x"
+)
+
+func ExampleParse() {
+
+	g, err := pegn.Parse(`
+# a greeting
+Greet <- ('hello' / 'hi') ' ' Name
+         '!'?
+Name  <= [a-z+
+`)
+	fmt.Println(g, err)
+
+	// unterminated quotes are reported where they begin
+	_, err = pegn.Parse("Greet <- 'hello\n")
+	fmt.Println(err)
+
+	g, err = pegn.Parse(`
+# a greeting
+Greet <= ('hello' / 'hi') ' ' Name
+         '!'?
+Name  <= (!' ' !'!' .)+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Print()
+	g.Rules[`Name`].Print()
+
+	g.Scan(`hi rob!`).Print()
+	g.Scan(`hello rob`).PrintText()
+	g.Scan(`howdy`).PrintError()
+
+	// Output:
+	// <nil> invalid PEGN at line 5, column 14 defining Name: "+"
+	// invalid PEGN at line 1, column 10 defining Greet: "'hello"
+	// x.N{"Greet", x.Seq{x.One{x.Str{"hello"}, x.Str{"hi"}}, x.Str{" "}, x.Ref{"Name"}, x.Mmx{0, 1, x.Str{"!"}}}}
+	// x.N{"Name", x.Mmx{1, -1, x.Seq{x.Not{x.Str{" "}}, x.Not{x.Str{"!"}}, x.Any{1}}}}
+	// {"N":"Greet","B":0,"E":7,"C":[{"B":0,"E":2,"C":[{"B":0,"E":2}]},{"B":2,"E":3},{"N":"Name","B":3,"E":6,"C":[{"B":3,"E":4,"C":[{"B":3,"E":3},{"B":3,"E":3},{"B":3,"E":4}]},{"B":4,"E":5,"C":[{"B":4,"E":4},{"B":4,"E":4},{"B":4,"E":5}]},{"B":5,"E":6,"C":[{"B":5,"E":5},{"B":5,"E":5},{"B":5,"E":6}]}]},{"B":6,"E":7,"C":[{"B":6,"E":7}]}],"R":"hi rob!"}
+	// hello rob
+	// expected: x.One{x.Str{"hello"}, x.Str{"hi"}}
+	// 1 | howdy
+	//   | ^
+
+}
+
+func ExampleFromX() {
+
+	fmt.Println(pegn.FromX(x.Seq{"foo", x.One{"bar", x.Rng{'a', 'f'}}, x.Mmx{0, -1, x.Not{'\n'}}}))
+	fmt.Println(pegn.FromX(x.Seq{x.Mmx{1, 3, x.Seq{"ab", x.Any{1}}}, x.To{"end"}, x.End{}}))
+	fmt.Println(pegn.FromX(x.See{x.Mmx{2, 2, x.Any{1}}}))
+	fmt.Println(pegn.FromX("it's\tdone"))
+	fmt.Println(pegn.FromX(x.N{`Fenced`, x.Seq{x.Sav{`Post`}, x.To{x.Val{`Post`}}, x.Val{`Post`}, x.N{`Post`, x.Mmx{3, 8, '`'}}}}))
+
+	// Output:
+	// 'foo' ('bar' / [a-f]) (!x0A)*
+	// ('ab' .){1,3} .. 'end' !.
+	// &.{2}
+	// 'it' x27 's' x09 'done'
+	// Fenced <= =Post .. $Post $Post Post
+	// Post <= '`'{3,8}
+
+}
+
+func ExampleParse_roundTrip() {
+
+	g, err := pegn.Parse(`
+# Greet is a friendly greeting
+#
+# (only hello and hi for now)
+Greet <- ('hello' / 'hi') ' ' Name '!'? # exclaim
+
+# not documentation
+
+Name <- (!' ' !'!' .)+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(g.PEGN())
+
+	// Output:
+	// # Greet is a friendly greeting
+	// #
+	// # (only hello and hi for now)
+	// Greet <- ('hello' / 'hi') ' ' Name '!'?
+	// Name  <- (!' ' !'!' .)+
+
+}
+
+func ExampleToString() {
+
+	s, err := pegn.ToString(pegn.FromString("it's\tdone\r\n"))
+	fmt.Printf("%q %v\n", s, err)
+
+	s, err = pegn.ToString(`'hello' SP u2563 x21 CRLF`)
+	fmt.Printf("%q %v\n", s, err)
+	fmt.Println(x.Str{s})
+
+	_, err = pegn.ToString(`'hello' xZZ`)
+	fmt.Println(err)
+
+	// Output:
+	// "it's\tdone\r\n" <nil>
+	// "hello ╣!\r\n" <nil>
+	// x.Str{"hello ╣!\r\n"}
+	// invalid PEGN at line 1, column 9: "xZZ"
+
+}
+
+func ExampleParseFile() {
+
+	g, err := pegn.ParseFile(`testdata/greet.pegn`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(g.PEGN())
+	g.Scan(`hi Rob!`).PrintText()
+
+	_, err = pegn.ParseFile(`testdata/cycle.pegn`)
+	var cycle pegn.ErrImportCycle
+	if errors.As(err, &cycle) {
+		for _, file := range cycle.Files {
+			fmt.Println(filepath.Base(file))
+		}
+	}
+
+	// Output:
+	// Greet <- ('hello' / 'hi') ' ' Name '!'?
+	// # shared rules
+	// Name  <- upper lower+
+	// hi Rob!
+	// cycle.pegn
+	// cycle2.pegn
+	// cycle.pegn
+
+}
+
+func ExampleParseFile_prefix() {
+
+	g, err := pegn.ParseFile(`testdata/account.pegn`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(g.PEGN())
+	for _, it := range g.Scan(`Rob:rob42`).WithName(`UserName`) {
+		fmt.Println(it.N, it.Text())
+	}
+
+	// Output:
+	// Account    <= CommonName ':' UserName
+	// # shared rules
+	// CommonName <- upper lower+
+	// # login names
+	// UserName   <= lower (lower / digit)*
+	// UserName rob42
+
+}
+
+func ExampleLint() {
+
+	findings, err := pegn.Lint(`
+Greet <- ('hi' / 'high' / 'hi') ' ' Name
+Name  <- upper lowr+
+Name  <- upper+
+Extra <- '!'? / 'x'
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, f := range findings {
+		fmt.Println(f.Kind, f.Name, f)
+	}
+
+	// Output:
+	// shadowed Greet 2:18: alternative "'high'" shadowed by earlier "'hi'"
+	// shadowed Greet 2:27: alternative "'hi'" shadowed by earlier "'hi'"
+	// undefined lowr 3:16: undefined rule: lowr
+	// duplicate Name 4:1: duplicate definition: Name
+	// unreachable Extra 5:1: unreachable rule: Extra
+	// shadowed Extra 5:17: alternative "'x'" shadowed by earlier "'!'?"
+
+}
+
+func ExampleFromString() {
+
+	fmt.Println(pegn.FromString(`Hello, World #42!`))
+	fmt.Println(pegn.FromString("Grüße ╣ 日本\t'quoted'\u2028"))
+	fmt.Println(pegn.FromString(``))
+
+	// Output:
+	// 'Hello, World #42!'
+	// 'Grüße ╣ 日本' x09 x27 'quoted' x27 u2028
+	// ''
+
+}
+
+func ExampleParse_runes() {
+
+	g, err := pegn.Parse(`
+Line <= [a-z]+ (SP / x09) [x41-x5A] u2563 b00100001 CR? LF
+SP   <- x20
+CR   <- x0D
+LF   <- x0A
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.Print()
+	fmt.Print(g.PEGN())
+	fmt.Printf("%q\n", g.Scan("abc\tZ╣!\r\n").Text())
+
+	_, err = pegn.Parse(`Bad <- [uD800-uDBFF]`)
+	fmt.Println(err)
+
+	// Output:
+	// x.N{"Line", x.Seq{x.Mmx{1, -1, x.Rng{'a', 'z'}}, x.One{x.Ref{"SP"}, x.Str{"\t"}}, x.Rng{'A', 'Z'}, x.Str{"╣!"}, x.Mmx{0, 1, x.Ref{"CR"}}, x.Ref{"LF"}}}
+	// Line <= [a-z]+ (SP / x09) [A-Z] '╣!' CR? LF
+	// SP   <- ' '
+	// CR   <- x0D
+	// LF   <- x0A
+	// "abc\tZ╣!\r\n"
+	// invalid PEGN at line 1, column 9 defining Bad: "uD800"
+
+}
+
+func ExampleGenerate() {
+
+	g, err := pegn.Parse(`
+# Greet is a friendly greeting
+Greet <= ('hello' / 'hi') ' ' Name '!'?
+type  <- 'x'
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := pegn.Generate(os.Stdout, g, `greet`, `greet.pegn`); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// // Code generated by rat/pegn from greet.pegn. DO NOT EDIT.
+	//
+	// package greet
+	//
+	// import (
+	// 	"github.com/rwxrob/rat"
+	// 	"github.com/rwxrob/rat/x"
+	// )
+	//
+	// // Greet is a friendly greeting
+	// var Greet = x.N{"Greet", x.Seq{x.One{x.Str{"hello"}, x.Str{"hi"}}, x.Str{" "}, x.Ref{"Name"}, x.Mmx{0, 1, x.Str{"!"}}}}
+	//
+	// var type_ = x.Str{"x"}
+	//
+	// var Name = x.N{"Name", x.Seq{x.Ref{"upper"}, x.Mmx{1, -1, x.Ref{"lower"}}}}
+	//
+	// // Grammar returns a new compiled Grammar with Greet as the Main rule.
+	// func Grammar() *rat.Grammar {
+	// 	g := new(rat.Grammar).Init()
+	// 	g.MakeAlias("type", type_)
+	// 	g.MakeRule(Name)
+	// 	return g.Pack(Greet)
+	// }
+
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func ExampleFromIsFunc() {
+
+	fmt.Println(pegn.FromIsFunc(isIdent))
+	fmt.Println(pegn.FromIsFunc(unicode.IsSpace))
+	fmt.Printf("%q\n", pegn.FromIsFunc(func(r rune) bool { return false }))
+
+	// Output:
+	// [0-9] / [A-Z] / '_' / [a-z]
+	// [x09-x0D] / ' ' / x85 / xA0 / u1680 / [u2000-u200A] / [u2028-u2029] / u202F / u205F / u3000
+	// ""
+
+}
+
+func ExampleRunTests() {
+
+	errs := pegn.RunTests(`
+Greet <- ('hello' / 'hi') ' ' Name '!'?
+Name  <- upper lower+
+
+@pass Greet 'hi Rob!'
+@pass Greet 'hello Rob'
+@fail Greet 'howdy'
+@pass Name  'rob'
+@fail Name  'Rob!'
+@fail Greet 'hi Rob' x21
+@pass Nope  'nope'
+`)
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 8:1: Name should match "rob" (expected: x.Rng{'A', 'Z'})
+	// 10:1: Greet should not match "hi Rob!"
+	// 11:1: Nope should match "nope" (undefined rule: Nope)
+
+}
+
+func ExampleErrParse() {
+
+	_, err := pegn.Parse(`
+Greet <- ('hello' / 'hi') ' '
+         Name %'!'
+Name  <- upper lower+
+`)
+	var e pegn.ErrParse
+	if errors.As(err, &e) {
+		fmt.Println(e.Line, e.Col, e.Rule, e.Token)
+	}
+	fmt.Println(err)
+
+	// Output:
+	// 3 15 Greet %'!'
+	// invalid PEGN at line 3, column 15 defining Greet: "%'!'"
+
+}
+
+func ExampleEqual() {
+
+	same, _ := pegn.Equal(
+		"Greet <= ('hi' / 'hello') ' ' Name\nName <= [a-z]+\n",
+		"Greet <= ('hi' / ('hello')) (' ' Name)\nName <= [a-z]+\n",
+	)
+	fmt.Println(same)
+
+	same, _ = pegn.Equal(
+		"Greet <= ('hi' / 'hello') ' ' Name\nName <= [a-z]+\n",
+		"Greet <= ('hello' / 'hi') ' ' Name\nName <= [a-z]+\n",
+	)
+	fmt.Println(same)
+
+	// Output:
+	// true
+	// false
+
+}
+
+type Person struct {
+	Name  string   `rat:"upper lower+"`
+	_     struct{} `rat:"', '"`
+	Age   int      `rat:"digit+"`
+	Email *Email
+	Tags  []Tag
+}
+
+type Email struct {
+	_      struct{} `rat:"' <'"`
+	User   string   `rat:"(!'@' visible)+"`
+	_      struct{} `rat:"'@'"`
+	Domain string   `rat:"(!'>' visible)+"`
+	_      struct{} `rat:"'>'"`
+}
+
+type Tag struct {
+	_    struct{} `rat:"' #'"`
+	Name string   `rat:"lower+"`
+}
+
+func ExampleStructPEGN() {
+	fmt.Print(pegn.StructPEGN(Person{}))
+	// Output:
+	// Person <= PersonName (', ') PersonAge Email? Tag*
+	// PersonName <= upper lower+
+	// PersonAge <= digit+
+	// Email <= (' <') EmailUser ('@') EmailDomain ('>')
+	// EmailUser <= (!'@' visible)+
+	// EmailDomain <= (!'>' visible)+
+	// Tag <= (' #') TagName
+	// TagName <= lower+
+	// <nil>
+}
+
+func ExampleUnmarshal() {
+
+	var p Person
+	fmt.Println(pegn.Unmarshal(`Rob, 42 <rob@rwx.gg> #go #peg`, &p))
+	fmt.Println(p.Name, p.Age, p.Email.User, p.Email.Domain, p.Tags[0].Name, p.Tags[1].Name)
+
+	var q Person
+	fmt.Println(pegn.Unmarshal(`Rob, 42`, &q))
+	fmt.Println(q.Name, q.Age, q.Email, q.Tags)
+
+	fmt.Println(pegn.Unmarshal(`Rob, 4x2`, &q))
+	fmt.Println(pegn.Unmarshal(`Rob, 99999999999999999999`, &q))
+
+	// Output:
+	// <nil>
+	// Rob 42 rob rwx.gg go peg
+	// <nil>
+	// Rob 42 <nil> []
+	// input does not match at line 1, column 7: "x2"
+	// cannot bind PersonAge from "99999999999999999999": strconv.ParseInt: parsing "99999999999999999999": value out of range
+}
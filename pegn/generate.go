@@ -0,0 +1,120 @@
+package pegn
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// GenerateFile reads the PEGN grammar file at path (see ParseFile) and
+// writes the Go source generated from it (see Generate) for the named
+// package into a file of the same name with a .go suffix (greet.pegn
+// becomes greet.pegn.go). This makes it easy to compile .pegn files as
+// part of go:generate with a small program calling it (such as
+// cmd/ratgen).
+func GenerateFile(path, pkg string) error {
+	g, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := Generate(buf, g, pkg, filepath.Base(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path+`.go`, buf.Bytes(), 0644)
+}
+
+// Generate writes formatted Go source for the named package to w
+// containing a variable with the rat/x definition of every named rule
+// and alias (see rat.Grammar.MakeAlias) in the Grammar (in the same
+// order and with the same documentation as Grammar.PEGN) and a Grammar
+// function returning a newly compiled *rat.Grammar with the same Main
+// rule. Names that are not valid Go
+// identifiers (keywords) have an underscore appended. The source
+// (usually a file name) is noted in the generated header comment. An
+// ErrNoMain is returned if the Grammar has no Main rule made from
+// a rat/x expression.
+func Generate(w io.Writer, g *rat.Grammar, pkg, source string) error {
+	if g.Main == nil || g.Main.Expr == nil {
+		return ErrNoMain{}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by rat/pegn from %v. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(buf, "package %v\n\n", pkg)
+	buf.WriteString("import (\n\t\"github.com/rwxrob/rat\"\n\t\"github.com/rwxrob/rat/x\"\n)\n\n")
+
+	main := rat.DefaultMainName
+	if _, is := g.Main.Expr.(x.N); is || g.Main.IsAlias() {
+		main = g.Main.Name
+	}
+
+	var makes []string
+	define := func(name string, it any, doc string) {
+		name = goName(name)
+		if doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				buf.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+			}
+		}
+		fmt.Fprintf(buf, "var %v = %v\n\n", name, x.String(it))
+	}
+
+	define(main, g.Main.Expr, g.Main.Doc)
+	for _, rule := range g.Ordered() {
+		if rule == g.Main {
+			continue
+		}
+		if rule.IsAlias() {
+			define(rule.Name, rule.Expr, rule.Doc)
+			makes = append(makes, fmt.Sprintf("g.MakeAlias(%q, %v)", rule.Name, goName(rule.Name)))
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		define(rule.Name, rule.Expr, rule.Doc)
+		makes = append(makes, fmt.Sprintf("g.MakeRule(%v)", goName(rule.Name)))
+	}
+
+	fmt.Fprintf(buf, "// Grammar returns a new compiled Grammar with %v as the Main rule.\n", goName(main))
+	buf.WriteString("func Grammar() *rat.Grammar {\n\tg := new(rat.Grammar).Init()\n")
+	for _, it := range makes {
+		fmt.Fprintf(buf, "\t%v\n", it)
+	}
+	if g.Main.IsAlias() {
+		fmt.Fprintf(buf, "\tg.Main = g.MakeAlias(%q, %v)\n\treturn g\n}\n", main, goName(main))
+	} else {
+		fmt.Fprintf(buf, "\treturn g.Pack(%v)\n}\n", goName(main))
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goName returns the name with an underscore appended if it is a Go
+// keyword or would collide with the generated Grammar function or
+// imported packages.
+func goName(name string) string {
+	switch {
+	case token.IsKeyword(name), name == `Grammar`, name == `rat`, name == `x`:
+		return name + `_`
+	}
+	return name
+}
@@ -0,0 +1,189 @@
+package pegn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// LintKind identifies the kind of problem reported by Lint.
+type LintKind int
+
+const (
+	LintUndefined   LintKind = iota + 1 // reference to undefined rule
+	LintDuplicate                       // rule defined more than once
+	LintUnreachable                     // rule never referenced from Main
+	LintShadowed                        // alternative that can never match
+)
+
+func (k LintKind) String() string {
+	switch k {
+	case LintUndefined:
+		return `undefined`
+	case LintDuplicate:
+		return `duplicate`
+	case LintUnreachable:
+		return `unreachable`
+	case LintShadowed:
+		return `shadowed`
+	}
+	return fmt.Sprintf(`LintKind(%d)`, int(k))
+}
+
+// Finding is a single problem reported by Lint. Line and Col (both
+// starting at 1) are the location of the offending PEGN. Name is the
+// name of the rule involved.
+type Finding struct {
+	Line int
+	Col  int
+	Kind LintKind
+	Name string
+	Text string // human readable description
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf(`%v:%v: %v`, f.Line, f.Col, f.Text)
+}
+
+// Lint parses a PEGN grammar document (see Parse) and returns any
+// semantic problems found sorted by position:
+//
+//   - references to rules that are not defined (or built-in classes)
+//   - rules defined more than once
+//   - rules never reachable from the first (Main) rule
+//   - alternatives that can never match because an earlier alternative
+//     always matches first (an earlier literal prefix, an earlier
+//     optional rule, or an identical earlier alternative)
+//
+// Imports are not followed so rules defined only in imported files
+// are reported as undefined. An ErrParse is returned if the document
+// is not valid PEGN.
+func Lint(in any) ([]Finding, error) {
+	res, err := scan(in, ``)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	add := func(r rat.Result, kind LintKind, name, format string, args ...any) {
+		line, col := r.Pos()
+		findings = append(findings, Finding{line, col, kind, name, fmt.Sprintf(format, args...)})
+	}
+
+	defs := res.Children(`Definition`)
+	defined := map[string]bool{}
+	refs := map[string][]string{}
+
+	for _, def := range defs {
+		name, _ := def.Child(`Name`)
+		if defined[name.Text()] {
+			add(name, LintDuplicate, name.Text(), LintDuplicateT, name.Text())
+		}
+		defined[name.Text()] = true
+	}
+
+	for _, def := range defs {
+		name, _ := def.Child(`Name`)
+		for _, ref := range def.WithName(`Ref`) {
+			it := ref.Text()
+			refs[name.Text()] = append(refs[name.Text()], it)
+			if _, class := x.Classes[it]; !defined[it] && !class {
+				add(ref, LintUndefined, it, LintUndefinedT, it)
+			}
+		}
+		for _, exp := range def.WithName(`Expression`) {
+			lintShadowed(exp, name.Text(), add)
+		}
+	}
+
+	if len(defs) > 0 {
+		main, _ := defs[0].Child(`Name`)
+		reached := map[string]bool{}
+		var reach func(name string)
+		reach = func(name string) {
+			if reached[name] {
+				return
+			}
+			reached[name] = true
+			for _, it := range refs[name] {
+				reach(it)
+			}
+		}
+		reach(main.Text())
+		for _, def := range defs[1:] {
+			name, _ := def.Child(`Name`)
+			if !reached[name.Text()] {
+				add(name, LintUnreachable, name.Text(), LintUnreachableT, name.Text())
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line == findings[j].Line {
+			return findings[i].Col < findings[j].Col
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings, nil
+}
+
+// lintShadowed adds a finding for every alternative (Sequence) of the
+// Expression that can never match because of an earlier alternative.
+func lintShadowed(exp rat.Result, name string, add func(rat.Result, LintKind, string, string, ...any)) {
+	seqs := exp.Children(`Sequence`)
+	its := make([]any, len(seqs))
+	for n, seq := range seqs {
+		its[n], _ = fromResult(seq)
+	}
+	for n := 1; n < len(seqs); n++ {
+		for m := 0; m < n; m++ {
+			if shadows(its[m], its[n]) {
+				add(seqs[n], LintShadowed, name, LintShadowedT, seqs[n].Text(), seqs[m].Text())
+				break
+			}
+		}
+	}
+}
+
+// shadows returns true if the earlier alternative always matches
+// whenever the later one would (making the later one unreachable).
+func shadows(earlier, later any) bool {
+	if earlier == nil || later == nil {
+		return false
+	}
+	if x.String(earlier) == x.String(later) {
+		return true
+	}
+	if m, is := earlier.(x.Mmx); is && len(m) == 3 && m[0] == 0 {
+		return true
+	}
+	e, is := earlier.(string)
+	if !is {
+		return false
+	}
+	return strings.HasPrefix(leadingStr(later), e)
+}
+
+// leadingStr returns the literal string with which the expression must
+// begin (if any).
+func leadingStr(it any) string {
+	switch v := it.(type) {
+	case string:
+		return v
+	case x.Seq:
+		var s string
+		for _, i := range v {
+			str, is := i.(string)
+			if !is {
+				break
+			}
+			s += str
+		}
+		return s
+	}
+	return ""
+}
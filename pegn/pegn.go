@@ -0,0 +1,558 @@
+/*
+Package pegn implements the Parsing Expression Grammar Notation (PEGN)
+for rat by parsing PEGN grammar documents into fully compiled
+*rat.Grammar instances. The PEGN parser itself is written entirely in
+rat/x and compiled into a rat.Grammar.
+
+Every definition is a line beginning with the rule name followed by the
+definition operator and the expression. Expressions may be continued
+onto subsequent lines as long as those lines are indented. Lines
+beginning with # are comments. The first definition becomes the Main
+rule of the Grammar. Rules may be referenced before they are defined.
+Comment lines directly preceding a definition become the Doc of its
+rule (see rat.Rule).
+
+	# a comment
+	Fenced   <- =FenceTok .. $FenceTok
+	FenceTok <- '`'{3,8}
+	Greet    <- ('hello' / 'hi') ' ' Name
+	            '!'?
+
+Other PEGN documents may be imported (at the beginning of a line) with
+the @import directive followed by the quoted path of the file. Relative
+paths are resolved from the directory of the importing file (or the
+current working directory for documents not read from a file). The
+definitions of the imported file are added in place of the directive
+(but never become the Main rule unless the importing document has no
+definitions of its own). Files imported more than once are
+only added once and import cycles are reported as an ErrImportCycle.
+Adding a prefix (as) gives every definition of the imported file (and
+every reference to one) a namespace so that files defining rules of the
+same name can be imported together (see rat.Grammar.Import).
+
+	@import 'common.pegn'
+	@import 'json.pegn' as Json
+
+Example inputs expected to match (@pass) or not match (@fail) the
+entirety of a given rule may be embedded (at the beginning of a line)
+as executable specifications (see Tests and RunTests). The input is
+a PEGN string expression (see ToString) and is ignored by Parse.
+
+	@pass Greet 'hi Rob!'
+	@fail Greet 'howdy' x0A
+
+The standard PEGN classes (alpha, digit, ws, visible, etc.) may be
+referenced by any grammar without being defined (see x.Classes).
+
+Grammars for simple record formats may also be derived from the PEGN
+expressions in the rat tags of struct fields and the input scanned
+directly into the struct (see StructPEGN and Unmarshal).
+
+The following PEGN expressions are supported (see rat/x for the
+equivalent types):
+
+	Foo <= rule      x.N{"Foo", rule}
+	Foo <- rule      rule (see rat.Grammar.MakeAlias)
+	=Foo             x.Sav{"Foo"}
+	$Foo             x.Val{"Foo"}
+	Foo              x.Ref{"Foo"}
+	rule1 rule2      x.Seq{rule1, rule2}
+	rule1 / rule2    x.One{rule1, rule2}
+	'foo'            x.Str{"foo"}
+	x0A              x.Str{"\n"} (hexadecimal)
+	u2563            x.Str{"╣"} (unicode)
+	b1010            x.Str{"\n"} (binary)
+	[a-f]            x.Rng{'a', 'f'}
+	[x43-x54]        x.Rng{'C', 'T'}
+	rule?            x.Mmx{0, 1, rule}
+	rule*            x.Mmx{0, -1, rule}
+	rule+            x.Mmx{1, -1, rule}
+	rule{n}          x.Mmx{n, n, rule}
+	rule{m,}         x.Mmx{m, -1, rule}
+	rule{m,n}        x.Mmx{m, n, rule}
+	rule{,n}         x.Mmx{0, n, rule}
+	&rule            x.See{rule}
+	!rule            x.Not{rule}
+	.. rule          x.To{rule}
+	.                x.Any{1}
+	!.               x.End{}
+*/
+package pegn
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/internal/notation"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	ws      = x.One{' ', '\t'}
+	eol     = x.One{"\r\n", '\n'}
+	gap     = x.Mmx{1, -1, x.One{ws, x.Seq{eol, x.See{ws}}}}
+	comment = x.N{`Comment`, x.Seq{'#', x.Mmx{0, -1, x.Seq{x.Not{eol}, x.Any{1}}}}}
+	spacing = x.Mmx{0, -1, x.One{ws, eol, comment}}
+	letter  = x.One{x.Rng{'a', 'z'}, x.Rng{'A', 'Z'}}
+	digit   = x.Rng{'0', '9'}
+	hexdig  = x.One{digit, x.Rng{'a', 'f'}, x.Rng{'A', 'F'}}
+	ident   = x.Seq{letter, x.Mmx{0, -1, x.One{letter, digit, '_'}}}
+	defop   = x.One{"<=", "<-"}
+	quoted  = x.Seq{'\'', x.Mmx{1, -1, x.Seq{x.Not{x.One{'\'', eol}}, x.Any{1}}}, '\''}
+	runelit = x.N{`Rune`, x.Seq{
+		x.One{
+			x.Seq{'x', x.Mmx{1, -1, hexdig}},
+			x.Seq{'u', x.Mmx{1, -1, hexdig}},
+			x.Seq{'b', x.Mmx{1, -1, x.One{'0', '1'}}},
+		},
+		x.Not{x.One{letter, digit, '_'}},
+	}}
+)
+
+// syntax is the PEGN grammar itself expressed in rat/x (see
+// notation.Parser).
+var syntax = []any{
+	x.N{`Grammar`, x.Seq{
+		spacing,
+		x.Mmx{0, -1, x.Seq{x.One{x.Ref{`Import`}, x.Ref{`Test`}, x.Ref{`Definition`}}, spacing}},
+		x.End{},
+	}},
+	x.N{`Import`, x.Seq{
+		"@import", x.Mmx{1, -1, ws}, x.N{`Path`, quoted},
+		x.Mmx{0, 1, x.Seq{x.Mmx{1, -1, ws}, "as", x.Mmx{1, -1, ws}, x.N{`Prefix`, ident}}},
+	}},
+	x.N{`Test`, x.Seq{
+		'@', x.N{`Expect`, x.One{"pass", "fail"}}, x.Mmx{1, -1, ws},
+		x.N{`Name`, ident}, x.Mmx{1, -1, ws},
+		x.N{`Input`, x.Mmx{1, -1, x.Seq{x.Not{eol}, x.Any{1}}}},
+	}},
+	x.N{`Definition`, x.Seq{
+		x.N{`Name`, ident}, x.Mmx{0, -1, ws},
+		x.N{`Op`, defop}, x.Mmx{0, 1, gap},
+		x.Ref{`Expression`},
+	}},
+	x.N{`Expression`, x.Seq{
+		x.Ref{`Sequence`},
+		x.Mmx{0, -1, x.Seq{x.Mmx{0, 1, gap}, '/', x.Mmx{0, 1, gap}, x.Ref{`Sequence`}}},
+	}},
+	x.N{`Sequence`, x.Seq{
+		x.Ref{`Rule`},
+		x.Mmx{0, -1, x.Seq{gap, x.Ref{`Rule`}}},
+	}},
+	x.N{`Rule`, x.One{
+		x.Seq{
+			x.N{`Prefix`, x.One{"..", '&', '!', '=', '$'}},
+			x.Mmx{0, -1, ws}, x.Ref{`Rule`},
+		},
+		x.Seq{x.Ref{`Primary`}, x.Mmx{0, 1, x.Ref{`Quant`}}},
+	}},
+	x.N{`Primary`, x.One{
+		x.Seq{'(', x.Mmx{0, 1, gap}, x.Ref{`Expression`}, x.Mmx{0, 1, gap}, ')'},
+		x.N{`Str`, quoted},
+		x.N{`Range`, x.Seq{'[', x.Ref{`Endpoint`}, '-', x.Ref{`Endpoint`}, ']'}},
+		runelit,
+		x.N{`Any`, '.'},
+		x.N{`Ref`, x.Seq{ident, x.Not{x.Seq{x.Mmx{0, -1, ws}, defop}}}},
+	}},
+	x.N{`Endpoint`, x.One{
+		runelit,
+		x.N{`Char`, x.Seq{x.Not{x.One{']', ws, eol}}, x.Any{1}}},
+	}},
+	x.N{`Quant`, x.One{'?', '*', '+',
+		x.Seq{'{', x.Mmx{0, -1, digit}, x.Mmx{0, 1, x.Seq{',', x.Mmx{0, -1, digit}}}, '}'},
+	}},
+}
+
+// scan parses the PEGN document (read from file, if any) returning an
+// ErrParse for the farthest failure if it is not valid PEGN.
+func scan(in any, file string) (rat.Result, error) {
+	res := notation.Scan(syntax, in)
+	res.SetSource(file)
+	if res.X != nil {
+		return res, newErrParseFile(res, file)
+	}
+	return res, nil
+}
+
+// Parse parses a PEGN grammar document (string, []byte, []rune, or
+// io.Reader) into a new compiled *rat.Grammar with a named rule (x.N)
+// for every significant (<=) definition and an alias (see
+// rat.Grammar.MakeAlias) for every insignificant (<-) definition so
+// that only results of significant definitions are named (captured).
+// The first definition is the Main rule. An ErrParse is returned if
+// the document is not valid PEGN. Any imported files are resolved from
+// the current working directory.
+func Parse(in any) (*rat.Grammar, error) {
+	g := new(rat.Grammar).Init()
+	if err := decode(g, in); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func init() {
+	rat.DecodePEGN = func(g *rat.Grammar, doc []byte) error { return decode(g, doc) }
+}
+
+// decode adds every definition of the PEGN document to the grammar (see
+// Parse) setting its Main rule to the first (if not set by the document)
+// so that rat.Grammar.UnmarshalText can make grammars (see
+// rat.DecodePEGN).
+func decode(g *rat.Grammar, in any) error {
+	p := newImporter(g)
+	if err := p.parse(in, ``, ``); err != nil {
+		return err
+	}
+	if g.Main == nil {
+		g.Main = p.first
+	}
+	return nil
+}
+
+// ParseFile is the same as Parse but reads the PEGN document from the
+// file at path. Any imported files are resolved from the directory
+// containing it and any ErrParse includes the File in which it occurred.
+func ParseFile(path string) (*rat.Grammar, error) {
+	g := new(rat.Grammar).Init()
+	p := newImporter(g)
+	if err := p.file(path, ``, ``); err != nil {
+		return nil, err
+	}
+	if g.Main == nil {
+		g.Main = p.first
+	}
+	return g, nil
+}
+
+// Equal parses both PEGN grammar documents (see Parse) and returns true
+// if they are semantically equivalent (see rat.GrammarEqual) even when
+// their definitions are ordered, grouped, or split differently. An
+// ErrParse is returned if either is not valid PEGN.
+func Equal(a, b any) (bool, error) {
+	ga, err := Parse(a)
+	if err != nil {
+		return false, err
+	}
+	gb, err := Parse(b)
+	if err != nil {
+		return false, err
+	}
+	return rat.GrammarEqual(ga, gb), nil
+}
+
+// importer tracks the files being (stack) and already (done) imported
+// into a single Grammar along with the first rule defined and the
+// tests embedded in any of them.
+type importer struct {
+	g     *rat.Grammar
+	stack []string
+	done  map[string]bool
+	depth int
+	first *rat.Rule
+	tests []Test
+}
+
+func newImporter(g *rat.Grammar) *importer {
+	return &importer{g: g, done: map[string]bool{}}
+}
+
+// file adds the definitions of the PEGN file at path (relative to dir)
+// with every name prefixed (if any) unless already added.
+func (p *importer) file(path, dir, prefix string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for n, it := range p.stack {
+		if it == path {
+			return ErrImportCycle{append(p.stack[n:], path)}
+		}
+	}
+	if p.done[prefix+` `+path] {
+		return nil
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p.stack = append(p.stack, path)
+	if prefix == `` {
+		err = p.parse(buf, path, filepath.Dir(path))
+	} else {
+		err = p.prefixed(buf, path, prefix)
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	p.done[prefix+` `+path] = true
+	return err
+}
+
+// prefixed adds the definitions of the PEGN file (see parse) with every
+// name prefixed (see rat.Grammar.Import) including those of its tests.
+func (p *importer) prefixed(buf []byte, path, prefix string) error {
+	sub := &importer{g: new(rat.Grammar).Init(), stack: p.stack, done: map[string]bool{}, depth: 1}
+	if err := sub.parse(buf, path, filepath.Dir(path)); err != nil {
+		return err
+	}
+	var names map[string]string
+	if err := p.g.Try(func() { names = p.g.Import(sub.g, prefix) }); err != nil {
+		return err
+	}
+	for _, t := range sub.tests {
+		if name, has := names[t.Rule]; has {
+			t.Rule = name
+		}
+		p.tests = append(p.tests, t)
+	}
+	if p.first == nil && sub.first != nil {
+		p.first = p.g.Rules[names[sub.first.Name]]
+	}
+	return nil
+}
+
+// parse adds the definitions of the PEGN document (read from file, if
+// any) resolving any imports relative to dir. The first definition of
+// the first document parsed becomes the Main rule.
+func (p *importer) parse(in any, file, dir string) error {
+	main := p.depth == 0
+	p.depth++
+	defer func() { p.depth-- }()
+
+	res, err := scan(in, file)
+	if err != nil {
+		return err
+	}
+
+	var doc []string
+	var last int // line of last comment or definition end
+
+	for _, c := range res.C {
+		line, _ := c.Pos()
+		switch c.N {
+
+		case `Comment`:
+			if line != last+1 {
+				doc = doc[:0]
+			}
+			if line != last || len(doc) > 0 {
+				text := strings.TrimPrefix(c.Text()[1:], ` `)
+				doc = append(doc, strings.TrimRight(text, " \t\r"))
+			}
+			last = line
+
+		case `Import`:
+			path, _ := c.Child(`Path`)
+			prefix, _ := c.Child(`Prefix`)
+			text := path.Text()
+			if err := p.file(text[1:len(text)-1], dir, prefix.Text()); err != nil {
+				return err
+			}
+			doc = doc[:0]
+
+		case `Test`:
+			expect, _ := c.Child(`Expect`)
+			name, _ := c.Child(`Name`)
+			input, _ := c.Child(`Input`)
+			text, err := ToString(strings.TrimRight(input.Text(), " \t\r"))
+			if err != nil {
+				return newErrParseFile(input, file)
+			}
+			p.tests = append(p.tests, Test{
+				File: file, Line: line, Col: 1, Rule: name.Text(),
+				Input: text, Match: expect.Text() == `pass`,
+			})
+			doc = doc[:0]
+
+		case `Definition`:
+			name, _ := c.Child(`Name`)
+			op, _ := c.Child(`Op`)
+			exp, _ := c.Child(`Expression`)
+			it, err := fromResult(exp)
+			if err != nil {
+				if e, is := err.(ErrParse); is {
+					e.File = file
+					return e
+				}
+				return err
+			}
+			var rule *rat.Rule
+			if op.Text() == `<-` {
+				rule = p.g.MakeAlias(name.Text(), it)
+			} else {
+				rule = p.g.MakeRule(x.N{name.Text(), it})
+			}
+			if len(doc) > 0 && line == last+1 {
+				rule.Doc = strings.Join(doc, "\n")
+			}
+			doc = doc[:0]
+			last, _ = rat.Result{R: c.R, B: c.E}.Pos()
+			if p.first == nil {
+				p.first = rule
+			}
+			if main {
+				p.g.Main = rule
+				main = false
+			}
+
+		}
+	}
+
+	return nil
+}
+
+// fromResult converts a named result from the PEGN syntax into the
+// equivalent rat/x expression.
+func fromResult(r rat.Result) (any, error) {
+
+	switch r.N {
+
+	case `Expression`, `Sequence`:
+		var list []any
+		for _, c := range r.C {
+			it, err := fromResult(c)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, it)
+		}
+		if len(list) == 1 {
+			return list[0], nil
+		}
+		if r.N == `Expression` {
+			return x.One(list), nil
+		}
+		return x.Seq(list), nil
+
+	case `Str`:
+		text := r.Text()
+		return text[1 : len(text)-1], nil
+
+	case `Any`:
+		return x.Any{1}, nil
+
+	case `Rune`, `Char`:
+		r, err := endpoint(r)
+		if err != nil {
+			return nil, err
+		}
+		return string(r), nil
+
+	case `Range`:
+		if len(r.C) != 2 || len(r.C[0].C) != 1 || len(r.C[1].C) != 1 {
+			return nil, newErrParse(r)
+		}
+		from, err := endpoint(r.C[0].C[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := endpoint(r.C[1].C[0])
+		if err != nil {
+			return nil, err
+		}
+		if to < from {
+			return nil, newErrParse(r)
+		}
+		return x.Rng{from, to}, nil
+
+	case `Ref`:
+		return x.Ref{r.Text()}, nil
+
+	case `Primary`:
+		if len(r.C) != 1 {
+			return nil, newErrParse(r)
+		}
+		return fromResult(r.C[0])
+
+	case `Rule`:
+		if len(r.C) > 0 && r.C[0].N == `Primary` {
+			it, err := fromResult(r.C[0])
+			if err != nil || len(r.C) == 1 {
+				return it, err
+			}
+			return quantify(it, r.C[1].Text(), r)
+		}
+		if len(r.C) != 2 {
+			return nil, newErrParse(r)
+		}
+		it, err := fromResult(r.C[1])
+		if err != nil {
+			return nil, err
+		}
+		switch prefix := r.C[0].Text(); prefix {
+		case `&`:
+			return x.See{it}, nil
+		case `!`:
+			if a, is := it.(x.Any); is && len(a) == 1 && a[0] == 1 {
+				return x.End{}, nil
+			}
+			return x.Not{it}, nil
+		case `..`:
+			return x.To{it}, nil
+		case `=`, `$`:
+			ref, is := it.(x.Ref)
+			if !is {
+				return nil, newErrParse(r)
+			}
+			if prefix == `=` {
+				return x.Sav{ref[0]}, nil
+			}
+			return x.Val{ref[0]}, nil
+		}
+		return it, nil
+
+	}
+
+	return nil, newErrParse(r)
+}
+
+// endpoint returns the rune for a Rune literal or single Char ensuring
+// that it is a valid Unicode code point (not a surrogate half).
+func endpoint(r rat.Result) (rune, error) {
+	if r.N == `Char` {
+		return r.R[r.B], nil
+	}
+	it, err := parseRune(r.Text())
+	if err != nil || !utf8.ValidRune(it) {
+		return 0, newErrParse(r)
+	}
+	return it, nil
+}
+
+// quantify wraps the expression in the x.Mmx matching the PEGN
+// quantifier.
+func quantify(it any, quant string, r rat.Result) (any, error) {
+	switch quant {
+	case `?`:
+		return x.Mmx{0, 1, it}, nil
+	case `*`:
+		return x.Mmx{0, -1, it}, nil
+	case `+`:
+		return x.Mmx{1, -1, it}, nil
+	}
+	m, n, comma := strings.Cut(quant[1:len(quant)-1], `,`)
+	min, max := 0, -1
+	var err error
+	if m != "" {
+		if min, err = strconv.Atoi(m); err != nil {
+			return nil, newErrParse(r)
+		}
+	}
+	switch {
+	case !comma:
+		max = min
+	case n != "":
+		if max, err = strconv.Atoi(n); err != nil {
+			return nil, newErrParse(r)
+		}
+	}
+	if m == "" && !comma || max != -1 && max < min {
+		return nil, newErrParse(r)
+	}
+	return x.Mmx{min, max, it}, nil
+}
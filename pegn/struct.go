@@ -0,0 +1,265 @@
+package pegn
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rwxrob/rat"
+)
+
+// StructPEGN returns the PEGN grammar document derived from the struct
+// (or pointer to one) so that simple record formats can be defined
+// and bound to data in one place (see Unmarshal). The struct becomes
+// the first (Main) definition (named after its type) matching every
+// field with a rat tag in order. The tag of every field is a PEGN
+// expression:
+//
+//	type Pair struct {
+//		Key string   `rat:"lower+"`
+//		_   struct{} `rat:"'='"`
+//		Val int      `rat:"digit+"`
+//	}
+//
+// Exported fields of string, bool, integer, float, and
+// encoding.TextUnmarshaler types become significant definitions named
+// after the struct type and field (PairKey <= lower+). Fields named _
+// (which may appear more than once) are matched without being named or
+// bound (separators and such). Fields of struct types (without a tag)
+// become references to the definitions of their own types which are
+// appended. Pointer fields are optional (?) and slice fields are
+// repeated zero or more times (*). An ErrStruct is returned for
+// anything else.
+func StructPEGN(v any) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", ErrStruct{Type: reflect.TypeOf(v).String(), Why: StructNotStruct}
+	}
+	var defs []string
+	if err := structDefs(t, map[reflect.Type]bool{}, &defs); err != nil {
+		return "", err
+	}
+	return strings.Join(defs, "\n") + "\n", nil
+}
+
+// StructGrammar parses the PEGN derived from the struct (see
+// StructPEGN) into a new Grammar.
+func StructGrammar(v any) (*rat.Grammar, error) {
+	doc, err := StructPEGN(v)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(doc)
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// scalar returns true if the type can be bound from matched text.
+func scalar(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// structField returns the element type of the field (without pointer or
+// slice) and the PEGN quantifier for the pointer (?) or slice (*).
+func structField(t reflect.Type) (reflect.Type, string) {
+	switch {
+	case t.Kind() == reflect.Pointer:
+		return t.Elem(), `?`
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return t.Elem(), `*`
+	}
+	return t, ``
+}
+
+// structDefs appends the definitions of the struct type and those of
+// the struct types of its fields (once each) to defs.
+func structDefs(t reflect.Type, done map[reflect.Type]bool, defs *[]string) error {
+	done[t] = true
+	var items, fields []string
+	var nested []reflect.Type
+	for n := 0; n < t.NumField(); n++ {
+		f := t.Field(n)
+		tag, has := f.Tag.Lookup(`rat`)
+		ft, quant := structField(f.Type)
+
+		switch {
+
+		case f.Name == `_`:
+			if !has {
+				continue
+			}
+			items = append(items, `(`+tag+`)`)
+
+		case !f.IsExported():
+			continue
+
+		case has && scalar(ft):
+			name := t.Name() + f.Name
+			items = append(items, name+quant)
+			fields = append(fields, name+` <= `+tag)
+
+		case !has && ft.Kind() == reflect.Struct:
+			items = append(items, ft.Name()+quant)
+			if !done[ft] {
+				nested = append(nested, ft)
+				done[ft] = true
+			}
+
+		case !has:
+			continue
+
+		default:
+			return ErrStruct{Type: t.Name(), Field: f.Name, Why: StructBadField}
+		}
+	}
+	if len(items) == 0 {
+		return ErrStruct{Type: t.Name(), Why: StructNoFields}
+	}
+	*defs = append(*defs, t.Name()+` <= `+strings.Join(items, ` `))
+	*defs = append(*defs, fields...)
+	for _, nt := range nested {
+		if err := structDefs(nt, done, defs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unmarshal scans the input (string, []byte, []rune, or io.Reader)
+// with the grammar derived from the struct pointed to by v (see
+// StructPEGN) and sets every field from the matching results. An
+// ErrMatch is returned (for the farthest failure) if the input does not
+// match entirely and an ErrBind if matched text cannot be converted to
+// the type of its field (such as an integer out of range).
+func Unmarshal(in any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrStruct{Type: reflect.TypeOf(v).String(), Why: StructNotPointer}
+	}
+	g, err := StructGrammar(v)
+	if err != nil {
+		return err
+	}
+	g.NamedOnly = true
+	res := g.Scan(in)
+	if res.X == nil && res.E < len(res.R) {
+		res.B, res.X = res.E, ErrMatch{}
+	}
+	if res.X != nil {
+		if g.Farthest.X != nil && g.Farthest.E >= res.E {
+			res = g.Farthest
+		}
+		return newErrMatch(res)
+	}
+	return bind(res, rv.Elem())
+}
+
+// newErrMatch returns the ErrMatch for the failed result.
+func newErrMatch(r rat.Result) ErrMatch {
+	r.B = r.E
+	line, col := r.Pos()
+	end := r.B
+	for end < len(r.R) && r.R[end] != '\n' {
+		end++
+	}
+	return ErrMatch{Line: line, Col: col, Text: string(r.R[r.B:end])}
+}
+
+// bind sets the fields of the struct value from the children of its
+// result.
+func bind(res rat.Result, v reflect.Value) error {
+	t := v.Type()
+	for _, c := range res.C {
+		for n := 0; n < t.NumField(); n++ {
+			f := t.Field(n)
+			if f.Name == `_` || !f.IsExported() {
+				continue
+			}
+			ft, _ := structField(f.Type)
+			_, has := f.Tag.Lookup(`rat`)
+			var name string
+			switch {
+			case has && scalar(ft):
+				name = t.Name() + f.Name
+			case !has && ft.Kind() == reflect.Struct:
+				name = ft.Name()
+			}
+			if name != c.N {
+				continue
+			}
+			if err := bindField(c, v.Field(n), has); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// bindField sets (or appends to) the field from the result.
+func bindField(res rat.Result, field reflect.Value, text bool) error {
+	target := field
+	switch field.Kind() {
+	case reflect.Pointer:
+		field.Set(reflect.New(field.Type().Elem()))
+		target = field.Elem()
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			target = reflect.New(field.Type().Elem()).Elem()
+			defer func() { field.Set(reflect.Append(field, target)) }()
+		}
+	}
+	if !text {
+		return bind(res, target)
+	}
+	if u, is := target.Addr().Interface().(encoding.TextUnmarshaler); is {
+		if err := res.Decode(u); err != nil {
+			return ErrBind{res.N, res.Text(), err}
+		}
+		return nil
+	}
+	return setText(target, res.Text(), res.N)
+}
+
+// setText converts the text to the type of the value and sets it.
+func setText(v reflect.Value, s, name string) error {
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 0, v.Type().Bits())
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, v.Type().Bits())
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+	}
+	if err != nil {
+		return ErrBind{name, s, err}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package pegn
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+)
+
+// Test is an example input embedded in a PEGN document (see @pass and
+// @fail) that is expected to either match the entirety of the named
+// Rule or not. File (if any), Line, and Col are the location of the
+// Test in the PEGN document.
+type Test struct {
+	File  string
+	Line  int
+	Col   int
+	Rule  string
+	Input string
+	Match bool
+}
+
+// Run checks the Input against the Rule of the Grammar returning an
+// ErrTest if the Rule is not defined or the Input does not match (or
+// does match) as expected.
+func (t Test) Run(g *rat.Grammar) error {
+	rule, has := g.Rules[t.Rule]
+	if !has {
+		return ErrTest{t, fmt.Sprintf(LintUndefinedT, t.Rule)}
+	}
+	res := rule.Scan(t.Input)
+	matched := res.X == nil && res.E == len(res.R)
+	if matched != t.Match {
+		return ErrTest{t, fmt.Sprintf(`%v`, res.X)}
+	}
+	return nil
+}
+
+// Tests is the same as Parse but also returns every Test embedded in
+// the PEGN document (and any it imports) in the order found.
+func Tests(in any) (*rat.Grammar, []Test, error) {
+	g := new(rat.Grammar).Init()
+	p := newImporter(g)
+	if err := p.parse(in, ``, ``); err != nil {
+		return nil, nil, err
+	}
+	if g.Main == nil {
+		g.Main = p.first
+	}
+	return g, p.tests, nil
+}
+
+// RunTests parses the PEGN document (see Tests) and runs every Test
+// embedded within it returning the errors for all that failed. If the
+// document cannot be parsed the parse error is the only one returned.
+func RunTests(in any) []error {
+	g, tests, err := Tests(in)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, t := range tests {
+		if err := t.Run(g); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ErrTest is returned when a Test does not pass. Result is a short
+// description of what happened instead (usually the error returned
+// from the Rule when it should have matched).
+type ErrTest struct {
+	Test   Test
+	Result string
+}
+
+func (e ErrTest) Error() string {
+	loc := fmt.Sprintf(`%v:%v`, e.Test.Line, e.Test.Col)
+	if e.Test.File != "" {
+		loc = e.Test.File + `:` + loc
+	}
+	if e.Test.Match {
+		return fmt.Sprintf(ErrTestPassT, loc, e.Test.Rule, e.Test.Input, e.Result)
+	}
+	return fmt.Sprintf(ErrTestFailT, loc, e.Test.Rule, e.Test.Input)
+}
@@ -0,0 +1,30 @@
+package pegn
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrParseT       = `invalid PEGN%v at line %v, column %v%v: %q`
+	ErrParseInT     = ` in %v`
+	ErrParseDefT    = ` defining %v`
+	ErrImportCycleT = `PEGN import cycle: %v`
+	ErrNoMainT      = `grammar has no Main rule with a rat/x expression`
+	ErrTestPassT    = `%v: %v should match %q (%v)`
+	ErrTestFailT    = `%v: %v should not match %q`
+	ErrStructT      = `cannot derive grammar from %v: %v`
+	ErrMatchT       = `input does not match at line %v, column %v: %q`
+	ErrBindT        = `cannot bind %v from %q: %v`
+
+	LintUndefinedT   = `undefined rule: %v`
+	LintDuplicateT   = `duplicate definition: %v`
+	LintUnreachableT = `unreachable rule: %v`
+	LintShadowedT    = `alternative %q shadowed by earlier %q`
+)
+
+// reasons for ErrStruct
+const (
+	StructNotStruct  = `not a struct`
+	StructNotPointer = `not a pointer to a struct`
+	StructNoFields   = `no fields with rat tags`
+	StructBadField   = `unsupported field type`
+)
@@ -0,0 +1,133 @@
+package pegn
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// FromX renders any rat/x expression as canonical PEGN (see x.PEGN for
+// details). If the expression is an x.N then a complete definition is
+// returned followed by definitions for every nested x.N so that the
+// result can be passed to Parse to recreate the original expressions.
+func FromX(it any) string { return x.PEGN(it) }
+
+// FromString renders a Go string as the equivalent PEGN string
+// expression (see x.PEGNString).
+func FromString(s string) string { return x.PEGNString(s) }
+
+// Tokens maps the standard PEGN token names that may appear in PEGN
+// string expressions to the strings they represent (see ToString).
+var Tokens = map[string]string{
+	`TAB`:  "\t",
+	`LF`:   "\n",
+	`CR`:   "\r",
+	`CRLF`: "\r\n",
+	`SP`:   " ",
+	`SQ`:   `'`,
+	`DQ`:   `"`,
+	`BKSL`: `\`,
+}
+
+// ToString is the inverse of FromString and returns the Go string for
+// a PEGN string expression: any combination of single quoted literals
+// ('foo'), hexadecimal (x0A), unicode (u2563), or binary (b1010) rune
+// literals, and Tokens (SP, CRLF) separated by spaces. An ErrParse is returned if
+// any part is not recognized.
+func ToString(s string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+
+		case s[i] == ' ' || s[i] == '\t':
+			i++
+
+		case s[i] == '\'':
+			end := strings.IndexAny(s[i+1:], "'\n")
+			if end < 0 || s[i+1+end] != '\'' {
+				return "", ErrParse{Line: 1, Col: i + 1, Text: s[i:]}
+			}
+			buf.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+
+		default:
+			end := strings.IndexAny(s[i:], " \t")
+			if end < 0 {
+				end = len(s) - i
+			}
+			part := s[i : i+end]
+			if tok, has := Tokens[part]; has {
+				buf.WriteString(tok)
+				i += end
+				continue
+			}
+			r, err := parseRune(part)
+			if err != nil {
+				return "", ErrParse{Line: 1, Col: i + 1, Text: part}
+			}
+			buf.WriteRune(r)
+			i += end
+
+		}
+	}
+	return buf.String(), nil
+}
+
+// parseRune parses a single hexadecimal (x0A), unicode (u2563), or
+// binary (b1010) PEGN rune literal.
+func parseRune(s string) (rune, error) {
+	if len(s) < 2 {
+		return 0, strconv.ErrSyntax
+	}
+	base := 16
+	switch s[0] {
+	case 'x', 'u':
+	case 'b':
+		base = 2
+	default:
+		return 0, strconv.ErrSyntax
+	}
+	n, err := strconv.ParseUint(s[1:], base, 32)
+	if err != nil || n > unicode.MaxRune {
+		return 0, strconv.ErrSyntax
+	}
+	return rune(n), nil
+}
+
+// FromIsFunc renders a rune class function (see x.Is) as the equivalent
+// PEGN by enumerating every valid Unicode code point and rendering each
+// contiguous range of runes for which it is true as a range ([a-z]) or
+// single rune literal ('_') combined as alternatives. An empty string
+// is returned if the function is never true.
+func FromIsFunc(f func(r rune) bool) string {
+	var one x.One
+	add := func(from, to rune) {
+		if from == to {
+			one = append(one, string(from))
+			return
+		}
+		one = append(one, x.Rng{from, to})
+	}
+	from := rune(-1)
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if (r < 0xD800 || r > 0xDFFF) && f(r) {
+			if from < 0 {
+				from = r
+			}
+			continue
+		}
+		if from >= 0 {
+			add(from, r-1)
+			from = -1
+		}
+	}
+	if from >= 0 {
+		add(from, unicode.MaxRune)
+	}
+	if len(one) == 0 {
+		return ""
+	}
+	return x.PEGNExpr(one)
+}
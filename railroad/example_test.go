@@ -0,0 +1,64 @@
+package railroad_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/rwxrob/rat/pegn"
+	"github.com/rwxrob/rat/railroad"
+	"github.com/rwxrob/rat/x"
+)
+
+func ExampleSVG() {
+	fmt.Print(railroad.SVG(x.One{"hi", x.Ref{"Name"}}))
+	// Output:
+	// <svg class="railroad" xmlns="http://www.w3.org/2000/svg" width="152" height="78" viewBox="0 0 152 78">
+	// <path d="M10 12 v20 m10 -20 v20 m0 -10 h10"/>
+	// <path d="M30 22 h20"/>
+	// <rect class="lit" x="50" y="10" width="52" height="24" rx="12"/><text x="76" y="26">&#39;hi&#39;</text>
+	// <path d="M102 22 h20"/>
+	// <path d="M30 22 a10 10 0 0 1 10 10 v14 a10 10 0 0 0 10 10"/>
+	// <rect class="ref" x="50" y="44" width="52" height="24" rx="0"/><a href="#Name"><text x="76" y="60">Name</text></a>
+	// <path d="M102 56 h0 a10 10 0 0 0 10 -10 v-14 a10 10 0 0 1 10 -10"/>
+	// <path d="M122 22 h20 m-10 -10 v20 m10 -20 v20"/>
+	// </svg>
+}
+
+func ExampleHTML() {
+
+	g, err := pegn.Parse(`
+# a friendly greeting
+Greet <= ('hello' / 'hi') ' ' Name '!'?
+Name  <= upper lower+
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := railroad.HTML(buf, g, `Greetings`); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// only the headings and definitions
+	for _, line := range strings.Split(buf.String(), "\n") {
+		for _, prefix := range []string{`<title>`, `<h2>`, `<p>`, `<pre>`} {
+			if strings.HasPrefix(line, prefix) {
+				fmt.Println(line)
+			}
+		}
+	}
+	fmt.Println(strings.Count(buf.String(), `<a href="#Name">`))
+
+	// Output:
+	// <title>Greetings</title>
+	// <h2>Greet</h2>
+	// <p>a friendly greeting</p>
+	// <pre>Greet &lt;= (&#39;hello&#39; / &#39;hi&#39;) &#39; &#39; Name &#39;!&#39;?</pre>
+	// <h2>Name</h2>
+	// <pre>Name &lt;= upper lower+</pre>
+	// 1
+}
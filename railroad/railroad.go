@@ -0,0 +1,380 @@
+/*
+Package railroad renders the rules of a rat.Grammar (or any rat/x
+expression) as railroad (syntax) diagrams in SVG, which is the usual way
+grammar authors communicate the syntax of their formats to those using
+them. HTML writes a single, self-contained page with a diagram for the
+Main rule and every named rule and alias (in the same order as
+rat.Grammar.PEGN) along with its documentation and PEGN definition.
+Rule references within diagrams link to the diagram of the rule.
+
+Literals are drawn as rounded boxes, references (and saved values) as
+square boxes, and everything else (ranges, any rune, end of data,
+classes) as boxes with a dashed border. Lookahead (See and Not) and To
+expressions are drawn within a dashed frame labeled with their PEGN
+operator. Repetitions are drawn as loops labeled with their counts
+unless one or more. Expressions are made canonical (see x.Canonical)
+first and are never wrapped.
+*/
+package railroad
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// HTML writes a complete HTML page with the title and a railroad
+// diagram (see SVG) for the Main rule of the grammar and every named
+// rule and alias (see rat.Grammar.MakeAlias) in the order added (see
+// rat.Grammar.Ordered). Built-in classes (see x.Classes) and rules
+// without a rat/x expression are skipped. Any error writing is
+// returned.
+func HTML(w io.Writer, g *rat.Grammar, title string) error {
+	var names, ops, docs []string
+	var exps []any
+	add := func(name, op, doc string, it any) {
+		names = append(names, name)
+		ops = append(ops, op)
+		docs = append(docs, doc)
+		exps = append(exps, it)
+	}
+
+	if g.Main != nil && g.Main.Expr != nil {
+		switch n, is := g.Main.Expr.(x.N); {
+		case is && len(n) == 2:
+			add(g.Main.Name, `<=`, g.Main.Doc, n[1])
+		case g.Main.IsAlias():
+			add(g.Main.Name, `<-`, g.Main.Doc, g.Main.Expr)
+		default:
+			add(rat.DefaultMainName, `<-`, g.Main.Doc, g.Main.Expr)
+		}
+	}
+
+	for _, rule := range g.Ordered() {
+		if rule == g.Main {
+			continue
+		}
+		if rule.IsAlias() {
+			add(rule.Name, `<-`, rule.Doc, rule.Expr)
+			continue
+		}
+		n, is := rule.Expr.(x.N)
+		if !is || len(n) != 2 {
+			continue
+		}
+		if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+			continue
+		}
+		add(rule.Name, `<=`, rule.Doc, n[1])
+	}
+
+	defined := map[string]bool{}
+	for _, name := range names {
+		defined[name] = true
+	}
+
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, pageHead, html.EscapeString(title))
+	for n, name := range names {
+		id := html.EscapeString(name)
+		fmt.Fprintf(buf, "<section id=\"%v\">\n<h2>%[1]v</h2>\n", id)
+		if docs[n] != "" {
+			fmt.Fprintf(buf, "<p>%v</p>\n", html.EscapeString(docs[n]))
+		}
+		buf.WriteString(svg(exps[n], defined))
+		fmt.Fprintf(buf, "<pre>%v</pre>\n</section>\n", html.EscapeString(
+			name+` `+ops[n]+` `+x.PEGNExpr(exps[n])))
+	}
+	buf.WriteString(pageFoot)
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+const pageHead = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%v</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+svg { display: block; margin: 1em 0; }
+svg path { stroke: #333; stroke-width: 2; fill: none; }
+svg rect { stroke: #333; stroke-width: 2; fill: #ffe; }
+svg rect.ref { fill: #eef; }
+svg rect.special { fill: #eee; stroke-dasharray: 4 2; }
+svg rect.frame { fill: none; stroke: #999; stroke-width: 1; stroke-dasharray: 4 2; }
+svg text { font-family: monospace; font-size: 13px; text-anchor: middle; }
+svg text.label { font-size: 11px; text-anchor: start; fill: #666; }
+svg a text { fill: #00c; text-decoration: underline; }
+</style>
+</head>
+<body>
+`
+
+const pageFoot = `</body>
+</html>
+`
+
+// SVG returns the railroad diagram of the rat/x expression as an SVG
+// element suitable for embedding in HTML. References (and named
+// expressions) link to an element with the same id as the name (see
+// HTML).
+func SVG(it any) string { return svg(it, nil) }
+
+// svg returns the diagram linking only references that are defined
+// (all if defined is nil).
+func svg(it any, defined map[string]bool) string {
+	d := diagram{defined: defined}
+	b := d.layout(x.Canonical(it))
+	w, h := b.w+2*margin+2*cap, b.up+b.down+2*margin
+	y := margin + b.up
+	s := new(strings.Builder)
+	fmt.Fprintf(s, `<svg class="railroad" xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %[1]v %[2]v">`, w, h)
+	s.WriteString("\n")
+	fmt.Fprintf(s, `<path d="M%v %v v20 m10 -20 v20 m0 -10 h%v"/>`, margin, y-10, cap-10)
+	s.WriteString("\n")
+	b.draw(s, margin+cap, y)
+	fmt.Fprintf(s, `<path d="M%v %v h%v m-10 -10 v20 m10 -20 v20"/>`, margin+cap+b.w, y, cap)
+	s.WriteString("\n</svg>\n")
+	return s.String()
+}
+
+// layout dimensions (pixels)
+const (
+	margin = 10 // around the entire diagram
+	cap    = 20 // start and end markers
+	gap    = 10 // horizontal space between items
+	arc    = 10 // radius of every curve
+	boxh   = 24 // height of every box
+	charw  = 8  // width of a single character
+)
+
+// box is the laid out diagram of an expression. The track enters on
+// the left and exits on the right at the baseline which is up from the
+// top and down from the bottom of the box (w wide).
+type box struct {
+	w, up, down int
+	draw        func(s *strings.Builder, x, y int)
+}
+
+type diagram struct {
+	defined map[string]bool
+}
+
+func (d diagram) layout(it any) box {
+	switch v := it.(type) {
+
+	case x.N:
+		return d.ref(fmt.Sprint(v[0]), ``)
+
+	case x.Ref:
+		name := fmt.Sprint(v...)
+		return d.ref(name, name)
+
+	case x.Sav:
+		name := fmt.Sprint(v...)
+		return d.ref(name, `=`+name)
+
+	case x.Val:
+		return leaf(`ref`, `$`+fmt.Sprint(v...), ``)
+
+	case string:
+		return leaf(`lit`, x.PEGNString(v), ``)
+
+	case x.Seq:
+		items := make([]box, len(v))
+		for n, it := range v {
+			items[n] = d.layout(it)
+		}
+		return seq(items)
+
+	case x.One:
+		items := make([]box, len(v))
+		for n, it := range v {
+			items[n] = d.layout(it)
+		}
+		return choice(items)
+
+	case x.Mmx:
+		m, _ := v[0].(int)
+		n, _ := v[1].(int)
+		item := d.layout(v[2])
+		var label string
+		switch {
+		case n == 1 && m == 0:
+			return choice([]box{item, seq(nil)})
+		case n == 1:
+			return item
+		case n < 0 && m > 1:
+			label = fmt.Sprintf(`%v or more times`, m)
+		case m == n:
+			label = fmt.Sprintf(`%v times`, m)
+		case n > 1 && m == 0:
+			label = fmt.Sprintf(`up to %v times`, n)
+		case n > 1:
+			label = fmt.Sprintf(`%v to %v times`, m, n)
+		}
+		if m == 0 {
+			return choice([]box{loop(item, label), seq(nil)})
+		}
+		return loop(item, label)
+
+	case x.See:
+		return frame(`&`, d.layout(v[0]))
+
+	case x.Not:
+		return frame(`!`, d.layout(v[0]))
+
+	case x.To:
+		return frame(`..`, d.layout(v[0]))
+
+	}
+
+	return leaf(`special`, x.PEGNExpr(it), ``)
+}
+
+// ref returns a reference box linked to the name (if defined).
+func (d diagram) ref(name, text string) box {
+	if text == `` {
+		text = name
+	}
+	if d.defined != nil && !d.defined[name] {
+		name = ``
+	}
+	return leaf(`ref`, text, name)
+}
+
+// leaf returns a box with the text (linked to the href name, if any)
+// of the CSS class (lit, ref, special).
+func leaf(class, text, href string) box {
+	w := utf8.RuneCountInString(text)*charw + 2*gap
+	return box{w: w, up: boxh / 2, down: boxh / 2, draw: func(s *strings.Builder, x, y int) {
+		rx := 0
+		if class == `lit` {
+			rx = boxh / 2
+		}
+		fmt.Fprintf(s, `<rect class="%v" x="%v" y="%v" width="%v" height="%v" rx="%v"/>`,
+			class, x, y-boxh/2, w, boxh, rx)
+		label := fmt.Sprintf(`<text x="%v" y="%v">%v</text>`, x+w/2, y+4, html.EscapeString(text))
+		if href != `` {
+			label = fmt.Sprintf(`<a href="#%v">%v</a>`, html.EscapeString(href), label)
+		}
+		s.WriteString(label + "\n")
+	}}
+}
+
+// seq returns the boxes joined left to right with a gap between them
+// (or an empty track if none).
+func seq(items []box) box {
+	var b box
+	for n, it := range items {
+		if n > 0 {
+			b.w += gap
+		}
+		b.w += it.w
+		b.up = max(b.up, it.up)
+		b.down = max(b.down, it.down)
+	}
+	b.draw = func(s *strings.Builder, x, y int) {
+		for n, it := range items {
+			if n > 0 {
+				fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x, y, gap)
+				x += gap
+			}
+			it.draw(s, x, y)
+			x += it.w
+		}
+	}
+	return b
+}
+
+// choice returns the boxes stacked with the first on the baseline and
+// the rest below it each joined to the baseline on both sides.
+func choice(items []box) box {
+	b := box{up: items[0].up}
+	ys := make([]int, len(items)) // baselines relative to the first
+	for n, it := range items {
+		b.w = max(b.w, it.w)
+		if n > 0 {
+			ys[n] = ys[n-1] + items[n-1].down + gap + it.up
+			ys[n] = max(ys[n], ys[n-1]+2*arc)
+		}
+	}
+	last := len(items) - 1
+	b.down = ys[last] + items[last].down
+	inner := b.w
+	b.w += 4 * arc
+	b.draw = func(s *strings.Builder, x, y int) {
+		for n, it := range items {
+			by := y + ys[n]
+			if n == 0 {
+				fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x, y, 2*arc)
+			} else {
+				fmt.Fprintf(s, `<path d="M%v %v a%v %[3]v 0 0 1 %[3]v %[3]v v%v a%[3]v %[3]v 0 0 0 %[3]v %[3]v"/>`+"\n",
+					x, y, arc, by-y-2*arc)
+			}
+			it.draw(s, x+2*arc, by)
+			rest := inner - it.w
+			if n == 0 {
+				fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x+2*arc+it.w, by, rest+2*arc)
+				continue
+			}
+			fmt.Fprintf(s, `<path d="M%v %v h%v a%v %[4]v 0 0 0 %[4]v -%[4]v v-%v a%[4]v %[4]v 0 0 1 %[4]v -%[4]v"/>`+"\n",
+				x+2*arc+it.w, by, rest, arc, by-y-2*arc)
+		}
+	}
+	return b
+}
+
+// loop returns the box with a track returning below it from the right
+// side to the left (so that it may be repeated) with the label (if
+// any) below the returning track.
+func loop(item box, label string) box {
+	b := box{w: item.w + 4*arc, up: item.up, down: max(item.down, arc) + 2*arc}
+	if label != `` {
+		b.down += boxh / 2
+		b.w = max(b.w, utf8.RuneCountInString(label)*charw)
+	}
+	b.draw = func(s *strings.Builder, x, y int) {
+		fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x, y, 2*arc)
+		item.draw(s, x+2*arc, y)
+		right := x + 2*arc + item.w
+		fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", right, y, x+b.w-right)
+		drop := max(item.down, arc) + arc
+		fmt.Fprintf(s, `<path d="M%v %v a%v %[3]v 0 0 1 %[3]v %[3]v v%v a%[3]v %[3]v 0 0 1 -%[3]v %[3]v h-%[5]v a%[3]v %[3]v 0 0 1 -%[3]v -%[3]v v-%[4]v a%[3]v %[3]v 0 0 1 %[3]v -%[3]v"/>`+"\n",
+			right, y, arc, drop-2*arc, item.w)
+		if label != `` {
+			fmt.Fprintf(s, `<text class="label" x="%v" y="%v">%v</text>`+"\n",
+				x+arc, y+drop+boxh/2+2, html.EscapeString(label))
+		}
+	}
+	return b
+}
+
+// frame returns the box within a dashed frame labeled above.
+func frame(label string, item box) box {
+	b := box{w: item.w + 2*gap, up: item.up + boxh/2 + gap, down: item.down + gap}
+	b.draw = func(s *strings.Builder, x, y int) {
+		fmt.Fprintf(s, `<rect class="frame" x="%v" y="%v" width="%v" height="%v"/>`+"\n",
+			x, y-b.up+gap/2, b.w, b.up+b.down-gap)
+		fmt.Fprintf(s, `<text class="label" x="%v" y="%v">%v</text>`+"\n",
+			x+gap/2, y-item.up-gap/2, html.EscapeString(label))
+		fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x, y, gap)
+		item.draw(s, x+gap, y)
+		fmt.Fprintf(s, `<path d="M%v %v h%v"/>`+"\n", x+gap+item.w, y, gap)
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -24,6 +24,9 @@ package rat
 import (
 	"fmt"
 	"io"
+	"unicode/utf8"
+
+	"github.com/rwxrob/rat/x"
 )
 
 // Pack interprets a sequence of any valid Go types into a Grammar
@@ -53,6 +56,17 @@ import (
 //
 func Pack(seq ...any) *Grammar { return new(Grammar).Init().Pack(seq...) }
 
+// CompileRegexp returns a new Grammar with a Main rule equivalent to
+// the Go regular expression (see x.FromRegexp) to ease migrating
+// regexp based validation into grammars.
+func CompileRegexp(expr string) (*Grammar, error) {
+	it, err := x.FromRegexp(expr)
+	if err != nil {
+		return nil, err
+	}
+	return Pack(it), nil
+}
+
 // RuleMaker implementations must return a new Rule created from any
 // input (but usually from rat/x expressions and other Go types).
 // Implementations may choose to cache the newly created rule and simply
@@ -64,6 +78,19 @@ type RuleMaker interface {
 	MakeRule(in any) *Rule
 }
 
+// Maker is implemented by expression types of other packages so that
+// Grammar.MakeRule (and so Pack and every expression containing one)
+// makes their rules with Make rather than as literals of their String
+// form (see Grammar.MakeCustom). Make must return a new Rule with only
+// its Check set, usually closing over rules made with MakeRule for any
+// nested expressions. The String and PEGN forms are used to render it
+// (see x.Custom).
+//
+type Maker interface {
+	x.Custom
+	Make(g *Grammar) *Rule
+}
+
 // Rule encapsulates a CheckFunc with a Name and Text representation.
 // The Name is use as the unique key in the Grammar.Rules cache. Text
 // can be anything, but it is strongly recommended that it contain rat/x
@@ -77,10 +104,20 @@ type RuleMaker interface {
 // correspond with the enclosed values within the CheckFunc closure and
 // so that the Name can be used to uniquely identify the Rule.
 //
+// Expr is the original rat/x expression (if any) used to create the
+// Rule and is required to render the Rule into other forms (see
+// Grammar.PEGN). Doc is documentation for the rule (usually the comment
+// lines directly preceding a PEGN definition) and is rendered as
+// comments when the rule is rendered.
+//
 type Rule struct {
-	Name  string    // uniquely identifying name (sometimes dynamically assigned)
-	Text  string    // prefer rat/x compatible expression (ex: x.Seq{"foo", "bar"})
-	Check CheckFunc // closure created with a RuleMaker
+	Name   string    // uniquely identifying name (sometimes dynamically assigned)
+	Text   string    // prefer rat/x compatible expression (ex: x.Seq{"foo", "bar"})
+	Check  CheckFunc // closure created with a RuleMaker
+	Expr   any       // rat/x expression from which rule was made (if any)
+	Doc    string    // documentation (from comments or x.Doc) for the rule
+	ID     int       // unique integer identity assigned by Grammar.AddRule
+	Redact bool      // mask matched text when results are rendered (see Grammar.Redact)
 }
 
 // String implements the fmt.Stringer interface by returning the
@@ -90,27 +127,77 @@ func (r Rule) String() string { return r.Text }
 // Print is a shortcut for fmt.Println(rule) which calls String.
 func (r Rule) Print() { fmt.Println(r) }
 
-func (r Rule) Scan(in any) Result {
-	var runes []rune
+// IsAlias returns true if the rule was made with Grammar.MakeAlias and
+// therefore has a Name that can be referenced but does not name (or
+// capture) its results.
+func (r Rule) IsAlias() bool {
+	_, named := r.Expr.(x.N)
+	return r.Expr != nil && !named && r.Name != r.Text && !isHashKey(r.Name)
+}
 
+// Scan checks the input ([]rune, string, []byte, io.RuneReader, or
+// io.Reader) with the CheckFunc. Since every CheckFunc examines a []rune
+// buffer (which is never copied again) input of any other type must be
+// decoded into one first. A string is therefore always copied into
+// a new []rune (four bytes for every rune) since nothing scans the
+// string itself. Bytes (and everything read) are decoded directly into
+// a buffer of exactly the number of runes required without an
+// intermediate string copy. Runes are read one at a time
+// from an io.RuneReader (or io.RuneScanner, such as a bufio.Reader)
+// that has already decoded them rather than reading all of its bytes
+// first. Pass a []rune directly to avoid decoding entirely.
+func (r Rule) Scan(in any) Result { return r.scan(in, 0) }
+
+// scan is Scan beginning at position i (in runes) of the input.
+func (r Rule) scan(in any, i int) Result {
+	runes, err := toRunes(in)
+	if err != nil {
+		return Result{X: err}
+	}
+	if r.Check == nil {
+		return Result{X: ErrNoCheckFunc{r}}
+	}
+	return r.Check(runes, i)
+}
+
+// toRunes returns the input ([]rune, string, []byte, io.RuneReader, or
+// io.Reader) as a []rune buffer (see Rule.Scan).
+func toRunes(in any) ([]rune, error) {
 	switch v := in.(type) {
 	case string:
-		runes = []rune(v)
+		return []rune(v), nil
 	case []byte:
-		runes = []rune(string(v))
+		return decodeRunes(v), nil
 	case []rune:
-		runes = v
+		return v, nil
+	case io.RuneReader:
+		return readRunes(v, 0, UTF8AsIs)
 	case io.Reader:
 		buf, err := io.ReadAll(v)
 		if err != nil {
-			return Result{X: err}
+			return nil, err
 		}
-		runes = []rune(string(buf))
+		return decodeRunes(buf), nil
 	}
-	if r.Check == nil {
-		return Result{X: ErrNoCheckFunc{r}}
+	return nil, nil
+}
+
+// decodeRunes returns the UTF-8 encoded bytes decoded into runes
+// (invalid encodings become utf8.RuneError) just as []rune(string(b))
+// does but without first copying the bytes into a string.
+func decodeRunes(b []byte) []rune {
+	runes := make([]rune, utf8.RuneCount(b))
+	for n := 0; len(b) > 0; n++ {
+		if b[0] < utf8.RuneSelf {
+			runes[n] = rune(b[0])
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		runes[n] = r
+		b = b[size:]
 	}
-	return r.Check(runes, 0)
+	return runes
 }
 
 // CheckFunc examines the []rune buffer at a specific position for
@@ -0,0 +1,169 @@
+package rattest
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rwxrob/rat"
+)
+
+// CaseSep separates the header from the input of a case file (see
+// ParseCase).
+const CaseSep = `---`
+
+// Case is a single input and what is expected when a rule checks it
+// (see Run). When Match is true the entire input must match (with the
+// tree Shape, if set). Otherwise the rule must fail (or not match the
+// entire input) stopping at position At (in runes) if not negative.
+type Case struct {
+	Name  string
+	Input string
+	Match bool
+	At    int
+	Shape string
+}
+
+// ParseCase parses the case file data (named name). Files begin with
+// header lines followed by a line containing only CaseSep after which
+// everything (including the final line ending) is the input. The
+// header contains either match or fail (optionally followed by the
+// position at which the rule is expected to stop) and optionally shape
+// followed by the expected tree shape (see Shape). Blank lines and
+// those beginning with # are ignored.
+//
+//	# keys must begin with a letter
+//	fail 0
+//	---
+//	1st=value
+//
+// A file without a CaseSep line is entirely input that must match.
+func ParseCase(name string, data []byte) (Case, error) {
+	c := Case{Name: name, Match: true, At: -1}
+	text := string(data)
+
+	head, input, found := strings.Cut(text, CaseSep+"\n")
+	if !found || (head != "" && !strings.HasSuffix(head, "\n")) {
+		c.Input = text
+		return c, nil
+	}
+	c.Input = input
+
+	for n, line := range strings.Split(strings.TrimSuffix(head, "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, _ := strings.Cut(line, " ")
+		val = strings.TrimSpace(val)
+		switch {
+		case key == `match` && val == "":
+			c.Match = true
+		case key == `fail` && val == "":
+			c.Match = false
+		case key == `fail`:
+			at, err := strconv.Atoi(val)
+			if err != nil || at < 0 {
+				return c, ErrCase{name, n + 1, line}
+			}
+			c.Match, c.At = false, at
+		case key == `shape` && val != "":
+			c.Shape = val
+		default:
+			return c, ErrCase{name, n + 1, line}
+		}
+	}
+	return c, nil
+}
+
+// LoadCases parses (see ParseCase) the file at root or every file
+// within it (and its subdirectories) if a directory in lexical order,
+// each named by its path relative to root. Files and directories
+// beginning with a dot are skipped.
+func LoadCases(fsys fs.FS, root string) ([]Case, error) {
+	var cases []Case
+	err := fs.WalkDir(fsys, root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(file, root), "/")
+		if name == "" {
+			name = path.Base(file)
+		}
+		c, err := ParseCase(name, data)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, c)
+		return nil
+	})
+	return cases, err
+}
+
+// Run checks every case with the rule as a subtest named by the case.
+func Run(t *testing.T, rule *rat.Rule, cases []Case) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) { Check(t, rule, c) })
+	}
+}
+
+// RunFS loads the cases from root (see LoadCases) and runs them (see
+// Run) stopping the test if they cannot be loaded.
+func RunFS(t *testing.T, rule *rat.Rule, fsys fs.FS, root string) {
+	t.Helper()
+	cases, err := LoadCases(fsys, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Run(t, rule, cases)
+}
+
+// Check reports an error unless the rule checking the input of the
+// case does what is expected.
+func Check(t testing.TB, rule *rat.Rule, c Case) rat.Result {
+	t.Helper()
+	if c.Match && c.Shape != "" {
+		return MatchShape(t, rule, c.Input, c.Shape)
+	}
+	res := rule.Scan(c.Input)
+	matched := res.X == nil && res.E == len(res.R)
+	switch {
+	case c.Match && res.X != nil:
+		t.Errorf(NoMatchT, label(rule), c.Input, res.X)
+	case c.Match && !matched:
+		t.Errorf(NoMatchT, label(rule), c.Input, rat.ErrIncomplete{B: res.E, E: len(res.R)})
+	case !c.Match && matched:
+		t.Errorf(MustFailT, label(rule), c.Input)
+	case !c.Match && c.At >= 0 && res.E != c.At:
+		t.Errorf(FailAtT, label(rule), c.Input, res.E, c.At)
+	}
+	return res
+}
+
+// ErrCase is an invalid header line (Line starting at 1) of a case file
+// (see ParseCase).
+type ErrCase struct {
+	Name string
+	Line int
+	V    string
+}
+
+func (e ErrCase) Error() string { return fmt.Sprintf(ErrCaseT, e.Name, e.Line, e.V) }
@@ -0,0 +1,115 @@
+package rattest_test
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/rattest"
+	"github.com/rwxrob/rat/x"
+)
+
+// recorder prints failures instead of failing (only for examples).
+type recorder struct{ testing.TB }
+
+func (recorder) Helper()                        {}
+func (recorder) Errorf(f string, args ...any)   { fmt.Printf(f+"\n", args...) }
+func (r recorder) Fatalf(f string, args ...any) { r.Errorf(f, args...) }
+
+func Example() {
+
+	t := recorder{}
+	g := new(rat.Grammar).Init()
+	key := g.MakeRule(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	pair := g.MakeRule(x.N{`Pair`, x.Seq{x.Ref{`Key`}, '=', x.N{`Val`, x.Mmx{1, -1, x.Rng{'0', '9'}}}}})
+
+	rattest.MustMatch(t, key, `foo`)
+	rattest.MustFail(t, key, `42`)
+	rattest.MatchText(t, key, `foo=42`, `foo`)
+	rattest.MatchShape(t, pair, `foo=42`, `Pair( Key Val )`)
+
+	// failures
+	rattest.MustMatch(t, key, `foo=42`)
+	rattest.MustFail(t, key, `foo`)
+	rattest.MatchText(t, key, `foo=42`, `foo=`)
+	rattest.MatchShape(t, pair, `foo=42`, `Pair(Key)`)
+
+	// Output:
+	// Key did not match "foo=42": unmatched input from 3 to 6
+	// Key unexpectedly matched "foo"
+	// Key matched "foo=42" as "foo", want "foo="
+	// Pair matched "foo=42" as Pair(Key Val), want Pair(Key)
+}
+
+func ExampleShape() {
+
+	g := rat.Pack(x.Mmx{1, -1, x.N{`Pair`, x.Seq{
+		x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, '=',
+		x.N{`Val`, x.One{x.N{`Num`, x.Mmx{1, -1, x.Rng{'0', '9'}}}, x.N{`Word`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}}}, ';',
+	}}})
+	fmt.Println(rattest.Shape(g.Scan(`a=1;b=bee;`)))
+
+	// Output:
+	// Pair(Key Val(Num)) Pair(Key Val(Word))
+}
+
+func ExampleLoadCases() {
+
+	fsys := fstest.MapFS{
+		`cases/good`:      {Data: []byte("foo=42")},
+		`cases/bad/key`:   {Data: []byte("# keys are letters\nfail 0\n---\n1=42")},
+		`cases/bad/wrong`: {Data: []byte("fail 2\n---\nfoo=bar")},
+		`cases/bad/val`:   {Data: []byte("fail 4\n---\nfoo=bar")},
+		`cases/shape`:     {Data: []byte("match\nshape Pair(Key Val)\n---\nfoo=42")},
+	}
+
+	cases, err := rattest.LoadCases(fsys, `cases`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	g := new(rat.Grammar).Init()
+	g.MakeRule(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+	pair := g.MakeRule(x.N{`Pair`, x.Seq{x.Ref{`Key`}, '=', x.N{`Val`, x.Mmx{1, -1, x.Rng{'0', '9'}}}}})
+
+	// usually rattest.Run(t, pair, cases) or rattest.RunFS(t, pair, fsys, `cases`)
+	for _, c := range cases {
+		fmt.Printf("%v %v %v %q\n", c.Name, c.Match, c.At, c.Shape)
+		rattest.Check(recorder{}, pair, c)
+	}
+
+	_, err = rattest.ParseCase(`oops`, []byte("maybe\n---\n"))
+	fmt.Println(err)
+
+	// Output:
+	// bad/key false 0 ""
+	// bad/val false 4 ""
+	// bad/wrong false 2 ""
+	// Pair failed "foo=bar" at 4, want 2
+	// good true -1 ""
+	// shape true -1 "Pair(Key Val)"
+	// oops:1: invalid case header: maybe
+}
+
+func ExampleInputs_Cases() {
+
+	g := new(rat.Grammar).Init()
+	key := g.MakeRule(x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}})
+
+	// usually rattest.Table(t, g, map[string]rattest.Inputs{`Key`: ...})
+	in := rattest.Inputs{Match: []string{`foo`, `bar`}, Fail: []string{`42`, ``, `foo`}}
+	for _, c := range in.Cases() {
+		fmt.Println(c.Name)
+		rattest.Check(recorder{}, key, c)
+	}
+
+	// Output:
+	// match/"foo"
+	// match/"bar"
+	// fail/"42"
+	// fail/""
+	// fail/"foo"
+	// Key unexpectedly matched "foo"
+}
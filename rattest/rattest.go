@@ -0,0 +1,123 @@
+/*
+Package rattest provides assertions for testing grammars and the rules
+within them so that tests read as the inputs each rule should and
+should not accept rather than pages of Result field comparisons. Every
+assertion reports failures through the testing.TB passed and returns
+the Result for further checks. Those beginning with Must stop
+the test (Fatalf) and the others continue (Errorf).
+
+Tree shapes are a compact form of the named results (see rat/x.N) of
+a result tree (see Shape) and are usually much easier to read and
+maintain than expected JSON.
+*/
+package rattest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+// MustMatch stops the test unless the rule matches the entire input
+// ([]rune, string, []byte, or io.Reader).
+func MustMatch(t testing.TB, rule *rat.Rule, in any) rat.Result {
+	t.Helper()
+	res := rule.Scan(in)
+	switch {
+	case res.X != nil:
+		t.Fatalf(NoMatchT, label(rule), string(res.R), res.X)
+	case res.E != len(res.R):
+		t.Fatalf(NoMatchT, label(rule), string(res.R), rat.ErrIncomplete{B: res.E, E: len(res.R)})
+	}
+	return res
+}
+
+// MustFail stops the test if the rule matches the entire input
+// ([]rune, string, []byte, or io.Reader). Matching only part of it is
+// failing.
+func MustFail(t testing.TB, rule *rat.Rule, in any) rat.Result {
+	t.Helper()
+	res := rule.Scan(in)
+	if res.X == nil && res.E == len(res.R) {
+		t.Fatalf(MustFailT, label(rule), string(res.R))
+	}
+	return res
+}
+
+// MatchText reports an error unless the rule matches the beginning of
+// the input ([]rune, string, []byte, or io.Reader) and the text matched
+// is want (which need not be all of the input).
+func MatchText(t testing.TB, rule *rat.Rule, in any, want string) rat.Result {
+	t.Helper()
+	res := rule.Scan(in)
+	if res.X != nil {
+		t.Errorf(NoMatchT, label(rule), string(res.R), res.X)
+		return res
+	}
+	if got := res.Text(); got != want {
+		t.Errorf(MatchTextT, label(rule), string(res.R), got, want)
+	}
+	return res
+}
+
+// MatchShape reports an error unless the rule matches the entire input
+// ([]rune, string, []byte, or io.Reader) with a result tree of the
+// shape wanted (see Shape). Whitespace within want is insignificant
+// beyond separating names.
+func MatchShape(t testing.TB, rule *rat.Rule, in any, want string) rat.Result {
+	t.Helper()
+	res := rule.Scan(in)
+	switch {
+	case res.X != nil:
+		t.Errorf(NoMatchT, label(rule), string(res.R), res.X)
+		return res
+	case res.E != len(res.R):
+		t.Errorf(NoMatchT, label(rule), string(res.R), rat.ErrIncomplete{B: res.E, E: len(res.R)})
+		return res
+	}
+	if got := Shape(res); got != normal(want) {
+		t.Errorf(MatchShapeT, label(rule), string(res.R), got, normal(want))
+	}
+	return res
+}
+
+// Shape returns the names of the named results (N) of the tree in
+// order with the children of each within parentheses after it and
+// separated by spaces:
+//
+//	Pair(Key Val) Pair(Key Val(Num))
+//
+// Anonymous results are replaced by their children (so the shape of
+// the usual anonymous root is the shapes of its children).
+func Shape(res rat.Result) string {
+	var parts []string
+	for _, c := range res.C {
+		if s := Shape(c); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	switch {
+	case res.N == "":
+		return strings.Join(parts, " ")
+	case len(parts) == 0:
+		return res.N
+	}
+	return res.N + "(" + strings.Join(parts, " ") + ")"
+}
+
+// label returns the name of a named rule or the Text of any other.
+func label(rule *rat.Rule) string {
+	if _, named := rule.Expr.(x.N); named {
+		return rule.Name
+	}
+	return rule.Text
+}
+
+// normal returns the shape with insignificant whitespace removed.
+func normal(shape string) string {
+	shape = strings.Join(strings.Fields(shape), " ")
+	shape = strings.ReplaceAll(shape, "( ", "(")
+	return strings.ReplaceAll(shape, " )", ")")
+}
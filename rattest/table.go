@@ -0,0 +1,58 @@
+package rattest
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/rwxrob/rat"
+)
+
+// Inputs are the inputs a rule must match entirely (Match) and must not
+// (Fail) for Table.
+type Inputs struct {
+	Match []string
+	Fail  []string
+}
+
+// Cases returns the inputs as Cases named by whether they must match
+// or fail followed by the quoted input (match/"foo=42").
+func (in Inputs) Cases() []Case {
+	var cases []Case
+	for _, it := range in.Match {
+		cases = append(cases, Case{Name: fmt.Sprintf(`match/%q`, it), Input: it, Match: true, At: -1})
+	}
+	for _, it := range in.Fail {
+		cases = append(cases, Case{Name: fmt.Sprintf(`fail/%q`, it), Input: it, At: -1})
+	}
+	return cases
+}
+
+// Table runs the Cases of the Inputs of every rule of the grammar by
+// name (in lexical order) as a subtest named by the rule containing
+// a subtest for every input (see Run) so that a single call tests every
+// rule of a grammar package thoroughly:
+//
+//	rattest.Table(t, g, map[string]rattest.Inputs{
+//		`Key`: {Match: []string{`foo`}, Fail: []string{`42`, ``}},
+//		`Val`: {Match: []string{`42`}, Fail: []string{`foo`}},
+//	})
+//
+// Names not in the Rules of the grammar are reported as errors.
+func Table(t *testing.T, g *rat.Grammar, table map[string]Inputs) {
+	t.Helper()
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rule, has := g.Rules[name]
+		if !has {
+			t.Errorf(NoRuleT, name)
+			continue
+		}
+		cases := table[name].Cases()
+		t.Run(name, func(t *testing.T) { Run(t, rule, cases) })
+	}
+}
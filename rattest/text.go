@@ -0,0 +1,14 @@
+package rattest
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	NoMatchT    = `%v did not match %q: %v`
+	MustFailT   = `%v unexpectedly matched %q`
+	MatchTextT  = `%v matched %q as %q, want %q`
+	MatchShapeT = `%v matched %q as %v, want %v`
+	FailAtT     = `%v failed %q at %v, want %v`
+	ErrCaseT    = `%v:%v: invalid case header: %v`
+	NoRuleT     = `no rule named %q`
+)
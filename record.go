@@ -0,0 +1,118 @@
+package rat
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Recording is a bundle of everything about a single Scan (see
+// Grammar.Record) that can be saved (see Encode), attached to a bug
+// report, and checked again later (see Grammar.Replay) to reproduce
+// how a grammar behaved. The input itself can be omitted (when it is
+// proprietary, for example) leaving only its Hash. Events and Result
+// are JSON (see TraceEvent.MarshalJSON and Result.MarshalJSON) with the
+// elapsed time of every event omitted so that replays compare equal.
+type Recording struct {
+	Hash    string            `json:"hash"`            // SHA-256 of input (hex)
+	Grammar string            `json:"grammar"`         // see Grammar.Dump
+	Input   string            `json:"input,omitempty"` // omitted if not kept
+	Events  []json.RawMessage `json:"events"`          // every named rule check
+	Result  json.RawMessage   `json:"result"`          // result of the Scan
+}
+
+// Encode writes the recording as indented JSON to w.
+func (rec Recording) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rec)
+}
+
+// DecodeRecording reads a recording written with Encode. The Events and
+// Result are compacted (to a single line each) as when recorded.
+func DecodeRecording(r io.Reader) (Recording, error) {
+	var rec Recording
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return rec, err
+	}
+	for n, e := range rec.Events {
+		rec.Events[n] = compact(e)
+	}
+	rec.Result = compact(rec.Result)
+	return rec, nil
+}
+
+// compact returns the JSON without insignificant whitespace.
+func compact(in json.RawMessage) json.RawMessage {
+	buf := new(bytes.Buffer)
+	if err := json.Compact(buf, in); err != nil {
+		return in
+	}
+	return buf.Bytes()
+}
+
+// Record scans the input ([]rune, string, []byte, or io.Reader) tracing
+// every named rule check (see TraceCheck) and returns the Result along
+// with a Recording of it, which includes the input only if keep is
+// true. The Trace and Tracer of the grammar are restored after.
+func (g *Grammar) Record(in any, keep bool) (Result, Recording) {
+	runes, err := toRunes(in)
+	if err != nil {
+		return Result{X: err}, Recording{}
+	}
+	sum := sha256.Sum256([]byte(string(runes)))
+	rec := Recording{Hash: hex.EncodeToString(sum[:]), Grammar: g.Dump()}
+	if keep {
+		rec.Input = string(runes)
+	}
+
+	trace, tracer := g.Trace, g.Tracer
+	g.Trace = TraceCheck
+	g.Tracer = TracerFunc(func(e TraceEvent) {
+		e.Elapsed = 0
+		buf, _ := e.MarshalJSON()
+		rec.Events = append(rec.Events, buf)
+	})
+	res := g.Scan(runes)
+	g.Trace, g.Tracer = trace, tracer
+
+	rec.Result, _ = res.MarshalJSON()
+	return res, rec
+}
+
+// Replay records the input (see Record) again (the Input of the
+// recording if in is nil) and returns the new recording along with an
+// ErrReplay for the first difference from the recording: the Hash of
+// the input, the Grammar (see Dump), the Events, and the Result in that
+// order. The error is nil if the grammar behaves exactly as recorded.
+func (g *Grammar) Replay(rec Recording, in any) (Recording, error) {
+	if in == nil {
+		in = rec.Input
+	}
+	_, got := g.Record(in, rec.Input != "")
+	switch {
+	case got.Hash != rec.Hash:
+		return got, ErrReplay{`hash`, rec.Hash, got.Hash}
+	case got.Grammar != rec.Grammar:
+		return got, ErrReplay{`grammar`, rec.Grammar, got.Grammar}
+	}
+	for n := 0; n < len(rec.Events) || n < len(got.Events); n++ {
+		var want, have json.RawMessage
+		if n < len(rec.Events) {
+			want = rec.Events[n]
+		}
+		if n < len(got.Events) {
+			have = got.Events[n]
+		}
+		if !bytes.Equal(want, have) {
+			return got, ErrReplay{fmt.Sprintf(`events[%v]`, n), string(want), string(have)}
+		}
+	}
+	if !bytes.Equal(got.Result, rec.Result) {
+		return got, ErrReplay{`result`, string(rec.Result), string(got.Result)}
+	}
+	return got, nil
+}
@@ -0,0 +1,89 @@
+package rat
+
+import "sync/atomic"
+
+// RedactMask replaces every rune of the text matched by a redacted rule
+// (see Grammar.Redact) wherever results are rendered.
+var RedactMask = '*'
+
+// redacting is set once any rule is redacted (see Redact) so that
+// results are only ever searched for redacted ones after.
+var redacting int32
+
+// Redact marks the rules with the names (usually those of passwords,
+// tokens, and other secrets) as redacted (see Rule.Redact), even those
+// made later, so that the text they match is masked (see RedactMask)
+// wherever results are rendered: MarshalJSON (and String, MarshalWith,
+// and Encode), MarshalCBOR, and Snippet (and PrintError). Since every
+// rune is masked all spans remain intact. Everything after the
+// beginning of a failed result of a redacted rule to the end of its
+// line is masked as well since how much of it was the secret is
+// unknown. Tracers are passed no buffer (R) for checks of redacted
+// rules. Text (and the other methods returning matched text to the
+// caller) are never masked. Only named rules (not aliases) produce
+// results that can be redacted. Results are only searched for redacted
+// ones once a rule is redacted here (or added with Rule.Redact already
+// set) so that rendering costs nothing more otherwise.
+func (g *Grammar) Redact(names ...string) {
+	if g.redact == nil {
+		g.redact = map[string]bool{}
+	}
+	for _, name := range names {
+		g.redact[name] = true
+		if rule, has := g.Rules[name]; has {
+			redactRule(rule)
+		}
+	}
+}
+
+// redactRule marks the rule as redacted (see Redact).
+func redactRule(rule *Rule) {
+	rule.Redact = true
+	atomic.StoreInt32(&redacting, 1)
+}
+
+// redacted returns the buffer (R) with the text of every result of
+// a redacted rule within the result masked (see Redact) as a copy (or
+// the buffer itself if none).
+func (m Result) redacted() []rune {
+	out := m.R
+	if atomic.LoadInt32(&redacting) != 0 {
+		m.redact(m.R, &out)
+	}
+	return out
+}
+
+// redact masks the text of the result (if of a redacted rule) and of
+// its descendants within out, which is copied from the buffer (r)
+// before it is first masked.
+func (m Result) redact(r []rune, out *[]rune) {
+	if m.O != nil && m.O.Redact && m.B >= 0 && m.B <= len(r) {
+		end := m.E
+		if m.X != nil {
+			for end < len(r) && r[end] != '\n' {
+				end++
+			}
+		}
+		if end > len(r) {
+			end = len(r)
+		}
+		if len(*out) > 0 && &(*out)[0] == &r[0] {
+			*out = append([]rune(nil), r...)
+		}
+		for n := m.B; n < end; n++ {
+			(*out)[n] = RedactMask
+		}
+	}
+	for _, c := range m.C {
+		c.redact(r, out)
+	}
+}
+
+// buffer returns the buffer for the trace events of the rule (nil if
+// redacted).
+func buffer(rule *Rule, r []rune) []rune {
+	if rule.Redact {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,58 @@
+package registry_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/registry"
+	"github.com/rwxrob/rat/x"
+)
+
+func digits() *rat.Grammar {
+	return rat.Pack(x.N{`Status`, x.Mmx{3, 3, x.Rng{'0', '9'}}})
+}
+
+func words() *rat.Grammar {
+	return rat.Pack(x.N{`Status`, x.Mmx{1, -1, x.Rng{'A', 'Z'}}})
+}
+
+func Example() {
+
+	reg := new(registry.Registry)
+	fmt.Println(reg.Register(`status`, `1.9`, digits))
+	fmt.Println(reg.Register(`status`, `1.10`, words))
+	fmt.Println(reg.Register(`status`, `1.9`, words))
+
+	g, _ := reg.Get(`status`, ``)
+	g.Scan(`OK`).Print()
+	g, _ = reg.Get(`status`, `1.9`)
+	g.Scan(`200`).Print()
+
+	fmt.Println(reg.Versions(`status`), reg.Names())
+	_, err := reg.Get(`status`, `2`)
+	fmt.Println(err)
+	_, err = reg.Get(`access`, ``)
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+	// <nil>
+	// grammar already registered: status 1.9
+	// {"N":"Status","B":0,"E":2,"C":[{"B":0,"E":1},{"B":1,"E":2}],"R":"OK"}
+	// {"N":"Status","B":0,"E":3,"C":[{"B":0,"E":1},{"B":1,"E":2},{"B":2,"E":3}],"R":"200"}
+	// [1.9 1.10] [status]
+	// grammar not found: status 2
+	// grammar not found: access
+}
+
+func ExampleCompare() {
+	fmt.Println(registry.Compare(`1.10`, `1.9`))
+	fmt.Println(registry.Compare(`v2`, `1.9.9`))
+	fmt.Println(registry.Compare(`1.0`, `1.0.1`))
+	fmt.Println(registry.Compare(`2023-01`, `2023-01`))
+	// Output:
+	// 1
+	// 1
+	// -1
+	// 0
+}
@@ -0,0 +1,178 @@
+/*
+Package registry keeps grammars by name and version so that
+applications maintaining many of them (log formats, protocol revisions)
+can register each once (usually from the init function of the package
+that defines it) and fetch them uniformly at runtime by name and either
+a specific version or the latest.
+
+Since a Grammar cannot be shared between concurrent scans what is
+registered is a function creating a new Grammar (like rat.ScanAll and
+validate.Handler) that is called for every Get. Registries themselves
+are safe for concurrent use.
+*/
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rwxrob/rat"
+)
+
+// Default is the Registry used by the package functions.
+var Default = new(Registry)
+
+// Register registers the grammar with the Default Registry (see
+// Registry.Register).
+func Register(name, version string, grammar func() *rat.Grammar) error {
+	return Default.Register(name, version, grammar)
+}
+
+// Get returns a new grammar from the Default Registry (see
+// Registry.Get).
+func Get(name, version string) (*rat.Grammar, error) { return Default.Get(name, version) }
+
+// Names returns the names in the Default Registry (see Registry.Names).
+func Names() []string { return Default.Names() }
+
+// Versions returns the versions of the named grammar in the Default
+// Registry (see Registry.Versions).
+func Versions(name string) []string { return Default.Versions(name) }
+
+// Registry is a set of functions creating grammars by name and version.
+// The zero value is an empty Registry ready to use.
+type Registry struct {
+	mu       sync.RWMutex
+	grammars map[string]map[string]func() *rat.Grammar
+}
+
+// Register adds the function creating the named grammar of the version
+// returning ErrRegistered if there already is one. Versions are
+// compared (see Compare) to determine the latest.
+func (r *Registry) Register(name, version string, grammar func() *rat.Grammar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.grammars == nil {
+		r.grammars = map[string]map[string]func() *rat.Grammar{}
+	}
+	versions, has := r.grammars[name]
+	if !has {
+		versions = map[string]func() *rat.Grammar{}
+		r.grammars[name] = versions
+	}
+	if _, has := versions[version]; has {
+		return ErrRegistered{name, version}
+	}
+	versions[version] = grammar
+	return nil
+}
+
+// Get returns a new grammar of the name and version (or the latest
+// version if empty) or ErrNotFound if there is none.
+func (r *Registry) Get(name, version string) (*rat.Grammar, error) {
+	r.mu.RLock()
+	versions := r.grammars[name]
+	if version == "" {
+		for v := range versions {
+			if version == "" || Compare(v, version) > 0 {
+				version = v
+			}
+		}
+	}
+	grammar, has := versions[version]
+	r.mu.RUnlock()
+	if !has {
+		return nil, ErrNotFound{name, version}
+	}
+	return grammar(), nil
+}
+
+// Names returns the names of every grammar registered in lexical
+// order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.grammars))
+	for name := range r.grammars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns every version of the named grammar from the oldest
+// to the latest (see Compare).
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []string
+	for v := range r.grammars[name] {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return Compare(list[i], list[j]) < 0 })
+	return list
+}
+
+// Compare returns -1, 0, or 1 if version a is older than, the same as,
+// or newer than b. Versions (with any leading v removed) are compared
+// by their dot-separated parts from left to right, numerically if both
+// are numbers and lexically if not, so that 1.10 is newer than 1.9 and
+// 2 is newer than 1.9.9. A version with more parts is newer when all
+// others are the same (1.0.1 is newer than 1.0).
+func Compare(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, `v`), `.`)
+	pb := strings.Split(strings.TrimPrefix(b, `v`), `.`)
+	for n := 0; n < len(pa) && n < len(pb); n++ {
+		if c := part(pa[n], pb[n]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
+
+// part compares a single part of a version (see Compare).
+func part(a, b string) int {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	switch {
+	case erra == nil && errb == nil && na < nb:
+		return -1
+	case erra == nil && errb == nil && na > nb:
+		return 1
+	case erra == nil && errb == nil:
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// ErrRegistered is returned when registering a name and version that
+// has already been registered.
+type ErrRegistered struct {
+	Name    string
+	Version string
+}
+
+func (e ErrRegistered) Error() string { return fmt.Sprintf(ErrRegisteredT, e.Name, e.Version) }
+
+// ErrNotFound is returned when no grammar has the name and version (or
+// any version if empty).
+type ErrNotFound struct {
+	Name    string
+	Version string
+}
+
+func (e ErrNotFound) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf(ErrNoGrammarT, e.Name)
+	}
+	return fmt.Sprintf(ErrNotFoundT, e.Name, e.Version)
+}
@@ -0,0 +1,10 @@
+package registry
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrRegisteredT = `grammar already registered: %v %v`
+	ErrNotFoundT   = `grammar not found: %v %v`
+	ErrNoGrammarT  = `grammar not found: %v`
+)
@@ -0,0 +1,67 @@
+package rat
+
+// maxFree is the maximum number of released children (C) slices
+// retained by a Grammar for reuse.
+const maxFree = 4096
+
+// Release returns every children (C) slice of the result tree to the
+// grammar so that they can be reused by subsequent checks instead of
+// allocated again, which dramatically reduces garbage collection when
+// scanning many inputs with the same grammar (see ScanFS). Release
+// should only be called on the root Result of a Scan (or Check) from
+// this grammar once it (and every result within it) is no longer
+// needed since the children of every result within it will be
+// overwritten. Results shared with an EventFunc (see Emit) must not be
+// released while still in use there.
+//
+// The intermediate results that a grammar discards during a check
+// (failed alternatives, lookahead, and results beyond any limit) are
+// released automatically unless Emit is set.
+func (g *Grammar) Release(res Result) {
+	for _, kid := range res.C {
+		g.Release(kid)
+	}
+	g.recycle(res.C)
+}
+
+// recycle clears the slice (so that its results do not keep their
+// input from being collected) and keeps it for reuse.
+func (g *Grammar) recycle(c []Result) {
+	if cap(c) == 0 || g.Arena != nil || len(g.free) >= maxFree {
+		return
+	}
+	c = c[:cap(c)]
+	for n := range c {
+		c[n] = Result{}
+	}
+	g.free = append(g.free, c[:0])
+}
+
+// discard releases the result (see Release) unless Emit is set in which
+// case it might still be in use.
+func (g *Grammar) discard(res Result) {
+	if g.Emit == nil {
+		g.Release(res)
+	}
+}
+
+// results returns an empty children slice (with capacity for at least
+// n results) from the Arena (if set) or reusing one released if
+// available.
+func (g *Grammar) results(n int) []Result {
+	if n < 4 {
+		n = 4
+	}
+	if g.Arena != nil {
+		return g.Arena.alloc(n)
+	}
+	if last := len(g.free) - 1; last >= 0 && cap(g.free[last]) >= n {
+		c := g.free[last]
+		g.free = g.free[:last]
+		return c
+	}
+	if n > 4 {
+		return make([]Result, 0, n)
+	}
+	return []Result{}
+}
@@ -0,0 +1,68 @@
+package repl_test
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rwxrob/rat/repl"
+)
+
+func Example() {
+
+	session := repl.New(nil)
+	session.Prompt = ""
+	session.Run(strings.NewReader(`
+Name <= upper lower+
+Greet <= ('hello' / 'hi') ' ' Name
+hi Rob!
+hi rob
+:named
+x.Seq{"hey ", x.Ref{"Name"}, x.Mmx{0, 1, '!'}}
+hey Rob!
+:rules
+`), os.Stdout)
+
+	// Output:
+	// Greet [0,6) "hi Rob"
+	//   - [0,2) "hi"
+	//     - [0,2) "hi"
+	//   - [2,3) " "
+	//   Name [3,6) "Rob"
+	//     upper [3,4) "R"
+	//     - [4,6) "ob"
+	//       lower [4,5) "o"
+	//       lower [5,6) "b"
+	// not matched: "!"
+	// column 4: expected: x.Rng{'A', 'Z'}
+	// 1 | hi rob
+	//   |    ^
+	// - [0,8) "hey Rob!"
+	//   Name [4,7) "Rob"
+	//     upper [4,5) "R"
+	//     lower [5,6) "o"
+	//     lower [6,7) "b"
+	// Main  <- 'hey ' Name '!'?
+	// Name  <= upper lower+
+	// Greet <= ('hello' / 'hi') ' ' Name
+
+}
+
+func ExampleSession_Eval() {
+
+	session := repl.New(nil)
+	for _, line := range []string{
+		`x.Mmx{1, -1, x.One{x.Rng{'a', 'z'}, x.Is{unicode.IsDigit}}}`,
+		`:json`,
+		`ab1`,
+		`x.Seq{os.Getenv("HOME")}`,
+		`:nope`,
+	} {
+		session.Eval(os.Stdout, line)
+	}
+
+	// Output:
+	// {"B":0,"E":3,"C":[{"B":0,"E":1,"C":[{"B":0,"E":1}]},{"B":1,"E":2,"C":[{"B":1,"E":2}]},{"B":2,"E":3,"C":[{"B":2,"E":3}]}],"R":"ab1"}
+	// error: unsupported in rat/x expression: os.Getenv("HOME")
+	// unknown command: :nope (see :help)
+
+}
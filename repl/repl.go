@@ -0,0 +1,221 @@
+/*
+Package repl is an interactive read-eval-print loop for trying rat/x
+and PEGN expressions on sample input and immediately seeing the Result
+tree, making grammar exploration as fast as with regex101-style tools.
+Every line read is one of the following:
+
+	Name <= upper lower+    PEGN definition (or <-), now the current rule
+	x.Seq{"hi ", x.Ref{"Name"}}    rat/x expression, now the current rule
+	:help                   command (see Help)
+	hi Rob                  anything else is input for the current rule
+
+Definitions are kept (replacing any earlier one of the same name) so
+that later definitions and expressions can refer to them by name.
+Expressions are written exactly as their String form (Go notation) and
+may contain only literals, rat/x types, and the unicode.Is functions
+(and x.IsWord). Input is scanned with the current rule and printed as
+an indented tree with the name (or -), span, and text of every result
+(or as JSON) followed by the part not matched (if any). Use the :scan
+command for input that would otherwise be taken for one of the
+others. The rat command starts one with -i.
+*/
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/pegn"
+	"github.com/rwxrob/rat/x"
+)
+
+// definition matches the beginning of a PEGN definition.
+var definition = regexp.MustCompile(`^\s*\pL[\pL\pN_]*\s*<[-=]`)
+
+// Session is a single REPL session with its own grammar of every rule
+// defined so far.
+type Session struct {
+	Prompt string // written before reading every line (if any)
+	JSON   bool   // print results as JSON instead of trees
+	Named  bool   // keep only named results (see rat.Grammar.NamedOnly)
+
+	g *rat.Grammar
+}
+
+// New returns a new Session with the DefaultPrompt starting with the
+// rules of the grammar (and its Main rule as the current rule) or none
+// if nil. The grammar is changed by the session.
+func New(g *rat.Grammar) *Session {
+	if g == nil {
+		g = new(rat.Grammar).Init()
+	}
+	return &Session{Prompt: DefaultPrompt, g: g}
+}
+
+// Run runs a new Session (see New) without any rules reading lines from
+// in and writing to out.
+func Run(in io.Reader, out io.Writer) error { return New(nil).Run(in, out) }
+
+// Grammar returns the grammar of every rule defined in the session with
+// the current rule as its Main rule.
+func (s *Session) Grammar() *rat.Grammar { return s.g }
+
+// Run evaluates (see Eval) every line read from in writing the Prompt
+// before each until the end of the input or the :quit command.
+func (s *Session) Run(in io.Reader, out io.Writer) error {
+	lines := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, s.Prompt)
+		if !lines.Scan() {
+			if s.Prompt != "" {
+				fmt.Fprintln(out)
+			}
+			return lines.Err()
+		}
+		if !s.Eval(out, lines.Text()) {
+			return nil
+		}
+	}
+}
+
+// Eval evaluates the line (see package documentation) writing the
+// results (or any error) to w and returns false only for the :quit
+// command. Blank lines are ignored.
+func (s *Session) Eval(w io.Writer, line string) bool {
+	var err error
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+	case strings.HasPrefix(trimmed, `:`):
+		return s.command(w, trimmed)
+	case definition.MatchString(line):
+		err = s.Define(line)
+	case strings.HasPrefix(trimmed, `x.`):
+		err = s.Expr(trimmed)
+	default:
+		s.Scan(w, line)
+	}
+	if err != nil {
+		fmt.Fprintf(w, ErrorT+"\n", err)
+	}
+	return true
+}
+
+// Define adds every definition of the PEGN document to the grammar of
+// the session making the first the current rule.
+func (s *Session) Define(doc string) error {
+	defs, err := pegn.Parse(doc)
+	if err != nil {
+		return err
+	}
+	return s.g.Try(func() {
+		for _, rule := range defs.Ordered() {
+			if class, is := x.Classes[rule.Name]; is && class.String() == rule.Text {
+				continue
+			}
+			switch {
+			case rule.IsAlias():
+				s.g.MakeAlias(rule.Name, rule.Expr).Doc = rule.Doc
+			case isNamed(rule):
+				s.g.MakeRule(rule.Expr).Doc = rule.Doc
+			}
+		}
+		s.g.Main = s.g.Rules[defs.Main.Name]
+	})
+}
+
+// isNamed returns true if the rule was made from a named (x.N)
+// expression.
+func isNamed(rule *rat.Rule) bool {
+	_, named := rule.Expr.(x.N)
+	return named
+}
+
+// Expr makes the rat/x expression (in Go notation) the current rule.
+func (s *Session) Expr(text string) error {
+	it, err := expr(text)
+	if err != nil {
+		return err
+	}
+	_, err = s.g.TryPack(it)
+	return err
+}
+
+// Scan scans the input with the current rule writing the result tree
+// (or JSON) and the part of the input not matched (if any) or the error
+// and where it occurred to w.
+func (s *Session) Scan(w io.Writer, input string) {
+	if s.g.Main == nil {
+		fmt.Fprintln(w, ErrNoRuleT)
+		return
+	}
+	s.g.NamedOnly = s.Named
+	res := s.g.Scan(input)
+	if res.X != nil {
+		info := res.ErrorInfo()
+		fmt.Fprintf(w, ErrScanT+"\n", info.Column, info.Message)
+		fmt.Fprintln(w, res.Snippet(0))
+		return
+	}
+	if s.JSON {
+		fmt.Fprintln(w, res)
+	} else {
+		tree(w, res, 0)
+	}
+	if res.E < len(res.R) {
+		fmt.Fprintf(w, RestT+"\n", string(res.R[res.E:]))
+	}
+}
+
+// tree writes the result and its children (indented one more level)
+// one per line with the name (if any), span, and quoted text.
+func tree(w io.Writer, res rat.Result, depth int) {
+	name := res.N
+	if name == "" {
+		name = `-`
+	}
+	fmt.Fprintf(w, "%v%v [%v,%v) %q\n", strings.Repeat(`  `, depth), name, res.B, res.E, string(res.R[res.B:res.E]))
+	for _, it := range res.C {
+		it.R = res.R
+		tree(w, it, depth+1)
+	}
+}
+
+// command runs the command line (see Help) returning false only for
+// :quit.
+func (s *Session) command(w io.Writer, line string) bool {
+	name, arg, _ := strings.Cut(line, ` `)
+	switch name {
+	case `:help`:
+		fmt.Fprint(w, Help)
+	case `:rules`:
+		fmt.Fprint(w, s.g.PEGN())
+	case `:json`:
+		s.JSON = true
+	case `:tree`:
+		s.JSON = false
+	case `:named`:
+		s.Named = !s.Named
+	case `:scan`:
+		s.Scan(w, arg)
+	case `:reset`:
+		s.g.Init()
+	case `:quit`:
+		return false
+	default:
+		fmt.Fprintf(w, ErrCommandT+"\n", name)
+	}
+	return true
+}
+
+// ErrUnsupported is returned for any part of a rat/x expression that is
+// not a literal, rat/x type, or one of the allowed functions (see Expr).
+type ErrUnsupported struct {
+	Expr string
+}
+
+func (e ErrUnsupported) Error() string { return fmt.Sprintf(ErrUnsupportedT, e.Expr) }
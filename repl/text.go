@@ -0,0 +1,32 @@
+package repl
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+// DefaultPrompt is the Prompt of a new Session.
+const DefaultPrompt = `rat> `
+
+// Help is written by the :help command.
+const Help = `Name <= expr    define a rule (PEGN) and make it the current rule
+x.Seq{...}      make a rat/x expression the current rule
+anything else   scan it with the current rule
+:scan text      scan the text (even if it looks like the others)
+:rules          print every rule defined (PEGN)
+:json           print results as JSON
+:tree           print results as trees (default)
+:named          keep only named results (or every result again)
+:reset          forget every rule
+:quit           end the session
+`
+
+const (
+	RestT = `not matched: %q`
+)
+
+const (
+	ErrorT          = `error: %v`
+	ErrNoRuleT      = `no rule to scan with (define one first)`
+	ErrScanT        = `column %v: %v`
+	ErrCommandT     = `unknown command: %v (see :help)`
+	ErrUnsupportedT = `unsupported in rat/x expression: %v`
+)
@@ -0,0 +1,138 @@
+package repl
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"unicode"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// types are the rat/x expression types by name.
+var types = map[string]func(args []any) any{
+	`N`:   func(args []any) any { return x.N(args) },
+	`Doc`: func(args []any) any { return x.Doc(args) },
+	`Sav`: func(args []any) any { return x.Sav(args) },
+	`Val`: func(args []any) any { return x.Val(args) },
+	`Ref`: func(args []any) any { return x.Ref(args) },
+	`Is`:  func(args []any) any { return x.Is(args) },
+	`Seq`: func(args []any) any { return x.Seq(args) },
+	`One`: func(args []any) any { return x.One(args) },
+	`Str`: func(args []any) any { return x.Str(args) },
+	`Mmx`: func(args []any) any { return x.Mmx(args) },
+	`See`: func(args []any) any { return x.See(args) },
+	`Not`: func(args []any) any { return x.Not(args) },
+	`To`:  func(args []any) any { return x.To(args) },
+	`Any`: func(args []any) any { return x.Any(args) },
+	`Rng`: func(args []any) any { return x.Rng(args) },
+	`End`: func(args []any) any { return x.End(args) },
+	`Wb`:  func(args []any) any { return x.Wb(args) },
+
+	`Byte`: func(args []any) any { return x.Byte(args) },
+	`BSet`: func(args []any) any { return x.BSet(args) },
+	`BRng`: func(args []any) any { return x.BRng(args) },
+	`Len`:  func(args []any) any { return x.Len(args) },
+	`Bits`: func(args []any) any { return x.Bits(args) },
+}
+
+// funcs are the functions that can be used within an x.Is (or directly)
+// by their qualified names.
+var funcs = map[string]func(r rune) bool{
+	`x.IsWord`:          x.IsWord,
+	`unicode.IsControl`: unicode.IsControl,
+	`unicode.IsDigit`:   unicode.IsDigit,
+	`unicode.IsGraphic`: unicode.IsGraphic,
+	`unicode.IsLetter`:  unicode.IsLetter,
+	`unicode.IsLower`:   unicode.IsLower,
+	`unicode.IsMark`:    unicode.IsMark,
+	`unicode.IsNumber`:  unicode.IsNumber,
+	`unicode.IsPrint`:   unicode.IsPrint,
+	`unicode.IsPunct`:   unicode.IsPunct,
+	`unicode.IsSpace`:   unicode.IsSpace,
+	`unicode.IsSymbol`:  unicode.IsSymbol,
+	`unicode.IsTitle`:   unicode.IsTitle,
+	`unicode.IsUpper`:   unicode.IsUpper,
+}
+
+// expr returns the rat/x expression written in Go notation (the String
+// form of one) as text. Only literals (strings, runes, and integers),
+// composite literals of the rat/x types (and []any), and the functions
+// of funcs are allowed. An ErrUnsupported is returned for anything
+// else.
+func expr(text string) (any, error) {
+	node, err := parser.ParseExpr(text)
+	if err != nil {
+		return nil, err
+	}
+	return value(text, node)
+}
+
+// value returns the value of the node of the expression (text).
+func value(text string, node ast.Expr) (any, error) {
+	unsupported := ErrUnsupported{text[node.Pos()-1 : node.End()-1]}
+	switch v := node.(type) {
+
+	case *ast.ParenExpr:
+		return value(text, v.X)
+
+	case *ast.BasicLit:
+		switch v.Kind {
+		case token.STRING:
+			return strconv.Unquote(v.Value)
+		case token.CHAR:
+			s, err := strconv.Unquote(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			return []rune(s)[0], nil
+		case token.INT:
+			return integer(v.Value)
+		}
+
+	case *ast.UnaryExpr:
+		if n, is := v.X.(*ast.BasicLit); is && v.Op == token.SUB && n.Kind == token.INT {
+			return integer(`-` + n.Value)
+		}
+
+	case *ast.SelectorExpr:
+		if f, has := funcs[unsupported.Expr]; has {
+			return f, nil
+		}
+
+	case *ast.CompositeLit:
+		var build func(args []any) any
+		switch t := v.Type.(type) {
+		case *ast.SelectorExpr:
+			if pkg, is := t.X.(*ast.Ident); is && pkg.Name == `x` {
+				build = types[t.Sel.Name]
+			}
+		case *ast.ArrayType:
+			if elt, is := t.Elt.(*ast.Ident); is && t.Len == nil && elt.Name == `any` {
+				build = func(args []any) any { return args }
+			}
+		}
+		if build == nil {
+			break
+		}
+		args := []any{}
+		for _, elt := range v.Elts {
+			it, err := value(text, elt)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, it)
+		}
+		return build(args), nil
+
+	}
+	return nil, unsupported
+}
+
+// integer returns the integer literal (such as 42, 0x2A, or -1) as an
+// int.
+func integer(lit string) (any, error) {
+	n, err := strconv.ParseInt(lit, 0, 0)
+	return int(n), err
+}
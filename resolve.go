@@ -0,0 +1,53 @@
+package rat
+
+import "github.com/rwxrob/rat/x"
+
+// Unresolved returns an ErrRefs with an ErrUnresolved (with suggested
+// names) for every x.Ref and x.Val of the grammar referring to a name
+// that no rule has (or saves) in the order added, or nil if every one
+// resolves. This is done when packing if Resolve is set so that typos
+// are found before scanning (which otherwise only fails, perhaps much
+// later, when the rule is checked).
+func (g *Grammar) Unresolved() error {
+	var errs []error
+	var refs, savs []string
+	for _, rule := range g.Ordered() {
+		switch v := rule.Expr.(type) {
+		case x.Ref:
+			name := v[0].(string)
+			if _, has := g.Rules[name]; has {
+				continue
+			}
+			if _, has := x.Classes[name]; has {
+				continue
+			}
+			if refs == nil {
+				refs = g.refNames()
+			}
+			errs = append(errs, ErrUnresolved{V: v, Suggest: suggest(name, refs)})
+		case x.Val:
+			name := v[0].(string)
+			if savs == nil {
+				savs = g.savNames()
+			}
+			if contains(savs, name) {
+				continue
+			}
+			errs = append(errs, ErrUnresolved{V: v, Suggest: suggest(name, savs)})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return ErrRefs(errs)
+}
+
+// contains returns true if the name is in the list.
+func contains(list []string, name string) bool {
+	for _, it := range list {
+		if it == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,15 @@
 package rat
 
 import (
+	"encoding"
 	"fmt"
+	"io"
+	"path"
+	"regexp"
 	"slices"
-	"strings"
+	"sort"
+	"strconv"
+	"unicode/utf8"
 )
 
 // Result contains the result of an evaluated Rule function along with
@@ -36,6 +42,19 @@ import (
 //
 // X (for "expected") contains any error encountered while parsing.
 //
+// T (for "truncated") indicates that some of the children (C) that
+// would otherwise be retained were discarded due to limits (see
+// Grammar.MaxChildren and Grammar.MaxDepth).
+//
+// O (for "origin") is the Rule that produced the result (even if
+// unnamed) allowing debugging tools to report exactly which rule
+// failed. It is never included when marshaling.
+//
+// S (for "source") is an optional file name or label identifying where
+// the buffer (R) came from (see Grammar.ScanSource). When set, it is
+// usually set for every result in the tree so that any one of them can
+// report its own Location.
+//
 // C (for "children") contains results within this result, sub-matches,
 // equivalent to parenthesized patterns of a regular expression.
 //
@@ -60,53 +79,195 @@ type Result struct {
 	B int      // beginning (inclusive)
 	E int      // ending (non-inclusive)
 	X error    // error, eXpected something else
+	T bool     // truncated, children discarded due to limits
+	O *Rule    // origin, rule that produced this result
+	S string   // source, file name or other label (optional)
 	C []Result // children, results within this result
 	R []rune   // reference data (underlying slice array shared)
 }
 
 // MarshalJSON fulfills the encoding.JSONMarshaler interface. The begin
 // (B), end (E) are always included. The name (N), id (I), buffer (R),
-// error (X) and child sub-matches (C) are only included if not empty.
+// error (X), truncated (T), and child sub-matches (C) are only included
+// if not empty.
 // Child sub-matches omit the buffer (R). The order of fields is
 // guaranteed not to change.  Output is always a single line. There is
 // no dependency on the reflect package. The buffer (R) is rendered as
 // a quoted string (%q) with no further escaping (unlike built-in Go
 // JSON marshaling which escapes things unnecessarily producing
 // unreadable output). The buffer (R) is never included for children
-// (which is the same). An error is never returned.
+// (which is the same). The source (S) is only included if set and is
+// omitted from children with the same source. An error is never
+// returned.
 func (m Result) MarshalJSON() ([]byte, error) {
+	return m.MarshalWith(JSONOpts{})
+}
+
+// JSONOpts contains the options for MarshalWith. The zero value
+// produces the same output as MarshalJSON.
+type JSONOpts struct {
+	Long   bool   // long field names (Name, ID, Begin, End, etc.)
+	Text   bool   // include the matched text for every result (V or Text)
+	Indent string // indent nested fields on multiple lines with this
+}
+
+// jsonkeys contains the short and long JSON field names.
+var jsonkeys = map[string]string{
+	`N`: `Name`,
+	`I`: `ID`,
+	`B`: `Begin`,
+	`E`: `End`,
+	`V`: `Text`,
+	`X`: `Error`,
+	`T`: `Truncated`,
+	`C`: `Children`,
+	`R`: `Buffer`,
+	`S`: `Source`,
+}
+
+// MarshalWith is the configurable form of MarshalJSON allowing long
+// field names (more readable for downstream, non-Go consumers), the
+// matched text of every result (which cannot otherwise be derived from
+// the children since they omit the buffer R), and indented multi-line
+// output. The order of fields remains the same. An error is never
+// returned.
+func (m Result) MarshalWith(opts JSONOpts) ([]byte, error) {
+	m.R = m.redacted()
+	j := jsonWriter{opts: opts}
+	m.marshal(&j, 0, m.R, m.S)
+	return j.b, nil
+}
+
+// Encode writes the same JSON as MarshalJSON to w as it is produced
+// rather than first building all of it in memory (which is preferable
+// for very large result trees). Only errors from w are returned.
+func (m Result) Encode(w io.Writer) error {
+	return m.EncodeWith(w, JSONOpts{})
+}
+
+// EncodeWith is the configurable form of Encode (see MarshalWith).
+func (m Result) EncodeWith(w io.Writer, opts JSONOpts) error {
+	m.R = m.redacted()
+	j := jsonWriter{opts: opts, w: w}
+	m.marshal(&j, 0, m.R, m.S)
+	j.flush(0)
+	return j.err
+}
+
+// jsonFlush is the size of JSON buffered by Encode before writing.
+const jsonFlush = 32 * 1024
+
+// jsonWriter appends JSON to a single buffer (b) that is written to
+// w (if set) whenever it grows beyond jsonFlush.
+type jsonWriter struct {
+	opts JSONOpts
+	b    []byte
+	w    io.Writer
+	err  error
+}
+
+// flush writes the buffer if it is at least min long and w is set.
+func (j *jsonWriter) flush(min int) {
+	if j.w == nil || len(j.b) < min {
+		return
+	}
+	if j.err == nil {
+		_, j.err = j.w.Write(j.b)
+	}
+	j.b = j.b[:0]
+}
+
+// newline appends a new line followed by depth indents (if indenting).
+func (j *jsonWriter) newline(depth int) {
+	if j.opts.Indent == "" {
+		return
+	}
+	j.b = append(j.b, '\n')
+	for n := 0; n < depth; n++ {
+		j.b = append(j.b, j.opts.Indent...)
+	}
+}
+
+// key appends the separator (unless first) and field key.
+func (j *jsonWriter) key(k string, first bool, depth int) {
+	if !first {
+		j.b = append(j.b, ',')
+	}
+	j.newline(depth + 1)
+	if j.opts.Long {
+		k = jsonkeys[k]
+	}
+	j.b = strconv.AppendQuote(j.b, k)
+	j.b = append(j.b, ':')
+	if j.opts.Indent != "" {
+		j.b = append(j.b, ' ')
+	}
+}
 
-	s := "{"
+func (m Result) marshal(j *jsonWriter, depth int, buf []rune, src string) {
+	j.b = append(j.b, '{')
 
 	if m.N != "" {
-		s += fmt.Sprintf(`"N":%q,`, m.N)
+		j.key(`N`, true, depth)
+		j.b = strconv.AppendQuote(j.b, m.N)
 	}
 
 	if m.I > 0 {
-		s += fmt.Sprintf(`"I":%v,`, m.I)
+		j.key(`I`, m.N == "", depth)
+		j.b = strconv.AppendInt(j.b, int64(m.I), 10)
 	}
 
-	s += fmt.Sprintf(`"B":%v,"E":%v`, m.B, m.E)
+	j.key(`B`, m.N == "" && m.I <= 0, depth)
+	j.b = strconv.AppendInt(j.b, int64(m.B), 10)
+	j.key(`E`, false, depth)
+	j.b = strconv.AppendInt(j.b, int64(m.E), 10)
+
+	if j.opts.Text && buf != nil && m.B <= m.E && m.E <= len(buf) {
+		j.key(`V`, false, depth)
+		j.b = strconv.AppendQuote(j.b, string(buf[m.B:m.E]))
+	}
 
 	if m.X != nil {
-		s += fmt.Sprintf(`,"X":%q`, m.X)
+		j.key(`X`, false, depth)
+		j.b = strconv.AppendQuote(j.b, m.X.Error())
+	}
+
+	if m.T {
+		j.key(`T`, false, depth)
+		j.b = append(j.b, `true`...)
 	}
 
 	if len(m.C) > 0 {
-		results := []string{}
-		for _, c := range m.C {
-			results = append(results, Result{c.N, c.I, c.B, c.E, c.X, c.C, nil}.String())
+		j.key(`C`, false, depth)
+		j.b = append(j.b, '[')
+		for n, c := range m.C {
+			if n > 0 {
+				j.b = append(j.b, ',')
+			}
+			j.newline(depth + 2)
+			c.R = nil
+			if c.S == src {
+				c.S = ""
+			}
+			c.marshal(j, depth+2, buf, src)
+			j.flush(jsonFlush)
 		}
-		s += `,"C":[` + strings.Join(results, ",") + `]`
+		j.newline(depth + 1)
+		j.b = append(j.b, ']')
 	}
 
 	if m.R != nil {
-		s += fmt.Sprintf(`,"R":%q`, string(m.R))
+		j.key(`R`, false, depth)
+		j.b = strconv.AppendQuote(j.b, string(m.R))
 	}
 
-	s += "}"
+	if m.S != "" {
+		j.key(`S`, false, depth)
+		j.b = strconv.AppendQuote(j.b, m.S)
+	}
 
-	return []byte(s), nil
+	j.newline(depth)
+	j.b = append(j.b, '}')
 }
 
 // String fulfills the fmt.Stringer interface as JSON by calling
@@ -126,8 +287,15 @@ func (m Result) Print() { fmt.Println(m) }
 // PrintText is short for fmt.Println(m.Text()).
 func (m Result) PrintText() { fmt.Println(m.Text()) }
 
-// PrintError is short for fmt.Println(m.X) but adds position.
-func (m Result) PrintError() { fmt.Println(m.X) }
+// PrintError is short for fmt.Println(m.X) followed by the Snippet of
+// the input (with SnippetContext lines) pointing to where it failed
+// (if it did).
+func (m Result) PrintError() {
+	fmt.Println(m.X)
+	if m.X != nil && m.R != nil {
+		fmt.Println(m.Snippet(SnippetContext))
+	}
+}
 
 // Text returns the text between beginning (B) and ending (E)
 // (non-inclusively) It is a shortcut for
@@ -136,6 +304,83 @@ func (m Result) Text() string {
 	return string(m.R[m.B:m.E])
 }
 
+// Runes returns the matched region of the buffer (R) between beginning
+// (B) and ending (E) without copying it. The returned slice shares the
+// underlying array of the buffer and must not be modified.
+func (m Result) Runes() []rune { return m.R[m.B:m.E] }
+
+// Bits returns the unsigned value of the bits matched (no more than 64
+// runes, each '0' or '1', see UTF8Bits) or false if not bits.
+func (m Result) Bits() (uint64, bool) {
+	if m.E-m.B > 64 {
+		return 0, false
+	}
+	var n uint64
+	for _, r := range m.R[m.B:m.E] {
+		if r != '0' && r != '1' {
+			return 0, false
+		}
+		n = n<<1 | uint64(r-'0')
+	}
+	return n, true
+}
+
+// Bytes returns the UTF-8 encoding of the matched region without first
+// converting it to a string (see Text). Use AppendBytes to reuse an
+// existing buffer and avoid allocation entirely.
+func (m Result) Bytes() []byte {
+	n := 0
+	for _, r := range m.R[m.B:m.E] {
+		n += utf8.RuneLen(r)
+	}
+	return m.AppendBytes(make([]byte, 0, n))
+}
+
+// AppendBytes appends the UTF-8 encoding of the matched region to buf
+// and returns the extended buffer (like the strconv Append functions).
+func (m Result) AppendBytes(buf []byte) []byte {
+	for _, r := range m.R[m.B:m.E] {
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+// Pos returns the line and column (both starting at 1 and counted in
+// runes) of the beginning (B) of the result within the buffer (R).
+// Lines are terminated by line feeds (\n).
+func (m Result) Pos() (line, col int) {
+	line, col = 1, 1
+	for n := 0; n < m.B && n < len(m.R); n++ {
+		if m.R[n] == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return
+}
+
+// Location returns the source (S), line, and column of the beginning of
+// the result in the form "file.pegn:12:3". The source is omitted if
+// empty ("12:3").
+func (m Result) Location() string {
+	line, col := m.Pos()
+	if m.S == "" {
+		return fmt.Sprintf(`%v:%v`, line, col)
+	}
+	return fmt.Sprintf(`%v:%v:%v`, m.S, line, col)
+}
+
+// SetSource sets the source (S) of the result and every one of its
+// descendants.
+func (m *Result) SetSource(src string) {
+	m.S = src
+	for n := range m.C {
+		m.C[n].SetSource(src)
+	}
+}
+
 // FlatFunc is function that returns a flattened rooted-node tree.
 type FlatFunc func(root Result) []Result
 
@@ -177,6 +422,28 @@ func WalkBy(flatten FlatFunc, root Result, do VisitFunc) {
 	}
 }
 
+// PathVisitFunc is like VisitFunc but is also passed the path of
+// ancestors leading to the visited result starting with the root. The
+// path is empty for the root itself. This allows transformations that
+// depend on context ("Key inside Section") without having to rebuild
+// that context manually.
+type PathVisitFunc func(a Result, path []Result)
+
+// WalkPath traverses a rooted node tree of Result structs in the same
+// synchronous, depth-first, preorder way as ByDepth passing each result
+// and its ancestor path to the PathVisitFunc. Note that the underlying
+// array of the path slice is reused between calls and must be copied if
+// retained.
+func WalkPath(root Result, do PathVisitFunc) { walkPath(root, nil, do) }
+
+func walkPath(m Result, path []Result, do PathVisitFunc) {
+	do(m, path)
+	path = append(path, m)
+	for _, child := range m.C {
+		walkPath(child, path, do)
+	}
+}
+
 // MaxGoroutines set the maximum number of goroutines by any method or
 // function in this package (WalkByAsync, for example). By default,
 // there is no limit (0).
@@ -195,3 +462,141 @@ func (m Result) WithName(names ...string) []Result {
 	})
 	return results
 }
+
+// WithNameMatch is like WithName but each of the patterns is a glob
+// (see path.Match) allowing names such as Heading1 through Heading6 to
+// be collected with a single pattern (Heading?). Unlike WithName,
+// results are returned in the order found no matter which pattern
+// matched. Malformed patterns never match.
+func (m Result) WithNameMatch(patterns ...string) []Result {
+	results := []Result{}
+	Walk(m, func(r Result) {
+		if r.N == "" {
+			return
+		}
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, r.N); ok {
+				results = append(results, r)
+				return
+			}
+		}
+	})
+	return results
+}
+
+// WithNameRegexp is like WithNameMatch but uses a regular expression.
+func (m Result) WithNameRegexp(re *regexp.Regexp) []Result {
+	results := []Result{}
+	Walk(m, func(r Result) {
+		if r.N != "" && re.MatchString(r.N) {
+			results = append(results, r)
+		}
+	})
+	return results
+}
+
+// Child returns the first immediate child (C) with the given name (N)
+// and true, or an empty Result and false if there is none. Prefer this
+// to indexing into C directly since optional rules can shift the
+// positions of the children.
+func (m Result) Child(name string) (Result, bool) {
+	for _, c := range m.C {
+		if c.N == name {
+			return c, true
+		}
+	}
+	return Result{}, false
+}
+
+// Children returns all immediate children (C) with the given name (N).
+// Returns zero length slice if no results. Unlike WithName, only the
+// immediate children are considered.
+func (m Result) Children(name string) []Result {
+	results := []Result{}
+	for _, c := range m.C {
+		if c.N == name {
+			results = append(results, c)
+		}
+	}
+	return results
+}
+
+// Decode passes the matched text (see Bytes) to the UnmarshalText
+// method of v so that any type implementing encoding.TextUnmarshaler
+// (net.IP, time.Time, big.Int, and custom types) can be set from
+// a result without an adapter. The error of UnmarshalText is returned.
+func (m Result) Decode(v encoding.TextUnmarshaler) error {
+	return v.UnmarshalText(m.Bytes())
+}
+
+// DecodeName is the same as Decode but for the first result (including
+// this one) with the name (see WithName). An ErrNotFound is returned
+// if there is none.
+func (m Result) DecodeName(name string, v encoding.TextUnmarshaler) error {
+	found := m.WithName(name)
+	if len(found) == 0 {
+		return ErrNotFound{name}
+	}
+	return found[0].Decode(v)
+}
+
+// Contains returns true if the span of the other result (B to E) is
+// entirely within the span of this one. Only positions are compared.
+// To compare against an arbitrary span (an editor selection, for
+// example) simply pass a Result with only B and E set.
+func (m Result) Contains(o Result) bool {
+	return m.B <= o.B && o.E <= m.E
+}
+
+// Overlaps returns true if the span of the other result shares at least
+// one rune with this one. Since E is exclusive, results that only touch
+// (m.E == o.B) do not overlap.
+func (m Result) Overlaps(o Result) bool {
+	return m.B < o.E && o.B < m.E
+}
+
+// Union returns a new Result with a span from the lowest beginning (B)
+// to the highest ending (E) of both results and with the buffer (R) of
+// this one. No other fields are set.
+func (m Result) Union(o Result) Result {
+	u := Result{B: m.B, E: m.E, R: m.R}
+	if o.B < u.B {
+		u.B = o.B
+	}
+	if o.E > u.E {
+		u.E = o.E
+	}
+	return u
+}
+
+// Leaves returns only the terminal results (those without children) in
+// the order they appear (depth-first, preorder). This is effectively
+// the token stream of any parse. If the result has no children, it is
+// itself returned as the only leaf.
+func (m Result) Leaves() []Result {
+	results := []Result{}
+	Walk(m, func(r Result) {
+		if len(r.C) == 0 {
+			results = append(results, r)
+		}
+	})
+	return results
+}
+
+// Errors returns every result (including this one) that has an error
+// (X) ordered by position (B) so that callers doing error recovery can
+// report all of the problems at once. Results with the same position
+// remain in depth-first, preorder order (parents before children).
+// Returns zero length slice if no errors.
+func (m Result) Errors() []Result {
+	results := []Result{}
+	Walk(m, func(r Result) {
+		if r.X != nil {
+			results = append(results, r)
+		}
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].B < results[j].B
+	})
+	return results
+}
@@ -0,0 +1,110 @@
+package rat
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ScanAll scans large, record-oriented input ([]rune, string, []byte,
+// or io.Reader) using every core by splitting it into chunks (several
+// per worker) that are scanned concurrently. Chunks end only at safe
+// boundaries (after a match of the boundary expression, such as "\n"
+// between records) and the Main rule of the grammar must match each
+// chunk entirely (usually a repetition of records). Since a Grammar
+// cannot be shared between concurrent scans each of the workers
+// (runtime.NumCPU if less than 1, never more than MaxGoroutines if
+// set) calls grammar to create its own. The input is read and
+// converted once (see MaxInput, UTF8, and NormalizeEOL of the first
+// Grammar) and each chunk is otherwise scanned just as by Scan
+// (limits, Expect, Locate, and binds included).
+//
+// The Result returned has the Result of every chunk as its children
+// (C) in input order and shares the same input buffer (R) so that all
+// positions (B and E) are within the entire input. If any chunk fails
+// (or does not match entirely, see ErrIncomplete) its error is set as
+// the error (X) of the Result and the end (E) is where that chunk
+// began.
+func ScanAll(in any, boundary any, grammar func() *Grammar, workers int) Result {
+
+	// the input pipeline (see MaxInput, UTF8, NormalizeEOL) is run once
+	// for the entire input so positions agree across chunks
+	first := grammar()
+	var runes []rune
+	var err error
+	if first.MaxInput > 0 || first.UTF8 != UTF8AsIs || first.NormalizeEOL {
+		runes, err = first.input(in)
+	} else {
+		runes, err = toRunes(in)
+	}
+	if err != nil {
+		return Result{R: runes, X: err}
+	}
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if MaxGoroutines > 0 && workers > MaxGoroutines {
+		workers = MaxGoroutines
+	}
+
+	// split into chunks ending after the first boundary at or beyond
+	// the target size
+	var ends []int
+	b := new(Grammar).Init().Pack(boundary)
+	size := len(runes)/(workers*4) + 1
+	for i := size; i < len(runes); i += size {
+		for ; i < len(runes); i++ {
+			if res := b.Main.Check(runes, i); res.X == nil && res.E > i {
+				i = res.E
+				ends = append(ends, i)
+				break
+			}
+		}
+	}
+	if len(ends) == 0 || ends[len(ends)-1] < len(runes) {
+		ends = append(ends, len(runes))
+	}
+
+	results := make([]Result, len(ends))
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		g := first
+		if n > 0 {
+			g = grammar()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range chunks {
+				var beg int
+				if n > 0 {
+					beg = ends[n-1]
+				}
+				if g.Main == nil {
+					results[n] = Result{R: runes, B: beg, E: beg, X: ErrIsZero{g.Main}}
+					continue
+				}
+				results[n] = g.check(runes[:ends[n]], beg)
+			}
+		}()
+	}
+	for n := range ends {
+		chunks <- n
+	}
+	close(chunks)
+	wg.Wait()
+
+	root := Result{R: runes, C: results}
+	for n, res := range results {
+		if res.X == nil && res.E != ends[n] {
+			res.X = ErrIncomplete{res.E, ends[n]}
+		}
+		if res.X != nil {
+			root.X = res.X
+			return root
+		}
+		root.E = res.E
+	}
+	return root
+}
@@ -0,0 +1,44 @@
+package rat
+
+// ScanOptions are the options of a Grammar.ScanWith.
+type ScanOptions struct {
+	Start int  // position (in runes) at which to begin
+	Max   int  // maximum runes from Start to consider (0 is all)
+	Full  bool // every rune considered must match (ErrIncomplete)
+}
+
+// ScanWith is the same as Scan but begins at the Start of the options
+// (limited to the end of the input) and considers no more than Max
+// runes from there as if the input ended (so x.End matches there) with
+// the buffer (R) of the Result ending there as well. When Full is set
+// a match that does not reach the end of the runes considered fails
+// with ErrIncomplete (as if the Main rule ended with x.End) but keeps
+// its end (E) and children. This avoids slicing buffers (which changes
+// positions) and adding End rules (which changes the grammar) by hand.
+func (g *Grammar) ScanWith(in any, opts ScanOptions) Result {
+	var runes []rune
+	var err error
+	if g.MaxInput > 0 || g.UTF8 != UTF8AsIs || g.NormalizeEOL {
+		runes, err = g.input(in)
+	} else {
+		runes, err = toRunes(in)
+	}
+	if err != nil {
+		return Result{R: runes, X: err}
+	}
+	i := opts.Start
+	if i < 0 {
+		i = 0
+	}
+	if i > len(runes) {
+		i = len(runes)
+	}
+	if opts.Max > 0 && i+opts.Max < len(runes) {
+		runes = runes[:i+opts.Max]
+	}
+	res := g.scan(runes, i)
+	if opts.Full && res.X == nil && res.E < len(runes) {
+		res.X = ErrIncomplete{res.E, len(runes)}
+	}
+	return res
+}
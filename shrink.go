@@ -0,0 +1,113 @@
+package rat
+
+import "fmt"
+
+// Counterexample is the smallest input found that fails the same way
+// as another (see Grammar.Shrink). Input fails at position I (in runes)
+// with the same rule (Rule) and error (X) as the farthest failure (see
+// Farthest Failure) of the original input. Rule is nil (and X an
+// ErrIncomplete) when the input is matched but not entirely.
+type Counterexample struct {
+	Prefix string // shortest prefix of the original input failing the same way
+	Input  string // smallest input (from Prefix) failing the same way
+	I      int    // position of the failure within Input
+	Rule   *Rule  // sub-rule that rejected the input
+	X      error  // error of the rejecting rule
+}
+
+// String fulfills the fmt.Stringer interface with a single line
+// containing the Input, position, rule, and error.
+func (c Counterexample) String() string {
+	var text string
+	if c.Rule != nil {
+		text = c.Rule.Text
+	}
+	return fmt.Sprintf(CounterexampleT, c.Input, c.I, text, c.X)
+}
+
+// Shrink returns the smallest input that fails the rule (the Main rule
+// if nil) the same way as the input ([]rune, string, []byte, or
+// io.Reader), the same way meaning with the same rule rejecting it with
+// the same error at the farthest failure (see Farthest Failure). First
+// the shortest failing prefix of the input is found and then every part
+// of it that can be removed (from halves down to single runes) is
+// removed, like the shrinking of a property-based test, leaving only
+// what is needed to reproduce the problem. False is returned if the
+// input is matched entirely (nothing to shrink) or cannot be read.
+func (g *Grammar) Shrink(rule *Rule, in any) (Counterexample, bool) {
+	if rule == nil {
+		rule = g.Main
+	}
+	if rule == nil || rule.Check == nil {
+		return Counterexample{}, false
+	}
+	r, err := toRunes(in)
+	if err != nil {
+		return Counterexample{}, false
+	}
+
+	want, failed := g.failure(rule, r)
+	if !failed {
+		return Counterexample{}, false
+	}
+	short := want.I >= len(r) // failed only by running out of input
+	same := func(r []rune) bool {
+		got, failed := g.failure(rule, r)
+		if !failed || got.Rule != want.Rule || (got.I >= len(r)) != short {
+			return false
+		}
+		if _, is := want.X.(ErrIncomplete); is {
+			_, is = got.X.(ErrIncomplete)
+			return is
+		}
+		return got.X.Error() == want.X.Error()
+	}
+
+	prefix := r
+	for n := want.I + 1; n < len(r); n++ {
+		if same(r[:n]) {
+			prefix = r[:n]
+			break
+		}
+	}
+
+	// remove every chunk possible from halves down to single runes
+	small := append([]rune{}, prefix...)
+	for size := len(small) / 2; size > 0; {
+		removed := false
+		for beg := 0; beg+size <= len(small); {
+			try := append(append([]rune{}, small[:beg]...), small[beg+size:]...)
+			if same(try) {
+				small, removed = try, true
+				continue
+			}
+			beg += size
+		}
+		if !removed || size > len(small) {
+			size /= 2
+		}
+	}
+
+	c, _ := g.failure(rule, small)
+	c.Prefix, c.Input = string(prefix), string(small)
+	return c, true
+}
+
+// failure checks the runes with the rule and returns the farthest
+// failure (if the rule did not match all of them) as a Counterexample
+// without the Prefix and Input.
+func (g *Grammar) failure(rule *Rule, r []rune) (Counterexample, bool) {
+	g.Farthest = Result{}
+	res := rule.Check(r, 0)
+	g.discard(res)
+	if res.X == nil && res.E == len(r) {
+		return Counterexample{}, false
+	}
+	if g.Farthest.X == nil {
+		if res.X != nil {
+			return Counterexample{I: res.E, Rule: res.O, X: res.X}, true
+		}
+		return Counterexample{I: res.E, X: ErrIncomplete{res.E, len(r)}}, true
+	}
+	return Counterexample{I: g.Farthest.E, Rule: g.Farthest.O, X: g.Farthest.X}, true
+}
@@ -0,0 +1,61 @@
+package rat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SnippetContext is the number of lines before the failing one shown
+// by PrintError (see Snippet).
+var SnippetContext = 0
+
+// Snippet returns the line of the input (R) at which the result failed
+// (the position of the error, see ErrorInfo, or the end, E, if it has
+// none) preceded by up to context lines before it with a caret (^) on
+// the line after it under the rune at that position. Lines are numbered
+// (starting at 1) in a gutter to the left and trailing white space is
+// removed. Tabs before the position are kept on the caret line so that
+// it lines up however they are shown.
+//
+//	2 | let x = one
+//	  |         ^
+func (m Result) Snippet(context int) string {
+	m.R = m.redacted()
+	at := m.E
+	if i, has := offset(m.X); has {
+		at = i
+	}
+	if at > len(m.R) {
+		at = len(m.R)
+	}
+	line, col := Result{R: m.R, B: at}.Pos()
+
+	beg := at - (col - 1)
+	for n := 0; n < context && beg > 0; n++ {
+		beg--
+		for beg > 0 && m.R[beg-1] != '\n' {
+			beg--
+		}
+	}
+	end := at
+	for end < len(m.R) && m.R[end] != '\n' {
+		end++
+	}
+
+	lines := strings.Split(string(m.R[beg:end]), "\n")
+	first := line - len(lines) + 1
+	width := len(fmt.Sprint(line))
+	buf := new(strings.Builder)
+	for n, text := range lines {
+		text = strings.TrimRight(fmt.Sprintf("%*v | %v", width, first+n, text), " \t")
+		buf.WriteString(text + "\n")
+	}
+	caret := []rune(lines[len(lines)-1])[:col-1]
+	for n, r := range caret {
+		if r != '\t' {
+			caret[n] = ' '
+		}
+	}
+	fmt.Fprintf(buf, "%*v | %v^", width, "", string(caret))
+	return buf.String()
+}
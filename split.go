@@ -0,0 +1,66 @@
+package rat
+
+import (
+	"bufio"
+	"unicode/utf8"
+)
+
+// SplitFunc returns a bufio.SplitFunc that splits a stream into tokens
+// each matching the Main rule from the beginning of the remaining data
+// so that existing bufio.Scanner pipelines can adopt grammar-defined
+// record boundaries (multi-line log records, for example). Separators
+// between records must be matched by the rule itself. Since a match
+// might change with more data, the scanner is asked for more whenever
+// the match (or any terminal rule checked, see Farthest) reached the
+// end of the data read so far. The error (X) of the Result is returned
+// if the rule fails and ErrEmptyToken if it succeeds without advancing
+// (which would otherwise loop forever).
+//
+// SplitFunc is provided by the Grammar (rather than a Rule) since
+// Farthest is needed to know when lookahead (or a failed optional
+// continuation) reached the end of the data.
+func (g *Grammar) SplitFunc() bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if g.Main == nil || g.Main.Check == nil {
+			return 0, nil, ErrNoCheckFunc{g.Main}
+		}
+
+		// do not check partial runes until the end
+		buf := data
+		if !atEOF {
+			for n := len(buf) - 1; n >= 0 && n >= len(buf)-utf8.UTFMax; n-- {
+				if utf8.RuneStart(buf[n]) {
+					if !utf8.FullRune(buf[n:]) {
+						buf = buf[:n]
+					}
+					break
+				}
+			}
+		}
+		runes := decodeRunes(buf)
+
+		g.Farthest = Result{}
+		res := g.Main.Check(runes, 0)
+
+		// failed Any rules end one before the end
+		if !atEOF && (res.E >= len(runes) || g.Farthest.E >= len(runes)-1) {
+			return 0, nil, nil
+		}
+		if res.X != nil {
+			return 0, nil, res.X
+		}
+		if res.E == 0 {
+			return 0, nil, ErrEmptyToken{g.Main}
+		}
+
+		var n int
+		for i := 0; i < res.E; i++ {
+			_, size := utf8.DecodeRune(data[n:])
+			n += size
+		}
+		return n, data[:n], nil
+	}
+}
@@ -0,0 +1,178 @@
+package rat
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// RuleStats are the statistics collected for a single rule (see
+// Grammar.CollectStats). Time includes the time spent checking every
+// rule within it and Self only the time spent in the rule itself.
+type RuleStats struct {
+	Rule    *Rule
+	Calls   int
+	Matches int
+	Time    time.Duration
+	Self    time.Duration
+}
+
+// Rate returns the fraction of calls that matched (0 if never called).
+func (s RuleStats) Rate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Matches) / float64(s.Calls)
+}
+
+// String fulfills the fmt.Stringer interface with a single line
+// containing the calls, matches, rate, total time, and rule (Text).
+func (s RuleStats) String() string {
+	return fmt.Sprintf(`%v %v %.2f %v %v`,
+		s.Calls, s.Matches, s.Rate(), s.Time, s.Rule.Text)
+}
+
+// CollectStats begins (or restarts with zeroed counts) the collection
+// of statistics for every rule currently in the grammar by wrapping the
+// CheckFunc of each so that authors can see which rules are slow,
+// which alternatives never match, and which rules need restructuring
+// (see Stats). Collecting slows scanning considerably. Rules added
+// after are not included until called again.
+func (g *Grammar) CollectStats() {
+	if g.stats == nil {
+		g.stats = map[*Rule]*RuleStats{}
+	}
+	g.calls = &callNode{}
+	for _, rule := range g.Ordered() {
+		if s, has := g.stats[rule]; has {
+			*s = RuleStats{Rule: rule}
+			continue
+		}
+		if rule.Check == nil {
+			continue
+		}
+		s := &RuleStats{Rule: rule}
+		g.stats[rule] = s
+		rule, check := rule, rule.Check
+		rule.Check = func(r []rune, i int) Result {
+			parent, inner := g.calls, g.inner
+			g.calls, g.inner = parent.kid(rule), 0
+			start := time.Now()
+			res := check(r, i)
+			elapsed := time.Since(start)
+			self := elapsed - g.inner
+			g.calls.self += self
+			g.calls, g.inner = parent, inner+elapsed
+			s.Time += elapsed
+			s.Self += self
+			s.Calls++
+			if res.X == nil {
+				s.Matches++
+			}
+			return res
+		}
+	}
+}
+
+// Stats returns the statistics collected (see CollectStats) for every
+// rule that has been called sorted by the most total time first.
+func (g *Grammar) Stats() []RuleStats {
+	var list []RuleStats
+	for _, s := range g.stats {
+		if s.Calls > 0 {
+			list = append(list, *s)
+		}
+	}
+	sort.SliceStable(list, func(a, b int) bool {
+		if list[a].Time != list[b].Time {
+			return list[a].Time > list[b].Time
+		}
+		return list[a].Rule.ID < list[b].Rule.ID
+	})
+	return list
+}
+
+// WriteStats writes the statistics (see Stats) as a table with aligned
+// columns (calls, matches, rate, time, self, and rule) and a header.
+func (g *Grammar) WriteStats(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, StatsHeaderT)
+	for _, s := range g.Stats() {
+		fmt.Fprintf(tw, "%v\t%v\t%.2f\t%v\t%v\t%v\n",
+			s.Calls, s.Matches, s.Rate(), s.Time, s.Self, s.Rule.Text)
+	}
+	return tw.Flush()
+}
+
+// callNode is a single rule within the tree of rules called while
+// collecting statistics (see Folded).
+type callNode struct {
+	rule *Rule
+	self time.Duration
+	kids map[*Rule]*callNode
+	list []*callNode // kids in order first called
+}
+
+// kid returns the node for the rule called from this one.
+func (n *callNode) kid(rule *Rule) *callNode {
+	if kid, has := n.kids[rule]; has {
+		return kid
+	}
+	if n.kids == nil {
+		n.kids = map[*Rule]*callNode{}
+	}
+	kid := &callNode{rule: rule}
+	n.kids[rule] = kid
+	n.list = append(n.list, kid)
+	return kid
+}
+
+// Folded returns the time spent in every rule itself (see
+// RuleStats.Self) for every stack of rules called (see CollectStats)
+// keyed by the rules of the stack (outermost first) separated by
+// semicolons. Named rules are identified by name and others by Text.
+func (g *Grammar) Folded() map[string]time.Duration {
+	folded := map[string]time.Duration{}
+	if g.calls == nil {
+		return folded
+	}
+	var walk func(n *callNode, stack string)
+	walk = func(n *callNode, stack string) {
+		for _, kid := range n.list {
+			name := kid.rule.Text
+			if _, named := kid.rule.Expr.(x.N); named {
+				name = kid.rule.Name
+			}
+			key := name
+			if stack != "" {
+				key = stack + `;` + name
+			}
+			folded[key] += kid.self
+			walk(kid, key)
+		}
+	}
+	walk(g.calls, "")
+	return folded
+}
+
+// WriteFolded writes the folded stacks (see Folded) one per line
+// (sorted) followed by the nanoseconds spent, the format consumed by
+// flame graph tools (such as flamegraph.pl and speedscope).
+func (g *Grammar) WriteFolded(w io.Writer) error {
+	folded := g.Folded()
+	keys := make([]string, 0, len(folded))
+	for key := range folded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%v %v\n", key, int64(folded[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package std_test
+
+import (
+	"fmt"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/std"
+	"github.com/rwxrob/rat/x"
+)
+
+func Example() {
+
+	g := rat.Pack(x.One{std.DateTime, std.Float, std.Integer, std.String})
+	for _, it := range []string{`-42`, `6.02E+23`, `2023-01-31T23:59:59.5-08:00`, `"say \"hi\"\n"`} {
+		res := g.Scan(it)
+		fmt.Println(res.C[0].N, res.E == len(it))
+	}
+
+	// Output:
+	// Integer true
+	// Float true
+	// DateTime true
+	// String true
+
+}
+
+func ExampleAdd() {
+
+	g := std.Add(new(rat.Grammar).Init())
+	g.Pack(x.N{`Entry`, x.Seq{x.Ref{`Date`}, ' ', x.Ref{`Integer`}}})
+	res := g.Scan(`2024-02-29 -7`)
+	for _, it := range res.WithName(`Date`, `Integer`) {
+		fmt.Println(it.N, it.Text())
+	}
+	fmt.Println(g.Scan(`2024-13-01 7`).X)
+
+	// Output:
+	// Date 2024-02-29
+	// Integer -7
+	// expected: x.One{x.Seq{x.Str{"0"}, x.Rng{'1', '9'}}, x.Seq{x.Str{"1"}, x.Rng{'0', '2'}}}
+
+}
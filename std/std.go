@@ -0,0 +1,79 @@
+/*
+Package std contains ready-made named (x.N) rat/x rules for the lexemes
+most grammars need (integers, floats, ISO-8601 dates and times, and
+double-quoted strings with escapes) so that they need not be rewritten
+for every grammar. Use them directly within any rat/x expression or add
+them all to a Grammar (see Add) so that they can be referenced (x.Ref)
+by name, even from grammars parsed from PEGN.
+*/
+package std
+
+import (
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/x"
+)
+
+var (
+	digit  = x.Rng{'0', '9'}
+	digits = x.Mmx{1, -1, digit}
+	sign   = x.Mmx{0, 1, x.One{'+', '-'}}
+	hexdig = x.One{digit, x.Rng{'a', 'f'}, x.Rng{'A', 'F'}}
+	exp    = x.Seq{x.One{'e', 'E'}, sign, digits}
+	hour   = x.One{x.Seq{x.Rng{'0', '1'}, digit}, x.Seq{'2', x.Rng{'0', '3'}}}
+	minute = x.Seq{x.Rng{'0', '5'}, digit}
+)
+
+// Integer is an optionally signed decimal integer (-42, +7, 0).
+var Integer = x.N{`Integer`, x.Seq{sign, digits}}
+
+// Float is an optionally signed decimal number with a fraction and/or
+// exponent (3.14, -1e10, 6.02E+23). Integers are not matched so that
+// Float can be tried before Integer as alternatives.
+var Float = x.N{`Float`, x.Seq{
+	sign, digits,
+	x.One{x.Seq{'.', digits, x.Mmx{0, 1, exp}}, exp},
+}}
+
+// Date is an ISO-8601 calendar date (2023-01-31).
+var Date = x.N{`Date`, x.Seq{
+	x.Mmx{4, 4, digit}, '-',
+	x.One{x.Seq{'0', x.Rng{'1', '9'}}, x.Seq{'1', x.Rng{'0', '2'}}}, '-',
+	x.One{x.Seq{'0', x.Rng{'1', '9'}}, x.Seq{x.Rng{'1', '2'}, digit}, x.Seq{'3', x.Rng{'0', '1'}}},
+}}
+
+// Time is an ISO-8601 time of day with optional fractional seconds
+// (23:59:59.999).
+var Time = x.N{`Time`, x.Seq{
+	hour, ':', minute, ':', minute, x.Mmx{0, 1, x.Seq{'.', digits}},
+}}
+
+// Zone is an ISO-8601 time zone designator (Z, +05:30, -08:00).
+var Zone = x.N{`Zone`, x.One{'Z', x.Seq{x.One{'+', '-'}, hour, ':', minute}}}
+
+// DateTime is an ISO-8601 (RFC 3339) date and time with optional Zone
+// (2023-01-31T23:59:59Z) containing named Date, Time, and Zone results.
+var DateTime = x.N{`DateTime`, x.Seq{Date, x.One{'T', 't', ' '}, Time, x.Mmx{0, 1, Zone}}}
+
+// String is a double-quoted string with the backslash escapes of JSON
+// (\" \\ \/ \b \f \n \r \t \u263A). Unescaped line returns are not
+// allowed.
+var String = x.N{`String`, x.Seq{
+	'"',
+	x.Mmx{0, -1, x.One{
+		x.Seq{'\\', x.One{'"', '\\', '/', 'b', 'f', 'n', 'r', 't', x.Seq{'u', x.Mmx{4, 4, hexdig}}}},
+		x.Seq{x.Not{x.One{'"', '\\', '\n', '\r'}}, x.Any{1}},
+	}},
+	'"',
+}}
+
+// Rules contains every rule of the package in the order documented.
+var Rules = []x.N{Integer, Float, Date, Time, Zone, DateTime, String}
+
+// Add makes every one of the Rules in the Grammar so that they can be
+// referenced (x.Ref) by name. Returns the Grammar for convenience.
+func Add(g *rat.Grammar) *rat.Grammar {
+	for _, rule := range Rules {
+		g.MakeRule(rule)
+	}
+	return g
+}
@@ -0,0 +1,90 @@
+package rat
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// streamChunk is the number of bytes read at a time by ScanStream.
+const streamChunk = 64 * 1024
+
+// StreamFunc is passed the Result of every record matched by ScanStream
+// and the position (in runes) of the beginning of its buffer (R) within
+// the stream.
+type StreamFunc func(off int, res Result)
+
+// ScanStream scans a stream (of any size) one record (match of the Main
+// rule) at a time passing each to do. Only a window of the stream is
+// kept in memory. Runes before the current record are discarded since
+// no rule can backtrack before the beginning of the record being
+// matched, so memory stays proportional to the size of the largest
+// record rather than the entire stream. Just as with SplitFunc (which
+// is preferred for bufio.Scanner pipelines), separators between records
+// must be matched by the rule itself and more of the stream is read
+// whenever the match (or Farthest) reaches the end of the window. Every
+// Result (and its buffer) passed to do must not be kept after it
+// returns (see Result.Text and such to keep what is needed).
+//
+// The error (X) of any failing record is returned (see ErrStream) as
+// is ErrEmptyToken if the rule succeeds without advancing and any error
+// reading the stream.
+func (g *Grammar) ScanStream(in io.Reader, do StreamFunc) error {
+	if g.Main == nil || g.Main.Check == nil {
+		return ErrNoCheckFunc{g.Main}
+	}
+
+	var window []rune
+	var partial []byte
+	var off int
+	buf := make([]byte, streamChunk)
+
+	for eof := false; ; {
+
+		// every complete record in the window
+		for len(window) > 0 {
+			g.Farthest = Result{}
+			res := g.Main.Check(window, 0)
+			if !eof && (res.E >= len(window) || g.Farthest.E >= len(window)-1) {
+				break
+			}
+			if res.X != nil {
+				return ErrStream{off + res.E, res.X}
+			}
+			if res.E == 0 {
+				return ErrEmptyToken{g.Main}
+			}
+			do(off, res)
+			off += res.E
+			window = window[res.E:]
+		}
+
+		if eof {
+			return nil
+		}
+
+		n, err := in.Read(buf)
+		data := append(partial, buf[:n]...)
+		if err == io.EOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+
+		// partial runes are kept until the rest is read
+		partial = nil
+		if !eof {
+			for n := len(data) - 1; n >= 0 && n >= len(data)-utf8.UTFMax; n-- {
+				if utf8.RuneStart(data[n]) {
+					if !utf8.FullRune(data[n:]) {
+						partial = append(partial, data[n:]...)
+						data = data[:n]
+					}
+					break
+				}
+			}
+		}
+
+		// appending only reallocates (dropping discarded runes) when full
+		window = append(window, decodeRunes(data)...)
+	}
+}
@@ -0,0 +1,134 @@
+package rat
+
+import (
+	"sort"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// MaxSuggest is the maximum number of names suggested in place of one
+// that does not exist (see ErrUnresolved).
+var MaxSuggest = 3
+
+// suggest returns up to MaxSuggest of the names (from) closest to the
+// name (see distance), closest first, that are close enough to be
+// a typo: one edit for every three runes (at least one).
+func suggest(name string, from []string) []string {
+	max := len([]rune(name)) / 3
+	if max < 1 {
+		max = 1
+	}
+	type near struct {
+		name string
+		d    int
+	}
+	var list []near
+	seen := map[string]bool{name: true}
+	for _, it := range from {
+		if seen[it] {
+			continue
+		}
+		seen[it] = true
+		if d := distance(name, it); d <= max {
+			list = append(list, near{it, d})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].d != list[j].d {
+			return list[i].d < list[j].d
+		}
+		return list[i].name < list[j].name
+	})
+	var names []string
+	for n := 0; n < len(list) && n < MaxSuggest; n++ {
+		names = append(names, list[n].name)
+	}
+	return names
+}
+
+// distance returns the number of runes inserted, deleted, substituted,
+// or swapped with the next one (optimal string alignment) to turn a
+// into b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] &&
+				d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// refNames returns the names that an x.Ref can refer to: every named
+// (x.N) rule, alias (see MakeAlias), and built-in class (see
+// x.Classes).
+func (g *Grammar) refNames() []string {
+	var names []string
+	for _, rule := range g.Ordered() {
+		if _, named := rule.Expr.(x.N); named || rule.IsAlias() {
+			names = append(names, rule.Name)
+		}
+	}
+	for name := range x.Classes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// savNames returns the names of every value that can be saved (see
+// x.Sav) by the rules of the grammar.
+func (g *Grammar) savNames() []string {
+	var names []string
+	for _, rule := range g.byid {
+		if sav, is := rule.Expr.(x.Sav); is && len(sav) == 1 {
+			if name, is := sav[0].(string); is {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// unresolved returns the ErrUnresolved of the rule (exp, an x.Ref or
+// x.Val) referring to the name or nil if the name is one of the names
+// after all. The result is cached (err) until rules are added or
+// rebound (stamp) since names are only looked up for the suggestions.
+func (g *Grammar) unresolved(exp any, name string, names func() []string, err *error, stamp *[2]int) error {
+	now := [2]int{len(g.byid), g.rebind}
+	if *stamp == now {
+		return *err
+	}
+	*stamp, *err = now, nil
+	list := names()
+	if contains(list, name) {
+		return nil
+	}
+	*err = ErrUnresolved{V: exp, Suggest: suggest(name, list)}
+	return *err
+}
@@ -1,14 +1,86 @@
 package rat
 
 // KEEP APP TEXT HERE
-// (This should be the only file to need translation, if needed.)
+// (This should be the only file to need translation, if needed, and
+// error text can be translated at run time, see SetMessages.)
 
 const (
+	ErrNoMatchT     = `no match`
+	ErrLimitT       = `limit reached`
+	ErrBadGrammarT  = `bad grammar`
 	ErrIsZeroT      = `zero value: %T`
 	ErrNotExistT    = `does not exist: %v`
 	ErrExpectedT    = `expected: %v`
+	ErrAtT          = `%v at line %v, col %v`
+	ErrOneOfT       = `expected one of: %v`
+	ErrOneOfSep     = `, `
 	ErrBadTypeT     = `unknown type: %v (%[1]T)`
 	ErrArgsT        = `missing or incorrect arguments: %v (%[1]T)`
 	ErrPackTypeT    = `invalid type`
 	ErrNoCheckFuncT = `no check function assigned: %v`
+	ErrEmptyTokenT  = `matched without advancing: %v`
+	ErrCBORT        = `invalid CBOR result at byte %v`
+	ErrIncompleteT  = `unmatched input from %v to %v`
+	ErrStreamT      = `stream position %v: %v`
+	ErrSyntaxT      = `line %v, col %v: expected %v`
+	ErrSyntaxOr     = ` or `
+	ErrUnexpectedT  = `line %v, col %v: unexpected input`
+	ErrUnreachableT = `unreachable alternative %v (shadowed by %v) in %v`
+	ErrNullableT    = `unlimited repetition of rule matching empty: %v`
+	ErrGenerateT    = `no input generated was matched in %v tries`
+	ErrReplayT      = `replay differs in %v: got %v, want %v`
+	ErrMakeT        = `malformed expression: %v`
+	ErrUsageT       = `%v in %v`
+	ErrUsageSep     = ` → `
+	ErrUnresolvedT  = `unresolved: %v`
+	ErrSuggestT     = `unresolved: %v (did you mean %v?)`
+	ErrSuggestSep   = ` or `
+	ErrRefsSep      = `; `
+)
+
+const (
+	ErrTooDeepT      = `rules nested deeper than %v at %v`
+	ErrTooManyStepsT = `more than %v rules checked at %v`
+	ErrTooLargeT     = `input larger than %v runes`
+	ErrTimeoutT      = `scan took longer than %v at %v`
+)
+
+const ErrInvalidEncodingT = `invalid UTF-8 at byte %v`
+
+const (
+	ErrBoundsT      = `repetition can only match nothing: %v`
+	ErrLongLiteralT = `literal of %v bytes keyed by hash`
+)
+
+const ErrConflictT = `rule %q already defined as %v (not %v)`
+
+const ErrBindT = `cannot bind %v %q: %v`
+
+const ErrLengthT = `invalid length: %q`
+
+const ErrNoDecoderT = `no PEGN decoder (import rat/pegn)`
+
+const (
+	TraceMakeT    = `MakeRule(%v)`
+	TraceEnterT   = `Check(%v) %v`
+	TraceCheckT   = `Check(%v) %v-%v %v %v`
+	TraceMatchedT = `matched`
+	TraceMakeMsg  = `make rule`
+	TraceEnterMsg = `enter rule`
+	TraceCheckMsg = `check rule`
+)
+
+const StatsHeaderT = "calls\tmatches\trate\ttime\tself\trule"
+
+const CounterexampleT = `%q fails at %v in %v: %v`
+
+const (
+	CacheHeaderT = "size\tid\ttext\tkey"
+	CacheStatsT  = `keys %v, rules %v, saved %v, hashed %v, key bytes %v (max %v), text bytes %v, free %v (cap %v), buffer %v`
+)
+
+const (
+	StepT       = "%v %v at %v (%v)\n  %v"
+	StepPromptT = `[enter] next, c continue: `
+	BreakT      = "break: "
 )
@@ -0,0 +1,185 @@
+package rat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"time"
+)
+
+// Trace levels (see Grammar.Trace and Trace). Every level includes the
+// events of the levels before it.
+const (
+	TraceMake  = 1 // making of every rule (MakeRule)
+	TraceCheck = 2 // checking of every named (x.N) rule
+)
+
+// TraceEvent is passed to a Tracer for every rule made (TraceMake) and
+// when every named rule check is entered and exited (TraceCheck)
+// depending on the trace level. Type (T) and position (I) are only set
+// for checks and end (E), error (X), and Elapsed only when exited.
+type TraceEvent struct {
+	Level   int           // TraceMake or TraceCheck
+	T       EventType     // EventEnter or EventExit (checks only)
+	Rule    string        // expression made (x.String) or name checked
+	I       int           // position check began
+	E       int           // position check ended
+	X       error         // error of failed check (nil if matched)
+	Elapsed time.Duration // time spent checking
+	R       []rune        // buffer being checked (checks only, nil if redacted)
+}
+
+// String fulfills the fmt.Stringer interface with a single line
+// describing the event.
+func (e TraceEvent) String() string {
+	switch {
+	case e.Level == TraceMake:
+		return fmt.Sprintf(TraceMakeT, e.Rule)
+	case e.T == EventEnter:
+		return fmt.Sprintf(TraceEnterT, e.Rule, e.I)
+	}
+	outcome := TraceMatchedT
+	if e.X != nil {
+		outcome = e.X.Error()
+	}
+	return fmt.Sprintf(TraceCheckT, e.Rule, e.I, e.E, outcome, e.Elapsed)
+}
+
+// Tracer implementations receive every TraceEvent of a Grammar with
+// Trace enabled (see Grammar.Tracer). When a Grammar has no Tracer the
+// events are passed to the DefaultTracer instead.
+type Tracer interface {
+	Trace(e TraceEvent)
+}
+
+// TracerFunc adapts any function into a Tracer.
+type TracerFunc func(e TraceEvent)
+
+// Trace fulfills the Tracer interface by calling the function.
+func (f TracerFunc) Trace(e TraceEvent) { f(e) }
+
+// WriterTracer writes every event (at or below Level if greater than
+// 0) as a single line to W (see TraceEvent.String).
+type WriterTracer struct {
+	W     io.Writer
+	Level int
+}
+
+// Trace fulfills the Tracer interface.
+func (t WriterTracer) Trace(e TraceEvent) {
+	if t.Level > 0 && e.Level > t.Level {
+		return
+	}
+	fmt.Fprintln(t.W, e)
+}
+
+// MarshalJSON fulfills the encoding.JSONMarshaler interface with
+// a single line object suitable for external tools (visualizers, flame
+// graphs) containing the type (t: make, enter, or exit), rule, position
+// (pos), matched length (len), error, and elapsed nanoseconds depending
+// on the type (see TraceEvent).
+func (e TraceEvent) MarshalJSON() ([]byte, error) {
+	v := struct {
+		T       string `json:"t"`
+		Rule    string `json:"rule"`
+		Pos     *int   `json:"pos,omitempty"`
+		Len     *int   `json:"len,omitempty"`
+		Error   string `json:"error,omitempty"`
+		Elapsed int64  `json:"elapsed,omitempty"`
+	}{T: `make`, Rule: e.Rule}
+	if e.Level != TraceMake {
+		v.T = e.T.String()
+		v.Pos = &e.I
+	}
+	if e.T == EventExit {
+		n := e.E - e.I
+		v.Len = &n
+		v.Elapsed = int64(e.Elapsed)
+		if e.X != nil {
+			v.Error = e.X.Error()
+		}
+	}
+	return json.Marshal(v)
+}
+
+// JSONTracer writes every event (at or below Level if greater than 0)
+// as JSON lines (see TraceEvent.MarshalJSON) to W.
+type JSONTracer struct {
+	W     io.Writer
+	Level int
+}
+
+// Trace fulfills the Tracer interface.
+func (t JSONTracer) Trace(e TraceEvent) {
+	if t.Level > 0 && e.Level > t.Level {
+		return
+	}
+	buf, _ := e.MarshalJSON()
+	t.W.Write(append(buf, '\n'))
+}
+
+// LogTracer writes every event with log.Print.
+var LogTracer = TracerFunc(func(e TraceEvent) { log.Print(e) })
+
+// NopTracer discards every event (to silence tracing in tests, for
+// example, see DefaultTracer).
+var NopTracer = TracerFunc(func(TraceEvent) {})
+
+// DefaultTracer receives the events of every Grammar without a Tracer
+// (LogTracer by default). Set it to SlogTracer{} to integrate tracing
+// with structured application logging (slog.Default) or to NopTracer
+// to silence it entirely.
+var DefaultTracer Tracer = LogTracer
+
+// SlogTracer logs every event to L (slog.Default if nil) at the debug
+// level with the fields of the event as attributes (rule, i, e, x, and
+// elapsed).
+type SlogTracer struct {
+	L *slog.Logger
+}
+
+// Trace fulfills the Tracer interface.
+func (t SlogTracer) Trace(e TraceEvent) {
+	l := t.L
+	if l == nil {
+		l = slog.Default()
+	}
+	switch {
+	case e.Level == TraceMake:
+		l.Debug(TraceMakeMsg, `rule`, e.Rule)
+		return
+	case e.T == EventEnter:
+		l.Debug(TraceEnterMsg, `rule`, e.Rule, `i`, e.I)
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String(`rule`, e.Rule),
+		slog.Int(`i`, e.I),
+		slog.Int(`e`, e.E),
+		slog.Duration(`elapsed`, e.Elapsed),
+	}
+	if e.X != nil {
+		attrs = append(attrs, slog.String(`x`, e.X.Error()))
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, TraceCheckMsg, attrs...)
+}
+
+// tracing returns true if events of the level are traced by either the
+// grammar or the package (see Trace).
+func (g *Grammar) tracing(level int) bool {
+	return g.Trace >= level || Trace >= level
+}
+
+// trace passes the event to the Tracer (or DefaultTracer if not set).
+func (g *Grammar) trace(e TraceEvent) {
+	if g.Tracer == nil {
+		if DefaultTracer != nil {
+			DefaultTracer.Trace(e)
+		}
+		return
+	}
+	g.Tracer.Trace(e)
+}
@@ -0,0 +1,67 @@
+package rat
+
+// Tree is a compact form of a Result tree for keeping very large trees
+// in memory. The buffer (R) and source (S) are stored once for the
+// entire tree instead of in every result and the nodes omit the origin
+// (O) as well making each much smaller than a Result (see Node). Use
+// Result.Tree to create one and Result to convert it back.
+type Tree struct {
+	R    []rune // buffer shared by every node
+	S    string // source, file name or other label (optional)
+	Root Node   // the root result
+}
+
+// Node is a single Result within a Tree without the buffer (R), source
+// (S), and origin (O). Fields are otherwise the same as Result.
+type Node struct {
+	N string // string name (x.Name)
+	I int    // integer identifier (x.ID)
+	B int    // beginning (inclusive)
+	E int    // ending (non-inclusive)
+	X error  // error, eXpected something else
+	T bool   // truncated, children discarded due to limits
+	C []Node // children, nodes within this node
+}
+
+// Tree returns the compact form of the result tree. The source (S) of
+// the result is used for the entire tree (see SetSource).
+func (m Result) Tree() Tree {
+	return Tree{R: m.R, S: m.S, Root: m.node()}
+}
+
+func (m Result) node() Node {
+	n := Node{N: m.N, I: m.I, B: m.B, E: m.E, X: m.X, T: m.T}
+	if len(m.C) > 0 {
+		n.C = make([]Node, len(m.C))
+		for i, c := range m.C {
+			n.C[i] = c.node()
+		}
+	}
+	return n
+}
+
+// Result returns the Result tree (with the buffer and source set for
+// every result) equivalent to the Tree.
+func (t Tree) Result() Result { return t.result(t.Root) }
+
+func (t Tree) result(n Node) Result {
+	m := Result{N: n.N, I: n.I, B: n.B, E: n.E, X: n.X, T: n.T, S: t.S, R: t.R}
+	if len(n.C) > 0 {
+		m.C = make([]Result, len(n.C))
+		for i, c := range n.C {
+			m.C[i] = t.result(c)
+		}
+	}
+	return m
+}
+
+// Text returns the text of the node from the buffer (R) of the tree
+// (see Result.Text).
+func (t Tree) Text(n Node) string { return string(t.R[n.B:n.E]) }
+
+// MarshalJSON fulfills the encoding.JSONMarshaler interface with the
+// same output as the Result.MarshalJSON of the full Result tree.
+func (t Tree) MarshalJSON() ([]byte, error) { return t.Result().MarshalJSON() }
+
+// String fulfills the fmt.Stringer interface as JSON (see MarshalJSON).
+func (t Tree) String() string { return t.Result().String() }
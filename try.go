@@ -0,0 +1,69 @@
+package rat
+
+import "runtime"
+
+// Try calls make (usually one or more Make* methods or Pack of the
+// grammar) returning an ErrMake instead of panicking if any expression
+// is malformed, which is essential for services that build grammars
+// from definitions provided by users. The ErrRefs of a Pack that must
+// resolve every reference (see Resolve) and the ErrConflict of
+// a Strict grammar are returned as is. When make
+// fails every rule it added is removed, every rule it replaced (see
+// Override) restored, and the Main rule and Warnings restored so that
+// the grammar is exactly as it was before. Panics that are not from
+// malformed expressions (runtime errors) are not recovered.
+func (g *Grammar) Try(make func()) (err error) {
+	names, byid, ruleid, main := len(g.names), len(g.byid), g.ruleid, g.Main
+	undo, warnings := len(g.undo), len(g.Warnings)
+	g.trying++
+	defer func() {
+		g.trying--
+		r := recover()
+		if r == nil {
+			if g.trying == 0 {
+				g.undo = g.undo[:0]
+			}
+			return
+		}
+		if _, is := r.(runtime.Error); is {
+			panic(r)
+		}
+		for n := len(g.undo) - 1; n >= undo; n-- {
+			g.Rules[g.undo[n].Name] = g.undo[n]
+		}
+		for _, name := range g.names[names:] {
+			delete(g.Rules, name)
+		}
+		g.undo, g.Warnings = g.undo[:undo], g.Warnings[:warnings]
+		g.names, g.byid, g.ruleid, g.Main = g.names[:names], g.byid[:byid], ruleid, main
+		g.rebind++
+		switch v := r.(type) {
+		case ErrRefs:
+			err = v
+		case ErrConflict:
+			err = v
+		default:
+			err = ErrMake{r}
+		}
+	}()
+	make()
+	return nil
+}
+
+// TryMakeRule is the same as MakeRule but returns an ErrMake instead of
+// panicking (see Try).
+func (g *Grammar) TryMakeRule(in any) (*Rule, error) {
+	var rule *Rule
+	err := g.Try(func() { rule = g.MakeRule(in) })
+	return rule, err
+}
+
+// TryPack is the same as Pack but returns an ErrMake instead of
+// panicking (see Try).
+func (g *Grammar) TryPack(in ...any) (*Grammar, error) {
+	return g, g.Try(func() { g.Pack(in...) })
+}
+
+// TryPack is the same as Pack but returns an ErrMake instead of
+// panicking (see Grammar.Try).
+func TryPack(seq ...any) (*Grammar, error) { return new(Grammar).Init().TryPack(seq...) }
@@ -0,0 +1,53 @@
+package rat
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/rwxrob/rat/x"
+)
+
+// usage returns the value (r) recovered from a panic while making
+// a rule from the expression (in) as an ErrUsage with the expression
+// added to the front of its Path so that the panic of a malformed
+// expression deep within another points to exactly where it is.
+// Runtime errors and ErrConflict (see Strict) are returned as is.
+func usage(r any, in any) any {
+	switch r.(type) {
+	case runtime.Error, ErrConflict:
+		return r
+	}
+	text := x.String(in)
+	e, is := r.(ErrUsage)
+	if !is {
+		return ErrUsage{Usage: r, Path: []string{fmt.Sprintf(`%T`, in)}, at: text}
+	}
+	if e.at == text {
+		return e
+	}
+	step := fmt.Sprintf(`%T`, in)
+	switch v := in.(type) {
+	case x.N:
+		if len(v) == 2 {
+			step = fmt.Sprintf(`x.N{%q}`, v[0])
+		}
+	case x.Seq:
+		step += child([]any(v), e.at)
+	case x.One:
+		step += child([]any(v), e.at)
+	}
+	e.Path = append([]string{step}, e.Path...)
+	e.at = text
+	return e
+}
+
+// child returns the index (as [n]) of the first expression in the list
+// with the String form (text) or nothing if not found.
+func child(list []any, text string) string {
+	for n, it := range list {
+		if x.String(it) == text {
+			return fmt.Sprintf(`[%v]`, n)
+		}
+	}
+	return ""
+}
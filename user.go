@@ -0,0 +1,12 @@
+package rat
+
+// ScanUser is the same as Scan but sets User to the value (usually
+// a pointer) for the duration of the Scan restoring the previous value
+// after. Every CheckFunc added (see AddRule) can reach it through the
+// grammar it closes over.
+func (g *Grammar) ScanUser(in any, user any) Result {
+	prev := g.User
+	g.User = user
+	defer func() { g.User = prev }()
+	return g.Scan(in)
+}
@@ -0,0 +1,146 @@
+package rat
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// UTF8Mode is how invalid UTF-8 encoding of input is handled (see
+// Grammar.UTF8).
+type UTF8Mode int
+
+const (
+	UTF8AsIs    UTF8Mode = iota // every invalid byte becomes utf8.RuneError
+	UTF8Strict                  // fail with ErrInvalidEncoding
+	UTF8Replace                 // every invalid sequence becomes one utf8.RuneError
+	UTF8Raw                     // every byte becomes a rune of the same value (binary)
+	UTF8Bits                    // every byte becomes eight runes, '0' or '1' (binary)
+)
+
+// decode returns the input (string or []byte, anything else is
+// returned as is) checked or normalized according to the UTF8 mode:
+// ErrInvalidEncoding with the offset (in bytes) of the first invalid
+// byte if UTF8Strict or every sequence of invalid bytes replaced with
+// a single U+FFFD if UTF8Replace. If UTF8Raw every byte is returned
+// as a rune of the same value without any decoding at all (see raw) or
+// if UTF8Bits as eight runes of its bits (see bits).
+func (g *Grammar) decode(in any) (any, error) {
+	switch g.UTF8 {
+	case UTF8Strict:
+		switch v := in.(type) {
+		case string:
+			if i := invalidString(v); i >= 0 {
+				return nil, ErrInvalidEncoding{i}
+			}
+		case []byte:
+			if i := invalidBytes(v); i >= 0 {
+				return nil, ErrInvalidEncoding{i}
+			}
+		}
+	case UTF8Replace:
+		switch v := in.(type) {
+		case string:
+			return strings.ToValidUTF8(v, string(utf8.RuneError)), nil
+		case []byte:
+			return bytes.ToValidUTF8(v, []byte(string(utf8.RuneError))), nil
+		}
+	case UTF8Raw:
+		switch v := in.(type) {
+		case string:
+			return raw([]byte(v)), nil
+		case []byte:
+			return raw(v), nil
+		}
+	case UTF8Bits:
+		switch v := in.(type) {
+		case string:
+			return bits([]byte(v)), nil
+		case []byte:
+			return bits(v), nil
+		}
+	}
+	return in, nil
+}
+
+// raw returns every byte as a rune of the same value (0-255) so that
+// binary input can be matched byte for byte (see x.Byte).
+func raw(b []byte) []rune {
+	runes := make([]rune, len(b))
+	for n, c := range b {
+		runes[n] = rune(c)
+	}
+	return runes
+}
+
+// bits returns every bit of every byte (most significant first) as
+// a rune, '0' or '1', so that binary input can be matched bit for bit
+// (see x.Bits) and every span is in bits.
+func bits(b []byte) []rune {
+	runes := make([]rune, 0, len(b)*8)
+	for _, c := range b {
+		for n := 7; n >= 0; n-- {
+			runes = append(runes, '0'+rune(c>>n&1))
+		}
+	}
+	return runes
+}
+
+// invalidString returns the offset (in bytes) of the first byte of the
+// string that is not valid UTF-8 or -1 if all of it is.
+func invalidString(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// invalidBytes is the same as invalidString for bytes.
+func invalidBytes(b []byte) int {
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// readRunes reads every rune from the reader (but no more than one
+// beyond max if greater than 0) according to the mode: returning
+// ErrInvalidEncoding (with the offset in bytes) if strict or replacing
+// every run of invalid bytes with a single utf8.RuneError (just as
+// strings.ToValidUTF8) if replacing. Otherwise every invalid byte is
+// a utf8.RuneError.
+func readRunes(rd io.RuneReader, max int, mode UTF8Mode) ([]rune, error) {
+	var runes []rune
+	var off int
+	var bad bool
+	for max <= 0 || len(runes) <= max {
+		r, size, err := rd.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		invalid := r == utf8.RuneError && size == 1
+		switch {
+		case invalid && mode == UTF8Strict:
+			return nil, ErrInvalidEncoding{off}
+		case invalid && mode == UTF8Replace && bad:
+			// already replaced
+		default:
+			runes = append(runes, r)
+		}
+		off += size
+		bad = invalid
+	}
+	return runes, nil
+}
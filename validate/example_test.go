@@ -0,0 +1,60 @@
+package validate_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/rwxrob/rat"
+	"github.com/rwxrob/rat/validate"
+	"github.com/rwxrob/rat/x"
+)
+
+func ExampleHandler() {
+
+	// one key=value pair per line
+	grammar := func() *rat.Grammar {
+		return rat.Pack(x.Mmx{0, -1, x.Seq{
+			x.Mmx{1, -1, x.Rng{'a', 'z'}}, `=`, x.Mmx{1, -1, x.Rng{'0', '9'}}, "\n",
+		}})
+	}
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "ok: %q", buf)
+	})
+
+	v := validate.Handler(grammar, echo)
+	v.MaxBytes = 16
+
+	for _, body := range []string{
+		"a=1\nb=2\n",
+		"a=1\nb=x\n",
+		"a=1\nb=2\n!",
+		"a=1\nb=2\nc=3\nd=4\ne=5\n",
+	} {
+		w := httptest.NewRecorder()
+		v.ServeHTTP(w, httptest.NewRequest(`POST`, `/`, strings.NewReader(body)))
+		fmt.Print(w.Code, " ", w.Body.String())
+		if w.Code == http.StatusOK {
+			fmt.Println()
+		}
+	}
+
+	// Output:
+	// 200 ok: "a=1\nb=2\n"
+	// 400 invalid at line 2, column 3: expected: x.Rng{'0', '9'}
+	// 400 invalid at line 3, column 1: expected: x.Rng{'a', 'z'}
+	// 413 body larger than 16 bytes
+}
+
+func ExampleCheck() {
+	g := rat.Pack(`hello`, x.End{})
+	fmt.Println(validate.Check(g, `hello`))
+	fmt.Println(validate.Check(g, `help`))
+	// Output:
+	// <nil>
+	// invalid at line 1, column 4: expected: l
+}
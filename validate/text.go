@@ -0,0 +1,10 @@
+package validate
+
+// KEEP APP TEXT HERE
+// (This should be the only file to need translation, if needed.)
+
+const (
+	ErrInvalidT    = `invalid at line %v, column %v: %v`
+	ErrUnexpectedT = `unexpected input`
+	ErrTooLargeT   = `body larger than %v bytes`
+)
@@ -0,0 +1,114 @@
+/*
+Package validate adapts a rat.Grammar into an HTTP middleware that
+validates request bodies before passing them on, since grammars are
+often written precisely to validate wire formats. Bodies that do not
+match the entire Main rule are rejected with 400 Bad Request and
+a plain text message containing the line and column of the farthest
+failure (see rat.Grammar.Farthest). Bodies larger than MaxBytes are
+rejected with 413 Request Entity Too Large. Valid bodies are passed to
+the next handler unchanged.
+*/
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/rwxrob/rat"
+)
+
+// DefaultMaxBytes is the MaxBytes of a Validator if not set.
+var DefaultMaxBytes int64 = 1 << 20
+
+// Validator is an http.Handler that validates the body of every
+// request with a Grammar before calling Next. Since a Grammar cannot be
+// shared between concurrent scans, Grammar is called to create new
+// ones as needed (which are then reused).
+type Validator struct {
+	Grammar  func() *rat.Grammar
+	Next     http.Handler
+	MaxBytes int64 // maximum size of body (DefaultMaxBytes if 0)
+
+	pool sync.Pool
+	once sync.Once
+}
+
+// Handler returns a new Validator for the grammar and next handler.
+func Handler(grammar func() *rat.Grammar, next http.Handler) *Validator {
+	return &Validator{Grammar: grammar, Next: next}
+}
+
+// ServeHTTP fulfills the http.Handler interface.
+func (v *Validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.once.Do(func() { v.pool.New = func() any { return v.Grammar() } })
+
+	max := v.MaxBytes
+	if max <= 0 {
+		max = DefaultMaxBytes
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(buf)) > max {
+		http.Error(w, fmt.Sprintf(ErrTooLargeT, max), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	g := v.pool.Get().(*rat.Grammar)
+	err = Check(g, buf)
+	v.pool.Put(g)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(buf))
+	v.Next.ServeHTTP(w, r)
+}
+
+// ErrUnexpected is the error of an ErrInvalid for input remaining after
+// a successful match.
+var ErrUnexpected = errors.New(ErrUnexpectedT)
+
+// ErrInvalid is returned by Check when the input does not match. Line
+// and Col (both starting at 1) are the location of the farthest
+// failure and Err its error.
+type ErrInvalid struct {
+	Line int
+	Col  int
+	Err  error
+}
+
+func (e ErrInvalid) Error() string { return fmt.Sprintf(ErrInvalidT, e.Line, e.Col, e.Err) }
+
+func (e ErrInvalid) Unwrap() error { return e.Err }
+
+// Check scans the input (string, []byte, []rune, or io.Reader) with the
+// Main rule of the grammar and returns an ErrInvalid for the farthest
+// failure if it fails or an ErrInvalid (wrapping ErrUnexpected) if it
+// does not match the entire input. The result tree is released (see
+// rat.Grammar.Release) unless the grammar has Emit set.
+func Check(g *rat.Grammar, in any) error {
+	res := g.Scan(in)
+	if g.Emit == nil {
+		defer g.Release(res)
+	}
+	if res.X == nil && res.E == len(res.R) {
+		return nil
+	}
+	if res.X == nil {
+		res.X = ErrUnexpected
+	}
+	if g.Farthest.X != nil && g.Farthest.E >= res.E {
+		res = g.Farthest
+	}
+	res.B = res.E
+	line, col := res.Pos()
+	return ErrInvalid{Line: line, Col: col, Err: res.X}
+}
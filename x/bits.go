@@ -0,0 +1,96 @@
+package x
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bits matches a number of bits (1 to 64) of input scanned as bits (see
+// rat.UTF8Bits) in which every byte is eight runes ('0' or '1', most
+// significant first). With only the number it matches any bits (a
+// field), with one more the bits must be that unsigned value (a magic
+// number or flag), and with two more the value must be within that
+// inclusive range. Flags are a single bit (see Flag) and are usually
+// named (x.N{`QR`, x.Bits{1}}).
+//
+// PEGN has no bit ranges so they are rendered just as any bits.
+//
+//	[01]{3}
+//	'0100'
+type Bits []any
+
+func (it Bits) String() string {
+	if _, _, _, ok := BitsOf(it); !ok {
+		return UsageBits
+	}
+	s := make([]string, len(it))
+	for n, v := range it {
+		s[n] = fmt.Sprint(v)
+	}
+	return `x.Bits{` + strings.Join(s, `, `) + `}`
+}
+
+func (it Bits) Print() { fmt.Println(it) }
+
+// BitsOf returns the number of bits and the lowest and highest value of
+// the Bits or false if they are not valid (see Bits).
+func BitsOf(it Bits) (n int, lo, hi uint64, ok bool) {
+	if len(it) < 1 || len(it) > 3 {
+		return
+	}
+	n, is := it[0].(int)
+	if !is || n < 1 || n > 64 {
+		return
+	}
+	max := uint64(1)<<n - 1
+	if n == 64 {
+		max = ^uint64(0)
+	}
+	vals := make([]uint64, len(it)-1)
+	for i, v := range it[1:] {
+		val, is := uint64Of(v)
+		if !is || val > max {
+			return
+		}
+		vals[i] = val
+	}
+	switch len(vals) {
+	case 0:
+		return n, 0, max, true
+	case 1:
+		return n, vals[0], vals[0], true
+	}
+	if vals[0] > vals[1] {
+		return
+	}
+	return n, vals[0], vals[1], true
+}
+
+// uint64Of returns the value of any unsigned integer or non-negative
+// signed one.
+func uint64Of(it any) (uint64, bool) {
+	switch v := it.(type) {
+	case int:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	case rune:
+		return uint64(v), v >= 0
+	case byte:
+		return uint64(v), true
+	case uint:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+	return 0, false
+}
+
+// pegnBits returns the PEGN of the Bits (see Bits).
+func pegnBits(it Bits) string {
+	n, lo, hi, _ := BitsOf(it)
+	if lo == hi {
+		return fmt.Sprintf(`'%0*b'`, n, lo)
+	}
+	return fmt.Sprintf(`[01]{%v}`, n)
+}
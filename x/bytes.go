@@ -0,0 +1,133 @@
+package x
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Byte matches every one of its bytes (byte, rune, or int from 0 to
+// 255) in order, such as the magic number at the beginning of a file
+// format (x.Byte{0x89, 'P', 'N', 'G'}). Bytes (like those of BSet and
+// BRng) are meant for binary input scanned without UTF-8 decoding (see
+// rat.UTF8Raw) in which every byte is a rune of the same value.
+// Otherwise only bytes below 0x80 (ASCII) ever match since the others
+// are only found within encoded runes.
+//
+// PEGN
+//
+//	x89 x50 x4E x47
+type Byte []any
+
+func (it Byte) String() string {
+	if len(it) == 0 {
+		return UsageByte
+	}
+	s, ok := byteList(it)
+	if !ok {
+		return UsageByte
+	}
+	return `x.Byte{` + s + `}`
+}
+
+func (it Byte) Print() { fmt.Println(it) }
+
+// BSet matches any one of its bytes (see Byte), such as one of the
+// types of a record header.
+//
+// PEGN
+//
+//	x00 / x01 / x7F
+type BSet []any
+
+func (it BSet) String() string {
+	if len(it) == 0 {
+		return UsageBSet
+	}
+	s, ok := byteList(it)
+	if !ok {
+		return UsageBSet
+	}
+	return `x.BSet{` + s + `}`
+}
+
+func (it BSet) Print() { fmt.Println(it) }
+
+// BRng matches any byte (see Byte) within the inclusive range.
+//
+// PEGN
+//
+//	[x00-x7F]
+type BRng []any
+
+func (it BRng) String() string {
+	if len(it) != 2 {
+		return UsageBRng
+	}
+	beg, ok1 := ByteOf(it[0])
+	end, ok2 := ByteOf(it[1])
+	if !ok1 || !ok2 || beg > end {
+		return UsageBRng
+	}
+	return fmt.Sprintf(`x.BRng{0x%02X, 0x%02X}`, beg, end)
+}
+
+func (it BRng) Print() { fmt.Println(it) }
+
+// ByteOf returns the byte of a byte, rune, or int from 0 to 255 (as
+// used by Byte, BSet, and BRng) or false if it is not one.
+func ByteOf(it any) (byte, bool) {
+	var n int
+	switch v := it.(type) {
+	case byte:
+		return v, true
+	case rune:
+		n = int(v)
+	case int:
+		n = v
+	default:
+		return 0, false
+	}
+	if n < 0 || n > 0xFF {
+		return 0, false
+	}
+	return byte(n), true
+}
+
+// Bytes returns the bytes of a Byte or BSet or false if any is not
+// a byte (see ByteOf).
+func Bytes(list []any) ([]byte, bool) {
+	bytes := make([]byte, len(list))
+	for n, it := range list {
+		b, ok := ByteOf(it)
+		if !ok {
+			return nil, false
+		}
+		bytes[n] = b
+	}
+	return bytes, true
+}
+
+// byteList returns the bytes in hexadecimal notation separated by
+// commas.
+func byteList(list []any) (string, bool) {
+	bytes, ok := Bytes(list)
+	if !ok {
+		return "", false
+	}
+	hex := make([]string, len(bytes))
+	for n, b := range bytes {
+		hex[n] = fmt.Sprintf(`0x%02X`, b)
+	}
+	return strings.Join(hex, `, `), true
+}
+
+// pegnBytes returns the bytes in PEGN hexadecimal notation (x0A)
+// separated by sep.
+func pegnBytes(list []any, sep string) string {
+	bytes, _ := Bytes(list)
+	hex := make([]string, len(bytes))
+	for n, b := range bytes {
+		hex[n] = fmt.Sprintf(`x%02X`, b)
+	}
+	return strings.Join(hex, sep)
+}
@@ -0,0 +1,143 @@
+package x
+
+import "strings"
+
+// Canonical returns an equivalent expression in canonical form so that
+// expressions that differ only in their construction can be compared
+// (see Equal). Literals (string, rune, Str, etc.) become a single
+// string, []any becomes Seq, nested Seq and One are flattened into
+// their parents, adjacent literals within a Seq are combined (and empty
+// ones dropped), later duplicate alternatives within a One are dropped,
+// Seq and One with only one item become the item, Mmx{1, 1, rule}
+// becomes the rule, Not{Any{1}} becomes End{}, and Doc becomes the rule
+// documented. Invalid expressions are returned as is.
+func Canonical(it any) any {
+	switch v := it.(type) {
+
+	case N:
+		if len(v) != 2 {
+			return v
+		}
+		return N{v[0], Canonical(v[1])}
+
+	case Doc:
+		if len(v) != 2 {
+			return v
+		}
+		return Canonical(v[1])
+
+	case Sav, Val, Ref, Is, IsFunc, func(r rune) bool, Any, Rng, End, Wb, Byte, BSet, BRng, Bits:
+		return v
+
+	case []any:
+		return Canonical(Seq(v))
+
+	case Seq:
+		var seq Seq
+		for _, i := range v {
+			i = Canonical(i)
+			if s, is := i.(Seq); is {
+				seq = append(seq, s...)
+				continue
+			}
+			seq = append(seq, i)
+		}
+		var out Seq
+		for _, i := range seq {
+			s, is := i.(string)
+			if is && s == "" && len(seq) > 1 {
+				continue
+			}
+			if !is {
+				out = append(out, i)
+				continue
+			}
+			if n := len(out) - 1; n >= 0 {
+				if prev, is := out[n].(string); is {
+					out[n] = prev + s
+					continue
+				}
+			}
+			out = append(out, s)
+		}
+		if len(out) == 1 {
+			return out[0]
+		}
+		return out
+
+	case One:
+		var one One
+		seen := map[string]bool{}
+		add := func(i any) {
+			key := String(i)
+			if !seen[key] {
+				seen[key] = true
+				one = append(one, i)
+			}
+		}
+		for _, i := range v {
+			i = Canonical(i)
+			if o, is := i.(One); is {
+				for _, j := range o {
+					add(j)
+				}
+				continue
+			}
+			add(i)
+		}
+		if len(one) == 1 {
+			return one[0]
+		}
+		return one
+
+	case Mmx:
+		if v.String() == UsageMmx {
+			return v
+		}
+		inner := Canonical(v[2])
+		if v[0] == 1 && v[1] == 1 {
+			return inner
+		}
+		return Mmx{v[0], v[1], inner}
+
+	case See:
+		if len(v) != 1 {
+			return v
+		}
+		return See{Canonical(v[0])}
+
+	case Not:
+		if len(v) != 1 {
+			return v
+		}
+		if a, is := v[0].(Any); is && len(a) == 1 && a[0] == 1 {
+			return End{}
+		}
+		return Not{Canonical(v[0])}
+
+	case To:
+		if len(v) != 1 {
+			return v
+		}
+		return To{Canonical(v[0])}
+
+	case Len:
+		if len(v) < 2 {
+			return v
+		}
+		out := Len{Canonical(v[0]), Canonical(v[1])}
+		return append(out, v[2:]...)
+
+	default:
+		s := String(v)
+		if !strings.HasPrefix(s, `x.Str{`) {
+			return v
+		}
+		return unquote(s[6 : len(s)-1])
+
+	}
+}
+
+// Equal returns true if the canonical forms (see Canonical) of both
+// expressions are the same.
+func Equal(a, b any) bool { return String(Canonical(a)) == String(Canonical(b)) }
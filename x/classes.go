@@ -0,0 +1,26 @@
+package x
+
+// Classes contains the standard PEGN classes as named (N) expressions
+// keyed to their names. Every rat.Grammar resolves a Ref to any of
+// these names (not otherwise defined by the grammar itself) to the
+// class so that grammar authors can write Ref{"alpha"} without
+// defining the primitives themselves. EndOfLine matches the line
+// endings of every platform (LF, CRLF, and CR) as one.
+var Classes = map[string]N{
+	`EndOfLine`: {`EndOfLine`, One{"\r\n", '\n', '\r'}},
+	`alpha`:     {`alpha`, One{Rng{'A', 'Z'}, Rng{'a', 'z'}}},
+	`alphanum`:  {`alphanum`, One{Rng{'A', 'Z'}, Rng{'a', 'z'}, Rng{'0', '9'}}},
+	`bitdig`:    {`bitdig`, One{'0', '1'}},
+	`control`:   {`control`, One{Rng{rune(0x00), rune(0x1F)}, Rng{rune(0x7F), rune(0x9F)}}},
+	`digit`:     {`digit`, Rng{'0', '9'}},
+	`hexdig`:    {`hexdig`, One{Rng{'0', '9'}, Rng{'a', 'f'}, Rng{'A', 'F'}}},
+	`lowerhex`:  {`lowerhex`, One{Rng{'0', '9'}, Rng{'a', 'f'}}},
+	`lower`:     {`lower`, Rng{'a', 'z'}},
+	`octdig`:    {`octdig`, Rng{'0', '7'}},
+	`punct`:     {`punct`, One{Rng{'!', '/'}, Rng{':', '@'}, Rng{'[', '`'}, Rng{'{', '~'}}},
+	`sign`:      {`sign`, One{'+', '-'}},
+	`uphex`:     {`uphex`, One{Rng{'0', '9'}, Rng{'A', 'F'}}},
+	`upper`:     {`upper`, Rng{'A', 'Z'}},
+	`visible`:   {`visible`, Rng{'!', '~'}},
+	`ws`:        {`ws`, One{' ', '\t', '\r', '\n'}},
+}
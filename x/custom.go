@@ -0,0 +1,16 @@
+package x
+
+import "fmt"
+
+// Custom is implemented by expression types defined outside of this
+// package (see rat.Maker) so that they can be used within any other
+// expression and rendered like the rest. String must return the
+// expression in Go notation (as for every type of this package) and
+// PEGN its PEGN rendering, which is grouped when needed as long as it
+// has no alternatives (/) outside of parentheses. Custom expressions
+// are never combined with literals (see CombineStr) and are opaque to
+// Walk, Canonical, and Regexp.
+type Custom interface {
+	fmt.Stringer
+	PEGN() string
+}
@@ -0,0 +1,24 @@
+package x
+
+import "fmt"
+
+// ErrRegexp is returned by Regexp when the expression (V) cannot be
+// converted into a regular expression for the given reason (Why).
+type ErrRegexp struct {
+	V   any
+	Why string
+}
+
+func (e ErrRegexp) Error() string { return fmt.Sprintf(ErrRegexpT, e.Why, String(e.V)) }
+
+// ErrFromRegexp is returned by FromRegexp when the regular expression
+// (Expr) contains something that cannot be converted.
+type ErrFromRegexp struct{ Expr string }
+
+func (e ErrFromRegexp) Error() string { return fmt.Sprintf(ErrFromRegexpT, e.Expr) }
+
+// ErrClass is returned by Class when the regular expression (Expr) is
+// not a single character class.
+type ErrClass struct{ Expr string }
+
+func (e ErrClass) Error() string { return fmt.Sprintf(ErrClassT, e.Expr) }
@@ -108,6 +108,26 @@ func ExampleN() {
 
 }
 
+// -------------------------------- Doc -------------------------------
+
+func ExampleDoc() {
+
+	pair := x.Doc{"Pair is a key and its value.\nKeys are lowercase.", x.N{`Pair`,
+		x.Seq{x.Doc{`Key is never empty.`, x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}}, '=', x.Any{1}},
+	}}
+	fmt.Println(x.PEGN(pair))
+	x.Doc{`no rule`}.Print()
+
+	// Output:
+	// # Pair is a key and its value.
+	// # Keys are lowercase.
+	// Pair <= Key '=' .
+	// # Key is never empty.
+	// Key <= [a-z]+
+	// "%!USAGE: x.Doc{doc, rule}"
+
+}
+
 // -------------------------------- Ref -------------------------------
 
 func ExampleRef() {
@@ -140,8 +160,30 @@ func ExampleSav() {
 
 	// Output:
 	// x.Sav{"Foo"}
-	// "%!USAGE: x.Sav{name}"
-	// "%!USAGE: x.Sav{name}"
+	// "%!USAGE: x.Sav{name[, func]}"
+	// "%!USAGE: x.Sav{name[, func]}"
+
+}
+
+func ExampleSav_transform() {
+
+	x.Sav{`Tag`, x.Folded}.Print()
+	x.Sav{`Tag`, 42}.Print()
+
+	// anonymous functions (closures) are told apart by address
+	quoted := func(q string) x.SavFunc {
+		return func(text string) any { return q + text + q }
+	}
+	fmt.Println(x.Sav{`Tag`, quoted(`'`)}.String() == x.Sav{`Tag`, quoted(`"`)}.String())
+	fmt.Println(x.Folded(`Li-2`))
+	fmt.Println(x.Trimmed(` b `))
+
+	// Output:
+	// x.Sav{"Tag", Folded}
+	// "%!USAGE: x.Sav{name[, func]}"
+	// false
+	// x.Seq{x.One{x.Str{"L"}, x.Str{"l"}}, x.One{x.Str{"i"}, x.Str{"I"}}, x.Str{"-2"}}
+	// x.Str{"b"}
 
 }
 
@@ -423,3 +465,189 @@ func ExampleEnd() {
 	// "%!USAGE: x.End{}"
 
 }
+
+// ------------------------------- Bytes ------------------------------
+
+func ExampleByte() {
+
+	x.Byte{0x89, 'P', 'N', 'G'}.Print()
+	x.BSet{0x00, 0xFF}.Print()
+	x.BRng{0x20, 0x7E}.Print()
+	x.Byte{256}.Print()
+	x.BRng{0x7E, 0x20}.Print()
+
+	fmt.Println(x.PEGN(x.N{`Header`, x.Seq{
+		x.Byte{0x89, 'P', 'N', 'G'}, x.BSet{0x00, 0xFF}, x.BRng{0x20, 0x7E},
+	}}))
+	fmt.Println(x.SeqOf(x.BytesOf(0xCA, 0xFE), x.ByteSet(1, 2), x.ByteRange(0, 9)))
+
+	// Output:
+	// x.Byte{0x89, 0x50, 0x4E, 0x47}
+	// x.BSet{0x00, 0xFF}
+	// x.BRng{0x20, 0x7E}
+	// "%!USAGE: x.Byte{...byte}"
+	// "%!USAGE: x.BRng{beg, end}"
+	// Header <= (x89 x50 x4E x47) (x00 / xFF) [x20-x7E]
+	// x.Seq{x.Byte{0xCA, 0xFE}, x.BSet{0x01, 0x02}, x.BRng{0x00, 0x09}}
+
+}
+
+func ExampleBits() {
+
+	x.Bits{3}.Print()
+	x.Bits{4, 0x0F}.Print()
+	x.Bits{4, 1, 3}.Print()
+	x.Flag(true).Print()
+	x.Bits{3, 8}.Print()
+	x.Bits{65}.Print()
+
+	// Output:
+	// x.Bits{3}
+	// x.Bits{4, 15}
+	// x.Bits{4, 1, 3}
+	// x.Bits{1, 1}
+	// "%!USAGE: x.Bits{n[, value]} or x.Bits{n, lo, hi}"
+	// "%!USAGE: x.Bits{n[, value]} or x.Bits{n, lo, hi}"
+
+}
+
+func ExampleWalk() {
+
+	x.Walk(x.Seq{"foo", x.One{"bar", x.Not{'\n'}}}, func(it any) {
+		fmt.Println(x.String(it))
+	})
+
+	// Output:
+	// x.Seq{x.Str{"foo"}, x.One{x.Str{"bar"}, x.Not{x.Str{"\n"}}}}
+	// x.Str{"foo"}
+	// x.One{x.Str{"bar"}, x.Not{x.Str{"\n"}}}
+	// x.Str{"bar"}
+	// x.Not{x.Str{"\n"}}
+	// x.Str{"\n"}
+
+}
+
+func ExamplePEGN() {
+
+	fmt.Println(x.PEGN(x.Seq{x.N{`Key`, x.Mmx{1, -1, x.Rng{'a', 'z'}}}, '=', x.Any{1}}))
+
+	// Output:
+	// Key '=' .
+
+}
+
+func ExamplePEGNMin() {
+
+	it := x.Seq{x.Seq{"foo", x.Str{"bar"}}, x.One{'a', 'b', x.Rng{'c', 'f'}, x.Seq{'z'}}, x.To{'\n'}}
+	fmt.Println(x.PEGNExpr(it))
+	fmt.Println(x.PEGNMin(it))
+	fmt.Println(x.PEGNMin(x.One{"yes", "no", x.Rng{'0', '0'}, '1'}))
+
+	// Output:
+	// 'foobar' ('a' / 'b' / [c-f] / 'z') .. x0A
+	// 'foobar' ([a-f]/'z') ..x0A
+	// 'yes'/'no'/[0-1]
+
+}
+
+func ExamplePEGNWrap() {
+
+	it := x.One{
+		x.Seq{"alpha", ' ', "beta", ' ', x.Mmx{1, -1, x.Rng{'a', 'z'}}, x.Ref{`Gamma`}, x.Ref{`Delta`}},
+		x.Seq{"epsilon", x.To{'\n'}},
+		"zeta",
+	}
+	fmt.Println(x.PEGNWrap(it, 0, 9))
+	fmt.Println(`Long  <= ` + x.PEGNWrap(it, 40, 9))
+	fmt.Println(`Short <= ` + x.PEGNWrap("zeta", 40, 9))
+
+	// Output:
+	// 'alpha beta ' [a-z]+ Gamma Delta / 'epsilon' .. x0A / 'zeta'
+	// Long  <= 'alpha beta ' [a-z]+ Gamma
+	//          Delta
+	//        / 'epsilon' .. x0A
+	//        / 'zeta'
+	// Short <= 'zeta'
+
+}
+
+func ExampleCanonical() {
+
+	fmt.Println(x.Canonical(x.Seq{'f', "oo", x.Seq{x.Str{"ba", 'r'}, x.One{"a", x.One{"b", "a"}}}}))
+	fmt.Println(x.Canonical([]any{x.Mmx{1, 1, x.Not{x.Any{1}}}}))
+	fmt.Println(x.Equal(x.Seq{"foo", "bar"}, "foobar"))
+	fmt.Println(x.Equal(x.One{"foo", "bar"}, x.One{"bar", "foo"}))
+
+	// Output:
+	// x.Seq{x.Str{"foobar"}, x.One{x.Str{"a"}, x.Str{"b"}}}
+	// x.End{}
+	// true
+	// false
+
+}
+
+func ExampleRegexp() {
+
+	num := x.N{`Num`, x.Seq{x.Mmx{0, 1, x.One{'+', '-'}}, x.Mmx{1, -1, x.Ref{`digit`}}, x.Mmx{0, 1, x.Seq{'.', x.Mmx{1, -1, x.Ref{`digit`}}}}}}
+	fmt.Println(x.Regexp(num))
+
+	str := x.Seq{'"', x.Mmx{0, -1, x.One{x.Seq{'\\', x.Any{1}}, x.Seq{x.Not{x.One{'"', '\\', '\n'}}, x.Any{1}}}}, '"', x.End{}}
+	fmt.Println(x.Regexp(str))
+
+	fmt.Println(x.Regexp(x.Seq{"foo", x.See{"bar"}}))
+	fmt.Println(x.Regexp(x.N{`List`, x.Seq{'(', x.Mmx{0, -1, x.Ref{`List`}}, ')'}}))
+
+	// Output:
+	// [+\-]?[0-9]+(?:\.[0-9]+)? <nil>
+	// "(?:\\(?s:.)|[^"\\\x{A}])*"\z <nil>
+	//  cannot convert to regexp (lookahead): x.See{x.Str{"bar"}}
+	//  cannot convert to regexp (recursive): x.Ref{"List"}
+
+}
+
+func ExampleFromRegexp() {
+
+	fmt.Println(x.FromRegexp(`^(?P<Year>\d{4})-(?P<Month>0[1-9]|1[0-2])$`))
+	fmt.Println(x.FromRegexp(`(?i:go+)|[^a-z]`))
+	fmt.Println(x.FromRegexp(`\bword\b`))
+	fmt.Println(x.FromRegexp(`\Bord`))
+
+	// Output:
+	// x.Seq{x.N{"Year", x.Mmx{4, 4, x.Rng{'0', '9'}}}, x.Str{"-"}, x.N{"Month", x.One{x.Seq{x.Str{"0"}, x.Rng{'1', '9'}}, x.Seq{x.Str{"1"}, x.Rng{'0', '2'}}}}, x.End{}} <nil>
+	// x.One{x.Seq{x.One{x.Str{"g"}, x.Str{"G"}}, x.Mmx{1, -1, x.One{x.Str{"o"}, x.Str{"O"}}}}, x.Rng{'\x00', '`'}, x.Rng{'{', '\U0010ffff'}} <nil>
+	// x.Seq{x.Wb{}, x.Str{"word"}, x.Wb{}} <nil>
+	// <nil> cannot convert from regexp: \B
+
+}
+
+func ExampleClass() {
+	fmt.Println(x.Class(`[[:alpha:]]`))
+	fmt.Println(x.Class(`[^a-z0-9]`))
+	fmt.Println(x.Class(`(?i)x`))
+	fmt.Println(x.Class(`\.`))
+	fmt.Println(x.Class(`ab`))
+	// Output:
+	// x.One{x.Rng{'A', 'Z'}, x.Rng{'a', 'z'}} <nil>
+	// x.One{x.Rng{'\x00', '/'}, x.Rng{':', '`'}, x.Rng{'{', '\U0010ffff'}} <nil>
+	// x.One{x.Str{"X"}, x.Str{"x"}} <nil>
+	// . <nil>
+	// <nil> not a single character class: ab
+}
+
+func ExampleSeqOf() {
+	digit := x.Range('0', '9')
+	year := x.Name(`Year`, x.Count(4, 4, digit))
+	sign := x.Opt(x.OneOf(x.Lit('+'), x.Lit('-')))
+	fmt.Println(x.SeqOf(sign, year, x.Lit(`-`), x.Some(digit), x.End{}))
+	fmt.Println(x.Until(x.Lit("\n")), x.NotAhead(x.RefTo(`ws`)))
+
+	// as well as anything else the []any forms accept
+	class, _ := x.Class(`[a-c]`)
+	fmt.Println(x.SeqOf(`a`, '-', x.Some(class)))
+	fmt.Println(x.OneOf(unicode.IsDigit, x.Func(unicode.IsDigit)))
+	// Output:
+	// x.Seq{x.Mmx{0, 1, x.One{x.Str{"+"}, x.Str{"-"}}}, x.N{"Year", x.Mmx{4, 4, x.Rng{'0', '9'}}}, x.Str{"-"}, x.Mmx{1, -1, x.Rng{'0', '9'}}, x.End{}}
+	// x.To{x.Str{"\n"}} x.Not{x.Ref{"ws"}}
+	// x.Seq{x.Str{"a-"}, x.Mmx{1, -1, x.Rng{'a', 'c'}}}
+	// x.One{x.Is{IsDigit}, x.Is{IsDigit}}
+}
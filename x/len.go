@@ -0,0 +1,61 @@
+package x
+
+import "fmt"
+
+// Bases of the count of a Len read as an unsigned binary integer of its
+// bytes (most or least significant first) rather than as text.
+const (
+	BigEndian    = 256
+	LittleEndian = -256
+)
+
+// Len matches the first rule (the count) and then exactly as many runes
+// as its result (bytes, see rat.UTF8Raw) with the second rule (the
+// field), the length-prefixed framing of so many protocols and formats
+// (netstrings, HTTP chunks, TLV records) that PEG alone cannot express.
+// The field only sees those runes and must match all of them. The text
+// of the count (or of the first named result within it, so that the
+// count can include delimiters such as x.Seq{x.N{`Size`, digits}, ':'})
+// is read as an integer of the optional third argument as
+// base (10 by default, 16 for hexadecimal) or, if BigEndian or
+// LittleEndian, as the unsigned integer of its bytes.
+//
+// PEGN has no equivalent so the field is rendered followed by the count
+// in braces (the usual place of a fixed count).
+//
+//	field{count}
+type Len []any
+
+func (it Len) String() string {
+	switch len(it) {
+	case 2:
+		return fmt.Sprintf(`x.Len{%v, %v}`, String(it[0]), String(it[1]))
+	case 3:
+		if _, ok := LenBase(it); !ok {
+			return UsageLen
+		}
+		return fmt.Sprintf(`x.Len{%v, %v, %v}`, String(it[0]), String(it[1]), it[2])
+	}
+	return UsageLen
+}
+
+func (it Len) Print() { fmt.Println(it) }
+
+// LenBase returns the base of the count of the Len (see Len) or false
+// if it is not 2 to 36, BigEndian, or LittleEndian.
+func LenBase(it Len) (int, bool) {
+	if len(it) < 3 {
+		return 10, true
+	}
+	base, is := it[2].(int)
+	if !is {
+		return 0, false
+	}
+	switch {
+	case base == BigEndian, base == LittleEndian:
+		return base, true
+	case 2 <= base && base <= 36:
+		return base, true
+	}
+	return 0, false
+}
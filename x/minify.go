@@ -0,0 +1,144 @@
+package x
+
+// minify returns the canonical expression (see Canonical) with every
+// run of single rune alternatives (string or Rng) within a One that
+// covers a contiguous range of runes replaced by a single Rng (or
+// string if only one rune). Since every such alternative matches
+// exactly one rune their order within the run does not matter.
+func minify(it any) any {
+	switch v := it.(type) {
+
+	case N:
+		if len(v) != 2 {
+			return v
+		}
+		return N{v[0], minify(v[1])}
+
+	case Seq:
+		seq := make(Seq, len(v))
+		for n, i := range v {
+			seq[n] = minify(i)
+		}
+		return seq
+
+	case One:
+		var one One
+		var run []Rng
+		flush := func() {
+			for _, r := range mergeRng(run) {
+				if r[0] == r[1] {
+					one = append(one, string(r[0].(rune)))
+					continue
+				}
+				one = append(one, r)
+			}
+			run = nil
+		}
+		for _, i := range v {
+			i = minify(i)
+			if r, is := runeRng(i); is {
+				run = append(run, r)
+				continue
+			}
+			flush()
+			one = append(one, i)
+		}
+		flush()
+		if len(one) == 1 {
+			return one[0]
+		}
+		return one
+
+	case Rng:
+		if r, is := runeRng(v); is && r[0] == r[1] {
+			return string(r[0].(rune))
+		}
+		return v
+
+	case Mmx:
+		if v.String() == UsageMmx {
+			return v
+		}
+		return Mmx{v[0], v[1], minify(v[2])}
+
+	case See:
+		if len(v) != 1 {
+			return v
+		}
+		return See{minify(v[0])}
+
+	case Not:
+		if len(v) != 1 {
+			return v
+		}
+		return Not{minify(v[0])}
+
+	case To:
+		if len(v) != 1 {
+			return v
+		}
+		return To{minify(v[0])}
+
+	case Len:
+		if len(v) < 2 {
+			return v
+		}
+		out := Len{minify(v[0]), minify(v[1])}
+		return append(out, v[2:]...)
+
+	}
+	return it
+}
+
+// runeRng returns the Rng matching the same single rune as the
+// expression (if it is a valid Rng or a string of only one rune).
+func runeRng(it any) (Rng, bool) {
+	switch v := it.(type) {
+	case string:
+		r := []rune(v)
+		if len(r) == 1 {
+			return Rng{r[0], r[0]}, true
+		}
+	case Rng:
+		if v.String() == UsageRng {
+			return nil, false
+		}
+		a, b := v[0].(rune), v[1].(rune)
+		if a <= b {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// mergeRng returns the ranges with any that overlap or are adjacent
+// merged together.
+func mergeRng(rngs []Rng) []Rng {
+	var out []Rng
+	for _, r := range rngs {
+		a, b := r[0].(rune), r[1].(rune)
+		for {
+			merged := false
+			for n := 0; n < len(out); n++ {
+				c, d := out[n][0].(rune), out[n][1].(rune)
+				if a > d+1 || b+1 < c {
+					continue
+				}
+				if c < a {
+					a = c
+				}
+				if d > b {
+					b = d
+				}
+				out = append(out[:n], out[n+1:]...)
+				merged = true
+				break
+			}
+			if !merged {
+				break
+			}
+		}
+		out = append(out, Rng{a, b})
+	}
+	return out
+}
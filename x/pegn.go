@@ -0,0 +1,460 @@
+package x
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PEGN returns the canonical PEGN rendering of any rat/x expression (or
+// any literal accepted by String). Nested N (named) expressions are
+// rendered as references to their names. If the expression itself is
+// an N (or a Doc of one) then a complete definition (Foo <= rule) is
+// returned followed by definitions for every nested N (in the order
+// first found) one per line. The text of every Doc of a definition is
+// rendered as comment lines (# doc) before it. Invalid expressions are
+// rendered with the same %! prefixed strings as String.
+func PEGN(it any) string {
+	top, _ := documented(it)
+	if top == nil {
+		return pegn(it, 0, false)
+	}
+	var defs []string
+	seen := map[string]bool{}
+	var define func(n N, doc string)
+	define = func(n N, doc string) {
+		name, _ := n[0].(string)
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				defs = append(defs, strings.TrimRight(`# `+line, ` `))
+			}
+		}
+		defs = append(defs, name+` <= `+pegn(n[1], 0, false))
+		Walk(n[1], func(it any) {
+			if n, doc := documented(it); n != nil {
+				define(n, doc)
+			}
+		})
+	}
+	define(documented(it))
+	return strings.Join(defs, "\n")
+}
+
+// documented returns the (valid) N of the expression and the text of
+// its Doc (if any) or nil if it is neither an N nor a Doc of one.
+func documented(it any) (N, string) {
+	switch v := it.(type) {
+	case N:
+		if len(v) == 2 {
+			return v, ""
+		}
+	case Doc:
+		if len(v) != 2 {
+			break
+		}
+		doc, _ := v[0].(string)
+		if n, is := v[1].(N); is && len(n) == 2 {
+			return n, doc
+		}
+	}
+	return nil, ""
+}
+
+// PEGNExpr is the same as PEGN but always returns a single expression
+// (never definitions) even when passed an N.
+func PEGNExpr(it any) string { return pegn(it, 0, false) }
+
+// PEGNMin is the same as PEGNExpr but returns the shortest equivalent
+// rendering for use in cache keys and transmission. The expression is
+// first made canonical (see Canonical) merging adjacent strings and
+// dropping redundant grouping. Then runs of single rune alternatives
+// (and ranges) that together form a contiguous range are collapsed
+// into that range ('a' / 'b' / [c-f] becomes [a-f]) and every optional
+// space is dropped ([a-f]/'x' ..'y').
+func PEGNMin(it any) string { return pegn(minify(Canonical(it)), 0, true) }
+
+// PEGNWrap is the same as PEGNExpr but wraps the rendering when it
+// would extend beyond width (0 never wraps) assuming the first line
+// begins at column indent (after the name and operator of a definition,
+// for example). Top-level alternatives (One) are placed on their own
+// lines with the / aligned before the indent and then items of any
+// sequence that remains too long are wrapped with continuation lines
+// indented to align with the first. Groups are never broken. The
+// result can be parsed as PEGN since every continuation line is
+// indented.
+func PEGNWrap(it any, width, indent int) string {
+	line := pegn(it, 0, false)
+	if width <= 0 || indent+utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	alts := []any{it}
+	if one, is := it.(One); is && len(one) > 1 {
+		alts = one
+	}
+
+	var lines []string
+	for n, alt := range alts {
+		items := []any{alt}
+		switch v := alt.(type) {
+		case []any:
+			items = CombineStr(v...)
+		case Seq:
+			items = CombineStr(v...)
+		}
+		prec := pegnSeq
+		if len(items) > 1 {
+			prec = pegnPre
+		}
+		var cur string
+		for _, item := range items {
+			s := pegn(item, prec, false)
+			if cur == "" {
+				cur = s
+				continue
+			}
+			if indent+utf8.RuneCountInString(cur+` `+s) > width {
+				lines = append(lines, pegnAlt(n, cur, indent))
+				cur = s
+				n = -1
+				continue
+			}
+			cur += ` ` + s
+		}
+		lines = append(lines, pegnAlt(n, cur, indent))
+	}
+
+	lines[0] = strings.TrimLeft(lines[0], ` `)
+	return strings.Join(lines, "\n")
+}
+
+// pegnAlt returns the line indented to the column with the alternative
+// operator (/) before it for every alternative after the first (n > 0).
+func pegnAlt(n int, line string, indent int) string {
+	pad := strings.Repeat(` `, indent)
+	if n > 0 && indent >= 2 {
+		return pad[2:] + `/ ` + line
+	}
+	if n > 0 {
+		return pad + `/ ` + line
+	}
+	return pad + line
+}
+
+// PEGN precedence levels (higher binds tighter)
+const (
+	pegnExpr = iota // alternatives (x / y)
+	pegnSeq         // sequence (x y)
+	pegnPre         // prefix operand (!x) and sequence items
+	pegnSuf         // suffix operand (x?)
+)
+
+func pegn(it any, prec int, min bool) string {
+
+	group := func(s string, p int) string {
+		if prec > p {
+			return `(` + s + `)`
+		}
+		return s
+	}
+
+	switch v := it.(type) {
+
+	case N:
+		if len(v) != 2 {
+			return UsageN
+		}
+		name, is := v[0].(string)
+		if !is {
+			return UsageN
+		}
+		return name
+
+	case Doc:
+		if len(v) != 2 {
+			return UsageDoc
+		}
+		return pegn(v[1], prec, min)
+
+	case Sav:
+		if v.String() == UsageSav {
+			return UsageSav
+		}
+		return group(fmt.Sprintf(`=%v`, v[0]), pegnPre)
+
+	case Val:
+		if len(v) != 1 {
+			return UsageVal
+		}
+		return group(fmt.Sprintf(`$%v`, v[0]), pegnPre)
+
+	case Ref:
+		if len(v) != 1 {
+			return UsageRef
+		}
+		return fmt.Sprintf(`%v`, v[0])
+
+	case Is:
+		if len(v) != 1 {
+			return UsageIs
+		}
+		return FuncName(v[0])
+
+	case func(r rune) bool:
+		return FuncName(v)
+
+	case IsFunc:
+		return FuncName(v)
+
+	case []any:
+		return pegn(Seq(v), prec, min)
+
+	case Seq:
+		if len(v) == 0 {
+			return UsageSeq
+		}
+		if len(v) == 1 {
+			if it, is := v[0].([]any); is {
+				return pegn(Seq(it), prec, min)
+			}
+			return pegn(v[0], prec, min)
+		}
+		v = CombineStr(v...)
+		if len(v) == 1 {
+			return pegn(v[0], prec, min)
+		}
+		items := make([]string, len(v))
+		for n, it := range v {
+			items[n] = pegn(it, pegnPre, min)
+		}
+		return group(strings.Join(items, ` `), pegnSeq)
+
+	case One:
+		if len(v) == 0 {
+			return UsageOne
+		}
+		if len(v) == 1 {
+			if it, is := v[0].([]any); is {
+				return pegn(One(it), prec, min)
+			}
+			return pegn(v[0], prec, min)
+		}
+		items := make([]string, len(v))
+		for n, it := range v {
+			items[n] = pegn(it, pegnSeq, min)
+		}
+		if min {
+			return group(strings.Join(items, `/`), pegnExpr)
+		}
+		return group(strings.Join(items, ` / `), pegnExpr)
+
+	case Str:
+		s := String(v)
+		if strings.HasPrefix(s, `"%!`) {
+			return s
+		}
+		return pegnLit(unquote(s[6:len(s)-1]), prec)
+
+	case Mmx:
+		s := v.String()
+		if s == UsageMmx {
+			return s
+		}
+		m, n := v[0].(int), v[1].(int)
+		return group(pegn(v[2], pegnSuf, min)+quant(m, n), pegnPre)
+
+	case See:
+		if len(v) != 1 {
+			return UsageSee
+		}
+		return group(`&`+pegn(v[0], pegnPre, min), pegnPre)
+
+	case Not:
+		if len(v) != 1 {
+			return UsageNot
+		}
+		return group(`!`+pegn(v[0], pegnPre, min), pegnPre)
+
+	case To:
+		if len(v) != 1 {
+			return UsageTo
+		}
+		if min {
+			return group(`..`+pegn(v[0], pegnPre, min), pegnPre)
+		}
+		return group(`.. `+pegn(v[0], pegnPre, min), pegnPre)
+
+	case Any:
+		s := v.String()
+		if s == UsageAny {
+			return s
+		}
+		if len(v) == 1 {
+			if v[0] == 1 {
+				return `.`
+			}
+			return group(fmt.Sprintf(`.{%v}`, v[0]), pegnPre)
+		}
+		if v[1] == 0 {
+			return group(fmt.Sprintf(`.{%v,}`, v[0]), pegnPre)
+		}
+		return group(fmt.Sprintf(`.{%v,%v}`, v[0], v[1]), pegnPre)
+
+	case Rng:
+		s := v.String()
+		if s == UsageRng {
+			return s
+		}
+		return `[` + pegnRune(v[0].(rune)) + `-` + pegnRune(v[1].(rune)) + `]`
+
+	case End:
+		if len(v) != 0 {
+			return UsageEnd
+		}
+		return group(`!.`, pegnPre)
+
+	case Wb:
+		if len(v) != 0 {
+			return UsageWb
+		}
+		return `wb`
+
+	case Byte:
+		if v.String() == UsageByte {
+			return UsageByte
+		}
+		if len(v) == 1 {
+			return pegnBytes(v, ``)
+		}
+		return group(pegnBytes(v, ` `), pegnSeq)
+
+	case BSet:
+		if v.String() == UsageBSet {
+			return UsageBSet
+		}
+		if len(v) == 1 {
+			return pegnBytes(v, ``)
+		}
+		if min {
+			return group(pegnBytes(v, `/`), pegnExpr)
+		}
+		return group(pegnBytes(v, ` / `), pegnExpr)
+
+	case BRng:
+		if v.String() == UsageBRng {
+			return UsageBRng
+		}
+		return `[` + pegnBytes(v[:1], ``) + `-` + pegnBytes(v[1:], ``) + `]`
+
+	case Bits:
+		if v.String() == UsageBits {
+			return UsageBits
+		}
+		return pegnBits(v)
+
+	case Len:
+		if v.String() == UsageLen {
+			return UsageLen
+		}
+		return group(pegn(v[1], pegnSuf, min)+`{`+pegn(v[0], pegnExpr, min)+`}`, pegnPre)
+
+	case Custom:
+		return group(v.PEGN(), pegnSeq)
+
+	default:
+		s := String(v)
+		if !strings.HasPrefix(s, `x.Str{`) {
+			return s
+		}
+		return pegnLit(unquote(s[6:len(s)-1]), prec)
+
+	}
+}
+
+// pegnLit renders the literal string grouping it (when needed) if it
+// contains more than one part.
+func pegnLit(s string, prec int) string {
+	parts := pegnParts(s)
+	str := strings.Join(parts, ` `)
+	if len(parts) > 1 && prec > pegnSeq {
+		return `(` + str + `)`
+	}
+	return str
+}
+
+// unquote returns the unquoted Go string or the string itself if it
+// cannot be unquoted.
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// quant returns the PEGN quantifier suffix for minimum m and maximum n.
+func quant(m, n int) string {
+	switch {
+	case m == 0 && n == 1:
+		return `?`
+	case m == 0 && n == -1:
+		return `*`
+	case m == 1 && n == -1:
+		return `+`
+	case m == n:
+		return fmt.Sprintf(`{%v}`, m)
+	case n == -1:
+		return fmt.Sprintf(`{%v,}`, m)
+	default:
+		return fmt.Sprintf(`{%v,%v}`, m, n)
+	}
+}
+
+// PEGNString returns the PEGN rendering of a literal string. Every
+// printable rune (see unicode.IsPrint) except the single quote is
+// rendered within single quotes and everything else as hexadecimal
+// (x0A) or unicode (u2028) rune literals. Multiple parts are separated
+// by spaces. An empty string is rendered as a pair of single quotes.
+func PEGNString(s string) string { return strings.Join(pegnParts(s), ` `) }
+
+func pegnParts(s string) []string {
+	var parts []string
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			parts = append(parts, `'`+buf.String()+`'`)
+			buf.Reset()
+		}
+	}
+	for _, r := range s {
+		if unicode.IsPrint(r) && r != '\'' {
+			buf.WriteRune(r)
+			continue
+		}
+		flush()
+		parts = append(parts, pegnRune(r))
+	}
+	flush()
+	if len(parts) == 0 {
+		return []string{`''`}
+	}
+	return parts
+}
+
+// pegnRune returns the PEGN rendering of a single rune suitable for use
+// as a range endpoint.
+func pegnRune(r rune) string {
+	switch {
+	case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return string(r)
+	case r <= 0xFF:
+		return fmt.Sprintf(`x%02X`, r)
+	default:
+		return fmt.Sprintf(`u%04X`, r)
+	}
+}
@@ -0,0 +1,509 @@
+package x
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Regexp returns a best-effort Go regular expression (see regexp)
+// equivalent to the rat/x expression for the (many) grammars that use
+// only regular constructs so they can be used where only a regexp is
+// accepted. References (Ref) are resolved (inlined) from the named
+// definitions passed (defs), from N expressions nested within the
+// expression itself, and then from the standard Classes. Named (N)
+// expressions become non-capturing groups. A lookahead (Not) of single
+// runes followed by any rune (!'"' .) becomes a negated class ([^"])
+// and alternatives of only single runes become a class ([a-z_]).
+// The end of data (End) becomes \z. An ErrRegexp is returned for
+// anything that cannot be expressed as a regular expression:
+// recursive, undefined, and function (Is) references, lookahead
+// assertions (See, Not, To), and saved values (Sav, Val).
+//
+// Note that the result is not anchored (matching anywhere) and that
+// alternatives in a PEG are never backtracked into once one has
+// matched, whereas regular expressions backtrack. Therefore, grammars
+// that rely on the ordering of alternatives may match more with the
+// regexp than with rat.
+func Regexp(it any, defs ...N) (string, error) {
+	b := &rxb{defs: map[string]any{}}
+	for _, n := range defs {
+		b.define(n)
+	}
+	Walk(it, func(it any) {
+		if n, is := it.(N); is {
+			b.define(n)
+		}
+	})
+	s, _, err := b.rx(it)
+	return s, err
+}
+
+// regexp precedence levels (higher binds tighter)
+const (
+	rxAlt  = iota // alternation (x|y)
+	rxCat         // concatenation (xy)
+	rxAtom        // single atom (quantifiable)
+)
+
+// rxb builds regular expressions resolving references from defs while
+// tracking those being resolved (stack) to detect recursion.
+type rxb struct {
+	defs  map[string]any
+	stack []string
+}
+
+func (b *rxb) define(n N) {
+	if len(n) != 2 {
+		return
+	}
+	if name, is := n[0].(string); is {
+		if _, has := b.defs[name]; !has {
+			b.defs[name] = n[1]
+		}
+	}
+}
+
+// resolve returns the expression of the named definition (or class)
+// pushing the name onto the stack. The caller must pop it.
+func (b *rxb) resolve(name string) (any, error) {
+	for _, it := range b.stack {
+		if it == name {
+			return nil, ErrRegexp{Ref{name}, RegexpRecursive}
+		}
+	}
+	it, has := b.defs[name]
+	if !has {
+		class, is := Classes[name]
+		if !is {
+			return nil, ErrRegexp{Ref{name}, RegexpUndefined}
+		}
+		it = class[1]
+	}
+	b.stack = append(b.stack, name)
+	return it, nil
+}
+
+func (b *rxb) pop() { b.stack = b.stack[:len(b.stack)-1] }
+
+// rx returns the regular expression for the rat/x expression along
+// with its precedence level.
+func (b *rxb) rx(it any) (string, int, error) {
+
+	switch v := it.(type) {
+
+	case N:
+		if len(v) != 2 {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		name, _ := v[0].(string)
+		it, err := b.resolve(name)
+		if err != nil {
+			return "", 0, err
+		}
+		defer b.pop()
+		return b.rx(it)
+
+	case Doc:
+		if len(v) != 2 {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		return b.rx(v[1])
+
+	case Byte, BSet, BRng, Bits:
+		return "", 0, ErrRegexp{v, RegexpBinary}
+
+	case Len:
+		return "", 0, ErrRegexp{v, RegexpLen}
+
+	case Ref:
+		if len(v) != 1 {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		it, err := b.resolve(fmt.Sprint(v[0]))
+		if err != nil {
+			return "", 0, err
+		}
+		defer b.pop()
+		return b.rx(it)
+
+	case Sav, Val:
+		return "", 0, ErrRegexp{v, RegexpBackref}
+
+	case Is, IsFunc, func(r rune) bool:
+		return "", 0, ErrRegexp{v, RegexpFunc}
+
+	case See, Not, To:
+		return "", 0, ErrRegexp{v, RegexpLookahead}
+
+	case []any:
+		return b.rx(Seq(v))
+
+	case Seq:
+		if len(v) == 0 {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		v = CombineStr(v...)
+		if len(v) == 1 {
+			return b.rx(v[0])
+		}
+		var str string
+		for n := 0; n < len(v); n++ {
+			if not, is := v[n].(Not); is && len(not) == 1 && n+1 < len(v) {
+				if a, is := v[n+1].(Any); is && len(a) == 1 && a[0] == 1 {
+					set, err := b.set(not[0])
+					if err != nil {
+						return "", 0, err
+					}
+					if set != nil {
+						str += rxClass(set, true)
+						n++
+						continue
+					}
+				}
+			}
+			s, p, err := b.rx(v[n])
+			if err != nil {
+				return "", 0, err
+			}
+			if p < rxCat {
+				s = `(?:` + s + `)`
+			}
+			str += s
+		}
+		return str, rxCat, nil
+
+	case One:
+		if len(v) == 0 {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		if len(v) == 1 {
+			return b.rx(v[0])
+		}
+		if set, _ := b.set(v); set != nil {
+			return rxClass(set, false), rxAtom, nil
+		}
+		items := make([]string, len(v))
+		for n, it := range v {
+			s, _, err := b.rx(it)
+			if err != nil {
+				return "", 0, err
+			}
+			items[n] = s
+		}
+		return strings.Join(items, `|`), rxAlt, nil
+
+	case Mmx:
+		if v.String() == UsageMmx {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		s, p, err := b.rx(v[2])
+		if err != nil {
+			return "", 0, err
+		}
+		if p < rxAtom {
+			s = `(?:` + s + `)`
+		}
+		return s + quant(v[0].(int), v[1].(int)), rxAtom, nil
+
+	case Any:
+		s := v.String()
+		if s == UsageAny {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		switch {
+		case len(v) == 1 && v[0] == 1:
+			return `(?s:.)`, rxAtom, nil
+		case len(v) == 1:
+			return fmt.Sprintf(`(?s:.{%v})`, v[0]), rxAtom, nil
+		case v[1] == 0:
+			return fmt.Sprintf(`(?s:.{%v,})`, v[0]), rxAtom, nil
+		}
+		return fmt.Sprintf(`(?s:.{%v,%v})`, v[0], v[1]), rxAtom, nil
+
+	case Rng:
+		r, is := runeRng(v)
+		if !is {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		return rxClass([]Rng{r}, false), rxAtom, nil
+
+	case End:
+		return `\z`, rxAtom, nil
+
+	case Wb:
+		return `\b`, rxAtom, nil
+
+	default:
+		s := String(v)
+		if !strings.HasPrefix(s, `x.Str{`) {
+			return "", 0, ErrRegexp{v, RegexpInvalid}
+		}
+		lit := unquote(s[6 : len(s)-1])
+		var str string
+		for _, r := range lit {
+			str += rxRune(r, false)
+		}
+		switch len([]rune(lit)) {
+		case 0:
+			return `(?:)`, rxAtom, nil
+		case 1:
+			return str, rxAtom, nil
+		}
+		return str, rxCat, nil
+
+	}
+}
+
+// set returns the ranges of runes matched by the expression if it only
+// ever matches a single rune from a set of literals and ranges (nil
+// otherwise).
+func (b *rxb) set(it any) ([]Rng, error) {
+	if r, is := runeRng(it); is {
+		return []Rng{r}, nil
+	}
+	switch v := it.(type) {
+	case rune:
+		return []Rng{{v, v}}, nil
+	case N, Ref:
+		var name string
+		if n, is := v.(N); is && len(n) == 2 {
+			name, _ = n[0].(string)
+		}
+		if r, is := v.(Ref); is && len(r) == 1 {
+			name = fmt.Sprint(r[0])
+		}
+		it, err := b.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		defer b.pop()
+		return b.set(it)
+	case Doc:
+		if len(v) == 2 {
+			return b.set(v[1])
+		}
+	case One:
+		var set []Rng
+		for _, i := range v {
+			s, err := b.set(i)
+			if s == nil || err != nil {
+				return nil, err
+			}
+			set = append(set, s...)
+		}
+		return set, nil
+	}
+	return nil, nil
+}
+
+// rxClass returns the character class ([a-z0-9]) matching the ranges
+// or any rune not within them if negated ([^a-z0-9]).
+func rxClass(set []Rng, negated bool) string {
+	var str string
+	for _, r := range mergeRng(set) {
+		a, z := r[0].(rune), r[1].(rune)
+		str += rxRune(a, true)
+		if z != a {
+			str += `-` + rxRune(z, true)
+		}
+	}
+	if negated {
+		return `[^` + str + `]`
+	}
+	if len(set) == 1 && set[0][0] == set[0][1] {
+		return rxRune(set[0][0].(rune), false)
+	}
+	return `[` + str + `]`
+}
+
+// rxRune returns the rune escaped for use in a regular expression (or
+// within a character class) as is if a printable, non-special rune or
+// as \x{hex} otherwise.
+func rxRune(r rune, class bool) string {
+	special := `\.+*?()|[]{}^$`
+	if class {
+		special = `\]-^[`
+	}
+	switch {
+	case strings.ContainsRune(special, r):
+		return `\` + string(r)
+	case unicode.IsPrint(r):
+		return string(r)
+	}
+	return fmt.Sprintf(`\x{%X}`, r)
+}
+
+// FromRegexp returns a rat/x expression equivalent to the Go regular
+// expression (see regexp/syntax with Perl flags) to ease migration of
+// regexp based validation into grammars. Named capture groups
+// ((?P<Name>...)) become named (N) expressions (so that extraction
+// relying on named groups can use rat.Result.WithName instead) and
+// other groups their contents. Classes become One of Rng (or strings for single runes),
+// repetitions become Mmx, \A and ^ (only at the beginning) are dropped
+// since matching always begins at the start, and \z and $ become End.
+// Since a parsing expression grammar never backtracks, every
+// repetition is possessive and alternatives are ordered so that
+// expressions such as a*a that rely on backtracking never match. An
+// ErrFromRegexp is returned for anything else (such as word
+// boundaries or multi-line anchors).
+func FromRegexp(expr string) (any, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return fromRegexp(re, true)
+}
+
+// fromRegexp converts the parsed regular expression (at the start of
+// the expression if start is true).
+func fromRegexp(re *syntax.Regexp, start bool) (any, error) {
+
+	// alternatives are all at the start but only the first of a concat
+	subs := func(all bool) ([]any, error) {
+		var list []any
+		for n, sub := range re.Sub {
+			it, err := fromRegexp(sub, start && (all || n == 0))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, it)
+		}
+		return list, nil
+	}
+
+	switch re.Op {
+
+	case syntax.OpEmptyMatch:
+		return "", nil
+
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			return string(re.Rune), nil
+		}
+		var seq Seq
+		for _, r := range re.Rune {
+			lo, up := unicode.ToLower(r), unicode.ToUpper(r)
+			if lo == up {
+				seq = append(seq, string(r))
+				continue
+			}
+			seq = append(seq, One{string(lo), string(up)})
+		}
+		return Canonical(seq), nil
+
+	case syntax.OpCharClass:
+		return fromClass(re.Rune), nil
+
+	case syntax.OpAnyCharNotNL:
+		return Seq{Not{"\n"}, Any{1}}, nil
+
+	case syntax.OpAnyChar:
+		return Any{1}, nil
+
+	case syntax.OpBeginText:
+		if start {
+			return "", nil
+		}
+
+	case syntax.OpEndText:
+		return End{}, nil
+
+	case syntax.OpWordBoundary:
+		return Wb{}, nil
+
+	case syntax.OpCapture:
+		it, err := fromRegexp(re.Sub[0], start)
+		if err != nil || re.Name == "" {
+			return it, err
+		}
+		return N{re.Name, it}, nil
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		it, err := fromRegexp(re.Sub[0], false)
+		if err != nil {
+			return nil, err
+		}
+		switch re.Op {
+		case syntax.OpStar:
+			return Mmx{0, -1, it}, nil
+		case syntax.OpPlus:
+			return Mmx{1, -1, it}, nil
+		case syntax.OpQuest:
+			return Mmx{0, 1, it}, nil
+		}
+		return Mmx{re.Min, re.Max, it}, nil
+
+	case syntax.OpConcat:
+		list, err := subs(false)
+		if err != nil {
+			return nil, err
+		}
+		return Canonical(Seq(list)), nil
+
+	case syntax.OpAlternate:
+		list, err := subs(true)
+		if err != nil {
+			return nil, err
+		}
+		return Canonical(One(list)), nil
+
+	}
+
+	return nil, ErrFromRegexp{re.String()}
+}
+
+// fromClass returns the One of Rng (or strings for single runes) for
+// the pairs of runes of a parsed character class.
+func fromClass(pairs []rune) any {
+	var one One
+	for n := 0; n+1 < len(pairs); n += 2 {
+		if pairs[n] == pairs[n+1] {
+			one = append(one, string(pairs[n]))
+			continue
+		}
+		one = append(one, Rng{pairs[n], pairs[n+1]})
+	}
+	if len(one) == 0 {
+		return Not{""} // never matches
+	}
+	return Canonical(one)
+}
+
+// Class returns a rat/x expression matching a single rune from the Go
+// regular expression character class (see regexp/syntax) so that
+// classes such as [[:alpha:]], \p{Greek}, \d, and [^a-z0-9] need not be
+// rewritten when migrating from regexp. The result is a One of Rng (or
+// strings for single runes), which every rat.Grammar checks with
+// a single binary search no matter how many ranges. Single rune
+// literals (a or \.) and case-insensitive ones ((?i)k) are also
+// accepted. An ErrClass is returned for anything else.
+func Class(expr string) (any, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	switch re.Op {
+	case syntax.OpCharClass:
+		return fromClass(re.Rune), nil
+	case syntax.OpLiteral:
+		if len(re.Rune) != 1 {
+			break
+		}
+		if re.Flags&syntax.FoldCase == 0 {
+			return string(re.Rune), nil
+		}
+		var pairs []rune
+		for r := unicode.SimpleFold(re.Rune[0]); ; r = unicode.SimpleFold(r) {
+			pairs = append(pairs, r, r)
+			if r == re.Rune[0] {
+				break
+			}
+		}
+		sort.Slice(pairs, func(a, b int) bool { return pairs[a] < pairs[b] })
+		return fromClass(pairs), nil
+	}
+	return nil, ErrClass{expr}
+}
@@ -0,0 +1,67 @@
+package x
+
+// Rename returns a copy of the expression with every name of a named
+// expression (N) and every name referred to (Ref, Sav, and Val) keyed
+// in names changed to the name it is keyed to. Names not keyed (such as
+// those of the built-in Classes) are left as is. This is used to give
+// every rule of a grammar a namespace (see rat.Grammar.Import).
+func Rename(it any, names map[string]string) any {
+	name := func(v any) any {
+		if s, is := v.(string); is {
+			if to, has := names[s]; has {
+				return to
+			}
+		}
+		return v
+	}
+	all := func(list []any) []any {
+		out := make([]any, len(list))
+		for n, it := range list {
+			out[n] = Rename(it, names)
+		}
+		return out
+	}
+	switch v := it.(type) {
+	case N:
+		if len(v) == 2 {
+			return N{name(v[0]), Rename(v[1], names)}
+		}
+	case Doc:
+		if len(v) == 2 {
+			return Doc{v[0], Rename(v[1], names)}
+		}
+	case Ref:
+		if len(v) == 1 {
+			return Ref{name(v[0])}
+		}
+	case Sav:
+		if len(v) >= 1 {
+			return append(Sav{name(v[0])}, v[1:]...)
+		}
+	case Val:
+		if len(v) == 1 {
+			return Val{name(v[0])}
+		}
+	case Mmx:
+		if len(v) == 3 {
+			return Mmx{v[0], v[1], Rename(v[2], names)}
+		}
+	case Len:
+		if len(v) >= 2 {
+			return append(Len{Rename(v[0], names), Rename(v[1], names)}, v[2:]...)
+		}
+	case See:
+		return See(all(v))
+	case Not:
+		return Not(all(v))
+	case To:
+		return To(all(v))
+	case Seq:
+		return Seq(all(v))
+	case One:
+		return One(all(v))
+	case []any:
+		return all(v)
+	}
+	return it
+}
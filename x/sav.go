@@ -0,0 +1,72 @@
+package x
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// SavFunc transforms the text of a saved result (see Sav) into the
+// rat/x expression of the rule used in its place by Val.
+type SavFunc func(text string) any
+
+// SavFuncOf returns the SavFunc (or plain function of the same
+// signature) or false if it is not one.
+func SavFuncOf(it any) (SavFunc, bool) {
+	switch f := it.(type) {
+	case SavFunc:
+		return f, f != nil
+	case func(text string) any:
+		return f, f != nil
+	}
+	return nil, false
+}
+
+// savFuncName returns the FuncName of the SavFunc followed (for an
+// anonymous function) by the address of the function value as
+// a comment since every closure of the same function literal has the
+// same name but may transform differently. Rules made from a Sav (keyed
+// to its String form) are therefore only shared by the same function.
+func savFuncName(f SavFunc) string {
+	name := FuncName(f)
+	long := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	if !strings.Contains(long, `.func`) {
+		return name
+	}
+	return fmt.Sprintf(`%v /* %p */`, name, *(*unsafe.Pointer)(unsafe.Pointer(&f)))
+}
+
+// Folded is a SavFunc matching the text saved regardless of case (such
+// as the name of an HTML tag closed with different case). Every rune
+// with other cases (see unicode.SimpleFold) becomes a One of all of
+// them.
+func Folded(text string) any {
+	seq := Seq{}
+	var lit []rune
+	for _, r := range text {
+		folds := One{}
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			folds = append(folds, f)
+		}
+		if len(folds) == 0 {
+			lit = append(lit, r)
+			continue
+		}
+		if len(lit) > 0 {
+			seq = append(seq, Str{string(lit)})
+			lit = nil
+		}
+		seq = append(seq, append(One{r}, folds...))
+	}
+	if len(lit) > 0 || len(seq) == 0 {
+		seq = append(seq, Str{string(lit)})
+	}
+	return seq
+}
+
+// Trimmed is a SavFunc matching the text saved without any leading or
+// trailing white space.
+func Trimmed(text string) any { return Str{strings.TrimSpace(text)} }
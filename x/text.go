@@ -3,7 +3,8 @@ package x
 var (
 	SyntaxError = `"%!ERROR: invalid rat/x type or syntax"`
 	UsageN      = `"%!USAGE: x.N{name, rule}"`
-	UsageSav    = `"%!USAGE: x.Sav{name}"`
+	UsageDoc    = `"%!USAGE: x.Doc{doc, rule}"`
+	UsageSav    = `"%!USAGE: x.Sav{name[, func]}"`
 	UsageVal    = `"%!USAGE: x.Val{name}"`
 	UsageRef    = `"%!USAGE: x.Ref{name}"`
 	UsageIs     = `"%!USAGE: namedFunc or x.IsFunc or x.Is{namedFunc}"`
@@ -17,4 +18,28 @@ var (
 	UsageTo     = `"%!USAGE: x.To{rule}"`
 	UsageRng    = `"%!USAGE: x.Rng{beg, end}"`
 	UsageEnd    = `"%!USAGE: x.End{}"`
+	UsageWb     = `"%!USAGE: x.Wb{}"`
+	UsageByte   = `"%!USAGE: x.Byte{...byte}"`
+	UsageBSet   = `"%!USAGE: x.BSet{...byte}"`
+	UsageBRng   = `"%!USAGE: x.BRng{beg, end}"`
+	UsageLen    = `"%!USAGE: x.Len{count, field[, base]}"`
+	UsageBits   = `"%!USAGE: x.Bits{n[, value]} or x.Bits{n, lo, hi}"`
+)
+
+const (
+	ErrRegexpT     = `cannot convert to regexp (%v): %v`
+	ErrFromRegexpT = `cannot convert from regexp: %v`
+	ErrClassT      = `not a single character class: %v`
+)
+
+// reasons for ErrRegexp
+const (
+	RegexpInvalid   = `invalid`
+	RegexpRecursive = `recursive`
+	RegexpUndefined = `undefined`
+	RegexpBackref   = `backreference`
+	RegexpFunc      = `function`
+	RegexpLookahead = `lookahead`
+	RegexpBinary    = `binary`
+	RegexpLen       = `length prefix`
 )
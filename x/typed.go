@@ -0,0 +1,133 @@
+package x
+
+import "fmt"
+
+// Rule is implemented by every expression type of this package and
+// nothing else. The typed constructors (SeqOf, OneOf, Name, and the
+// others) return these types and produce exactly the same []any
+// expressions as writing them out by hand. Since they accept every
+// expression the []any forms do (including literals, functions,
+// Custom expressions, and rat.Maker expressions) the rules passed to
+// them are of type any. Literals (strings and runes) may still be made
+// into Rules with Lit and functions with Func.
+type Rule interface {
+	fmt.Stringer
+	rule()
+}
+
+func (N) rule()   {}
+func (Doc) rule() {}
+func (Sav) rule() {}
+func (Val) rule() {}
+func (Ref) rule() {}
+func (Is) rule()  {}
+func (Seq) rule() {}
+func (One) rule() {}
+func (Str) rule() {}
+func (Mmx) rule() {}
+func (See) rule() {}
+func (Not) rule() {}
+func (To) rule()  {}
+func (Any) rule() {}
+func (Rng) rule() {}
+func (End) rule() {}
+func (Wb) rule()  {}
+
+func (Byte) rule() {}
+func (BSet) rule() {}
+func (BRng) rule() {}
+func (Len) rule()  {}
+func (Bits) rule() {}
+
+// Lit returns the literal string or rune (or any type based on either)
+// as a Str.
+func Lit[T ~string | ~rune](v T) Str { return Str{string(v)} }
+
+// Func returns the function as an Is.
+func Func(f IsFunc) Is { return Is{f} }
+
+// Name returns the rule named name as an N.
+func Name(name string, rule any) N { return N{name, rule} }
+
+// Document returns the rule documented with the text as a Doc.
+func Document(doc string, rule any) Doc { return Doc{doc, rule} }
+
+// RefTo returns a reference to the rule named name as a Ref.
+func RefTo(name string) Ref { return Ref{name} }
+
+// SaveAs returns a Sav saving the result of the rule named name.
+func SaveAs(name string) Sav { return Sav{name} }
+
+// ValOf returns a Val of the result saved for the rule named name.
+func ValOf(name string) Val { return Val{name} }
+
+// SeqOf returns the rules as a Seq.
+func SeqOf(rules ...any) Seq { return Seq(anys(rules)) }
+
+// OneOf returns the rules as a One.
+func OneOf(rules ...any) One { return One(anys(rules)) }
+
+// Range returns the inclusive range of runes from lo to hi as a Rng.
+func Range(lo, hi rune) Rng { return Rng{lo, hi} }
+
+// Count returns an Mmx of at least min and at most max (-1 for no
+// maximum) of the rule.
+func Count(min, max int, rule any) Mmx { return Mmx{min, max, rule} }
+
+// Opt returns an Mmx of zero or one of the rule (rule?).
+func Opt(rule any) Mmx { return Mmx{0, 1, rule} }
+
+// Many returns an Mmx of zero or more of the rule (rule*).
+func Many(rule any) Mmx { return Mmx{0, -1, rule} }
+
+// Some returns an Mmx of one or more of the rule (rule+).
+func Some(rule any) Mmx { return Mmx{1, -1, rule} }
+
+// Ahead returns a positive lookahead of the rule as a See.
+func Ahead(rule any) See { return See{rule} }
+
+// NotAhead returns a negative lookahead of the rule as a Not.
+func NotAhead(rule any) Not { return Not{rule} }
+
+// Until returns every rune until the rule matches as a To.
+func Until(rule any) To { return To{rule} }
+
+// Runes returns exactly n of any rune as an Any.
+func Runes(n int) Any { return Any{n} }
+
+// BytesOf returns the bytes as a Byte.
+func BytesOf(bytes ...byte) Byte { return Byte(byteAnys(bytes)) }
+
+// ByteSet returns the bytes as a BSet.
+func ByteSet(bytes ...byte) BSet { return BSet(byteAnys(bytes)) }
+
+// ByteRange returns the inclusive range of bytes from lo to hi as
+// a BRng.
+func ByteRange(lo, hi byte) BRng { return BRng{lo, hi} }
+
+// Field returns any n bits as Bits.
+func Field(n int) Bits { return Bits{n} }
+
+// Flag returns a single bit that must be set (or not) as Bits.
+func Flag(set bool) Bits {
+	if set {
+		return Bits{1, 1}
+	}
+	return Bits{1, 0}
+}
+
+// anys returns a copy of the rules.
+func anys(rules []any) []any {
+	out := make([]any, len(rules))
+	copy(out, rules)
+	return out
+}
+
+// byteAnys returns the bytes as a []any.
+func byteAnys(bytes []byte) []any {
+	out := make([]any, len(bytes))
+	for n, b := range bytes {
+		out[n] = b
+	}
+	return out
+}
@@ -56,6 +56,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 // String returns a valid rat/x type for anything passed including all
@@ -143,7 +144,7 @@ func CombineStr(args ...any) []any {
 	var combining bool
 	for _, it := range args {
 		switch it.(type) {
-		case N, Sav, Val, Ref, Is, Seq, One, Mmx, See, Not, To, Any, Rng, End:
+		case N, Doc, Sav, Val, Ref, Is, Seq, One, Mmx, See, Not, To, Any, Rng, End, Wb, Byte, BSet, BRng, Len, Bits, Custom:
 			if combining {
 				rules = append(rules, comb)
 				comb = Str{}
@@ -161,6 +162,51 @@ func CombineStr(args ...any) []any {
 	return rules
 }
 
+// Walk calls the function for the expression passed and then for every
+// expression nested within it (depth-first, preorder). Literals within
+// a Str are not passed individually and the names of N, Sav, Val, and
+// Ref (and the text of Doc) are not considered nested expressions. An
+// []any slice is treated as a Seq (but passed as is).
+func Walk(it any, do func(it any)) {
+	do(it)
+	switch v := it.(type) {
+	case N:
+		if len(v) == 2 {
+			Walk(v[1], do)
+		}
+	case Doc:
+		if len(v) == 2 {
+			Walk(v[1], do)
+		}
+	case Mmx:
+		if len(v) == 3 {
+			Walk(v[2], do)
+		}
+	case See:
+		walkAll(v, do)
+	case Not:
+		walkAll(v, do)
+	case To:
+		walkAll(v, do)
+	case Len:
+		if len(v) >= 2 {
+			walkAll(v[:2], do)
+		}
+	case Seq:
+		walkAll(v, do)
+	case One:
+		walkAll(v, do)
+	case []any:
+		walkAll(v, do)
+	}
+}
+
+func walkAll(list []any, do func(it any)) {
+	for _, it := range list {
+		Walk(it, do)
+	}
+}
+
 // N (name) encapsulates another Result with a name. In PEGN these are
 // called "significant" (<=) because they can be easily found in the
 // parsed results tree. Names can be any valid Go string but keeping to
@@ -208,11 +254,44 @@ func (it N) String() string {
 
 func (it N) Print() { fmt.Println(it) }
 
+// Doc documents a rule (usually an N) with the text of a comment (first
+// argument) so that grammars can be self-documenting. The second
+// argument is the rule documented, which it matches exactly like. The
+// rule made from it has the text as its Doc (see rat.Rule) which is
+// rendered as comments before its definition when exported as PEGN
+// (see PEGN and rat.Grammar.PEGN), in generated code, and with railroad
+// diagrams. Canonical drops it since documentation never changes what
+// is matched.
+//
+// PEGN
+//
+//    # Greeting is said first.
+//    Greeting <= 'hello'
+//
+type Doc []any
+
+func (it Doc) String() string {
+	if len(it) != 2 {
+		return UsageDoc
+	}
+	if _, is := it[0].(string); !is {
+		return UsageDoc
+	}
+	return fmt.Sprintf(`x.Doc{%q, %v}`, it[0], String(it[1]))
+}
+
+func (it Doc) Print() { fmt.Println(it) }
+
 // Sav saves the results of a successful rule as a rule representing
 // the literal output of that result allowing it to be used later with
 // Val. There can only be one saved result for any saved rule at a time.
 // Like Ref, the first argument must be a string matching the name of
-// a rule.
+// a rule. An optional second argument (any SavFunc) transforms the
+// text saved into the expression matched by Val instead, for example,
+// to match it regardless of case (see Folded) or without surrounding
+// white space (see Trimmed). The expression is made apart from the
+// grammar (and so cannot refer to its rules). PEGN has no such
+// transforms (which are therefore not rendered).
 //
 // PEGN
 //
@@ -229,6 +308,13 @@ func (args Sav) String() string {
 			return UsageSav
 		}
 		return fmt.Sprintf(`x.Sav{%q}`, args[0])
+	case 2:
+		_, is := args[0].(string)
+		f, ok := SavFuncOf(args[1])
+		if !is || !ok {
+			return UsageSav
+		}
+		return fmt.Sprintf(`x.Sav{%q, %v}`, args[0], savFuncName(f))
 	default:
 		return UsageSav
 	}
@@ -295,14 +381,23 @@ type IsFunc func(r rune) bool
 // FuncName returns the best guess at the function name without the
 // package. Note that this is generally only useful when passing named
 // funcitons. This function is called by Is when creating names for
-// Grammar caching.
+// Grammar caching. Names are cached by function pointer since the
+// reflection required is expensive.
 func FuncName(it any) string {
 	fp := reflect.ValueOf(it).Pointer()
+	if name, cached := funcNames.Load(fp); cached {
+		return name.(string)
+	}
 	long := runtime.FuncForPC(fp).Name()
 	parts := strings.Split(long, `.`)
-	return parts[len(parts)-1]
+	name := parts[len(parts)-1]
+	funcNames.Store(fp, name)
+	return name
 }
 
+// funcNames caches every FuncName keyed to function pointer (uintptr).
+var funcNames sync.Map
+
 // Is takes a single IsFunc argument which must refer to a non-anonymous
 // function (see FuncName). The function is encapsulated within the
 // CheckFunc of the resulting rule.
@@ -680,3 +775,35 @@ func (it End) String() string {
 }
 
 func (it End) Print() { fmt.Println(it) }
+
+// Wb represents a word boundary, that the runes before and after the
+// current position are not both (or both not) word runes (ASCII
+// letters, digits, and underscore) exactly like \b of a regular
+// expression. Like End it never advances and must be an empty []any
+// slice. Wb is most useful following keywords so that they do not
+// match the beginning of longer identifiers (x.Seq{"if", x.Wb{}} does
+// not match the "if" of "ifdef").
+//
+// PEGN
+//
+// PEGN has no word boundary so Wb is rendered as a reference to a "wb"
+// rule (like Is functions) that must be defined elsewhere.
+//
+//     wb
+//
+type Wb []any
+
+func (it Wb) String() string {
+	if len(it) != 0 {
+		return UsageWb
+	}
+	return `x.Wb{}`
+}
+
+func (it Wb) Print() { fmt.Println(it) }
+
+// IsWord returns true if the rune is a word rune (ASCII letter, digit,
+// or underscore) as used by Wb.
+func IsWord(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}